@@ -89,6 +89,25 @@ func ModuleCommand(client dgclient.DGateClient) *cli.Command {
 					return jsonPrettyPrint(mod)
 				},
 			},
+			{
+				Name:  "jobs",
+				Usage: "get the jobs of a module and their runs",
+				Action: func(ctx *cli.Context) error {
+					mod, err := createMapFromArgs[spec.Module](
+						ctx.Args().Slice(), "name",
+					)
+					if err != nil {
+						return err
+					}
+					jobs, err := client.GetModuleJobs(
+						mod.Name, mod.NamespaceName,
+					)
+					if err != nil {
+						return err
+					}
+					return jsonPrettyPrint(jobs)
+				},
+			},
 		},
 	}
 }
@@ -90,6 +90,29 @@ func RouteCommand(client dgclient.DGateClient) *cli.Command {
 					return jsonPrettyPrint(rt)
 				},
 			},
+			{
+				Name:  "purge-cache",
+				Usage: "purge the cached responses of a route",
+				Action: func(ctx *cli.Context) error {
+					purge, err := createMapFromArgs[struct {
+						Name          string `json:"name"`
+						NamespaceName string `json:"namespace"`
+						Prefix        string `json:"prefix"`
+						Tag           string `json:"tag"`
+					}](ctx.Args().Slice(), "name")
+					if err != nil {
+						return err
+					}
+					purged, err := client.PurgeRouteCache(
+						purge.Name, purge.NamespaceName,
+						purge.Prefix, purge.Tag,
+					)
+					if err != nil {
+						return err
+					}
+					return jsonPrettyPrint(map[string]int{"purged": purged})
+				},
+			},
 		},
 	}
 }
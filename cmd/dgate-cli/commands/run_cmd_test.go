@@ -225,6 +225,11 @@ func (m *mockDGClient) ListRoute(namespace string) ([]*spec.Route, error) {
 	return args[0].([]*spec.Route), args.Error(1)
 }
 
+func (m *mockDGClient) PurgeRouteCache(name, namespace, prefix, tag string) (int, error) {
+	args := m.Called(name, namespace, prefix, tag)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *mockDGClient) GetNamespace(name string) (*spec.Namespace, error) {
 	args := m.Called(name)
 	if args.Get(0) == nil {
@@ -301,6 +306,14 @@ func (m *mockDGClient) ListService(namespace string) ([]*spec.Service, error) {
 	return args[0].([]*spec.Service), args.Error(1)
 }
 
+func (m *mockDGClient) GetServiceHealth(name, namespace string) ([]*spec.UpstreamStatus, error) {
+	args := m.Called(name, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args[0].([]*spec.UpstreamStatus), args.Error(1)
+}
+
 func (m *mockDGClient) GetModule(name, namespace string) (*spec.Module, error) {
 	args := m.Called(name, namespace)
 	if args.Get(0) == nil {
@@ -309,6 +322,14 @@ func (m *mockDGClient) GetModule(name, namespace string) (*spec.Module, error) {
 	return args[0].(*spec.Module), args.Error(1)
 }
 
+func (m *mockDGClient) GetModuleJobs(name, namespace string) (*spec.ModuleJobs, error) {
+	args := m.Called(name, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args[0].(*spec.ModuleJobs), args.Error(1)
+}
+
 func (m *mockDGClient) CreateModule(mod *spec.Module) error {
 	args := m.Called(mod)
 	return args.Error(0)
@@ -89,6 +89,25 @@ func ServiceCommand(client dgclient.DGateClient) *cli.Command {
 					return jsonPrettyPrint(ns)
 				},
 			},
+			{
+				Name:  "health",
+				Usage: "get the health of a service's urls",
+				Action: func(ctx *cli.Context) error {
+					svc, err := createMapFromArgs[spec.Service](
+						ctx.Args().Slice(), "name",
+					)
+					if err != nil {
+						return err
+					}
+					statuses, err := client.GetServiceHealth(
+						svc.Name, svc.NamespaceName,
+					)
+					if err != nil {
+						return err
+					}
+					return jsonPrettyPrint(statuses)
+				},
+			},
 		},
 	}
 }
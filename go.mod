@@ -25,6 +25,7 @@ require (
 	github.com/spf13/pflag v1.0.5
 	github.com/stoewer/go-strcase v1.3.0
 	github.com/stretchr/testify v1.9.0
+	github.com/tetratelabs/wazero v1.7.3
 	github.com/urfave/cli/v2 v2.27.1
 	go.etcd.io/bbolt v1.3.10
 	go.opentelemetry.io/otel v1.26.0
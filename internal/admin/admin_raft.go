@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net"
@@ -12,6 +13,7 @@ import (
 	"github.com/dgate-io/chi-router"
 	"github.com/dgate-io/dgate/internal/admin/changestate"
 	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/proxy/rate_limit"
 	"github.com/dgate-io/dgate/pkg/raftadmin"
 	"github.com/dgate-io/dgate/pkg/rafthttp"
 	"github.com/dgate-io/dgate/pkg/storage"
@@ -127,6 +129,24 @@ func setupRaft(
 		raftAdmin.ServeHTTP(w, r)
 	})
 
+	// Setup handler for the rate limit counts of the other nodes
+	server.Post("/ratelimit/sync", func(w http.ResponseWriter, r *http.Request) {
+		if adminConfig.Replication.SharedKey != "" {
+			sharedKey := r.Header.Get("X-DGate-Shared-Key")
+			if sharedKey != adminConfig.Replication.SharedKey {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		var counts rate_limit.Counts
+		if err := json.NewDecoder(r.Body).Decode(&counts); err != nil {
+			util.JsonError(w, http.StatusBadRequest, "error decoding rate limit counts")
+			return
+		}
+		cs.SyncRateLimits(&counts)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
 	// Setup handler for stats
 	server.Handle("/raftadmin/stats", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Raft-State", raftNode.State().String())
@@ -2,6 +2,7 @@ package changestate
 
 import (
 	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/internal/proxy/rate_limit"
 	"github.com/dgate-io/dgate/pkg/raftadmin"
 	"github.com/dgate-io/dgate/pkg/resources"
 	"github.com/dgate-io/dgate/pkg/spec"
@@ -28,6 +29,18 @@ type ChangeState interface {
 	// Resources
 	ResourceManager() *resources.ResourceManager
 	DocumentManager() resources.DocumentManager
+
+	// Health
+	ServiceHealth(name, namespace string) ([]spec.UpstreamStatus, bool)
+
+	// Module Jobs
+	ModuleJobs(name, namespace string) (*spec.ModuleJobs, bool)
+
+	// Response Cache
+	PurgeResponseCache(namespace, route, prefix, tag string) (int, bool)
+
+	// Rate Limits
+	SyncRateLimits(counts *rate_limit.Counts)
 }
 
 var _ ChangeState = (*proxy.ProxyState)(nil)
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 
 	"github.com/dgate-io/dgate/internal/admin/changestate"
+	"github.com/dgate-io/dgate/internal/proxy/rate_limit"
 	"github.com/dgate-io/dgate/pkg/resources"
 	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/dgate-io/dgate/pkg/raftadmin"
@@ -86,6 +87,35 @@ func (m *MockChangeState) WaitForChanges(cl *spec.ChangeLog) error {
 	return m.Called(cl).Error(0)
 }
 
+// ServiceHealth implements changestate.ChangeState.
+func (m *MockChangeState) ServiceHealth(name, namespace string) ([]spec.UpstreamStatus, bool) {
+	args := m.Called(name, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1)
+	}
+	return args.Get(0).([]spec.UpstreamStatus), args.Bool(1)
+}
+
+// ModuleJobs implements changestate.ChangeState.
+func (m *MockChangeState) ModuleJobs(name, namespace string) (*spec.ModuleJobs, bool) {
+	args := m.Called(name, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1)
+	}
+	return args.Get(0).(*spec.ModuleJobs), args.Bool(1)
+}
+
+// PurgeResponseCache implements changestate.ChangeState.
+func (m *MockChangeState) PurgeResponseCache(namespace, route, prefix, tag string) (int, bool) {
+	args := m.Called(namespace, route, prefix, tag)
+	return args.Int(0), args.Bool(1)
+}
+
+// SyncRateLimits implements changestate.ChangeState.
+func (m *MockChangeState) SyncRateLimits(counts *rate_limit.Counts) {
+	m.Called(counts)
+}
+
 // ChangeLogs implements changestate.ChangeState.
 func (m *MockChangeState) ChangeLogs() []*spec.ChangeLog {
 	return m.Called().Get(0).([]*spec.ChangeLog)
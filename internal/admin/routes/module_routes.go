@@ -42,6 +42,14 @@ func ConfigureModuleAPI(server chi.Router, logger *zap.Logger, cs changestate.Ch
 		if !mod.Type.Valid() {
 			mod.Type = spec.ModuleTypeTypescript
 		}
+		if err := spec.ValidateModulePermissions(mod.Permissions); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := mod.Limits.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		cl := spec.NewChangeLog(&mod, mod.NamespaceName, spec.AddModuleCommand)
 		if err = cs.ApplyChangeLog(cl); err != nil {
 			util.JsonError(w, http.StatusBadRequest, err.Error())
@@ -120,4 +128,26 @@ func ConfigureModuleAPI(server chi.Router, logger *zap.Logger, cs changestate.Ch
 		}
 		util.JsonResponse(w, http.StatusOK, spec.TransformDGateModule(mod))
 	})
+
+	server.Get("/module/{name}/jobs", func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		nsName := r.URL.Query().Get("namespace")
+		if nsName == "" {
+			if appConfig.DisableDefaultNamespace {
+				util.JsonError(w, http.StatusBadRequest, "namespace is required")
+				return
+			}
+			nsName = spec.DefaultNamespace.Name
+		}
+		if _, ok := rm.GetModule(name, nsName); !ok {
+			util.JsonError(w, http.StatusNotFound, "module not found")
+			return
+		}
+		jobs, ok := cs.ModuleJobs(name, nsName)
+		if !ok {
+			util.JsonError(w, http.StatusNotFound, "module cannot have jobs")
+			return
+		}
+		util.JsonResponse(w, http.StatusOK, jobs)
+	})
 }
@@ -114,3 +114,48 @@ func TestAdminRoutes_ModuleError(t *testing.T) {
 		}
 	}
 }
+
+func TestAdminRoutes_ModuleJobs(t *testing.T) {
+	config := configtest.NewTest4DGateConfig()
+	ps := proxy.NewProxyState(zap.NewNop(), config)
+	if err := ps.Start(); err != nil {
+		t.Fatal(err)
+	}
+	mux := chi.NewMux()
+	mux.Route("/api/v1", func(r chi.Router) {
+		routes.ConfigureModuleAPI(r, zap.NewNop(), ps, config)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := dgclient.NewDGateClient()
+	if err := client.Init(server.URL, server.Client()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.CreateModule(&spec.Module{
+		Name:          "test",
+		NamespaceName: "test",
+		Payload: base64.StdEncoding.EncodeToString([]byte(
+			`export const jobs = { refresh: { schedule: "@hourly", handler: () => {} } };`,
+		)),
+		Type: spec.ModuleTypeTypescript,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	jobs, err := client.GetModuleJobs("test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "test", jobs.Module)
+	assert.Empty(t, jobs.Error)
+	if assert.Equal(t, 1, len(jobs.Jobs)) {
+		assert.Equal(t, "refresh", jobs.Jobs[0].Name)
+		assert.Equal(t, "@hourly", jobs.Jobs[0].Schedule)
+		assert.Empty(t, jobs.Jobs[0].Runs)
+	}
+
+	if _, err := client.GetModuleJobs("unknown", "test"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -34,6 +34,11 @@ func ConfigureNamespaceAPI(server chi.Router, logger *zap.Logger, cs changestate
 			return
 		}
 
+		if err := namespace.RateLimit.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		cl := spec.NewChangeLog(&namespace, namespace.Name, spec.AddNamespaceCommand)
 		if err = cs.ApplyChangeLog(cl); err != nil {
 			util.JsonError(w, http.StatusBadRequest, err.Error())
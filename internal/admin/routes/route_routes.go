@@ -42,6 +42,41 @@ func ConfigureRouteAPI(server chi.Router, logger *zap.Logger, cs changestate.Cha
 			route.NamespaceName = spec.DefaultNamespace.Name
 		}
 
+		if err := route.TrafficSplit.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := route.Mirror.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := route.Match.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := spec.ValidateRewriteRules(route.Rewrite); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := route.Cache.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := route.RateLimit.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := route.ConcurrencyLimit.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		cl := spec.NewChangeLog(&route, route.NamespaceName, spec.AddRouteCommand)
 		if err = cs.ApplyChangeLog(cl); err != nil {
 			util.JsonError(w, http.StatusBadRequest, err.Error())
@@ -129,4 +164,44 @@ func ConfigureRouteAPI(server chi.Router, logger *zap.Logger, cs changestate.Cha
 		util.JsonResponse(w, http.StatusOK,
 			spec.TransformDGateRoute(rt))
 	})
+
+	server.Delete("/route/{name}/cache", func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		nsName := r.URL.Query().Get("namespace")
+		if nsName == "" {
+			if appConfig.DisableDefaultNamespace {
+				util.JsonError(w, http.StatusBadRequest, "namespace is required")
+				return
+			}
+			nsName = spec.DefaultNamespace.Name
+		}
+		if _, ok := rm.GetRoute(name, nsName); !ok {
+			util.JsonError(w, http.StatusNotFound, "route not found")
+			return
+		}
+		purged, ok := cs.PurgeResponseCache(nsName, name,
+			r.URL.Query().Get("prefix"), r.URL.Query().Get("tag"))
+		if !ok {
+			util.JsonError(w, http.StatusNotFound, "route has no response cache")
+			return
+		}
+		util.JsonResponse(w, http.StatusOK, map[string]int{"purged": purged})
+	})
+
+	server.Delete("/cache", func(w http.ResponseWriter, r *http.Request) {
+		nsName := r.URL.Query().Get("namespace")
+		if nsName == "" {
+			if appConfig.DisableDefaultNamespace {
+				util.JsonError(w, http.StatusBadRequest, "namespace is required")
+				return
+			}
+			nsName = spec.DefaultNamespace.Name
+		} else if _, ok := rm.GetNamespace(nsName); !ok {
+			util.JsonError(w, http.StatusBadRequest, "namespace not found: "+nsName)
+			return
+		}
+		purged, _ := cs.PurgeResponseCache(nsName, "",
+			r.URL.Query().Get("prefix"), r.URL.Query().Get("tag"))
+		util.JsonResponse(w, http.StatusOK, map[string]int{"purged": purged})
+	})
 }
@@ -112,3 +112,70 @@ func TestAdminRoutes_RouteError(t *testing.T) {
 		}
 	}
 }
+
+func TestAdminRoutes_RouteCachePurge(t *testing.T) {
+	config := configtest.NewTest3DGateConfig()
+	ps := proxy.NewProxyState(zap.NewNop(), config)
+	if err := ps.Start(); err != nil {
+		t.Fatal(err)
+	}
+	mux := chi.NewMux()
+	mux.Route("/api/v1", func(r chi.Router) {
+		routes.ConfigureRouteAPI(r, zap.NewNop(), ps, config)
+		routes.ConfigureServiceAPI(r, zap.NewNop(), ps, config)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := dgclient.NewDGateClient()
+	if err := client.Init(server.URL, server.Client()); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateService(&spec.Service{
+		Name:          "test",
+		NamespaceName: "test",
+		URLs:          []string{"http://localhost:8080"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateRoute(&spec.Route{
+		Name:          "cached",
+		NamespaceName: "test",
+		Paths:         []string{"/cached"},
+		Methods:       []string{"GET"},
+		ServiceName:   "test",
+		Cache:         &spec.ResponseCache{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateRoute(&spec.Route{
+		Name:          "uncached",
+		NamespaceName: "test",
+		Paths:         []string{"/uncached"},
+		Methods:       []string{"GET"},
+		ServiceName:   "test",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateRoute(&spec.Route{
+		Name:          "invalid",
+		NamespaceName: "test",
+		Paths:         []string{"/invalid"},
+		Methods:       []string{"GET"},
+		Cache:         &spec.ResponseCache{Key: "{body}"},
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	purged, err := client.PurgeRouteCache("cached", "test", "GET:", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, purged)
+	if _, err := client.PurgeRouteCache("uncached", "test", "", ""); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := client.PurgeRouteCache("unknown", "test", "", ""); err == nil {
+		t.Fatal("expected error")
+	}
+}
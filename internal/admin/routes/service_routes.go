@@ -71,6 +71,30 @@ func ConfigureServiceAPI(server chi.Router, logger *zap.Logger, cs changestate.C
 				}
 			}
 		}
+		if err := svc.LoadBalancer.Validate(len(svc.URLs)); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := svc.HealthCheck.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := svc.OutlierDetection.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := svc.CircuitBreaker.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := svc.RetryPolicy.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := svc.ConcurrencyLimit.Validate(); err != nil {
+			util.JsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if svc.NamespaceName == "" {
 			if appConfig.DisableDefaultNamespace {
 				util.JsonError(w, http.StatusBadRequest, "namespace is required")
@@ -157,4 +181,31 @@ func ConfigureServiceAPI(server chi.Router, logger *zap.Logger, cs changestate.C
 		}
 		util.JsonResponse(w, http.StatusOK, spec.TransformDGateService(svc))
 	})
+
+	server.Get("/service/{name}/health", func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		nsName := r.URL.Query().Get("namespace")
+		if nsName == "" {
+			if appConfig.DisableDefaultNamespace {
+				util.JsonError(w, http.StatusBadRequest, "namespace is required")
+				return
+			}
+			nsName = spec.DefaultNamespace.Name
+		}
+		svc, ok := rm.GetService(name, nsName)
+		if !ok {
+			util.JsonError(w, http.StatusNotFound, "service not found")
+			return
+		}
+		if svc.HealthCheck == nil && svc.OutlierDetection == nil {
+			util.JsonError(w, http.StatusNotFound, "service has no health check or outlier detection")
+			return
+		}
+		statuses, ok := cs.ServiceHealth(name, nsName)
+		if !ok {
+			util.JsonError(w, http.StatusNotFound, "service health not found")
+			return
+		}
+		util.JsonResponse(w, http.StatusOK, statuses)
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/dgate-io/chi-router"
 	"github.com/dgate-io/dgate/internal/admin/changestate/testutil"
@@ -111,3 +112,66 @@ func TestAdminRoutes_ServiceError(t *testing.T) {
 		}
 	}
 }
+
+func TestAdminRoutes_ServiceHealth(t *testing.T) {
+	config := configtest.NewTest4DGateConfig()
+	ps := proxy.NewProxyState(zap.NewNop(), config)
+	if err := ps.Start(); err != nil {
+		t.Fatal(err)
+	}
+	mux := chi.NewMux()
+	mux.Route("/api/v1", func(r chi.Router) {
+		routes.ConfigureServiceAPI(r, zap.NewNop(), ps, config)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := dgclient.NewDGateClient()
+	if err := client.Init(server.URL, server.Client()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.CreateService(&spec.Service{
+		Name:          "test",
+		URLs:          []string{"http://localhost:8080"},
+		NamespaceName: "test",
+		HealthCheck: &spec.HealthCheck{
+			Path:     "/healthz",
+			Interval: time.Minute,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := client.GetServiceHealth("test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(statuses))
+	assert.Equal(t, "http://localhost:8080", statuses[0].URL)
+	assert.True(t, statuses[0].Healthy)
+
+	if err := client.CreateService(&spec.Service{
+		Name:          "test2",
+		URLs:          []string{"http://localhost:8080"},
+		NamespaceName: "test",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetServiceHealth("test2", "test"); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := client.GetServiceHealth("unknown", "test"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if err := client.CreateService(&spec.Service{
+		Name:          "test3",
+		URLs:          []string{"http://localhost:8080"},
+		NamespaceName: "test",
+		HealthCheck: &spec.HealthCheck{
+			Interval: time.Millisecond,
+		},
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -21,6 +21,7 @@ type (
 		ProxyConfig      DGateProxyConfig       `koanf:"proxy"`
 		AdminConfig      *DGateAdminConfig      `koanf:"admin"`
 		TestServerConfig *DGateTestServerConfig `koanf:"test_server"`
+		SecretsConfig    *DGateSecretsConfig    `koanf:"secrets"`
 
 		DisableMetrics          bool     `koanf:"disable_metrics"`
 		DisableDefaultNamespace bool     `koanf:"disable_default_namespace"`
@@ -33,19 +34,22 @@ type (
 	}
 
 	DGateProxyConfig struct {
-		Host                     string                   `koanf:"host"`
-		Port                     int                      `koanf:"port"`
-		TLS                      *DGateTLSConfig          `koanf:"tls"`
-		EnableH2C                bool                     `koanf:"enable_h2c"`
-		EnableHTTP2              bool                     `koanf:"enable_http2"`
-		ConsoleLogLevel          string                   `koanf:"console_log_level"`
-		RedirectHttpsDomains     []string                 `koanf:"redirect_https"`
-		AllowedDomains           []string                 `koanf:"allowed_domains"`
-		GlobalHeaders            map[string]string        `koanf:"global_headers"`
-		Transport                DGateHttpTransportConfig `koanf:"client_transport"`
-		DisableXForwardedHeaders bool                     `koanf:"disable_x_forwarded_headers"`
-		StrictMode               bool                     `koanf:"strict_mode"`
-		XForwardedForDepth       int                      `koanf:"x_forwarded_for_depth"`
+		Host                     string                     `koanf:"host"`
+		Port                     int                        `koanf:"port"`
+		TLS                      *DGateTLSConfig            `koanf:"tls"`
+		EnableH2C                bool                       `koanf:"enable_h2c"`
+		EnableHTTP2              bool                       `koanf:"enable_http2"`
+		ConsoleLogLevel          string                     `koanf:"console_log_level"`
+		RedirectHttpsDomains     []string                   `koanf:"redirect_https"`
+		AllowedDomains           []string                   `koanf:"allowed_domains"`
+		GlobalHeaders            map[string]string          `koanf:"global_headers"`
+		Transport                DGateHttpTransportConfig   `koanf:"client_transport"`
+		DisableXForwardedHeaders bool                       `koanf:"disable_x_forwarded_headers"`
+		StrictMode               bool                       `koanf:"strict_mode"`
+		XForwardedForDepth       int                        `koanf:"x_forwarded_for_depth"`
+		ModuleLimits             DGateModuleLimitsConfig    `koanf:"module_limits"`
+		NativeModules            []DGateNativeModulesConfig `koanf:"native_modules"`
+		RuntimePool              DGateRuntimePoolConfig     `koanf:"runtime_pool"`
 
 		// WARN: debug use only
 		InitResources *DGateResources `koanf:"init_resources"`
@@ -60,11 +64,22 @@ type (
 		GlobalHeaders map[string]string `koanf:"global_headers"`
 	}
 
+	// DGateNativeModulesConfig is a go plugin (built with -buildmode=plugin)
+	// loaded on startup, modules of the native type refer to it by name.
 	DGateNativeModulesConfig struct {
 		Name string `koanf:"name"`
 		Path string `koanf:"path"`
 	}
 
+	// DGateRuntimePoolConfig configures the module runtimes shared by the
+	// routes, max memory is the heap size (bytes) where idle runtimes are evicted.
+	DGateRuntimePoolConfig struct {
+		MinIdle     int           `koanf:"min_idle"`
+		MaxIdle     int           `koanf:"max_idle"`
+		IdleTimeout time.Duration `koanf:"idle_timeout"`
+		MaxMemory   uint64        `koanf:"max_memory"`
+	}
+
 	DGateAdminConfig struct {
 		Host               string                  `koanf:"host"`
 		Port               int                     `koanf:"port"`
@@ -173,6 +188,33 @@ type (
 		DisablePrivateIPs      bool          `koanf:"disable_private_ips"`
 	}
 
+	// DGateModuleLimitsConfig is the default limits of the modules,
+	// modules can override them with their own limits.
+	DGateModuleLimitsConfig struct {
+		CPUTime  time.Duration `koanf:"cpu_time"`
+		WallTime time.Duration `koanf:"wall_time"`
+		// MaxAllocations is the max bytes of memory of wasm modules
+		MaxAllocations uint64 `koanf:"max_allocations"`
+		// StatusCode is the status of the requests of modules
+		// that break their limits, defaults to 500.
+		StatusCode int `koanf:"status_code"`
+	}
+
+	DGateSecretsConfig struct {
+		// the key used to encrypt secrets at rest
+		DGateSecretKeyConfig `koanf:",squash"`
+		// PreviousKeys are used to decrypt secrets that were encrypted
+		// before the key was rotated, they are re-encrypted on startup.
+		PreviousKeys []DGateSecretKeyConfig `koanf:"previous_keys"`
+	}
+
+	// DGateSecretKeyConfig is a base64 encoded 32 byte key
+	// that is read from a file or an environment variable.
+	DGateSecretKeyConfig struct {
+		KeyFile string `koanf:"key_file"`
+		KeyEnv  string `koanf:"key_env"`
+	}
+
 	DGateStorageConfig struct {
 		StorageType StorageType    `koanf:"type"`
 		Config      map[string]any `koanf:",remain"`
@@ -265,6 +265,35 @@ func determineParser(configDataType string) (koanf.Parser, error) {
 	}
 }
 
+// LoadKey reads the key from the key file or the environment variable.
+func (config *DGateSecretKeyConfig) LoadKey() ([]byte, error) {
+	var encodedKey string
+	if config.KeyFile != "" && config.KeyEnv != "" {
+		return nil, errors.New("secret key cannot have both key_file and key_env")
+	} else if config.KeyFile != "" {
+		keyBytes, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return nil, errors.New("error reading secret key file: " + err.Error())
+		}
+		encodedKey = string(keyBytes)
+	} else if config.KeyEnv != "" {
+		var ok bool
+		if encodedKey, ok = os.LookupEnv(config.KeyEnv); !ok {
+			return nil, errors.New("secret key env var not set: " + config.KeyEnv)
+		}
+	} else {
+		return nil, errors.New("secret key requires key_file or key_env")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, errors.New("secret key must be base64 encoded: " + err.Error())
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
 func (config *DGateReplicationConfig) LoadRaftConfig(defaultConfig *raft.Config) *raft.Config {
 	rc := defaultConfig
 	if defaultConfig == nil {
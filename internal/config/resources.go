@@ -27,6 +27,9 @@ func (resources *DGateResources) Validate() (int, error) {
 		if ns.Name == "" {
 			return 0, errors.New("namespace name must be specified")
 		}
+		if err := ns.RateLimit.Validate(); err != nil {
+			return 0, errors.New("namespace (" + ns.Name + ") " + err.Error())
+		}
 		namespaces[ns.Name] = &ns
 	}
 	numChanges += len(namespaces)
@@ -52,6 +55,12 @@ func (resources *DGateResources) Validate() (int, error) {
 		if mod.Payload != "" && mod.PayloadFile != "" {
 			return 0, errors.New("module payload and payload file cannot both be specified")
 		}
+		if err := spec.ValidateModulePermissions(mod.Permissions); err != nil {
+			return 0, errors.New("module (" + mod.Name + ") " + err.Error())
+		}
+		if err := mod.Limits.Validate(); err != nil {
+			return 0, errors.New("module (" + mod.Name + ") " + err.Error())
+		}
 		modules[key] = &mod.Module
 	}
 	numChanges += len(modules)
@@ -71,6 +80,24 @@ func (resources *DGateResources) Validate() (int, error) {
 		} else {
 			return 0, errors.New("service (" + svc.Name + ") must specify namespace")
 		}
+		if err := svc.LoadBalancer.Validate(len(svc.URLs)); err != nil {
+			return 0, errors.New("service (" + svc.Name + ") " + err.Error())
+		}
+		if err := svc.HealthCheck.Validate(); err != nil {
+			return 0, errors.New("service (" + svc.Name + ") " + err.Error())
+		}
+		if err := svc.OutlierDetection.Validate(); err != nil {
+			return 0, errors.New("service (" + svc.Name + ") " + err.Error())
+		}
+		if err := svc.CircuitBreaker.Validate(); err != nil {
+			return 0, errors.New("service (" + svc.Name + ") " + err.Error())
+		}
+		if err := svc.RetryPolicy.Validate(); err != nil {
+			return 0, errors.New("service (" + svc.Name + ") " + err.Error())
+		}
+		if err := svc.ConcurrencyLimit.Validate(); err != nil {
+			return 0, errors.New("service (" + svc.Name + ") " + err.Error())
+		}
 		services[key] = &svc
 	}
 	numChanges += len(services)
@@ -88,6 +115,39 @@ func (resources *DGateResources) Validate() (int, error) {
 				return 0, errors.New("route (" + route.Name + ") references non-existent service (" + route.ServiceName + ")")
 			}
 		}
+		if route.TrafficSplit != nil {
+			if err := route.TrafficSplit.Validate(); err != nil {
+				return 0, errors.New("route (" + route.Name + ") " + err.Error())
+			}
+			for _, svcName := range route.TrafficSplit.ServiceNames() {
+				if _, ok := services[svcName+"-"+route.NamespaceName]; !ok {
+					return 0, errors.New("route (" + route.Name + ") references non-existent service (" + svcName + ")")
+				}
+			}
+		}
+		if err := route.Match.Validate(); err != nil {
+			return 0, errors.New("route (" + route.Name + ") " + err.Error())
+		}
+		if err := spec.ValidateRewriteRules(route.Rewrite); err != nil {
+			return 0, errors.New("route (" + route.Name + ") " + err.Error())
+		}
+		if err := route.Cache.Validate(); err != nil {
+			return 0, errors.New("route (" + route.Name + ") " + err.Error())
+		}
+		if err := route.RateLimit.Validate(); err != nil {
+			return 0, errors.New("route (" + route.Name + ") " + err.Error())
+		}
+		if err := route.ConcurrencyLimit.Validate(); err != nil {
+			return 0, errors.New("route (" + route.Name + ") " + err.Error())
+		}
+		if route.Mirror != nil {
+			if err := route.Mirror.Validate(); err != nil {
+				return 0, errors.New("route (" + route.Name + ") " + err.Error())
+			}
+			if _, ok := services[route.Mirror.ServiceName+"-"+route.NamespaceName]; !ok {
+				return 0, errors.New("route (" + route.Name + ") references non-existent service (" + route.Mirror.ServiceName + ")")
+			}
+		}
 		if route.NamespaceName != "" {
 			if _, ok := namespaces[route.NamespaceName]; !ok {
 				return 0, errors.New("route (" + route.Name + ") references non-existent namespace (" + route.NamespaceName + ")")
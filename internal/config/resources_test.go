@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/stretchr/testify/assert"
@@ -56,3 +57,22 @@ func TestValidate(t *testing.T) {
 	}
 	assert.Equal(t, 5, changes)
 }
+
+func TestValidate_ModuleLimits(t *testing.T) {
+	cpuTime := -time.Second
+	resources := &DGateResources{
+		Namespaces: []spec.Namespace{{Name: "default"}},
+		Modules: []ModuleSpec{
+			{
+				Module: spec.Module{
+					Name:          "default",
+					NamespaceName: "default",
+					Payload:       "void(0)",
+					Limits:        &spec.ModuleLimits{CPUTime: &cpuTime},
+				},
+			},
+		},
+	}
+	_, err := resources.Validate()
+	assert.ErrorContains(t, err, "module (default) module limits: cpuTime cannot be negative")
+}
@@ -1,11 +1,14 @@
 package proxy
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"errors"
 
+	"github.com/dgate-io/dgate/internal/proxy/secret_keyring"
+	"github.com/dgate-io/dgate/pkg/resources"
 	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/dgate-io/dgate/pkg/util/sliceutil"
 	"github.com/hashicorp/raft"
@@ -68,7 +71,7 @@ func (ps *ProxyState) processChangeLog(cl *spec.ChangeLog, reload, store bool) (
 				} else if !ps.changeHash.CompareAndSwap(oldHash, newHash) {
 					goto hash_retry
 				}
-			} else {
+			} else if !errors.As(err, &rejectedChangeError{}) {
 				go ps.restartState(func(err error) {
 					if err != nil {
 						ps.Stop()
@@ -229,6 +232,11 @@ func (ps *ProxyState) processModule(mod *spec.Module, cl *spec.ChangeLog) (err e
 	}
 	switch cl.Cmd.Action() {
 	case spec.Add:
+		// the module is only stored when its imports and code are valid, so
+		// a broken module is rejected without changing the state.
+		if err = ps.validateModule(mod); err != nil {
+			return rejectedChangeError{err}
+		}
 		_, err = ps.rm.AddModule(mod)
 	case spec.Delete:
 		err = ps.rm.RemoveModule(mod.Name, mod.NamespaceName)
@@ -238,6 +246,47 @@ func (ps *ProxyState) processModule(mod *spec.Module, cl *spec.ChangeLog) (err e
 	return err
 }
 
+// validateModule checks the imports of the module and compiles it
+func (ps *ProxyState) validateModule(mod *spec.Module) error {
+	ns, ok := ps.rm.GetNamespace(mod.NamespaceName)
+	if !ok {
+		return resources.ErrNamespaceNotFound(mod.NamespaceName)
+	}
+	md, err := spec.TransformModule(ns, mod)
+	if err != nil {
+		return err
+	}
+	// modules imported later are checked when the module is used by a route
+	if _, err = ps.moduleDependencies(md, true); err != nil {
+		return err
+	}
+	switch {
+	case md.Type == spec.ModuleTypeNative:
+		_, err = ps.nativeModule(md)
+	case md.Type == spec.ModuleTypeWasm:
+		_, err = ps.compileWasmModule(context.TODO(), md)
+	case md.Type.Valid():
+		// syntax errors are returned here, so they are not
+		// only found when a route using the module is changed
+		_, _, err = ps.compileModulePrograms(context.TODO(), md, false)
+	}
+	return err
+}
+
+// rejectedChangeError is returned for changes that were rejected
+// before the state was changed, so the state is not restarted.
+type rejectedChangeError struct {
+	err error
+}
+
+func (e rejectedChangeError) Error() string {
+	return e.err.Error()
+}
+
+func (e rejectedChangeError) Unwrap() error {
+	return e.err
+}
+
 func (ps *ProxyState) processDomain(dom *spec.Domain, cl *spec.ChangeLog) (err error) {
 	if dom.NamespaceName == "" {
 		dom.NamespaceName = cl.Namespace
@@ -326,6 +375,19 @@ func (ps *ProxyState) processSecret(scrt *spec.Secret, cl *spec.ChangeLog) (err
 	}
 	switch cl.Cmd.Action() {
 	case spec.Add:
+		// secrets are kept decrypted in memory
+		if err = ps.decryptSecret(scrt); err != nil {
+			// the raft log keeps the secrets written before a key was removed,
+			// they were applied again with the current key by the leader.
+			if ps.raftEnabled && errors.Is(err, secret_keyring.ErrUnknownKey) {
+				ps.logger.Warn("skipping secret encrypted with a removed key",
+					zap.String("secret", scrt.Name),
+					zap.String("namespace", scrt.NamespaceName),
+				)
+				return nil
+			}
+			return err
+		}
 		_, err = ps.rm.AddSecret(scrt)
 	case spec.Delete:
 		err = ps.rm.RemoveSecret(scrt.Name, scrt.NamespaceName)
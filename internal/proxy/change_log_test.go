@@ -1,10 +1,22 @@
 package proxy
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy/secret_keyring"
 	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
 
@@ -170,3 +182,168 @@ func testChangeLogRemoveList(t *testing.T, logs []*spec.ChangeLog, order ...int)
 		}
 	}
 }
+
+func TestRotateSecretKeys(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	t.Setenv("DGATE_TEST_OLD_KEY", base64.StdEncoding.EncodeToString(oldKey))
+	t.Setenv("DGATE_TEST_NEW_KEY", base64.StdEncoding.EncodeToString(newKey))
+	conf := configtest.NewTestDGateConfig()
+	conf.Storage = config.DGateStorageConfig{
+		StorageType: config.StorageTypeFile,
+		Config:      map[string]any{"dir": t.TempDir()},
+	}
+	conf.SecretsConfig = &config.DGateSecretsConfig{
+		DGateSecretKeyConfig: config.DGateSecretKeyConfig{KeyEnv: "DGATE_TEST_NEW_KEY"},
+		PreviousKeys: []config.DGateSecretKeyConfig{
+			{KeyEnv: "DGATE_TEST_OLD_KEY"},
+		},
+	}
+	ps := NewProxyState(zap.NewNop(), conf)
+	if err := ps.store.InitStore(); err != nil {
+		t.Fatal(err)
+	}
+	defer ps.store.CloseStore()
+
+	oldKeys, err := secret_keyring.New(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := &spec.Secret{Name: "encrypted", NamespaceName: "test"}
+	if encrypted.Data, err = oldKeys.Encrypt([]byte("old"),
+		secretAdditionalData(encrypted)); err != nil {
+		t.Fatal(err)
+	}
+	plaintext := &spec.Secret{Name: "plaintext", NamespaceName: "test",
+		Data: base64.RawStdEncoding.EncodeToString([]byte("legacy"))}
+	ps.changeLogs = []*spec.ChangeLog{
+		spec.NewChangeLog(encrypted, "test", spec.AddSecretCommand),
+		spec.NewChangeLog(plaintext, "test", spec.AddSecretCommand),
+	}
+
+	if err := ps.rotateSecretKeys(); err != nil {
+		t.Fatal(err)
+	}
+	logs, err := ps.store.FetchChangeLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, logs, 2) {
+		return
+	}
+	expected := map[string]string{"encrypted": "old", "plaintext": "legacy"}
+	for _, cl := range logs {
+		scrt, err := decode[spec.Secret](cl.Item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, ps.secretKeys.NeedsRotation(scrt.Data))
+		if assert.NoError(t, ps.decryptSecret(&scrt)) {
+			data, _ := base64.RawStdEncoding.DecodeString(scrt.Data)
+			assert.Equal(t, expected[scrt.Name], string(data))
+		}
+	}
+}
+
+// changeLogFSM applies the raft logs to the proxy state, like the admin fsm
+type changeLogFSM struct {
+	ps *ProxyState
+}
+
+func (fsm *changeLogFSM) Apply(log *raft.Log) any {
+	var cl spec.ChangeLog
+	if err := json.Unmarshal(log.Data, &cl); err != nil {
+		return err
+	}
+	return fsm.ps.ProcessChangeLog(&cl, true)
+}
+
+func (fsm *changeLogFSM) Snapshot() (raft.FSMSnapshot, error) {
+	return nil, errors.New("snapshots not supported")
+}
+
+func (fsm *changeLogFSM) Restore(io.ReadCloser) error {
+	return nil
+}
+
+func TestRotateSecretKeys_Raft(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	t.Setenv("DGATE_TEST_OLD_KEY", base64.StdEncoding.EncodeToString(oldKey))
+	t.Setenv("DGATE_TEST_NEW_KEY", base64.StdEncoding.EncodeToString(newKey))
+	conf := configtest.NewTestDGateConfig()
+	conf.AdminConfig = &config.DGateAdminConfig{
+		Replication: &config.DGateReplicationConfig{RaftID: "test"},
+	}
+	conf.SecretsConfig = &config.DGateSecretsConfig{
+		DGateSecretKeyConfig: config.DGateSecretKeyConfig{KeyEnv: "DGATE_TEST_NEW_KEY"},
+		PreviousKeys: []config.DGateSecretKeyConfig{
+			{KeyEnv: "DGATE_TEST_OLD_KEY"},
+		},
+	}
+	ps := NewProxyState(zap.NewNop(), conf)
+
+	raftConf := raft.DefaultConfig()
+	raftConf.LocalID = "test"
+	raftConf.Logger = hclog.NewNullLogger()
+	raftConf.HeartbeatTimeout = 50 * time.Millisecond
+	raftConf.ElectionTimeout = 50 * time.Millisecond
+	raftConf.LeaderLeaseTimeout = 50 * time.Millisecond
+	store := raft.NewInmemStore()
+	addr, transport := raft.NewInmemTransport("")
+	r, err := raft.NewRaft(raftConf, &changeLogFSM{ps}, store, store,
+		raft.NewInmemSnapshotStore(), transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Shutdown()
+	ps.SetupRaft(r, nil)
+	err = r.BootstrapCluster(raft.Configuration{Servers: []raft.Server{
+		{ID: raftConf.LocalID, Address: addr},
+	}}).Error()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the secret is written to the raft log with the old key
+	oldKeys, err := secret_keyring.New(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scrt := &spec.Secret{Name: "encrypted", NamespaceName: "test"}
+	if scrt.Data, err = oldKeys.Encrypt([]byte("old"),
+		secretAdditionalData(scrt)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(spec.NewChangeLog(scrt, "test", spec.AddSecretCommand))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Eventually(t, func() bool {
+		return r.State() == raft.Leader
+	}, 5*time.Second, 10*time.Millisecond)
+	if err = r.Apply(data, time.Second).Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ps.rotateRaftSecretKeys(); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := ps.secretsToRotate()
+	if assert.NoError(t, err) {
+		assert.Empty(t, secrets)
+	}
+
+	// the log can be replayed without the old key
+	newKeys, err := secret_keyring.New(newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps.secretKeys = newKeys
+	ps.rm.Empty()
+	for _, cl := range ps.ChangeLogs() {
+		assert.NoError(t, ps.processChangeLog(cl, false, false))
+	}
+	sec, ok := ps.rm.GetSecret("encrypted", "test")
+	if assert.True(t, ok) {
+		assert.Equal(t, "old", sec.Data)
+	}
+}
@@ -0,0 +1,157 @@
+package circuit_breaker
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	}
+	return "unknown"
+}
+
+// StateChangeFunc is called when the breaker changes state
+type StateChangeFunc func(from, to State)
+
+// Result is the result of a request that was allowed by the breaker
+type Result int
+
+const (
+	// Skipped is a request that was not sent to the upstream, its
+	// slot is released without changing the state of the breaker.
+	Skipped Result = iota
+	Success
+	Failure
+)
+
+// DoneFunc reports the result of a request that was allowed by the breaker
+type DoneFunc func(result Result)
+
+// Breaker is a circuit breaker for a service. It opens after too many
+// failures in a row, and once the open duration has passed a limited
+// number of trial requests are allowed (half-open) to decide if the
+// breaker should close again.
+type Breaker struct {
+	mtx       sync.Mutex
+	spec      *spec.CircuitBreaker
+	cb        spec.CircuitBreaker
+	state     State
+	failures  int
+	successes int
+	trials    int
+	openedAt  time.Time
+	onChange  StateChangeFunc
+}
+
+// New creates a closed breaker, onChange may be nil.
+func New(cb *spec.CircuitBreaker, onChange StateChangeFunc) *Breaker {
+	return &Breaker{
+		spec:     cb,
+		cb:       cb.WithDefaults(),
+		onChange: onChange,
+	}
+}
+
+// Matches checks if the breaker was created with the same config.
+func (b *Breaker) Matches(cb *spec.CircuitBreaker) bool {
+	return reflect.DeepEqual(b.spec, cb)
+}
+
+func (b *Breaker) State() State {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.state
+}
+
+// Allow checks if a request can be sent, the returned done func must be called with
+// the result of the request, or with Skipped when it was not sent. When the request is not allowed, retryAfter is the
+// time left until the breaker lets requests through again.
+func (b *Breaker) Allow() (done DoneFunc, retryAfter time.Duration, ok bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.state == StateOpen {
+		if remaining := b.cb.OpenDuration - time.Since(b.openedAt); remaining > 0 {
+			return nil, remaining, false
+		}
+		b.setState(StateHalfOpen)
+	}
+	trial := false
+	if b.state == StateHalfOpen {
+		if b.trials >= b.cb.HalfOpenRequests {
+			return nil, time.Second, false
+		}
+		b.trials++
+		trial = true
+	}
+	once := sync.Once{}
+	return func(result Result) {
+		once.Do(func() {
+			b.record(trial, result)
+		})
+	}, 0, true
+}
+
+func (b *Breaker) record(trial bool, result Result) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if trial && b.trials > 0 {
+		b.trials--
+	}
+	if result == Skipped {
+		return
+	}
+	success := result == Success
+	switch b.state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+		} else if b.failures++; b.failures >= b.cb.FailureThreshold {
+			b.open()
+		}
+	case StateHalfOpen:
+		if !success {
+			b.open()
+		} else if b.successes++; b.successes >= b.cb.HalfOpenRequests {
+			b.failures = 0
+			b.successes = 0
+			b.trials = 0
+			b.setState(StateClosed)
+		}
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = time.Now()
+	b.successes = 0
+	b.trials = 0
+	b.setState(StateOpen)
+}
+
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
@@ -0,0 +1,104 @@
+package circuit_breaker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/circuit_breaker"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_OpenAndClose(t *testing.T) {
+	changes := []circuit_breaker.State{}
+	b := circuit_breaker.New(&spec.CircuitBreaker{
+		FailureThreshold: 3,
+		OpenDuration:     50 * time.Millisecond,
+		HalfOpenRequests: 1,
+	}, func(_, to circuit_breaker.State) {
+		changes = append(changes, to)
+	})
+
+	for i := 0; i < 3; i++ {
+		done, _, ok := b.Allow()
+		assert.True(t, ok)
+		done(circuit_breaker.Failure)
+	}
+	assert.Equal(t, circuit_breaker.StateOpen, b.State())
+	_, retryAfter, ok := b.Allow()
+	assert.False(t, ok)
+	assert.Greater(t, retryAfter, time.Duration(0))
+
+	time.Sleep(60 * time.Millisecond)
+	done, _, ok := b.Allow()
+	assert.True(t, ok)
+	assert.Equal(t, circuit_breaker.StateHalfOpen, b.State())
+	// only one trial request is allowed while half-open
+	_, _, ok = b.Allow()
+	assert.False(t, ok)
+	done(circuit_breaker.Success)
+	assert.Equal(t, circuit_breaker.StateClosed, b.State())
+
+	assert.Equal(t, []circuit_breaker.State{
+		circuit_breaker.StateOpen,
+		circuit_breaker.StateHalfOpen,
+		circuit_breaker.StateClosed,
+	}, changes)
+}
+
+func TestCircuitBreaker_HalfOpenFailure(t *testing.T) {
+	b := circuit_breaker.New(&spec.CircuitBreaker{
+		FailureThreshold: 1,
+		OpenDuration:     20 * time.Millisecond,
+	}, nil)
+	done, _, _ := b.Allow()
+	done(circuit_breaker.Failure)
+	assert.Equal(t, circuit_breaker.StateOpen, b.State())
+
+	time.Sleep(30 * time.Millisecond)
+	done, _, ok := b.Allow()
+	assert.True(t, ok)
+	done(circuit_breaker.Failure)
+	// calling done again has no effect
+	done(circuit_breaker.Success)
+	assert.Equal(t, circuit_breaker.StateOpen, b.State())
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b := circuit_breaker.New(&spec.CircuitBreaker{
+		FailureThreshold: 2,
+	}, nil)
+	for i := 0; i < 5; i++ {
+		done, _, ok := b.Allow()
+		assert.True(t, ok)
+		if i%2 == 0 {
+			done(circuit_breaker.Success)
+		} else {
+			done(circuit_breaker.Failure)
+		}
+	}
+	assert.Equal(t, circuit_breaker.StateClosed, b.State())
+	assert.True(t, b.Matches(&spec.CircuitBreaker{FailureThreshold: 2}))
+	assert.False(t, b.Matches(&spec.CircuitBreaker{FailureThreshold: 3}))
+}
+
+func TestCircuitBreaker_Skipped(t *testing.T) {
+	b := circuit_breaker.New(&spec.CircuitBreaker{
+		FailureThreshold: 1,
+		OpenDuration:     20 * time.Millisecond,
+		HalfOpenRequests: 1,
+	}, nil)
+	done, _, _ := b.Allow()
+	done(circuit_breaker.Failure)
+
+	time.Sleep(30 * time.Millisecond)
+	done, _, ok := b.Allow()
+	assert.True(t, ok)
+	// a request that was not sent does not close the breaker, but frees its trial
+	done(circuit_breaker.Skipped)
+	assert.Equal(t, circuit_breaker.StateHalfOpen, b.State())
+	done, _, ok = b.Allow()
+	assert.True(t, ok)
+	done(circuit_breaker.Success)
+	assert.Equal(t, circuit_breaker.StateClosed, b.State())
+}
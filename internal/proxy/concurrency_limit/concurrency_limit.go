@@ -0,0 +1,170 @@
+package concurrency_limit
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+var (
+	// ErrQueueFull is returned when the limit is reached and no more requests can be queued
+	ErrQueueFull = errors.New("concurrency limit reached")
+	// ErrQueueTimeout is returned when a queued request waited longer than the queue timeout
+	ErrQueueTimeout = errors.New("concurrency limit queue timeout")
+)
+
+// Limiter limits the number of concurrent requests, in the adaptive
+// modes the limit is changed by the latency of the requests.
+type Limiter struct {
+	mtx      sync.Mutex
+	config   spec.ConcurrencyLimit
+	limit    float64
+	inflight int
+	// queue has the channels of the waiting requests, oldest first
+	queue *list.List
+	// longRTT is the exponential moving average of the latency, used by the gradient mode
+	longRTT time.Duration
+}
+
+func New(config *spec.ConcurrencyLimit) *Limiter {
+	cfg := spec.ConcurrencyLimit{}
+	if config != nil {
+		cfg = *config
+	}
+	cfg = cfg.WithDefaults()
+	return &Limiter{
+		config: cfg,
+		limit:  float64(max(cfg.Limit, 1)),
+		queue:  list.New(),
+	}
+}
+
+// Limit returns the current limit
+func (l *Limiter) Limit() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.currentLimit()
+}
+
+// Inflight returns the number of requests being handled
+func (l *Limiter) Inflight() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.inflight
+}
+
+// RetryAfter is the suggested time for clients to wait after their request was shed
+func (l *Limiter) RetryAfter() time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return max(l.longRTT, time.Second)
+}
+
+func (l *Limiter) currentLimit() int {
+	return int(math.Floor(l.limit))
+}
+
+// Acquire waits for the limit and returns the function that must be called when the request
+// is done. Requests wait in the queue until the queue timeout or until the context is done.
+func (l *Limiter) Acquire(ctx context.Context) (func(failed bool), error) {
+	l.mtx.Lock()
+	if l.inflight < l.currentLimit() && l.queue.Len() == 0 {
+		l.inflight++
+		l.mtx.Unlock()
+		return l.release(time.Now()), nil
+	}
+	if l.queue.Len() >= l.config.QueueSize {
+		l.mtx.Unlock()
+		return nil, ErrQueueFull
+	}
+	ready := make(chan struct{})
+	el := l.queue.PushBack(ready)
+	l.mtx.Unlock()
+
+	timer := time.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-ready:
+		return l.release(time.Now()), nil
+	case <-timer.C:
+		err = ErrQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	select {
+	case <-ready:
+		// the slot was handed over while timing out, so it is given back
+		l.inflight--
+		l.dispatch()
+	default:
+		l.queue.Remove(el)
+	}
+	return nil, err
+}
+
+func (l *Limiter) release(start time.Time) func(failed bool) {
+	var once sync.Once
+	return func(failed bool) {
+		once.Do(func() {
+			rtt := time.Since(start)
+			l.mtx.Lock()
+			defer l.mtx.Unlock()
+			l.inflight--
+			l.update(rtt, failed)
+			l.dispatch()
+		})
+	}
+}
+
+// dispatch hands the free slots over to the queued requests
+func (l *Limiter) dispatch() {
+	for l.inflight < l.currentLimit() && l.queue.Len() > 0 {
+		ready := l.queue.Remove(l.queue.Front()).(chan struct{})
+		l.inflight++
+		close(ready)
+	}
+}
+
+// update changes the limit with the latency of a finished request
+func (l *Limiter) update(rtt time.Duration, failed bool) {
+	if l.longRTT == 0 {
+		l.longRTT = rtt
+	} else {
+		l.longRTT = time.Duration(0.95*float64(l.longRTT) + 0.05*float64(rtt))
+	}
+
+	switch l.config.Mode {
+	case spec.ConcurrencyLimitAIMD:
+		if failed || rtt > l.config.LatencyThreshold {
+			l.limit *= l.config.BackoffRatio
+		} else if l.inflight+1 >= l.currentLimit()/2 {
+			// the limit is only raised when it is being used
+			l.limit += 1 / l.limit
+		}
+	case spec.ConcurrencyLimitGradient:
+		gradient := 1.0
+		if failed {
+			gradient = 0.5
+		} else if rtt > 0 {
+			gradient = max(0.5, min(1, float64(l.longRTT)/float64(rtt)))
+		}
+		newLimit := l.limit * gradient
+		if gradient == 1 && l.inflight+1 >= l.currentLimit()/2 {
+			// headroom to probe for a higher limit
+			newLimit += math.Sqrt(l.limit)
+		}
+		l.limit = 0.8*l.limit + 0.2*newLimit
+	default:
+		return
+	}
+	l.limit = max(float64(l.config.MinLimit), min(l.limit, float64(l.config.MaxLimit)))
+}
@@ -0,0 +1,124 @@
+package concurrency_limit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/concurrency_limit"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Fixed(t *testing.T) {
+	l := concurrency_limit.New(&spec.ConcurrencyLimit{Limit: 2})
+	r1, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	_, err = l.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, l.Inflight())
+
+	_, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, concurrency_limit.ErrQueueFull)
+
+	r1(false)
+	// releasing twice does not free another slot
+	r1(false)
+	assert.Equal(t, 1, l.Inflight())
+	_, err = l.Acquire(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, l.Limit())
+}
+
+func TestLimiter_Queue(t *testing.T) {
+	l := concurrency_limit.New(&spec.ConcurrencyLimit{
+		Limit:        1,
+		QueueSize:    1,
+		QueueTimeout: time.Second,
+	})
+	release, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+
+	acquired := make(chan error)
+	go func() {
+		_, err := l.Acquire(context.Background())
+		acquired <- err
+	}()
+	assert.Eventually(t, func() bool {
+		_, err := l.Acquire(context.Background())
+		return err == concurrency_limit.ErrQueueFull
+	}, time.Second, time.Millisecond)
+
+	release(false)
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("queued request was not handed the released slot")
+	}
+	assert.Equal(t, 1, l.Inflight())
+}
+
+func TestLimiter_QueueTimeout(t *testing.T) {
+	l := concurrency_limit.New(&spec.ConcurrencyLimit{
+		Limit:        1,
+		QueueSize:    1,
+		QueueTimeout: 20 * time.Millisecond,
+	})
+	_, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+
+	_, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, concurrency_limit.ErrQueueTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, l.Inflight())
+}
+
+func TestLimiter_AIMD(t *testing.T) {
+	l := concurrency_limit.New(&spec.ConcurrencyLimit{
+		Limit:            10,
+		Mode:             spec.ConcurrencyLimitAIMD,
+		MinLimit:         2,
+		LatencyThreshold: time.Second,
+		BackoffRatio:     0.5,
+	})
+	release, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	release(true)
+	assert.Equal(t, 5, l.Limit())
+
+	for i := 0; i < 5; i++ {
+		release, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+		release(true)
+	}
+	assert.Equal(t, 2, l.Limit(), "limit should not go under the min limit")
+
+	// the limit grows when the slots are used
+	for i := 0; i < 20; i++ {
+		r1, _ := l.Acquire(context.Background())
+		r2, _ := l.Acquire(context.Background())
+		r1(false)
+		r2(false)
+	}
+	assert.Greater(t, l.Limit(), 2)
+}
+
+func TestLimiter_Gradient(t *testing.T) {
+	l := concurrency_limit.New(&spec.ConcurrencyLimit{
+		Limit: 20,
+		Mode:  spec.ConcurrencyLimitGradient,
+	})
+	for i := 0; i < 10; i++ {
+		release, err := l.Acquire(context.Background())
+		require.NoError(t, err)
+		release(true)
+	}
+	assert.Less(t, l.Limit(), 20)
+	assert.GreaterOrEqual(t, l.Limit(), 1)
+}
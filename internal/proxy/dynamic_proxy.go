@@ -1,18 +1,24 @@
 package proxy
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"math"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/dgate-io/dgate/internal/proxy/route_match"
 	"github.com/dgate-io/dgate/internal/router"
 	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/wasm"
 	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/dgate-io/dgate/pkg/typescript"
+	"github.com/dgate-io/dgate/pkg/util"
 	"github.com/dgate-io/dgate/pkg/util/tree/avl"
 	"github.com/dop251/goja"
 	"go.uber.org/zap"
@@ -42,6 +48,16 @@ func (ps *ProxyState) reconfigureState(log *spec.ChangeLog) (err error) {
 		ps.logger.Error("Error setting up modules", zap.Error(err))
 		return
 	}
+	if err = ps.setupHealthChecks(); err != nil {
+		ps.logger.Error("Error setting up health checks", zap.Error(err))
+		return
+	}
+	ps.setupModuleJobs()
+	ps.setupCircuitBreakers()
+	ps.setupRetryBudgets()
+	ps.setupResponseCaches()
+	ps.setupRateLimiters()
+	ps.setupConcurrencyLimits()
 	if err = ps.setupRoutes(ctx, log); err != nil {
 		ps.logger.Error("Error setting up routes", zap.Error(err))
 		return
@@ -72,55 +88,55 @@ func (ps *ProxyState) setupModules(
 		routes = ps.rm.GetRoutesByNamespace(log.Namespace)
 	}
 	programs := avl.NewTree[string, *goja.Program]()
+	chainPrograms := avl.NewTree[string, *goja.Program]()
+	imports := avl.NewTree[string, []string]()
+	wasmModules := avl.NewTree[string, *wasm.Module]()
 	grp, ctx := customErrGroup(ctx, len(routes))
 	start := time.Now()
 	for _, rt := range routes {
 		if len(rt.Modules) > 0 {
 			route := rt
 			grp.Go(func() error {
-				mod := route.Modules[0]
-				var (
-					err        error
-					program    *goja.Program
-					modPayload string = mod.Payload
-				)
-				if mod.Type == spec.ModuleTypeTypescript {
-					tsBucket := ps.sharedCache.Bucket("typescript")
-					// hash the typescript module payload
-					tsHash, err := HashString(1337, modPayload)
-					if err != nil {
-						ps.logger.Error("Error hashing module: " + mod.Name)
-					} else if cacheData, ok := tsBucket.Get(tsHash); ok {
-						if modPayload, ok = cacheData.(string); ok {
-							goto compile
+				// chained modules are also compiled in their own scope
+				chained := len(route.Modules) > 1
+				for _, mod := range route.Modules {
+					if mod.Type == spec.ModuleTypeNative {
+						// native modules are loaded on startup, there is nothing to compile
+						if _, err := ps.nativeModule(mod); err != nil {
+							return err
+						}
+						continue
+					} else if mod.Type == spec.ModuleTypeWasm {
+						wasmMod, err := ps.compileWasmModule(ctx, mod)
+						if err != nil {
+							return err
 						}
+						wasmModules.Insert(mod.Name+"/"+route.Namespace.Name, wasmMod)
+						continue
 					}
-					if modPayload, err = typescript.Transpile(ctx, modPayload); err != nil {
-						ps.logger.Error("Error transpiling module: " + mod.Name)
+					program, chainProgram, err := ps.compileModule(ctx, route, mod, chained)
+					if err != nil {
 						return err
-					} else {
-						tsBucket.SetWithTTL(tsHash, modPayload, 5*time.Minute)
 					}
-				}
-			compile:
-				if mod.Type == spec.ModuleTypeJavascript || mod.Type == spec.ModuleTypeTypescript {
-					if program, err = goja.Compile(mod.Name, modPayload, true); err != nil {
-						ps.logger.Error("Error compiling module: " + mod.Name)
+					programs.Insert(mod.Name+"/"+route.Namespace.Name, program)
+					if chainProgram != nil {
+						chainPrograms.Insert(mod.Name+"/"+route.Namespace.Name, chainProgram)
+					}
+					// imported modules are compiled in their own scope, like chained modules
+					deps, err := ps.moduleDependencies(mod, false)
+					if err != nil {
 						return err
 					}
-				} else {
-					return errors.New("invalid module type: " + mod.Type.String())
-				}
-
-				tmpCtx := NewRuntimeContext(ps, route, mod)
-				defer tmpCtx.Clean()
-				if err = extractors.SetupModuleEventLoop(ps.printer, tmpCtx); err != nil {
-					ps.logger.Error("Error applying module changes",
-						zap.Error(err), zap.String("module", mod.Name),
-					)
-					return err
+					names := make([]string, len(deps))
+					for i, dep := range deps {
+						if _, chainProgram, err = ps.compileModule(ctx, route, dep, true); err != nil {
+							return err
+						}
+						chainPrograms.Insert(dep.Name+"/"+route.Namespace.Name, chainProgram)
+						names[i] = dep.Name
+					}
+					imports.Insert(mod.Name+"/"+route.Namespace.Name, names)
 				}
-				programs.Insert(mod.Name+"/"+route.Namespace.Name, program)
 				return nil
 			})
 		}
@@ -133,12 +149,116 @@ func (ps *ProxyState) setupModules(
 		ps.modPrograms.Insert(s, p)
 		return true
 	})
+	chainPrograms.Each(func(s string, p *goja.Program) bool {
+		ps.modChainPrograms.Insert(s, p)
+		return true
+	})
+	imports.Each(func(s string, names []string) bool {
+		ps.modImports.Insert(s, names)
+		return true
+	})
+	wasmModules.Each(func(s string, m *wasm.Module) bool {
+		ps.modWasm.Insert(s, m)
+		return true
+	})
 	ps.logger.Debug("Modules setup",
 		zap.Duration("elapsed", time.Since(start)),
 	)
 	return nil
 }
 
+// transpileModule returns the javascript source of the module, typescript modules are
+// transpiled with an inline source map, so errors refer to the typescript source.
+func (ps *ProxyState) transpileModule(ctx context.Context, mod *spec.DGateModule) (string, error) {
+	if mod.Type != spec.ModuleTypeTypescript {
+		return mod.Payload, nil
+	}
+	tsBucket := ps.sharedCache.Bucket("typescript")
+	// hash the typescript module name and payload, the
+	// source map of the transpiled module refers to the name
+	tsHash, err := HashString(1337, mod.Name, mod.Payload)
+	if err != nil {
+		ps.logger.Error("Error hashing module: " + mod.Name)
+	} else if cacheData, ok := tsBucket.Get(tsHash); ok {
+		if modPayload, ok := cacheData.(string); ok {
+			return modPayload, nil
+		}
+	}
+	modPayload, err := typescript.TranspileModule(ctx, mod.Name, mod.Payload)
+	if err != nil {
+		ps.logger.Error("Error transpiling module: "+mod.Name, zap.Error(err))
+		return "", err
+	}
+	tsBucket.SetWithTTL(tsHash, modPayload, 5*time.Minute)
+	return modPayload, nil
+}
+
+// compileModulePrograms compiles the module, and the chain program of the module when chained is true,
+// wasm modules are compiled by compileWasmModule.
+func (ps *ProxyState) compileModulePrograms(
+	ctx context.Context,
+	mod *spec.DGateModule,
+	chained bool,
+) (program, chainProgram *goja.Program, err error) {
+	if mod.Type != spec.ModuleTypeJavascript && mod.Type != spec.ModuleTypeTypescript {
+		return nil, nil, errors.New("invalid module type: " + mod.Type.String())
+	}
+	modPayload, err := ps.transpileModule(ctx, mod)
+	if err != nil {
+		return nil, nil, err
+	}
+	if program, err = goja.Compile(mod.Name, modPayload, true); err != nil {
+		ps.logger.Error("Error compiling module: "+mod.Name, zap.Error(err))
+		return nil, nil, err
+	}
+	if chained {
+		chainSource := extractors.ChainModuleSource(modPayload)
+		if chainProgram, err = goja.Compile(mod.Name, chainSource, true); err != nil {
+			ps.logger.Error("Error compiling module: "+mod.Name, zap.Error(err))
+			return nil, nil, err
+		}
+	}
+	return program, chainProgram, nil
+}
+
+// compileWasmModule compiles the binary of a wasm module, the module is instantiated
+// once, so missing imports are found before the module is used by a request.
+func (ps *ProxyState) compileWasmModule(ctx context.Context, mod *spec.DGateModule) (*wasm.Module, error) {
+	wasmMod, err := ps.wasmRuntime.Compile(ctx, mod.Name, []byte(mod.Payload))
+	if err != nil {
+		ps.logger.Error("Error compiling module: "+mod.Name, zap.Error(err))
+		return nil, err
+	}
+	if _, err = wasmMod.Functions(ctx, ps.moduleLimits(mod)); err != nil {
+		ps.logger.Error("Error instantiating module: "+mod.Name, zap.Error(err))
+		return nil, err
+	}
+	return wasmMod, nil
+}
+
+// compileModule compiles the module, the chain program is only compiled
+// when the module is chained with other modules of the route.
+func (ps *ProxyState) compileModule(
+	ctx context.Context,
+	route *spec.DGateRoute,
+	mod *spec.DGateModule,
+	chained bool,
+) (program, chainProgram *goja.Program, err error) {
+	if program, chainProgram, err = ps.compileModulePrograms(ctx, mod, chained); err != nil {
+		return nil, nil, err
+	}
+
+	tmpCtx := NewRuntimeContext(ps, route, mod)
+	defer tmpCtx.Clean()
+	if err = extractors.SetupModuleEventLoop(ps.printer, tmpCtx); err != nil {
+		ps.logger.Error("Error applying module changes",
+			zap.Error(err), zap.String("module", mod.Name),
+		)
+		return nil, nil, err
+	}
+	return program, chainProgram, nil
+}
+
 func (ps *ProxyState) setupRoutes(
 	ctx context.Context,
 	log *spec.ChangeLog,
@@ -146,7 +266,9 @@ func (ps *ProxyState) setupRoutes(
 	var rtMap map[string][]*spec.DGateRoute
 	if log.Namespace == "" || ps.pendingChanges {
 		rtMap = ps.rm.GetRouteNamespaceMap()
-		ps.providers.Clear()
+		// the runtime pools are released after the new routes acquire
+		// them, so the runtimes of unchanged modules are kept warm.
+		defer releaseModulePools(ps.clearProviders())
 	} else {
 		rtMap = make(map[string][]*spec.DGateRoute)
 		routes := ps.rm.GetRoutesByNamespace(log.Namespace)
@@ -168,38 +290,86 @@ func (ps *ProxyState) setupRoutes(
 				}
 			}()
 			mux := router.NewMux()
+			// routes are grouped by path and method, so routes sharing
+			// a path can be picked by their match conditions.
+			candidates := make(map[string]map[string][]*routeCandidate)
+			paths := []string{}
 			for _, rt := range routes {
 				reqCtxProvider := NewRequestContextProvider(rt, ps)
 				if len(rt.Modules) > 0 {
-					modExtFunc := ps.createModuleExtractorFunc(rt)
-					if modPool, err := NewModulePool(
-						0, 1024, time.Minute*5,
-						reqCtxProvider, modExtFunc,
-					); err != nil {
-						ps.logger.Error("Error creating module buffer", zap.Error(err))
-						return err
-					} else {
-						reqCtxProvider.UpdateModulePool(modPool)
+					for _, provider := range reqCtxProvider.Providers() {
+						provider := provider
+						modExtFunc := ps.createModuleExtractorFunc(provider.route)
+						key, moduleNames, err := ps.runtimePoolKey(provider.route)
+						if err != nil {
+							return err
+						}
+						if modPool, err := ps.runtimes.Acquire(
+							key, rt.Namespace.Name, moduleNames,
+							func() (ModuleExtractor, error) {
+								return modExtFunc(provider)
+							},
+						); err != nil {
+							ps.logger.Error("Error creating module buffer", zap.Error(err))
+							return err
+						} else {
+							provider.UpdateModulePool(modPool)
+						}
 					}
 				}
 				oldReqCtxProvider := ps.providers.Insert(rt.Namespace.Name+"/"+rt.Name, reqCtxProvider)
 				if oldReqCtxProvider != nil {
 					oldReqCtxProvider.Close()
 				}
+				match, err := route_match.New(rt.Match)
+				if err != nil {
+					return err
+				}
+				if len(rt.Methods) > 0 && rt.Methods[0] == "*" {
+					if len(rt.Methods) > 1 {
+						return errors.New("route methods cannot have other methods with *")
+					}
+				} else if len(rt.Methods) == 0 {
+					return errors.New("route must have at least one method")
+				} else if err = ValidateMethods(rt.Methods); err != nil {
+					return err
+				}
+				candidate := &routeCandidate{
+					provider: reqCtxProvider,
+					match:    match,
+					priority: rt.Priority,
+				}
 				for _, path := range rt.Paths {
-					if len(rt.Methods) > 0 && rt.Methods[0] == "*" {
-						if len(rt.Methods) > 1 {
-							return errors.New("route methods cannot have other methods with *")
-						}
-						mux.Handle(path, ps.HandleRoute(reqCtxProvider, path))
-					} else {
-						if len(rt.Methods) == 0 {
-							return errors.New("route must have at least one method")
-						} else if err = ValidateMethods(rt.Methods); err != nil {
-							return err
-						}
-						for _, method := range rt.Methods {
-							mux.Method(method, path, ps.HandleRoute(reqCtxProvider, path))
+					if _, ok := candidates[path]; !ok {
+						candidates[path] = make(map[string][]*routeCandidate)
+						paths = append(paths, path)
+					}
+					for _, method := range rt.Methods {
+						candidates[path][method] = append(candidates[path][method], candidate)
+					}
+				}
+			}
+			for _, path := range paths {
+				anyMethod := candidates[path]["*"]
+				if len(anyMethod) > 0 && len(candidates[path]) == 1 {
+					mux.Handle(path, ps.handleRouteCandidates(anyMethod, path))
+					continue
+				}
+				// routes for any method are also candidates for requests
+				// with a method that has routes of its own
+				for method, methodCandidates := range candidates[path] {
+					if method != "*" {
+						mux.Method(method, path, ps.handleRouteCandidates(
+							slices.Concat(methodCandidates, anyMethod), path))
+					}
+				}
+				// the mux can not have a handler for any method on a
+				// path with method handlers, so each method is added.
+				if len(anyMethod) > 0 {
+					anyHandler := ps.handleRouteCandidates(anyMethod, path)
+					for _, method := range validMethods {
+						if _, ok := candidates[path][method]; !ok {
+							mux.Method(method, path, anyHandler)
 						}
 					}
 				}
@@ -222,19 +392,46 @@ func (ps *ProxyState) setupRoutes(
 	return nil
 }
 
+// clearProviders removes the request context providers of all the routes, and returns them
+func (ps *ProxyState) clearProviders() []*RequestContextProvider {
+	providers := make([]*RequestContextProvider, 0, ps.providers.Length())
+	ps.providers.Each(func(_ string, p *RequestContextProvider) bool {
+		providers = append(providers, p)
+		return true
+	})
+	ps.providers.Clear()
+	return providers
+}
+
+// releaseModulePools closes the module pools of the providers, requests
+// still using the providers can return their runtimes to the pools.
+func releaseModulePools(providers []*RequestContextProvider) {
+	for _, p := range providers {
+		for _, provider := range p.Providers() {
+			provider.UpdateModulePool(nil)
+		}
+	}
+}
+
 func (ps *ProxyState) createModuleExtractorFunc(rt *spec.DGateRoute) ModuleExtractorFunc {
 	return func(reqCtx *RequestContextProvider) (_ ModuleExtractor, err error) {
 		if len(rt.Modules) == 0 {
 			return nil, fmt.Errorf("no modules found for route: %s/%s", rt.Name, rt.Namespace.Name)
 		}
-		// TODO: Perhaps have some entrypoint flag to determine which module to use
+		// wasm and native modules only run in a chain, their functions are not in the event loop
+		if len(rt.Modules) > 1 || rt.Modules[0].Type == spec.ModuleTypeWasm ||
+			rt.Modules[0].Type == spec.ModuleTypeNative {
+			return ps.createModuleChainExtractor(rt, reqCtx)
+		}
 		m := rt.Modules[0]
 		if program, ok := ps.modPrograms.Find(m.Name + "/" + rt.Namespace.Name); !ok {
 			ps.logger.Error("Error getting module program: invalid state", zap.Error(err))
 			return nil, fmt.Errorf("cannot find module program: %s/%s", m.Name, rt.Namespace.Name)
 		} else {
 			rtCtx := NewRuntimeContext(ps, rt, rt.Modules...)
-			if err := extractors.SetupModuleEventLoop(ps.printer, rtCtx, program); err != nil {
+			if err := ps.registerModuleImports(rtCtx, rt); err != nil {
+				return nil, err
+			} else if err := extractors.SetupModuleEventLoop(ps.printer, rtCtx, program); err != nil {
 				ps.logger.Error("Error creating runtime for route",
 					zap.String("route", reqCtx.route.Name),
 					zap.String("namespace", reqCtx.route.Namespace.Name),
@@ -242,27 +439,28 @@ func (ps *ProxyState) createModuleExtractorFunc(rt *spec.DGateRoute) ModuleExtra
 				return nil, err
 			} else {
 				loop := rtCtx.EventLoop()
-				errorHandler, err := extractors.ExtractErrorHandlerFunction(loop)
+				limits := ps.moduleLimits(m)
+				errorHandler, err := extractors.ExtractErrorHandlerFunction(loop, limits)
 				if err != nil {
 					ps.logger.Error("Error extracting error handler function", zap.Error(err))
 					return nil, err
 				}
-				fetchUpstream, err := extractors.ExtractFetchUpstreamFunction(loop)
+				fetchUpstream, err := extractors.ExtractFetchUpstreamFunction(loop, limits)
 				if err != nil {
 					ps.logger.Error("Error extracting fetch upstream function", zap.Error(err))
 					return nil, err
 				}
-				reqModifier, err := extractors.ExtractRequestModifierFunction(loop)
+				reqModifier, err := extractors.ExtractRequestModifierFunction(loop, limits)
 				if err != nil {
 					ps.logger.Error("Error extracting request modifier function", zap.Error(err))
 					return nil, err
 				}
-				resModifier, err := extractors.ExtractResponseModifierFunction(loop)
+				resModifier, err := extractors.ExtractResponseModifierFunction(loop, limits)
 				if err != nil {
 					ps.logger.Error("Error extracting response modifier function", zap.Error(err))
 					return nil, err
 				}
-				reqHandler, err := extractors.ExtractRequestHandlerFunction(loop)
+				reqHandler, err := extractors.ExtractRequestHandlerFunction(loop, limits)
 				if err != nil {
 					ps.logger.Error("Error extracting request handler function", zap.Error(err))
 					return nil, err
@@ -277,6 +475,85 @@ func (ps *ProxyState) createModuleExtractorFunc(rt *spec.DGateRoute) ModuleExtra
 	}
 }
 
+// runtimePoolKey returns the key of the runtime pool of the route, routes of a namespace share
+// a pool when they use the same modules, and when the modules and their imports did not change.
+func (ps *ProxyState) runtimePoolKey(rt *spec.DGateRoute) (string, []string, error) {
+	names := make([]string, 0, len(rt.Modules))
+	mods := make([]*spec.DGateModule, 0, len(rt.Modules))
+	for _, mod := range rt.Modules {
+		deps, err := ps.moduleDependencies(mod, true)
+		if err != nil {
+			return "", nil, err
+		}
+		names = append(names, mod.Name)
+		mods = append(append(mods, deps...), mod)
+	}
+	hash, err := HashString(0, mods...)
+	if err != nil {
+		return "", nil, err
+	}
+	return rt.Namespace.Name + "/" + strings.Join(names, ",") + "/" + hash, names, nil
+}
+
+// createModuleChainExtractor creates the module extractor for a route with more than one module,
+// the functions of the modules are run in the order the modules are declared in the route.
+func (ps *ProxyState) createModuleChainExtractor(
+	rt *spec.DGateRoute, reqCtx *RequestContextProvider,
+) (ModuleExtractor, error) {
+	chain := make([]extractors.ChainModule, len(rt.Modules))
+	for i, m := range rt.Modules {
+		if m.Type == spec.ModuleTypeNative {
+			nativeMod, err := ps.nativeModule(m)
+			if err != nil {
+				return nil, err
+			}
+			fns := nativeMod.Functions()
+			fns.Module = m.Name
+			chain[i] = extractors.ChainModule{Name: m.Name, Functions: fns}
+			continue
+		} else if m.Type == spec.ModuleTypeWasm {
+			wasmMod, ok := ps.modWasm.Find(m.Name + "/" + rt.Namespace.Name)
+			if !ok {
+				ps.logger.Error("Error getting wasm module: invalid state")
+				return nil, fmt.Errorf("cannot find wasm module: %s/%s", m.Name, rt.Namespace.Name)
+			}
+			fns, err := wasmMod.Functions(context.TODO(), ps.moduleLimits(m))
+			if err != nil {
+				return nil, err
+			}
+			chain[i] = extractors.ChainModule{Name: m.Name, Functions: fns}
+			continue
+		}
+		program, ok := ps.modChainPrograms.Find(m.Name + "/" + rt.Namespace.Name)
+		if !ok {
+			ps.logger.Error("Error getting module program: invalid state")
+			return nil, fmt.Errorf("cannot find module program: %s/%s", m.Name, rt.Namespace.Name)
+		}
+		chain[i] = extractors.ChainModule{
+			Name: m.Name, Program: program,
+			Limits: ps.moduleLimits(m),
+		}
+	}
+	rtCtx := NewRuntimeContext(ps, rt, rt.Modules...)
+	if err := ps.registerModuleImports(rtCtx, rt); err != nil {
+		return nil, err
+	}
+	modChain, err := extractors.SetupModuleChainEventLoop(ps.printer, rtCtx, chain...)
+	if err != nil {
+		ps.logger.Error("Error creating runtime for route",
+			zap.Error(err),
+			zap.String("route", reqCtx.route.Name),
+			zap.String("namespace", reqCtx.route.Namespace.Name),
+		)
+		return nil, err
+	}
+	return NewModuleExtractor(
+		rtCtx, modChain.FetchUpstream(),
+		modChain.RequestModifier(), modChain.ResponseModifier(),
+		modChain.ErrorHandler(), modChain.RequestHandler(),
+	), nil
+}
+
 func (ps *ProxyState) startProxyServer() {
 	cfg := ps.config.ProxyConfig
 	hostPort := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
@@ -361,6 +638,8 @@ func (ps *ProxyState) Start() (err error) {
 	if !ps.raftEnabled {
 		if err = ps.restoreFromChangeLogs(false); err != nil {
 			return err
+		} else if err = ps.rotateSecretKeys(); err != nil {
+			return err
 		} else {
 			ps.SetReady(true)
 		}
@@ -391,11 +670,48 @@ func (ps *ProxyState) Stop() {
 	}
 }
 
+type routeCandidate struct {
+	provider *RequestContextProvider
+	match    *route_match.Matcher
+	priority int
+}
+
+// handleRouteCandidates handles requests for routes that share a path and method. Routes are tried
+// in order of priority, then by how specific their match conditions are, and the first route
+// the request matches handles it. Routes with equal priority and specificity keep their order.
+func (ps *ProxyState) handleRouteCandidates(candidates []*routeCandidate, pattern string) http.HandlerFunc {
+	if len(candidates) == 1 && candidates[0].match == nil {
+		return ps.HandleRoute(candidates[0].provider, pattern)
+	}
+	slices.SortStableFunc(candidates, func(a, b *routeCandidate) int {
+		if a.priority != b.priority {
+			return cmp.Compare(b.priority, a.priority)
+		}
+		return cmp.Compare(b.match.Specificity(), a.match.Specificity())
+	})
+	handlers := make([]http.HandlerFunc, len(candidates))
+	for i, c := range candidates {
+		handlers[i] = ps.HandleRoute(c.provider, pattern)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i, c := range candidates {
+			if c.match.Matches(r) {
+				handlers[i](w, r)
+				return
+			}
+		}
+		util.WriteStatusCodeError(w, http.StatusNotFound)
+	}
+}
+
 func (ps *ProxyState) HandleRoute(requestCtxProvider *RequestContextProvider, pattern string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// ctx, cancel := context.WithCancel(requestCtxPrdovider.ctx)
 		// defer cancel()
-		ps.ProxyHandler(ps, requestCtxProvider.
-			CreateRequestContext(requestCtxProvider.ctx, w, r, pattern))
+		provider := requestCtxProvider.Pick(r)
+		// requests are not canceled when the provider is replaced,
+		// so in-flight requests finish after the routes are reloaded
+		ps.ProxyHandler(ps, provider.CreateRequestContext(
+			context.WithoutCancel(provider.ctx), w, r, pattern))
 	}
 }
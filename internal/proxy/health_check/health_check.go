@@ -0,0 +1,183 @@
+package health_check
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+// Checker actively probes the urls of a service and keeps track of their health.
+// Urls are considered healthy until enough consecutive checks have failed.
+type Checker struct {
+	config   spec.HealthCheck
+	original *spec.HealthCheck
+	urls     []*url.URL
+	client   *http.Client
+	checking atomic.Bool
+
+	mtx       sync.RWMutex
+	upstreams map[*url.URL]*spec.UpstreamStatus
+}
+
+func New(hc *spec.HealthCheck, urls []*url.URL, transport http.RoundTripper) *Checker {
+	upstreams := make(map[*url.URL]*spec.UpstreamStatus, len(urls))
+	for _, u := range urls {
+		upstreams[u] = &spec.UpstreamStatus{
+			URL:     u.String(),
+			Healthy: true,
+		}
+	}
+	config := hc.WithDefaults()
+	return &Checker{
+		config:    config,
+		original:  hc,
+		urls:      urls,
+		upstreams: upstreams,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Interval returns the time between each check.
+func (c *Checker) Interval() time.Duration {
+	return c.config.Interval
+}
+
+// Matches checks if the checker was created for the same configuration,
+// in which case it can be reused and keep its state.
+func (c *Checker) Matches(hc *spec.HealthCheck, urls []*url.URL) bool {
+	if !reflect.DeepEqual(c.original, hc) || len(c.urls) != len(urls) {
+		return false
+	}
+	for i, u := range urls {
+		if c.urls[i].String() != u.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// Rebind points the checker at a new set of urls that are equal to the current ones,
+// this is needed because services are recreated when they are updated.
+func (c *Checker) Rebind(urls []*url.URL) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	upstreams := make(map[*url.URL]*spec.UpstreamStatus, len(urls))
+	for i, u := range urls {
+		upstreams[u] = c.upstreams[c.urls[i]]
+	}
+	c.urls = urls
+	c.upstreams = upstreams
+}
+
+// Healthy returns false only if the url is known to be down.
+func (c *Checker) Healthy(u *url.URL) bool {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	if status, ok := c.upstreams[u]; ok {
+		return status.Healthy
+	}
+	return true
+}
+
+// Statuses returns a snapshot of the health of each url.
+func (c *Checker) Statuses() []spec.UpstreamStatus {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	statuses := make([]spec.UpstreamStatus, 0, len(c.urls))
+	for _, u := range c.urls {
+		statuses = append(statuses, *c.upstreams[u])
+	}
+	return statuses
+}
+
+// Check probes all urls concurrently and waits for the results,
+// it returns immediately if a previous check is still running.
+func (c *Checker) Check(ctx context.Context) {
+	if !c.checking.CompareAndSwap(false, true) {
+		return
+	}
+	defer c.checking.Store(false)
+
+	c.mtx.RLock()
+	urls := c.urls
+	c.mtx.RUnlock()
+
+	wg := sync.WaitGroup{}
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u *url.URL) {
+			defer wg.Done()
+			statusCode, err := c.probe(ctx, u)
+			c.record(u, statusCode, err)
+		}(u)
+	}
+	wg.Wait()
+}
+
+func (c *Checker) probe(ctx context.Context, u *url.URL) (int, error) {
+	req, err := http.NewRequestWithContext(ctx,
+		c.config.Method, u.JoinPath(c.config.Path).String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "DGate-HealthCheck")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if !c.config.ExpectsStatus(resp.StatusCode) {
+		return resp.StatusCode, &unexpectedStatusError{resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+func (c *Checker) record(u *url.URL, statusCode int, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	status, ok := c.upstreams[u]
+	if !ok {
+		// urls were rebound while the probe was running
+		return
+	}
+	status.LastCheck = time.Now()
+	status.LastStatusCode = statusCode
+	if err != nil {
+		status.LastError = err.Error()
+		status.ConsecutiveSuccesses = 0
+		status.ConsecutiveFailures++
+		if status.ConsecutiveFailures >= c.config.UnhealthyThreshold {
+			status.Healthy = false
+		}
+	} else {
+		status.LastError = ""
+		status.ConsecutiveFailures = 0
+		status.ConsecutiveSuccesses++
+		if status.ConsecutiveSuccesses >= c.config.HealthyThreshold {
+			status.Healthy = true
+		}
+	}
+}
+
+type unexpectedStatusError struct {
+	statusCode int
+}
+
+func (e *unexpectedStatusError) Error() string {
+	return "unexpected status code: " + strconv.Itoa(e.statusCode)
+}
@@ -0,0 +1,97 @@
+package health_check_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy/health_check"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheck_Thresholds(t *testing.T) {
+	status := atomic.Int32{}
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/healthz", r.URL.Path)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	checker := health_check.New(&spec.HealthCheck{
+		Path:               "/healthz",
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+	}, []*url.URL{u}, http.DefaultTransport)
+	assert.True(t, checker.Healthy(u))
+	assert.Equal(t, spec.DefaultHealthCheckInterval, checker.Interval())
+
+	status.Store(http.StatusInternalServerError)
+	checker.Check(context.Background())
+	assert.True(t, checker.Healthy(u))
+	checker.Check(context.Background())
+	assert.False(t, checker.Healthy(u))
+
+	statuses := checker.Statuses()
+	assert.Equal(t, 1, len(statuses))
+	assert.Equal(t, server.URL, statuses[0].URL)
+	assert.Equal(t, 2, statuses[0].ConsecutiveFailures)
+	assert.Equal(t, http.StatusInternalServerError, statuses[0].LastStatusCode)
+	assert.NotEmpty(t, statuses[0].LastError)
+
+	status.Store(http.StatusOK)
+	checker.Check(context.Background())
+	assert.False(t, checker.Healthy(u))
+	checker.Check(context.Background())
+	assert.True(t, checker.Healthy(u))
+	assert.Empty(t, checker.Statuses()[0].LastError)
+}
+
+func TestHealthCheck_ExpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	checker := health_check.New(&spec.HealthCheck{
+		ExpectedStatus:     []int{http.StatusUnauthorized},
+		UnhealthyThreshold: 1,
+	}, []*url.URL{u}, http.DefaultTransport)
+	checker.Check(context.Background())
+	assert.True(t, checker.Healthy(u))
+	assert.Equal(t, 1, checker.Statuses()[0].ConsecutiveSuccesses)
+}
+
+func TestHealthCheck_ConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	u, _ := url.Parse(server.URL)
+	server.Close()
+
+	checker := health_check.New(&spec.HealthCheck{
+		UnhealthyThreshold: 1,
+	}, []*url.URL{u}, http.DefaultTransport)
+	checker.Check(context.Background())
+	assert.False(t, checker.Healthy(u))
+}
+
+func TestHealthCheck_MatchesAndRebind(t *testing.T) {
+	hc := &spec.HealthCheck{Path: "/health", UnhealthyThreshold: 1}
+	u1, _ := url.Parse("http://127.0.0.1:1")
+	checker := health_check.New(hc, []*url.URL{u1}, http.DefaultTransport)
+	checker.Check(context.Background())
+	assert.False(t, checker.Healthy(u1))
+
+	u2, _ := url.Parse("http://127.0.0.1:1")
+	assert.True(t, checker.Matches(&spec.HealthCheck{Path: "/health", UnhealthyThreshold: 1}, []*url.URL{u2}))
+	assert.False(t, checker.Matches(&spec.HealthCheck{Path: "/other"}, []*url.URL{u2}))
+
+	// state is carried over to the new urls
+	checker.Rebind([]*url.URL{u2})
+	assert.False(t, checker.Healthy(u2))
+}
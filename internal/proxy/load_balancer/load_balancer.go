@@ -0,0 +1,309 @@
+package load_balancer
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dgate-io/dgate/pkg/util"
+)
+
+var (
+	ErrNoUpstreams = errors.New("no upstream urls available")
+)
+
+// Balancer selects an upstream url for each request.
+type Balancer interface {
+	// Next returns the upstream url that should be used for the request
+	Next(req *http.Request) (*url.URL, error)
+	// Release must be called once the request to the upstream url is done
+	Release(upstream *url.URL)
+	// Strategy returns the strategy used by the balancer
+	Strategy() spec.LoadBalancerStrategy
+}
+
+// AvailableFunc reports whether an upstream url can be selected,
+// urls that are not available are skipped by all strategies.
+type AvailableFunc func(*url.URL) bool
+
+func allAvailable(*url.URL) bool { return true }
+
+// New creates a balancer for the given urls, if lb is nil round robin is used.
+// xffDepth is the X-Forwarded-For depth used to resolve the client ip.
+func New(
+	lb *spec.LoadBalancer, urls []*url.URL,
+	xffDepth int, available AvailableFunc,
+) (Balancer, error) {
+	if available == nil {
+		available = allAvailable
+	}
+	if lb == nil {
+		return newRoundRobin(urls, available), nil
+	}
+	if err := lb.Validate(len(urls)); err != nil {
+		return nil, err
+	}
+	switch lb.Strategy {
+	case spec.LoadBalancerRoundRobin:
+		return newRoundRobin(urls, available), nil
+	case spec.LoadBalancerRandom:
+		return &randomBalancer{urls: urls, available: available}, nil
+	case spec.LoadBalancerWeighted:
+		return newWeighted(urls, lb.Weights, available), nil
+	case spec.LoadBalancerLeastConnections:
+		return newLeastConnections(urls, available), nil
+	case spec.LoadBalancerConsistentHash:
+		return newConsistentHash(urls, lb.HashOn, lb.HashKey, xffDepth, available), nil
+	default:
+		return nil, errors.New("unknown load balancer strategy: " + lb.Strategy.String())
+	}
+}
+
+type roundRobinBalancer struct {
+	urls      []*url.URL
+	available AvailableFunc
+	next      atomic.Uint64
+}
+
+func newRoundRobin(urls []*url.URL, available AvailableFunc) *roundRobinBalancer {
+	return &roundRobinBalancer{urls: urls, available: available}
+}
+
+func (b *roundRobinBalancer) Next(*http.Request) (*url.URL, error) {
+	for i := 0; i < len(b.urls); i++ {
+		n := b.next.Add(1) - 1
+		if u := b.urls[n%uint64(len(b.urls))]; b.available(u) {
+			return u, nil
+		}
+	}
+	return nil, ErrNoUpstreams
+}
+
+func (b *roundRobinBalancer) Release(*url.URL) {}
+
+func (b *roundRobinBalancer) Strategy() spec.LoadBalancerStrategy {
+	return spec.LoadBalancerRoundRobin
+}
+
+type randomBalancer struct {
+	urls      []*url.URL
+	available AvailableFunc
+}
+
+func (b *randomBalancer) Next(*http.Request) (*url.URL, error) {
+	if len(b.urls) == 0 {
+		return nil, ErrNoUpstreams
+	}
+	start := rand.Intn(len(b.urls))
+	for i := 0; i < len(b.urls); i++ {
+		if u := b.urls[(start+i)%len(b.urls)]; b.available(u) {
+			return u, nil
+		}
+	}
+	return nil, ErrNoUpstreams
+}
+
+func (b *randomBalancer) Release(*url.URL) {}
+
+func (b *randomBalancer) Strategy() spec.LoadBalancerStrategy {
+	return spec.LoadBalancerRandom
+}
+
+// weightedBalancer uses smooth weighted round robin,
+// which spreads out the selections of heavier urls.
+type weightedBalancer struct {
+	mtx       sync.Mutex
+	urls      []*url.URL
+	weights   []int
+	current   []int
+	available AvailableFunc
+}
+
+func newWeighted(urls []*url.URL, weights []int, available AvailableFunc) *weightedBalancer {
+	return &weightedBalancer{
+		urls:      urls,
+		weights:   weights,
+		current:   make([]int, len(urls)),
+		available: available,
+	}
+}
+
+func (b *weightedBalancer) Next(*http.Request) (*url.URL, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	best, total := -1, 0
+	for i, w := range b.weights {
+		if w <= 0 || !b.available(b.urls[i]) {
+			continue
+		}
+		total += w
+		b.current[i] += w
+		if best == -1 || b.current[i] > b.current[best] {
+			best = i
+		}
+	}
+	if best == -1 {
+		return nil, ErrNoUpstreams
+	}
+	b.current[best] -= total
+	return b.urls[best], nil
+}
+
+func (b *weightedBalancer) Release(*url.URL) {}
+
+func (b *weightedBalancer) Strategy() spec.LoadBalancerStrategy {
+	return spec.LoadBalancerWeighted
+}
+
+type leastConnectionsBalancer struct {
+	urls      []*url.URL
+	active    []atomic.Int64
+	index     map[*url.URL]int
+	next      atomic.Uint64
+	available AvailableFunc
+}
+
+func newLeastConnections(urls []*url.URL, available AvailableFunc) *leastConnectionsBalancer {
+	index := make(map[*url.URL]int, len(urls))
+	for i, u := range urls {
+		index[u] = i
+	}
+	return &leastConnectionsBalancer{
+		urls:      urls,
+		active:    make([]atomic.Int64, len(urls)),
+		index:     index,
+		available: available,
+	}
+}
+
+func (b *leastConnectionsBalancer) Next(*http.Request) (*url.URL, error) {
+	if len(b.urls) == 0 {
+		return nil, ErrNoUpstreams
+	}
+	// start at a rotating offset, so ties are spread across urls
+	offset := int(b.next.Add(1) % uint64(len(b.urls)))
+	best := -1
+	for i := 0; i < len(b.urls); i++ {
+		idx := (offset + i) % len(b.urls)
+		if !b.available(b.urls[idx]) {
+			continue
+		}
+		if best == -1 || b.active[idx].Load() < b.active[best].Load() {
+			best = idx
+		}
+	}
+	if best == -1 {
+		return nil, ErrNoUpstreams
+	}
+	b.active[best].Add(1)
+	return b.urls[best], nil
+}
+
+func (b *leastConnectionsBalancer) Release(upstream *url.URL) {
+	if idx, ok := b.index[upstream]; ok {
+		b.active[idx].Add(-1)
+	}
+}
+
+func (b *leastConnectionsBalancer) Strategy() spec.LoadBalancerStrategy {
+	return spec.LoadBalancerLeastConnections
+}
+
+// number of points each url gets on the hash ring
+const hashReplicas = 64
+
+type consistentHashBalancer struct {
+	ring      []uint32
+	owners    map[uint32]*url.URL
+	hashOn    spec.HashOn
+	hashKey   string
+	xffDepth  int
+	available AvailableFunc
+	fallback  *roundRobinBalancer
+}
+
+func newConsistentHash(
+	urls []*url.URL, hashOn spec.HashOn,
+	hashKey string, xffDepth int,
+	available AvailableFunc,
+) *consistentHashBalancer {
+	b := &consistentHashBalancer{
+		ring:      make([]uint32, 0, len(urls)*hashReplicas),
+		owners:    make(map[uint32]*url.URL, len(urls)*hashReplicas),
+		hashOn:    hashOn,
+		hashKey:   hashKey,
+		xffDepth:  xffDepth,
+		available: available,
+		fallback:  newRoundRobin(urls, available),
+	}
+	for _, u := range urls {
+		for i := 0; i < hashReplicas; i++ {
+			h := hashString(strconv.Itoa(i) + "-" + u.String())
+			if _, ok := b.owners[h]; ok {
+				continue
+			}
+			b.owners[h] = u
+			b.ring = append(b.ring, h)
+		}
+	}
+	sort.Slice(b.ring, func(i, j int) bool {
+		return b.ring[i] < b.ring[j]
+	})
+	return b
+}
+
+func (b *consistentHashBalancer) Next(req *http.Request) (*url.URL, error) {
+	if len(b.ring) == 0 {
+		return nil, ErrNoUpstreams
+	}
+	key := b.requestKey(req)
+	if key == "" {
+		// without a key, there is nothing to be sticky on
+		return b.fallback.Next(req)
+	}
+	h := hashString(key)
+	idx := sort.Search(len(b.ring), func(i int) bool {
+		return b.ring[i] >= h
+	})
+	// walk the ring until an available url is found,
+	// so only keys owned by unavailable urls are moved
+	for i := 0; i < len(b.ring); i++ {
+		if u := b.owners[b.ring[(idx+i)%len(b.ring)]]; b.available(u) {
+			return u, nil
+		}
+	}
+	return nil, ErrNoUpstreams
+}
+
+func (b *consistentHashBalancer) requestKey(req *http.Request) string {
+	switch b.hashOn {
+	case spec.HashOnHeader:
+		return req.Header.Get(b.hashKey)
+	case spec.HashOnCookie:
+		if cookie, err := req.Cookie(b.hashKey); err == nil {
+			return cookie.Value
+		}
+		return ""
+	default:
+		return util.GetTrustedIP(req, b.xffDepth)
+	}
+}
+
+func (b *consistentHashBalancer) Release(*url.URL) {}
+
+func (b *consistentHashBalancer) Strategy() spec.LoadBalancerStrategy {
+	return spec.LoadBalancerConsistentHash
+}
+
+func hashString(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
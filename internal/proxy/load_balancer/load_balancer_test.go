@@ -0,0 +1,205 @@
+package load_balancer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy/load_balancer"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func testUrls(t *testing.T, n int) []*url.URL {
+	urls := make([]*url.URL, n)
+	for i := range urls {
+		u, err := url.Parse("http://upstream-" + string(rune('a'+i)) + ":8080")
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls[i] = u
+	}
+	return urls
+}
+
+func TestLoadBalancer_RoundRobin(t *testing.T) {
+	urls := testUrls(t, 3)
+	lb, err := load_balancer.New(nil, urls, 0, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, spec.LoadBalancerRoundRobin, lb.Strategy())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 9; i++ {
+		u, err := lb.Next(req)
+		assert.Nil(t, err)
+		assert.Equal(t, urls[i%3], u)
+	}
+}
+
+func TestLoadBalancer_Random(t *testing.T) {
+	urls := testUrls(t, 3)
+	lb, err := load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerRandom,
+	}, urls, 0, nil)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 100; i++ {
+		u, err := lb.Next(req)
+		assert.Nil(t, err)
+		assert.Contains(t, urls, u)
+	}
+}
+
+func TestLoadBalancer_Weighted(t *testing.T) {
+	urls := testUrls(t, 3)
+	lb, err := load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerWeighted,
+		Weights:  []int{5, 1, 0},
+	}, urls, 0, nil)
+	assert.Nil(t, err)
+
+	counts := make(map[*url.URL]int)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 60; i++ {
+		u, err := lb.Next(req)
+		assert.Nil(t, err)
+		counts[u]++
+	}
+	assert.Equal(t, 50, counts[urls[0]])
+	assert.Equal(t, 10, counts[urls[1]])
+	assert.Equal(t, 0, counts[urls[2]])
+}
+
+func TestLoadBalancer_LeastConnections(t *testing.T) {
+	urls := testUrls(t, 2)
+	lb, err := load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerLeastConnections,
+	}, urls, 0, nil)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	first, _ := lb.Next(req)
+	second, _ := lb.Next(req)
+	assert.NotEqual(t, first, second)
+
+	// first is released, so it has the fewest active connections
+	lb.Release(first)
+	for i := 0; i < 5; i++ {
+		u, err := lb.Next(req)
+		assert.Nil(t, err)
+		assert.Equal(t, first, u)
+		lb.Release(u)
+	}
+}
+
+func TestLoadBalancer_ConsistentHash(t *testing.T) {
+	urls := testUrls(t, 4)
+	lb, err := load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerConsistentHash,
+		HashOn:   spec.HashOnHeader,
+		HashKey:  "X-User-Id",
+	}, urls, 0, nil)
+	assert.Nil(t, err)
+
+	seen := make(map[*url.URL]struct{})
+	for _, user := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User-Id", user)
+		expected, err := lb.Next(req)
+		assert.Nil(t, err)
+		seen[expected] = struct{}{}
+		for i := 0; i < 5; i++ {
+			u, _ := lb.Next(req)
+			assert.Equal(t, expected, u)
+		}
+	}
+	assert.Greater(t, len(seen), 1)
+
+	lb, err = load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerConsistentHash,
+		HashOn:   spec.HashOnCookie,
+		HashKey:  "session",
+	}, urls, 0, nil)
+	assert.Nil(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	expected, _ := lb.Next(req)
+	for i := 0; i < 5; i++ {
+		u, _ := lb.Next(req)
+		assert.Equal(t, expected, u)
+	}
+
+	lb, err = load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerConsistentHash,
+		HashOn:   spec.HashOnClientIP,
+	}, urls, 1, nil)
+	assert.Nil(t, err)
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	expected, _ = lb.Next(req)
+	for i := 0; i < 5; i++ {
+		u, _ := lb.Next(req)
+		assert.Equal(t, expected, u)
+	}
+}
+
+func TestLoadBalancer_InvalidConfig(t *testing.T) {
+	urls := testUrls(t, 2)
+	_, err := load_balancer.New(&spec.LoadBalancer{
+		Strategy: "unknown",
+	}, urls, 0, nil)
+	assert.NotNil(t, err)
+
+	_, err = load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerWeighted,
+		Weights:  []int{1},
+	}, urls, 0, nil)
+	assert.NotNil(t, err)
+
+	_, err = load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerConsistentHash,
+		HashOn:   spec.HashOnHeader,
+	}, urls, 0, nil)
+	assert.NotNil(t, err)
+}
+
+func TestLoadBalancer_NoUpstreams(t *testing.T) {
+	lb, err := load_balancer.New(nil, nil, 0, nil)
+	assert.Nil(t, err)
+	_, err = lb.Next(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.ErrorIs(t, err, load_balancer.ErrNoUpstreams)
+}
+
+func TestLoadBalancer_Available(t *testing.T) {
+	urls := testUrls(t, 3)
+	down := urls[1]
+	available := func(u *url.URL) bool {
+		return u != down
+	}
+	strategies := []*spec.LoadBalancer{
+		nil,
+		{Strategy: spec.LoadBalancerRandom},
+		{Strategy: spec.LoadBalancerWeighted, Weights: []int{1, 5, 1}},
+		{Strategy: spec.LoadBalancerLeastConnections},
+		{Strategy: spec.LoadBalancerConsistentHash, HashOn: spec.HashOnClientIP},
+	}
+	for _, strategy := range strategies {
+		lb, err := load_balancer.New(strategy, urls, 0, available)
+		assert.Nil(t, err)
+		for i := 0; i < 30; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0." + string(rune('0'+i%10)) + ":1234"
+			u, err := lb.Next(req)
+			assert.Nil(t, err)
+			assert.NotEqual(t, down, u, lb.Strategy())
+			lb.Release(u)
+		}
+	}
+
+	lb, err := load_balancer.New(nil, urls, 0, func(*url.URL) bool { return false })
+	assert.Nil(t, err)
+	_, err = lb.Next(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.ErrorIs(t, err, load_balancer.ErrNoUpstreams)
+}
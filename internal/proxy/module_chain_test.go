@@ -0,0 +1,104 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const (
+	authModuleTS = `
+export const requestModifier = async (ctx: any) => {
+	if (!ctx.request().headers.get("X-Api-Key")) {
+		ctx.response().status(401).end("unauthorized");
+	}
+};
+`
+	transformModuleJS = `
+const requestModifier = (ctx) => {
+	ctx.request().headers.set("X-Transformed", "true");
+};
+const responseModifier = (ctx) => {
+	ctx.upstream().headers.set("X-Chain", "transform");
+};
+module.exports = { requestModifier, responseModifier };
+`
+)
+
+func chainConfig(upstreamUrl string, modules ...config.ModuleSpec) *config.DGateConfig {
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{
+		{Name: "test", URLs: []string{upstreamUrl}, NamespaceName: "test"},
+	}
+	resources.Modules = modules
+	moduleNames := make([]string, len(modules))
+	for i, mod := range modules {
+		moduleNames[i] = mod.Name
+	}
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test"},
+		Methods:       []string{"GET"},
+		Modules:       moduleNames,
+		ServiceName:   "test",
+		NamespaceName: "test",
+	}}
+	return conf
+}
+
+func chainModuleSpec(name, payload string, modType spec.ModuleType) config.ModuleSpec {
+	return config.ModuleSpec{Module: spec.Module{
+		Name:          name,
+		NamespaceName: "test",
+		Payload:       payload,
+		Type:          modType,
+	}}
+}
+
+func TestProxyHandler_ModuleChain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Transformed")))
+	}))
+	defer server.Close()
+
+	conf := chainConfig(server.URL,
+		chainModuleSpec("auth", authModuleTS, spec.ModuleTypeTypescript),
+		chainModuleSpec("transform", transformModuleJS, spec.ModuleTypeJavascript),
+	)
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+	wr := httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, http.StatusUnauthorized, wr.Code)
+	assert.Equal(t, "unauthorized", wr.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+	req.Header.Set("X-Api-Key", "key")
+	wr = httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, http.StatusOK, wr.Code)
+	assert.Equal(t, "true", wr.Body.String())
+	assert.Equal(t, "transform", wr.Header().Get("X-Chain"))
+}
+
+func TestProxyHandler_ModuleChainExportConflict(t *testing.T) {
+	conf := chainConfig("http://localhost:8080",
+		chainModuleSpec("a", `exports.fetchUpstream = () => "http://a";`, spec.ModuleTypeJavascript),
+		chainModuleSpec("b", `exports.fetchUpstream = () => "http://b";`, spec.ModuleTypeJavascript),
+	)
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true)
+	assert.ErrorContains(t, err, "export conflict: fetchUpstream is defined by modules a, b")
+}
@@ -0,0 +1,68 @@
+package proxy_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const throwingModuleTS = `
+interface Claims {
+	sub: string;
+}
+
+const verify = (claims: Claims): void => {
+	throw new Error("invalid token: " + claims.sub);
+};
+
+export const requestModifier = async (ctx: any) => {
+	verify({ sub: "user" });
+};
+`
+
+func TestProxyHandler_ModuleErrorPosition(t *testing.T) {
+	authModule := chainModuleSpec("auth", throwingModuleTS, spec.ModuleTypeTypescript)
+	otherModule := chainModuleSpec("other", `exports.requestModifier = () => {};`, spec.ModuleTypeJavascript)
+	// chained modules are wrapped, the source map must be kept
+	for _, conf := range []*config.DGateConfig{
+		chainConfig("http://localhost:8080", authModule),
+		chainConfig("http://localhost:8080", otherModule, authModule),
+	} {
+		conf.Debug = true
+		ps := proxy.NewProxyState(zap.NewNop(), conf)
+		require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		assert.Equal(t, http.StatusInternalServerError, wr.Code)
+		moduleErr := wr.Header().Get("X-DGate-Module-Error")
+		assert.Contains(t, moduleErr, "Error: invalid token: user")
+		assert.Contains(t, moduleErr, "(auth.ts:7:")
+	}
+}
+
+func TestProxyHandler_ModuleSyntaxError(t *testing.T) {
+	conf := chainConfig("http://localhost:8080")
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	mod := &spec.Module{
+		Name:          "auth",
+		NamespaceName: "test",
+		Type:          spec.ModuleTypeTypescript,
+		Payload: base64.StdEncoding.EncodeToString(
+			[]byte("const a = 1;\nconst b = (a: number => a;\n")),
+	}
+	err := ps.ProcessChangeLog(spec.NewChangeLog(
+		mod, mod.NamespaceName, spec.AddModuleCommand), true)
+	assert.ErrorContains(t, err, "auth.ts:2:")
+}
@@ -2,100 +2,365 @@ package proxy
 
 import (
 	"context"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dgate-io/dgate/internal/config"
 	"go.uber.org/zap"
 )
 
 type ModulePool interface {
 	Borrow() ModuleExtractor
 	Return(me ModuleExtractor)
+	// Discard stops the module extractor without returning it to the pool
+	Discard(me ModuleExtractor)
 	Close()
 }
 
-type modulePool struct {
-	modExtChan chan ModuleExtractor
-	min, max   int
-	cancel  context.CancelFunc
-	ctx        context.Context
+const (
+	defaultRuntimeMaxIdle     = 1024
+	defaultRuntimeIdleTimeout = 5 * time.Minute
+	// maxRuntimeSweepInterval is the max time between the sweeps of the runtime manager
+	maxRuntimeSweepInterval = 30 * time.Second
+)
+
+// RuntimePoolStats are the runtimes of all the pools of the runtime manager
+type RuntimePoolStats struct {
+	Pools    int
+	Borrowed int
+	Idle     int
+	Created  int64
+	Evicted  int64
+}
 
-	createModExt func() (ModuleExtractor, error)
+// RuntimeManager manages the module runtimes of all the routes. Routes of a namespace
+// that use the same modules share a pool of runtimes, so they are kept warm across route
+// changes. Idle runtimes are evicted after the idle timeout, and when the heap is over the
+// memory cap, idle runtimes are evicted and runtimes are not returned to the pools.
+type RuntimeManager struct {
+	conf    config.DGateRuntimePoolConfig
+	logger  *zap.Logger
+	metrics *ProxyMetrics
+
+	mtx   sync.Mutex
+	pools map[string]*runtimePool
+
+	created, evicted atomic.Int64
+	overMemory       atomic.Bool
 }
 
-func NewModulePool(
-	minBuffers, maxBuffers int,
-	bufferTimeout time.Duration,
-	reqCtxProvider *RequestContextProvider,
-	createModExts ModuleExtractorFunc,
+func NewRuntimeManager(
+	conf config.DGateRuntimePoolConfig,
+	logger *zap.Logger,
+	metrics *ProxyMetrics,
+) *RuntimeManager {
+	if conf.MaxIdle <= 0 {
+		conf.MaxIdle = defaultRuntimeMaxIdle
+	}
+	if conf.IdleTimeout <= 0 {
+		conf.IdleTimeout = defaultRuntimeIdleTimeout
+	}
+	conf.MinIdle = min(conf.MinIdle, conf.MaxIdle)
+	rm := &RuntimeManager{
+		conf:    conf,
+		logger:  logger,
+		metrics: metrics,
+		pools:   make(map[string]*runtimePool),
+	}
+	go rm.sweepLoop(min(conf.IdleTimeout/2, maxRuntimeSweepInterval))
+	return rm
+}
+
+// Acquire returns the pool of the key, the pool is created when no route uses it.
+// The pool is closed when all the module pools returned for the key are closed.
+func (rm *RuntimeManager) Acquire(
+	key, namespace string, modules []string,
+	createModExt func() (ModuleExtractor, error),
 ) (ModulePool, error) {
-	if maxBuffers < minBuffers {
-		panic("maxBuffers must be greater than minBuffers")
+	rm.mtx.Lock()
+	if pool, ok := rm.pools[key]; ok && !pool.closed {
+		pool.refs++
+		rm.mtx.Unlock()
+		return &runtimePoolLease{pool: pool}, nil
+	}
+	pool := &runtimePool{
+		rm:           rm,
+		key:          key,
+		namespace:    namespace,
+		modules:      strings.Join(modules, ","),
+		createModExt: createModExt,
+		refs:         1,
 	}
-	if _, err := createModExts(reqCtxProvider); err != nil {
+	rm.pools[key] = pool
+	rm.mtx.Unlock()
+
+	// the first runtime checks the modules of the pool, it is kept warm
+	me, err := pool.create()
+	if err != nil {
+		pool.release()
 		return nil, err
 	}
-	modExtChan := make(chan ModuleExtractor, maxBuffers)
-	mb := &modulePool{
-		min:        minBuffers,
-		max:        maxBuffers,
-		modExtChan: modExtChan,
-		createModExt: func() (ModuleExtractor, error) {
-			return createModExts(reqCtxProvider)
-		},
-	}
-	mb.ctx, mb.cancel = context.WithCancel(reqCtxProvider.ctx)
-
-	// add min module extractors to the pool
-	defer func() {
-		for i := 0; i < minBuffers; i++ {
-			me, err := mb.createModExt()
-			if err == nil {
-				mb.modExtChan <- me
-			}
-		}
-	}()
+	pool.Return(me)
+	go pool.warm()
+	return &runtimePoolLease{pool: pool}, nil
+}
+
+// Stats returns the runtimes of all the pools
+func (rm *RuntimeManager) Stats() RuntimePoolStats {
+	rm.mtx.Lock()
+	defer rm.mtx.Unlock()
+	stats := RuntimePoolStats{
+		Pools:   len(rm.pools),
+		Created: rm.created.Load(),
+		Evicted: rm.evicted.Load(),
+	}
+	for _, pool := range rm.pools {
+		pool.mtx.Lock()
+		stats.Borrowed += pool.borrowed
+		stats.Idle += len(pool.idle)
+		pool.mtx.Unlock()
+	}
+	return stats
+}
 
-	return mb, nil
+func (rm *RuntimeManager) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		rm.sweep(time.Now())
+	}
 }
 
-func (mb *modulePool) Borrow() ModuleExtractor {
-	if mb == nil || mb.ctx.Err() != nil {
-		zap.L().Warn("stale use of module pool",
-			zap.Any("modPool", mb),
+// sweep evicts the runtimes idle for longer than the idle timeout, keeping the min idle
+// runtimes, and then warms the pools. All the idle runtimes are evicted when the heap
+// is over the memory cap, the pools are warmed again when the heap is below it.
+func (rm *RuntimeManager) sweep(now time.Time) {
+	overMemory := rm.conf.MaxMemory > 0 && heapBytes() > rm.conf.MaxMemory
+	if overMemory && !rm.overMemory.Load() {
+		rm.logger.Warn("module runtimes are over the memory cap, evicting idle runtimes",
+			zap.Uint64("max_memory", rm.conf.MaxMemory),
 		)
-		return nil
 	}
-	var (
-		me  ModuleExtractor
-		err error
-	)
-	select {
-	case me = <-mb.modExtChan:
-		break
-	default:
-		if me, err = mb.createModExt(); err != nil {
-			return nil
+	rm.overMemory.Store(overMemory)
+
+	rm.mtx.Lock()
+	pools := make([]*runtimePool, 0, len(rm.pools))
+	for _, pool := range rm.pools {
+		pools = append(pools, pool)
+	}
+	rm.mtx.Unlock()
+	for _, pool := range pools {
+		if overMemory {
+			pool.evictIdle(now, 0)
+		} else {
+			pool.evictIdle(now.Add(-rm.conf.IdleTimeout), rm.conf.MinIdle)
+			pool.warm()
 		}
 	}
+}
+
+func (rm *RuntimeManager) remove(pool *runtimePool) {
+	rm.mtx.Lock()
+	defer rm.mtx.Unlock()
+	if rm.pools[pool.key] == pool {
+		delete(rm.pools, pool.key)
+	}
+}
+
+// heapBytes returns the bytes of the heap objects of the process
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+type idleRuntime struct {
+	me    ModuleExtractor
+	since time.Time
+}
+
+// runtimePool is the pool of runtimes of the routes of a namespace that use the same modules.
+type runtimePool struct {
+	rm                 *RuntimeManager
+	key                string
+	namespace, modules string
+	createModExt       func() (ModuleExtractor, error)
+
+	mtx sync.Mutex
+	// idle are the idle runtimes, the most recently returned runtime is the last one
+	idle     []idleRuntime
+	borrowed int
+	refs     int
+	closed   bool
+}
+
+func (pool *runtimePool) Borrow() ModuleExtractor {
+	pool.mtx.Lock()
+	if pool.closed {
+		pool.mtx.Unlock()
+		return nil
+	}
+	if n := len(pool.idle); n > 0 {
+		pool.borrowed++
+		me := pool.idle[n-1].me
+		pool.idle = pool.idle[:n-1]
+		pool.mtx.Unlock()
+		pool.measure(1, -1, 0, 0)
+		return me
+	}
+	pool.mtx.Unlock()
+	me, err := pool.create()
+	if err != nil {
+		pool.rm.logger.Error("Error creating module runtime", zap.Error(err),
+			zap.String("namespace", pool.namespace),
+			zap.String("modules", pool.modules),
+		)
+		return nil
+	}
 	return me
 }
 
-func (mb *modulePool) Return(me ModuleExtractor) {
-	// if context is canceled, do not return module extract
-	if mb.ctx != nil && mb.ctx.Err() == nil {
-		select {
-		case mb.modExtChan <- me:
+// create creates a runtime, the runtime is borrowed until it is returned
+func (pool *runtimePool) create() (ModuleExtractor, error) {
+	pool.mtx.Lock()
+	pool.borrowed++
+	pool.mtx.Unlock()
+	me, err := pool.createModExt()
+	if err != nil {
+		pool.mtx.Lock()
+		pool.borrowed--
+		pool.mtx.Unlock()
+		return nil, err
+	}
+	pool.rm.created.Add(1)
+	pool.measure(1, 0, 1, 0)
+	return me, nil
+}
+
+func (pool *runtimePool) Return(me ModuleExtractor) {
+	pool.mtx.Lock()
+	if pool.borrowed > 0 {
+		pool.borrowed--
+	}
+	if pool.closed || len(pool.idle) >= pool.rm.conf.MaxIdle || pool.rm.overMemory.Load() {
+		pool.mtx.Unlock()
+		me.Stop(false)
+		pool.rm.evicted.Add(1)
+		pool.measure(-1, 0, 0, 1)
+		return
+	}
+	pool.idle = append(pool.idle, idleRuntime{me: me, since: time.Now()})
+	pool.mtx.Unlock()
+	pool.measure(-1, 1, 0, 0)
+}
+
+func (pool *runtimePool) Discard(me ModuleExtractor) {
+	pool.mtx.Lock()
+	if pool.borrowed > 0 {
+		pool.borrowed--
+	}
+	pool.mtx.Unlock()
+	me.Stop(false)
+	pool.measure(-1, 0, 0, 0)
+}
+
+// warm creates runtimes until the pool has the min idle runtimes
+func (pool *runtimePool) warm() {
+	for {
+		pool.mtx.Lock()
+		if pool.closed || len(pool.idle) >= pool.rm.conf.MinIdle || pool.rm.overMemory.Load() {
+			pool.mtx.Unlock()
+			return
+		}
+		pool.mtx.Unlock()
+		me, err := pool.create()
+		if err != nil {
+			pool.rm.logger.Error("Error warming module runtime", zap.Error(err),
+				zap.String("namespace", pool.namespace),
+				zap.String("modules", pool.modules),
+			)
 			return
-		default:
-			// if buffer is full, discard module extract
 		}
+		pool.Return(me)
 	}
-	me.Stop(false)
 }
 
-func (mb *modulePool) Close() {
-	if mb.cancel != nil {
-		mb.cancel()
+// evictIdle stops the runtimes idle since before the time, keeping the newest keep runtimes.
+func (pool *runtimePool) evictIdle(before time.Time, keep int) {
+	pool.mtx.Lock()
+	evict := 0
+	for evict < len(pool.idle)-keep && !pool.idle[evict].since.After(before) {
+		evict++
+	}
+	evicted := pool.idle[:evict]
+	pool.idle = pool.idle[evict:]
+	pool.mtx.Unlock()
+	pool.stop(evicted)
+}
+
+// release closes the pool when no route uses it
+func (pool *runtimePool) release() {
+	pool.mtx.Lock()
+	pool.refs--
+	if pool.refs > 0 || pool.closed {
+		pool.mtx.Unlock()
+		return
+	}
+	pool.closed = true
+	evicted := pool.idle
+	pool.idle = nil
+	pool.mtx.Unlock()
+	pool.rm.remove(pool)
+	pool.stop(evicted)
+}
+
+func (pool *runtimePool) stop(evicted []idleRuntime) {
+	for _, idle := range evicted {
+		idle.me.Stop(false)
+	}
+	if n := int64(len(evicted)); n > 0 {
+		pool.rm.evicted.Add(n)
+		pool.measure(0, -n, 0, n)
+	}
+}
+
+func (pool *runtimePool) measure(borrowed, idle, created, evicted int64) {
+	pool.rm.metrics.MeasureRuntimePool(context.Background(),
+		pool.namespace, pool.modules, borrowed, idle, created, evicted)
+}
+
+// runtimePoolLease is the module pool of a route, closing it releases the shared pool.
+type runtimePoolLease struct {
+	pool   *runtimePool
+	closed atomic.Bool
+}
+
+func (lease *runtimePoolLease) Borrow() ModuleExtractor {
+	if lease.closed.Load() {
+		zap.L().Warn("stale use of module pool",
+			zap.String("modules", lease.pool.modules),
+		)
+		return nil
+	}
+	return lease.pool.Borrow()
+}
+
+func (lease *runtimePoolLease) Return(me ModuleExtractor) {
+	lease.pool.Return(me)
+}
+
+func (lease *runtimePoolLease) Discard(me ModuleExtractor) {
+	lease.pool.Discard(me)
+}
+
+func (lease *runtimePoolLease) Close() {
+	if lease.closed.CompareAndSwap(false, true) {
+		lease.pool.release()
 	}
-	close(mb.modExtChan)
 }
@@ -65,6 +65,9 @@ func (me *moduleExtract) Start(reqCtx *RequestContext) {
 
 // Stop stops the event loop for the module extractor
 func (me *moduleExtract) Stop(wait bool) {
+	if me.moduleContext != nil {
+		types.CloseModuleContext(me.moduleContext)
+	}
 	me.moduleContext = nil
 	me.runtimeContext.reqCtx = nil
 	if wait {
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dop251/goja"
+)
+
+// moduleImportPrefix is the prefix of imports of other modules in the namespace
+const moduleImportPrefix = "module:"
+
+// moduleImportRegex matches the imports (import/export ... from, import and require)
+// of other modules, typescript imports are matched before they are transpiled.
+var moduleImportRegex = regexp.MustCompile(
+	`(?:\bfrom|\bimport|\brequire\s*\()\s*["']` + moduleImportPrefix + `([^"']+)["']`,
+)
+
+var errModuleImportNotFound = errors.New("imported module not found")
+
+// moduleImports returns the names of the modules imported by the payload
+func moduleImports(payload string) []string {
+	names := []string{}
+	for _, match := range moduleImportRegex.FindAllStringSubmatch(payload, -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// moduleDependencies returns the modules imported by the module and by its imports, the
+// imports of a module are before the module. An import cycle is an error, and missing
+// modules are an error (errModuleImportNotFound) unless ignoreMissing is true. The
+// module does not have to be stored yet, imports of its name are resolved to it.
+func (ps *ProxyState) moduleDependencies(
+	mod *spec.DGateModule, ignoreMissing bool,
+) ([]*spec.DGateModule, error) {
+	root := mod
+	deps := []*spec.DGateModule{}
+	done := map[string]bool{}
+	path := []string{}
+	var visit func(mod *spec.DGateModule) error
+	visit = func(mod *spec.DGateModule) error {
+		for i, name := range path {
+			if name == mod.Name {
+				cycle := slices.Concat(path[i:], []string{mod.Name})
+				return fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		if done[mod.Name] {
+			return nil
+		}
+		// the payload of wasm and native modules is not a script, so it has no imports
+		if mod.Type == spec.ModuleTypeWasm || mod.Type == spec.ModuleTypeNative {
+			if len(path) > 0 {
+				return fmt.Errorf("module %s imports %s%s, which is a %s module and cannot be imported",
+					path[len(path)-1], moduleImportPrefix, mod.Name, mod.Type)
+			}
+			return nil
+		}
+		path = append(path, mod.Name)
+		for _, name := range moduleImports(mod.Payload) {
+			imported, ok := root, name == root.Name
+			if !ok {
+				imported, ok = ps.rm.GetModule(name, mod.Namespace.Name)
+			}
+			if !ok {
+				if ignoreMissing {
+					continue
+				}
+				return fmt.Errorf("%w: module %s imports %s%s, which does not exist in namespace %s",
+					errModuleImportNotFound, mod.Name, moduleImportPrefix, name, mod.Namespace.Name)
+			}
+			if err := visit(imported); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		done[mod.Name] = true
+		if len(path) > 0 {
+			deps = append(deps, mod)
+		}
+		return nil
+	}
+	if err := visit(mod); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// registerModuleImports registers the modules imported by the modules of the route,
+// the imported modules run in their own scope when they are first imported.
+func (ps *ProxyState) registerModuleImports(rtCtx *runtimeContext, route *spec.DGateRoute) error {
+	for _, mod := range route.Modules {
+		imports, ok := ps.modImports.Find(mod.Name + "/" + route.Namespace.Name)
+		if !ok {
+			continue
+		}
+		for _, name := range imports {
+			program, ok := ps.modChainPrograms.Find(name + "/" + route.Namespace.Name)
+			if !ok {
+				return fmt.Errorf("cannot find module program: %s/%s", name, route.Namespace.Name)
+			}
+			registerModuleImport(rtCtx, name, program)
+		}
+	}
+	return nil
+}
+
+// registerModuleImport registers the chain program of an imported module
+func registerModuleImport(rtCtx *runtimeContext, name string, program *goja.Program) {
+	reg := rtCtx.EventLoop().Registry()
+	reg.RegisterNativeModule(moduleImportPrefix+name, func(rt *goja.Runtime, module *goja.Object) {
+		wrapper, err := rt.RunProgram(program)
+		if err != nil {
+			panic(rt.NewGoError(err))
+		}
+		call, ok := goja.AssertFunction(wrapper)
+		if !ok {
+			panic(rt.NewTypeError("module " + name + " cannot be imported"))
+		}
+		if _, err = call(goja.Undefined(), module, module.Get("exports")); err != nil {
+			panic(rt.NewGoError(err))
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package proxy_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	sharedAuthModuleTS = `
+import { prefix } from "module:shared-log";
+
+export const checkKey = (key: string): boolean => key === "secret";
+export const label = (name: string): string => prefix + name;
+`
+	sharedLogModuleJS = `
+const prefix = "checked:";
+module.exports = { prefix };
+`
+	importingModuleTS = `
+import { checkKey, label } from "module:shared-auth";
+
+export const requestModifier = (ctx: any) => {
+	if (!checkKey(ctx.request().headers.get("X-Api-Key"))) {
+		ctx.response().status(401).end("unauthorized");
+		return;
+	}
+	ctx.request().headers.set("X-Label", label("auth"));
+};
+`
+)
+
+func TestProxyHandler_ModuleImports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Label")))
+	}))
+	defer server.Close()
+
+	conf := chainConfig(server.URL,
+		chainModuleSpec("auth", importingModuleTS, spec.ModuleTypeTypescript))
+	// library modules are not used by the route
+	conf.ProxyConfig.InitResources.Modules = append(conf.ProxyConfig.InitResources.Modules,
+		chainModuleSpec("shared-auth", sharedAuthModuleTS, spec.ModuleTypeTypescript),
+		chainModuleSpec("shared-log", sharedLogModuleJS, spec.ModuleTypeJavascript),
+	)
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+	wr := httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, http.StatusUnauthorized, wr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+	req.Header.Set("X-Api-Key", "secret")
+	wr = httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, http.StatusOK, wr.Code)
+	assert.Equal(t, "checked:auth", wr.Body.String())
+
+	// changing a library module changes the modules importing it
+	mod := &spec.Module{
+		Name:          "shared-log",
+		NamespaceName: "test",
+		Type:          spec.ModuleTypeJavascript,
+		Payload: base64.StdEncoding.EncodeToString(
+			[]byte(`module.exports = { prefix: "v2:" };`)),
+	}
+	require.NoError(t, ps.ProcessChangeLog(spec.NewChangeLog(
+		mod, mod.NamespaceName, spec.AddModuleCommand), true))
+	wr = httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, "v2:auth", wr.Body.String())
+}
+
+func TestProxyHandler_ModuleImportErrors(t *testing.T) {
+	conf := chainConfig("http://localhost:8080", chainModuleSpec("auth", `
+const { checkKey } = require("module:missing");
+exports.requestModifier = () => {};`, spec.ModuleTypeJavascript))
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true)
+	assert.ErrorContains(t, err, "module auth imports module:missing, which does not exist in namespace test")
+
+	conf = chainConfig("http://localhost:8080")
+	conf.ProxyConfig.InitResources.Modules = append(conf.ProxyConfig.InitResources.Modules,
+		chainModuleSpec("a", `exports.b = require("module:b");`, spec.ModuleTypeJavascript))
+	ps = proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+	mod := &spec.Module{
+		Name:          "b",
+		NamespaceName: "test",
+		Type:          spec.ModuleTypeTypescript,
+		Payload: base64.StdEncoding.EncodeToString(
+			[]byte(`export * from "module:a";`)),
+	}
+	err = ps.ProcessChangeLog(spec.NewChangeLog(
+		mod, mod.NamespaceName, spec.AddModuleCommand), true)
+	assert.ErrorContains(t, err, "import cycle: b -> a -> b")
+	// the rejected module is not stored, and the state is not restarted
+	_, ok := ps.ResourceManager().GetModule("b", "test")
+	assert.False(t, ok)
+	_, ok = ps.ResourceManager().GetModule("a", "test")
+	assert.True(t, ok)
+
+	// a module with a syntax error does not replace the current module
+	mod.Name, mod.Payload = "a", base64.StdEncoding.EncodeToString([]byte(`exports.a = (;`))
+	err = ps.ProcessChangeLog(spec.NewChangeLog(
+		mod, mod.NamespaceName, spec.AddModuleCommand), true)
+	assert.Error(t, err)
+	if md, ok := ps.ResourceManager().GetModule("a", "test"); assert.True(t, ok) {
+		assert.Equal(t, spec.ModuleTypeJavascript, md.Type)
+	}
+}
@@ -0,0 +1,289 @@
+package proxy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/scheduler"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+)
+
+// maxModuleJobRuns is the number of runs kept in the history of a job
+const maxModuleJobRuns = 20
+
+func moduleJobTaskName(key, job string) string {
+	return "module-job:" + key + ":" + job
+}
+
+// moduleJobs are the jobs exported by a module, the jobs share
+// the runtime of the module, so they run one at a time.
+type moduleJobs struct {
+	key, hash         string
+	module, namespace string
+	// err is set when the jobs of the module could not be loaded
+	err   error
+	rtCtx *runtimeContext
+	jobs  []*moduleJob
+
+	mtx    sync.Mutex
+	closed bool
+}
+
+type moduleJob struct {
+	extractors.Job
+	running atomic.Bool
+
+	mtx     sync.Mutex
+	lastRun time.Time
+	lastErr string
+	runs    []spec.ModuleJobRun
+}
+
+// setupModuleJobs syncs the jobs with the current modules, the jobs of modules
+// that did not change (including their imports) are kept, so their history is not lost.
+// Errors loading the jobs of a module are logged and returned by ModuleJobs.
+func (ps *ProxyState) setupModuleJobs() {
+	active := make(map[string]struct{})
+	for _, mod := range ps.rm.GetModules() {
+		if mod.Type != spec.ModuleTypeJavascript && mod.Type != spec.ModuleTypeTypescript {
+			continue
+		}
+		deps, _ := ps.moduleDependencies(mod, true)
+		if !mentionsJobs(mod, deps) {
+			continue
+		}
+		key := mod.Name + "/" + mod.Namespace.Name
+		active[key] = struct{}{}
+		hash, err := HashString(0, append(deps, mod)...)
+		if err != nil {
+			ps.logger.Error("Error hashing module: "+mod.Name, zap.Error(err))
+		} else if mj, ok := ps.moduleJobs.Find(key); ok && mj.hash == hash {
+			continue
+		}
+		mj := ps.loadModuleJobs(mod, key, hash)
+		if old := ps.moduleJobs.Insert(key, mj); old != nil {
+			ps.stopModuleJobs(old)
+		}
+		ps.scheduleModuleJobs(mj)
+	}
+
+	removed := []*moduleJobs{}
+	ps.moduleJobs.Each(func(key string, mj *moduleJobs) bool {
+		if _, ok := active[key]; !ok {
+			removed = append(removed, mj)
+		}
+		return true
+	})
+	for _, mj := range removed {
+		ps.moduleJobs.Delete(mj.key)
+		ps.stopModuleJobs(mj)
+	}
+}
+
+// mentionsJobs checks if the module or one of its imports mentions jobs. Modules are
+// run on each node to find their jobs, so this skips running modules that cannot have
+// jobs, it does not find jobs whose name is built at runtime (e.g. exports["jo" + "bs"]).
+func mentionsJobs(mod *spec.DGateModule, deps []*spec.DGateModule) bool {
+	for _, m := range append(deps, mod) {
+		if strings.Contains(m.Payload, "jobs") {
+			return true
+		}
+	}
+	return false
+}
+
+// loadModuleJobs runs the module in a runtime for its jobs, and extracts the jobs
+func (ps *ProxyState) loadModuleJobs(mod *spec.DGateModule, key, hash string) *moduleJobs {
+	mj := &moduleJobs{
+		key:       key,
+		hash:      hash,
+		module:    mod.Name,
+		namespace: mod.Namespace.Name,
+	}
+	logger := ps.logger.With(
+		zap.String("module", mod.Name),
+		zap.String("namespace", mod.Namespace.Name),
+	)
+	ctx, cancel := context.WithTimeout(context.TODO(), 30*time.Second)
+	defer cancel()
+	deps, err := ps.moduleDependencies(mod, false)
+	if err != nil {
+		logger.Error("Error loading module jobs", zap.Error(err))
+		mj.err = err
+		return mj
+	}
+	program, _, err := ps.compileModulePrograms(ctx, mod, false)
+	if err != nil {
+		mj.err = err
+		return mj
+	}
+	rtCtx := NewRuntimeContext(ps, nil, mod)
+	for _, dep := range deps {
+		_, chainProgram, err := ps.compileModulePrograms(ctx, dep, true)
+		if err != nil {
+			rtCtx.Clean()
+			mj.err = err
+			return mj
+		}
+		registerModuleImport(rtCtx, dep.Name, chainProgram)
+	}
+	var jobs []extractors.Job
+	if err = extractors.SetupModuleEventLoop(ps.printer, rtCtx, program); err == nil {
+		jobs, err = extractors.ExtractJobs(rtCtx.EventLoop(), ps.moduleLimits(mod))
+	}
+	if err != nil || len(jobs) == 0 {
+		if err != nil {
+			logger.Error("Error loading module jobs", zap.Error(err))
+		}
+		rtCtx.Clean()
+		mj.err = err
+		return mj
+	}
+	mj.rtCtx = rtCtx
+	for _, job := range jobs {
+		mj.jobs = append(mj.jobs, &moduleJob{Job: job})
+	}
+	return mj
+}
+
+func (ps *ProxyState) scheduleModuleJobs(mj *moduleJobs) {
+	for _, job := range mj.jobs {
+		job := job
+		err := ps.skdr.ScheduleTask(moduleJobTaskName(mj.key, job.Name), scheduler.TaskOptions{
+			Schedule:  job.Schedule,
+			Overwrite: true,
+			// a run is skipped when the previous run of the job has not returned
+			Overlap: scheduler.OverlapSkip,
+			TaskFunc: func(ctx context.Context) {
+				ps.runModuleJob(mj, job)
+			},
+		})
+		if err != nil {
+			ps.logger.Error("Error scheduling module job", zap.Error(err),
+				zap.String("module", mj.module),
+				zap.String("namespace", mj.namespace),
+				zap.String("job", job.Name),
+			)
+		}
+	}
+}
+
+// stopModuleJobs stops the tasks of the jobs, the runtime is cleaned after the running job returns
+func (ps *ProxyState) stopModuleJobs(mj *moduleJobs) {
+	for _, job := range mj.jobs {
+		ps.skdr.StopTask(moduleJobTaskName(mj.key, job.Name))
+	}
+	if mj.rtCtx == nil {
+		return
+	}
+	go func() {
+		mj.mtx.Lock()
+		defer mj.mtx.Unlock()
+		if !mj.closed {
+			mj.closed = true
+			mj.rtCtx.Clean()
+		}
+	}()
+}
+
+func (ps *ProxyState) clearModuleJobs() {
+	ps.moduleJobs.Each(func(_ string, mj *moduleJobs) bool {
+		ps.stopModuleJobs(mj)
+		return true
+	})
+	ps.moduleJobs.Clear()
+}
+
+// runModuleJob runs the job, when raft is enabled jobs only run on the leader, so they run
+// once for the cluster.
+func (ps *ProxyState) runModuleJob(mj *moduleJobs, job *moduleJob) {
+	if ps.raftEnabled {
+		if r := ps.Raft(); r == nil || r.State() != raft.Leader {
+			return
+		}
+	}
+	logger := ps.logger.With(
+		zap.String("module", mj.module),
+		zap.String("namespace", mj.namespace),
+		zap.String("job", job.Name),
+	)
+	job.running.Store(true)
+	defer job.running.Store(false)
+
+	mj.mtx.Lock()
+	defer mj.mtx.Unlock()
+	if mj.closed {
+		return
+	}
+	start := time.Now()
+	mj.rtCtx.Runtime().ClearInterrupt()
+	mj.rtCtx.EventLoop().Start()
+	err := job.Run()
+	mj.rtCtx.EventLoop().Stop()
+	job.record(start, err)
+	if err != nil {
+		logger.Error("Error running module job", zap.Error(err))
+	} else {
+		logger.Debug("module job finished", zap.Duration("elapsed", time.Since(start)))
+	}
+}
+
+func (job *moduleJob) record(start time.Time, err error) {
+	run := spec.ModuleJobRun{
+		Start:    start,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+	job.lastRun = start
+	job.lastErr = run.Error
+	job.runs = append([]spec.ModuleJobRun{run}, job.runs...)
+	if len(job.runs) > maxModuleJobRuns {
+		job.runs = job.runs[:maxModuleJobRuns]
+	}
+}
+
+func (job *moduleJob) status() spec.ModuleJobStatus {
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+	return spec.ModuleJobStatus{
+		Name:      job.Name,
+		Schedule:  job.Spec,
+		Running:   job.running.Load(),
+		LastRun:   job.lastRun,
+		LastError: job.lastErr,
+		Runs:      append([]spec.ModuleJobRun{}, job.runs...),
+	}
+}
+
+// ModuleJobs returns the jobs of the module and their runs, ok
+// is false if the module does not exist or cannot have jobs.
+func (ps *ProxyState) ModuleJobs(name, namespace string) (*spec.ModuleJobs, bool) {
+	mod, ok := ps.rm.GetModule(name, namespace)
+	if !ok || (mod.Type != spec.ModuleTypeJavascript && mod.Type != spec.ModuleTypeTypescript) {
+		return nil, false
+	}
+	jobs := &spec.ModuleJobs{
+		Module:    name,
+		Namespace: namespace,
+		Jobs:      []spec.ModuleJobStatus{},
+	}
+	if mj, ok := ps.moduleJobs.Find(name + "/" + namespace); ok {
+		if mj.err != nil {
+			jobs.Error = mj.err.Error()
+		}
+		for _, job := range mj.jobs {
+			jobs.Jobs = append(jobs.Jobs, job.status())
+		}
+	}
+	return jobs, true
+}
@@ -0,0 +1,118 @@
+package proxy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const jobsModuleTS = `
+let count = 0;
+export const jobs = {
+	counter: {
+		schedule: "@every 1s",
+		handler: (job: any) => {
+			count++;
+			if (count > 1) {
+				throw new Error(job.name + " failed");
+			}
+		},
+	},
+};
+`
+
+func TestModuleJobs(t *testing.T) {
+	conf := chainConfig("http://localhost:8080",
+		chainModuleSpec("jobs", jobsModuleTS, spec.ModuleTypeTypescript))
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	var status spec.ModuleJobStatus
+	assert.Eventually(t, func() bool {
+		jobs, ok := ps.ModuleJobs("jobs", "test")
+		if !ok || len(jobs.Jobs) != 1 {
+			return false
+		}
+		status = jobs.Jobs[0]
+		return len(status.Runs) >= 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.Equal(t, "counter", status.Name)
+	assert.Equal(t, "@every 1s", status.Schedule)
+	assert.Contains(t, status.LastError, "counter failed")
+	// runs are ordered by the newest run first
+	last := status.Runs[len(status.Runs)-1]
+	assert.Empty(t, last.Error)
+	assert.Equal(t, status.LastRun, status.Runs[0].Start)
+
+	_, ok := ps.ModuleJobs("unknown", "test")
+	assert.False(t, ok)
+}
+
+func TestModuleJobs_InvalidSchedule(t *testing.T) {
+	conf := chainConfig("http://localhost:8080",
+		chainModuleSpec("jobs", `export const jobs = {
+			test: { schedule: "@every 10ms", handler: () => {} },
+		};`, spec.ModuleTypeTypescript))
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	jobs, ok := ps.ModuleJobs("jobs", "test")
+	require.True(t, ok)
+	assert.Contains(t, jobs.Error, "schedule interval must be at least 1 second")
+	assert.Empty(t, jobs.Jobs)
+}
+
+func TestModuleJobs_Namespace(t *testing.T) {
+	conf := chainConfig("http://localhost:8080",
+		chainModuleSpec("jobs", `
+import { setCache } from "dgate/storage";
+import { getSecret } from "dgate/secrets";
+
+export const jobs = {
+	store: {
+		schedule: "@every 1s",
+		handler: () => {
+			// the job runtime uses the namespace of the module
+			setCache("last-run", Date.now(), { ttl: 60 });
+			getSecret("missing");
+		},
+	},
+};`, spec.ModuleTypeTypescript))
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	var status spec.ModuleJobStatus
+	assert.Eventually(t, func() bool {
+		jobs, ok := ps.ModuleJobs("jobs", "test")
+		if !ok || len(jobs.Jobs) != 1 {
+			return false
+		}
+		status = jobs.Jobs[0]
+		return len(status.Runs) >= 1
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Empty(t, status.LastError)
+}
+
+func TestModuleJobs_ReExported(t *testing.T) {
+	conf := chainConfig("http://localhost:8080",
+		chainModuleSpec("app", `export * from "module:lib";`, spec.ModuleTypeTypescript))
+	conf.ProxyConfig.InitResources.Modules = append(conf.ProxyConfig.InitResources.Modules,
+		chainModuleSpec("lib", `export const jobs = {
+			test: { schedule: "@every 1h", handler: () => {} },
+		};`, spec.ModuleTypeTypescript))
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	jobs, ok := ps.ModuleJobs("app", "test")
+	require.True(t, ok)
+	assert.Empty(t, jobs.Error)
+	if assert.Len(t, jobs.Jobs, 1) {
+		assert.Equal(t, "test", jobs.Jobs[0].Name)
+	}
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+// moduleLimits returns the limits of the module, the limits
+// the module does not set are the defaults of the proxy.
+func (ps *ProxyState) moduleLimits(mod *spec.DGateModule) extractors.Limits {
+	conf := ps.config.ProxyConfig.ModuleLimits
+	limits := extractors.Limits{
+		Module:         mod.Name,
+		CPUTime:        conf.CPUTime,
+		WallTime:       conf.WallTime,
+		MaxAllocations: conf.MaxAllocations,
+	}
+	if mod.Limits != nil {
+		if mod.Limits.CPUTime != nil {
+			limits.CPUTime = *mod.Limits.CPUTime
+		}
+		if mod.Limits.WallTime != nil {
+			limits.WallTime = *mod.Limits.WallTime
+		}
+		if mod.Limits.MaxAllocations != nil {
+			limits.MaxAllocations = *mod.Limits.MaxAllocations
+		}
+	}
+	return limits
+}
+
+// moduleErrorStatus returns the status of the response for a module error, errors of
+// modules that broke their limits use the status of the module limits config. The
+// runtime of the request is discarded, because it stays interrupted.
+func moduleErrorStatus(ps *ProxyState, reqCtx *RequestContext, err error, status int) int {
+	var limitErr *extractors.LimitError
+	if !errors.As(err, &limitErr) {
+		return status
+	}
+	if !reqCtx.moduleLimitExceeded {
+		reqCtx.moduleLimitExceeded = true
+		ps.metrics.MeasureModuleLimitExceeded(reqCtx.ctx, reqCtx, limitErr)
+	}
+	if status = ps.config.ProxyConfig.ModuleLimits.StatusCode; status == 0 {
+		status = http.StatusInternalServerError
+	}
+	return status
+}
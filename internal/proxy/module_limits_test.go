@@ -0,0 +1,65 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const limitsModuleJS = `
+exports.requestModifier = async (ctx) => {
+	switch (ctx.request().headers.get("X-Mode")) {
+	case "loop":
+		while (true) {}
+	case "wait":
+		await new Promise(() => {});
+	}
+	ctx.request().headers.set("X-Module", "ok");
+};
+`
+
+func TestProxyHandler_ModuleLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Module")))
+	}))
+	defer server.Close()
+
+	cpuTime := 100 * time.Millisecond
+	mod := chainModuleSpec("limits", limitsModuleJS, spec.ModuleTypeJavascript)
+	mod.Limits = &spec.ModuleLimits{CPUTime: &cpuTime}
+	conf := chainConfig(server.URL, mod)
+	conf.ProxyConfig.ModuleLimits.WallTime = 200 * time.Millisecond
+	conf.ProxyConfig.ModuleLimits.CPUTime = 10 * time.Second
+	conf.ProxyConfig.ModuleLimits.StatusCode = http.StatusServiceUnavailable
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	for mode, limit := range map[string]string{
+		"loop": "cpu_time",
+		"wait": "wall_time",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		req.Header.Set("X-Mode", mode)
+		wr := httptest.NewRecorder()
+		start := time.Now()
+		ps.ServeHTTP(wr, req)
+		assert.Less(t, time.Since(start), 5*time.Second, mode)
+		assert.Equal(t, http.StatusServiceUnavailable, wr.Code, mode)
+		assert.Contains(t, wr.Header().Get("X-DGate-Module-Error"),
+			"module limits exceeded the "+limit+" limit", mode)
+
+		// the interrupted runtime is not used again
+		req = httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr = httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		assert.Equal(t, http.StatusOK, wr.Code, mode)
+		assert.Equal(t, "ok", wr.Body.String(), mode)
+	}
+}
@@ -42,6 +42,11 @@ func (mb *mockModulePool) Return(me proxy.ModuleExtractor) {
 	mb.Called(me)
 }
 
+// Discard implements proxy.ModulePool.
+func (mb *mockModulePool) Discard(me proxy.ModuleExtractor) {
+	mb.Called(me)
+}
+
 type mockModuleExtractor struct {
 	mock.Mock
 }
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/pkg/modules/native"
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+// loadNativeModules loads the go plugins of the config
+func loadNativeModules(configs []config.DGateNativeModulesConfig) (map[string]*native.Module, error) {
+	mods := make(map[string]*native.Module, len(configs))
+	for _, conf := range configs {
+		if _, ok := mods[conf.Name]; ok {
+			return nil, fmt.Errorf("duplicate native module: %s", conf.Name)
+		}
+		mod, err := native.Open(conf.Name, conf.Path)
+		if err != nil {
+			return nil, err
+		}
+		mods[conf.Name] = mod
+	}
+	return mods, nil
+}
+
+// nativeModule returns the native module of a module resource, the payload is the name of the
+// native module (the name of the module resource is used when it is empty). Plugins of the
+// config are used before the modules registered in the binary.
+func (ps *ProxyState) nativeModule(mod *spec.DGateModule) (*native.Module, error) {
+	name := mod.Payload
+	if name == "" {
+		name = mod.Name
+	}
+	if nativeMod, ok := ps.nativeModules[name]; ok {
+		return nativeMod, nil
+	} else if nativeMod, ok := native.Registered(name); ok {
+		return nativeMod, nil
+	}
+	return nil, fmt.Errorf("module %s: native module not found: %s", mod.Name, name)
+}
@@ -0,0 +1,68 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestProxyHandler_ModulePermissions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	open := chainModuleSpec("open", `
+exports.requestModifier = (ctx) => {
+	ctx.request().headers.set("X-Path", String(process.env.PATH));
+};`, spec.ModuleTypeJavascript)
+	locked := chainModuleSpec("locked", `
+exports.requestModifier = (ctx) => {
+	ctx.request().headers.set("X-Home", String(process.env.HOME));
+};`, spec.ModuleTypeJavascript)
+	// an empty list denies every permission, no list allows everything
+	locked.Permissions = []string{}
+
+	for _, test := range []struct {
+		name    string
+		modules []string
+		status  int
+	}{
+		{"allowed", []string{"open"}, http.StatusOK},
+		{"denied", []string{"open", "locked"}, http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			conf := chainConfig(server.URL, open, locked)
+			conf.ProxyConfig.InitResources.Routes[0].Modules = test.modules
+			core, logs := observer.New(zapcore.WarnLevel)
+			ps := proxy.NewProxyState(zap.New(core), conf)
+			if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+			wr := httptest.NewRecorder()
+			ps.ServeHTTP(wr, req)
+			assert.Equal(t, test.status, wr.Code)
+
+			denied := logs.FilterMessage("module permission denied").All()
+			if test.status == http.StatusOK {
+				assert.Empty(t, denied)
+				return
+			}
+			if assert.Len(t, denied, 1) {
+				fields := denied[0].ContextMap()
+				assert.Equal(t, "locked", fields["module"])
+				assert.Equal(t, "os:env:read:HOME", fields["permission"])
+				assert.Equal(t, "test", fields["route"])
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package proxy_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// runtimePoolConfig has two routes that use the transform module
+func runtimePoolConfig(upstreamUrl string) *config.DGateConfig {
+	conf := chainConfig(upstreamUrl,
+		chainModuleSpec("transform", transformModuleJS, spec.ModuleTypeJavascript))
+	resources := conf.ProxyConfig.InitResources
+	resources.Routes = append(resources.Routes, spec.Route{
+		Name:          "test2",
+		Paths:         []string{"/test2"},
+		Methods:       []string{"GET"},
+		Modules:       []string{"transform"},
+		ServiceName:   "test",
+		NamespaceName: "test",
+	})
+	return conf
+}
+
+func TestRuntimePool_SharedByRoutes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Transformed")))
+	}))
+	defer server.Close()
+
+	ps := proxy.NewProxyState(zap.NewNop(), runtimePoolConfig(server.URL))
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+	assert.Equal(t, 1, ps.RuntimePoolStats().Pools)
+
+	for _, path := range []string{"/test", "/test2", "/test"} {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		assert.Equal(t, http.StatusOK, wr.Code)
+		assert.Equal(t, "true", wr.Body.String())
+	}
+	// the runtime is returned after each request, so the routes reuse it
+	stats := ps.RuntimePoolStats()
+	assert.Equal(t, int64(1), stats.Created)
+	assert.Equal(t, 0, stats.Borrowed)
+	assert.Equal(t, 1, stats.Idle)
+}
+
+func TestRuntimePool_ModuleChange(t *testing.T) {
+	ps := proxy.NewProxyState(zap.NewNop(), runtimePoolConfig("http://localhost:8080"))
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	mod := &spec.Module{
+		Name:          "transform",
+		NamespaceName: "test",
+		Type:          spec.ModuleTypeJavascript,
+		Payload: base64.StdEncoding.EncodeToString(
+			[]byte(transformModuleJS + "\n// changed")),
+	}
+	require.NoError(t, ps.ProcessChangeLog(spec.NewChangeLog(
+		mod, mod.NamespaceName, spec.AddModuleCommand), true))
+
+	// the routes use a new pool, the runtimes of the old pool are evicted
+	stats := ps.RuntimePoolStats()
+	assert.Equal(t, 1, stats.Pools)
+	assert.Equal(t, int64(2), stats.Created)
+	assert.Equal(t, int64(1), stats.Evicted)
+	assert.Equal(t, 1, stats.Idle)
+}
+
+func TestRuntimePool_MinIdle(t *testing.T) {
+	conf := runtimePoolConfig("http://localhost:8080")
+	conf.ProxyConfig.RuntimePool.MinIdle = 3
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	assert.Eventually(t, func() bool {
+		return ps.RuntimePoolStats().Idle == 3
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestRuntimePool_IdleTimeout(t *testing.T) {
+	conf := runtimePoolConfig("http://localhost:8080")
+	conf.ProxyConfig.RuntimePool.IdleTimeout = time.Millisecond * 50
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	assert.Eventually(t, func() bool {
+		stats := ps.RuntimePoolStats()
+		return stats.Idle == 0 && stats.Evicted == 1
+	}, time.Second*5, time.Millisecond*10)
+	// the pool is kept for the routes, runtimes are created when needed
+	assert.Equal(t, 1, ps.RuntimePoolStats().Pools)
+}
+
+func TestRuntimePool_MaxMemory(t *testing.T) {
+	conf := runtimePoolConfig("http://localhost:8080")
+	conf.ProxyConfig.RuntimePool.MinIdle = 2
+	conf.ProxyConfig.RuntimePool.IdleTimeout = time.Millisecond * 50
+	conf.ProxyConfig.RuntimePool.MaxMemory = 1
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	// the heap is always over the memory cap, so the pool is not warmed
+	assert.Eventually(t, func() bool {
+		stats := ps.RuntimePoolStats()
+		return stats.Idle == 0 && stats.Evicted > 0
+	}, time.Second*5, time.Millisecond*10)
+}
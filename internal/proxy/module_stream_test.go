@@ -0,0 +1,127 @@
+package proxy_test
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const streamModuleJS = `
+const upper = (chunk) => String.fromCharCode(...chunk).toUpperCase();
+const requestModifier = (ctx) => {
+	ctx.request().transformBody((chunk) => chunk.subarray(1));
+	ctx.request().transformBody(upper);
+};
+const responseModifier = (ctx) => {
+	ctx.upstream().transformBody(async (chunk) => "[" + String.fromCharCode(...chunk) + "]", () => "!");
+};
+module.exports = { requestModifier, responseModifier };
+`
+
+func TestProxyHandler_ModuleTransformBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Content-Length", r.Header.Get("Content-Length"))
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	conf := chainConfig(server.URL,
+		chainModuleSpec("stream", streamModuleJS, spec.ModuleTypeJavascript))
+	conf.ProxyConfig.InitResources.Routes[0].Methods = []string{"POST"}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	req := httptest.NewRequest(http.MethodPost,
+		"http://localhost/test", strings.NewReader("hello"))
+	wr := httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, http.StatusOK, wr.Code)
+	assert.Equal(t, "[ELLO]!", wr.Body.String())
+	assert.Empty(t, wr.Header().Get("Content-Length"))
+}
+
+func TestProxyHandler_ModuleStreamingResponse(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n"))
+		w.(http.Flusher).Flush()
+		// the second event is sent once the client has read the first one
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+		w.Write([]byte("data: second\n"))
+	}))
+	defer server.Close()
+
+	conf := chainConfig(server.URL, chainModuleSpec("headers", `
+exports.responseModifier = (ctx) => {
+	ctx.upstream().headers.set("X-Module", "true");
+};`, spec.ModuleTypeJavascript), chainModuleSpec("stream", `
+exports.responseModifier = (ctx) => {
+	ctx.upstream().transformBody((chunk) => String.fromCharCode(...chunk).replace("data", "event"));
+};`, spec.ModuleTypeJavascript))
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+	proxyServer := httptest.NewServer(ps)
+	defer proxyServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, proxyServer.URL+"/test", nil)
+	require.NoError(t, err)
+	req.Host = "localhost"
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "true", resp.Header.Get("X-Module"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: first\n", line)
+	// the first event is not held back until the upstream is done
+	assert.Less(t, time.Since(start), 2*time.Second)
+	close(release)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: second\n", line)
+}
+
+func TestProxyHandler_ModuleBodyStream(t *testing.T) {
+	conf := chainConfig("http://localhost:8080", chainModuleSpec("handler", `
+exports.requestHandler = async (ctx) => {
+	const reader = ctx.request().body().getReader();
+	let size = 0, chunks = 0;
+	for (let res = await reader.read(); !res.done; res = await reader.read()) {
+		size += res.value.length;
+		chunks++;
+	}
+	ctx.response().send(size + ":" + (chunks > 1) + ":" + ctx.request().body().locked);
+};`, spec.ModuleTypeJavascript))
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = nil
+	resources.Routes[0].ServiceName = ""
+	resources.Routes[0].Methods = []string{"POST"}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	body := strings.Repeat("a", 100*1024)
+	req := httptest.NewRequest(http.MethodPost,
+		"http://localhost/test", strings.NewReader(body))
+	wr := httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, http.StatusOK, wr.Code)
+	assert.Equal(t, "102400:true:true", wr.Body.String())
+}
@@ -0,0 +1,72 @@
+package proxy_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/modules/native"
+	"github.com/dgate-io/dgate/pkg/modules/types"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type headerModule struct{}
+
+func (headerModule) RequestModifier(modCtx *types.ModuleContext) error {
+	modCtx.Request().Headers.Set("X-Native", "true")
+	return nil
+}
+
+func (headerModule) ResponseModifier(modCtx *types.ModuleContext, res *http.Response) error {
+	modCtx.Upstream().Headers.Set("X-Native-Response", "ok")
+	return nil
+}
+
+func TestProxyHandler_NativeModule(t *testing.T) {
+	require.NoError(t, native.Register("proxy-test-headers", headerModule{}))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Native")))
+	}))
+	defer server.Close()
+
+	// the payload is the name of the native module
+	for _, conf := range [][]config.ModuleSpec{
+		{chainModuleSpec("headers", "proxy-test-headers", spec.ModuleTypeNative)},
+		{
+			chainModuleSpec("headers", "proxy-test-headers", spec.ModuleTypeNative),
+			chainModuleSpec("transform", transformModuleJS, spec.ModuleTypeJavascript),
+		},
+	} {
+		ps := proxy.NewProxyState(zap.NewNop(), chainConfig(server.URL, conf...))
+		require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		assert.Equal(t, http.StatusOK, wr.Code)
+		assert.Equal(t, "true", wr.Body.String())
+		assert.Equal(t, "ok", wr.Header().Get("X-Native-Response"))
+	}
+}
+
+func TestProxyHandler_NativeModuleNotFound(t *testing.T) {
+	conf := chainConfig("http://localhost:8080")
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	mod := &spec.Module{
+		Name:          "headers",
+		NamespaceName: "test",
+		Type:          spec.ModuleTypeNative,
+		Payload:       base64.StdEncoding.EncodeToString([]byte("missing")),
+	}
+	err := ps.ProcessChangeLog(spec.NewChangeLog(
+		mod, mod.NamespaceName, spec.AddModuleCommand), true)
+	assert.ErrorContains(t, err, "native module not found: missing")
+}
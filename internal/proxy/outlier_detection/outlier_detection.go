@@ -0,0 +1,225 @@
+package outlier_detection
+
+import (
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+// EjectFunc is called when a url is ejected or re-ejected after a failed probe
+type EjectFunc func(u *url.URL, duration time.Duration)
+
+// Detector passively tracks the results of proxied requests for each
+// url of a service and ejects urls that are failing. Once the ejection
+// expires, a single probe request is let through (half-open), the url is
+// restored if it succeeds and ejected again for longer if it fails.
+type Detector struct {
+	mtx       sync.Mutex
+	spec      *spec.OutlierDetection
+	od        spec.OutlierDetection
+	urls      []*url.URL
+	upstreams map[*url.URL]*upstream
+	onEject   EjectFunc
+}
+
+type upstream struct {
+	consecutiveFailures int
+	windowStart         time.Time
+	requests            int
+	failures            int
+	ejections           int
+	ejectedUntil        time.Time
+	probeStart          time.Time
+}
+
+func (up *upstream) ejected() bool {
+	return !up.ejectedUntil.IsZero()
+}
+
+func (up *upstream) resetWindow(now time.Time) {
+	up.windowStart = now
+	up.requests = 0
+	up.failures = 0
+	up.consecutiveFailures = 0
+}
+
+// New creates a detector for the urls, onEject may be nil.
+func New(od *spec.OutlierDetection, urls []*url.URL, onEject EjectFunc) *Detector {
+	d := &Detector{
+		spec:    od,
+		od:      od.WithDefaults(),
+		onEject: onEject,
+	}
+	d.Rebind(urls)
+	return d
+}
+
+// Matches checks if the detector was created with the same config and urls.
+func (d *Detector) Matches(od *spec.OutlierDetection, urls []*url.URL) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if !reflect.DeepEqual(d.spec, od) || len(d.urls) != len(urls) {
+		return false
+	}
+	for i, u := range urls {
+		if d.urls[i].String() != u.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// Rebind replaces the urls of the detector, state is kept for urls that did not change.
+func (d *Detector) Rebind(urls []*url.URL) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	prev := make(map[string]*upstream, len(d.upstreams))
+	for u, up := range d.upstreams {
+		prev[u.String()] = up
+	}
+	d.urls = urls
+	d.upstreams = make(map[*url.URL]*upstream, len(urls))
+	for _, u := range urls {
+		if up, ok := prev[u.String()]; ok {
+			d.upstreams[u] = up
+		} else {
+			d.upstreams[u] = &upstream{windowStart: time.Now()}
+		}
+	}
+}
+
+// Available reports if requests can be sent to the url, it does not change the state
+// of the url. When the ejection of the url has expired, the url is available until a
+// request takes the probe slot with TryProbe.
+func (d *Detector) Available(u *url.URL) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	up, ok := d.upstreams[u]
+	if !ok || !up.ejected() {
+		return true
+	}
+	now := time.Now()
+	return !now.Before(up.ejectedUntil) && d.probeFree(up, now)
+}
+
+// TryProbe must be called once a url is selected for a request. It reports if
+// the request can be sent to the url, when the ejection of the url has expired
+// the request takes the probe slot, so only one probe request is sent at a time.
+func (d *Detector) TryProbe(u *url.URL) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	up, ok := d.upstreams[u]
+	if !ok || !up.ejected() {
+		return true
+	}
+	now := time.Now()
+	if now.Before(up.ejectedUntil) || !d.probeFree(up, now) {
+		return false
+	}
+	up.probeStart = now
+	return true
+}
+
+// probeFree checks if the probe slot can be taken, the slot
+// is given back if the probe never reports a result
+func (d *Detector) probeFree(up *upstream, now time.Time) bool {
+	return up.probeStart.IsZero() || now.Sub(up.probeStart) > d.od.EjectionDuration
+}
+
+// Record reports the result of a request sent to the url.
+func (d *Detector) Record(u *url.URL, failed bool) {
+	d.mtx.Lock()
+	up, ok := d.upstreams[u]
+	if !ok {
+		d.mtx.Unlock()
+		return
+	}
+	now := time.Now()
+	var ejectedFor time.Duration
+	if up.ejected() {
+		// results of requests sent before the url was ejected are ignored
+		if now.Before(up.ejectedUntil) {
+			d.mtx.Unlock()
+			return
+		}
+		up.probeStart = time.Time{}
+		if failed {
+			ejectedFor = d.eject(up, now)
+		} else {
+			up.ejections = 0
+			up.ejectedUntil = time.Time{}
+			up.resetWindow(now)
+		}
+	} else {
+		if now.Sub(up.windowStart) > d.od.Interval {
+			up.resetWindow(now)
+		}
+		up.requests++
+		if failed {
+			up.failures++
+			up.consecutiveFailures++
+		} else {
+			up.consecutiveFailures = 0
+		}
+		if d.shouldEject(up) && d.canEject() {
+			ejectedFor = d.eject(up, now)
+		}
+	}
+	d.mtx.Unlock()
+
+	if ejectedFor > 0 && d.onEject != nil {
+		d.onEject(u, ejectedFor)
+	}
+}
+
+func (d *Detector) shouldEject(up *upstream) bool {
+	if up.consecutiveFailures >= d.od.ConsecutiveFailures {
+		return true
+	}
+	if d.od.ErrorRate > 0 && up.requests >= d.od.MinRequests {
+		return float64(up.failures)*100/float64(up.requests) >= d.od.ErrorRate
+	}
+	return false
+}
+
+// canEject checks that ejecting another url will not go over the max ejection percent,
+// at least one url can always be ejected.
+func (d *Detector) canEject() bool {
+	ejected := 0
+	for _, up := range d.upstreams {
+		if up.ejected() {
+			ejected++
+		}
+	}
+	maxEjected := max(1, len(d.upstreams)*d.od.MaxEjectionPercent/100)
+	return ejected < maxEjected
+}
+
+func (d *Detector) eject(up *upstream, now time.Time) time.Duration {
+	up.ejections++
+	duration := min(d.od.EjectionDuration*time.Duration(up.ejections), d.od.MaxEjectionDuration)
+	up.ejectedUntil = now.Add(duration)
+	up.resetWindow(now)
+	return duration
+}
+
+// Statuses returns the outlier state of each url.
+func (d *Detector) Statuses() []spec.UpstreamStatus {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	statuses := make([]spec.UpstreamStatus, 0, len(d.urls))
+	for _, u := range d.urls {
+		up := d.upstreams[u]
+		statuses = append(statuses, spec.UpstreamStatus{
+			URL:                 u.String(),
+			Healthy:             !up.ejected(),
+			ConsecutiveFailures: up.consecutiveFailures,
+			Ejected:             up.ejected(),
+			EjectedUntil:        up.ejectedUntil,
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,154 @@
+package outlier_detection_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/load_balancer"
+	"github.com/dgate-io/dgate/internal/proxy/outlier_detection"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func testUrls(t *testing.T, n int) []*url.URL {
+	urls := make([]*url.URL, n)
+	for i := range urls {
+		u, err := url.Parse("http://upstream-" + string(rune('a'+i)) + ":8080")
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls[i] = u
+	}
+	return urls
+}
+
+func TestOutlierDetection_ConsecutiveFailures(t *testing.T) {
+	urls := testUrls(t, 2)
+	ejected := []*url.URL{}
+	d := outlier_detection.New(&spec.OutlierDetection{
+		ConsecutiveFailures: 3,
+		EjectionDuration:    50 * time.Millisecond,
+	}, urls, func(u *url.URL, _ time.Duration) {
+		ejected = append(ejected, u)
+	})
+
+	d.Record(urls[0], true)
+	d.Record(urls[0], true)
+	d.Record(urls[0], false)
+	d.Record(urls[0], true)
+	d.Record(urls[0], true)
+	assert.True(t, d.Available(urls[0]))
+	d.Record(urls[0], true)
+	assert.False(t, d.Available(urls[0]))
+	assert.True(t, d.Available(urls[1]))
+	assert.Equal(t, []*url.URL{urls[0]}, ejected)
+
+	statuses := d.Statuses()
+	assert.True(t, statuses[0].Ejected)
+	assert.False(t, statuses[1].Ejected)
+
+	// half-open, a single probe is allowed and restores the url
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, d.Available(urls[0]))
+	assert.True(t, d.TryProbe(urls[0]))
+	assert.False(t, d.Available(urls[0]))
+	assert.False(t, d.TryProbe(urls[0]))
+	d.Record(urls[0], false)
+	assert.True(t, d.Available(urls[0]))
+	assert.True(t, d.Available(urls[0]))
+}
+
+func TestOutlierDetection_FailedProbe(t *testing.T) {
+	urls := testUrls(t, 2)
+	durations := []time.Duration{}
+	d := outlier_detection.New(&spec.OutlierDetection{
+		ConsecutiveFailures: 1,
+		EjectionDuration:    20 * time.Millisecond,
+	}, urls, func(_ *url.URL, d time.Duration) {
+		durations = append(durations, d)
+	})
+	d.Record(urls[0], true)
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, d.TryProbe(urls[0]))
+	d.Record(urls[0], true)
+	assert.False(t, d.Available(urls[0]))
+	// ejection duration grows with each ejection
+	assert.Equal(t, []time.Duration{
+		20 * time.Millisecond, 40 * time.Millisecond,
+	}, durations)
+}
+
+func TestOutlierDetection_ErrorRate(t *testing.T) {
+	urls := testUrls(t, 2)
+	d := outlier_detection.New(&spec.OutlierDetection{
+		ConsecutiveFailures: 100,
+		ErrorRate:           50,
+		MinRequests:         4,
+	}, urls, nil)
+	d.Record(urls[0], true)
+	d.Record(urls[0], false)
+	d.Record(urls[0], true)
+	assert.True(t, d.Available(urls[0]))
+	d.Record(urls[0], false)
+	assert.False(t, d.Available(urls[0]))
+}
+
+func TestOutlierDetection_MaxEjectionPercent(t *testing.T) {
+	urls := testUrls(t, 2)
+	d := outlier_detection.New(&spec.OutlierDetection{
+		ConsecutiveFailures: 1,
+		MaxEjectionPercent:  50,
+	}, urls, nil)
+	d.Record(urls[0], true)
+	d.Record(urls[1], true)
+	assert.False(t, d.Available(urls[0]))
+	assert.True(t, d.Available(urls[1]))
+}
+
+func TestOutlierDetection_MatchesAndRebind(t *testing.T) {
+	od := &spec.OutlierDetection{ConsecutiveFailures: 1}
+	urls := testUrls(t, 1)
+	d := outlier_detection.New(od, urls, nil)
+	d.Record(urls[0], true)
+
+	newUrls := testUrls(t, 1)
+	assert.True(t, d.Matches(&spec.OutlierDetection{ConsecutiveFailures: 1}, newUrls))
+	assert.False(t, d.Matches(&spec.OutlierDetection{ConsecutiveFailures: 2}, newUrls))
+
+	d.Rebind(newUrls)
+	assert.False(t, d.Available(newUrls[0]))
+}
+
+func TestOutlierDetection_WeightedBalancerProbe(t *testing.T) {
+	urls := testUrls(t, 2)
+	d := outlier_detection.New(&spec.OutlierDetection{
+		ConsecutiveFailures: 1,
+		EjectionDuration:    20 * time.Millisecond,
+	}, urls, nil)
+	lb, err := load_balancer.New(&spec.LoadBalancer{
+		Strategy: spec.LoadBalancerWeighted,
+		Weights:  []int{10, 1},
+	}, urls, 0, d.Available)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Record(urls[1], true)
+	time.Sleep(30 * time.Millisecond)
+
+	// scanning the ejected url does not take the probe slot,
+	// only the request sent to it does
+	probed := 0
+	for i := 0; i < 22; i++ {
+		u, err := lb.Next(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.TryProbe(u) && u == urls[1] {
+			probed++
+		}
+	}
+	assert.Equal(t, 1, probed)
+	d.Record(urls[1], false)
+	assert.False(t, d.Statuses()[1].Ejected)
+}
@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"reflect"
+
+	"github.com/dgate-io/dgate/internal/proxy/response_cache"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"go.uber.org/zap"
+)
+
+type responseCache struct {
+	spec  spec.ResponseCache
+	cache *response_cache.Cache
+}
+
+func routeKey(route *spec.DGateRoute) string {
+	return route.Namespace.Name + "/" + route.Name
+}
+
+// setupResponseCaches syncs the response caches with the current routes, the
+// cached responses are kept for routes with an unchanged cache config.
+func (ps *ProxyState) setupResponseCaches() {
+	active := make(map[string]struct{})
+	for _, route := range ps.rm.GetRoutes() {
+		if route.Cache == nil {
+			continue
+		}
+		key := routeKey(route)
+		if rc, ok := ps.caches.Find(key); ok &&
+			reflect.DeepEqual(rc.spec, *route.Cache) {
+			active[key] = struct{}{}
+			continue
+		}
+		cache, err := response_cache.New(route.Cache)
+		if err != nil {
+			ps.logger.Error("Error creating response cache, caching is disabled",
+				zap.Error(err),
+				zap.String("route", route.Name),
+				zap.String("namespace", route.Namespace.Name),
+			)
+			continue
+		}
+		active[key] = struct{}{}
+		ps.caches.Insert(key, &responseCache{
+			spec:  *route.Cache,
+			cache: cache,
+		})
+	}
+
+	removed := []string{}
+	ps.caches.Each(func(key string, _ *responseCache) bool {
+		if _, ok := active[key]; !ok {
+			removed = append(removed, key)
+		}
+		return true
+	})
+	for _, key := range removed {
+		ps.caches.Delete(key)
+	}
+}
+
+// routeResponseCache returns the response cache of the route, or nil if it has none
+func (ps *ProxyState) routeResponseCache(route *spec.DGateRoute) *response_cache.Cache {
+	if rc, ok := ps.caches.Find(routeKey(route)); ok {
+		return rc.cache
+	}
+	return nil
+}
+
+// PurgeResponseCache removes cached responses of the route, or of all routes in the namespace
+// when route is empty. Only the responses with the key prefix and tag are removed, when set.
+// ok is false when the route does not exist or does not cache responses.
+func (ps *ProxyState) PurgeResponseCache(namespace, route, prefix, tag string) (int, bool) {
+	caches := []*response_cache.Cache{}
+	if route != "" {
+		rt, ok := ps.rm.GetRoute(route, namespace)
+		if !ok {
+			return 0, false
+		}
+		cache := ps.routeResponseCache(rt)
+		if cache == nil {
+			return 0, false
+		}
+		caches = append(caches, cache)
+	} else {
+		for _, rt := range ps.rm.GetRoutesByNamespace(namespace) {
+			if cache := ps.routeResponseCache(rt); cache != nil {
+				caches = append(caches, cache)
+			}
+		}
+	}
+
+	purged := 0
+	for _, cache := range caches {
+		purged += cache.Purge(prefix, tag)
+	}
+	return purged, true
+}
@@ -0,0 +1,68 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/internal/proxy/response_cache"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProxyHandler_ResponseCache(t *testing.T) {
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Cache-Tag", "items")
+		w.Write([]byte("cached " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{
+		{Name: "test", URLs: []string{server.URL}, NamespaceName: "test"},
+	}
+	resources.Modules = nil
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test/{id}"},
+		Methods:       []string{"GET", "POST"},
+		ServiceName:   "test",
+		NamespaceName: "test",
+		Cache:         &spec.ResponseCache{Key: "{path}"},
+	}}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, httptest.NewRequest(method, "http://localhost"+path, nil))
+		return wr
+	}
+	wr := serve(http.MethodGet, "/test/1")
+	assert.Equal(t, "cached /test/1", wr.Body.String())
+	assert.Equal(t, "MISS", wr.Header().Get(response_cache.CacheStatusHeader))
+	wr = serve(http.MethodGet, "/test/1")
+	assert.Equal(t, "cached /test/1", wr.Body.String())
+	assert.Equal(t, "HIT", wr.Header().Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, int32(1), calls.Load())
+
+	serve(http.MethodPost, "/test/1")
+	assert.Equal(t, int32(2), calls.Load())
+
+	purged, ok := ps.PurgeResponseCache("test", "test", "", "items")
+	assert.True(t, ok)
+	assert.Equal(t, 1, purged)
+	wr = serve(http.MethodGet, "/test/1")
+	assert.Equal(t, "MISS", wr.Header().Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/concurrency_limit"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dgate-io/dgate/pkg/util"
+	"go.uber.org/zap"
+)
+
+type concurrencyLimiter struct {
+	spec    spec.ConcurrencyLimit
+	limiter *concurrency_limit.Limiter
+}
+
+// setupConcurrencyLimits syncs the concurrency limiters with the current services and
+// routes, service limiters are shared by all routes of the service. Limiters with an
+// unchanged config are kept, so the adaptive limits are not reset.
+func (ps *ProxyState) setupConcurrencyLimits() {
+	active := make(map[string]struct{})
+	setup := func(key string, cl *spec.ConcurrencyLimit) {
+		active[key] = struct{}{}
+		if l, ok := ps.concurrencyLimits.Find(key); ok && reflect.DeepEqual(l.spec, *cl) {
+			return
+		}
+		ps.concurrencyLimits.Insert(key, &concurrencyLimiter{
+			spec:    *cl,
+			limiter: concurrency_limit.New(cl),
+		})
+	}
+	for _, svc := range ps.rm.GetServices() {
+		if svc.ConcurrencyLimit != nil {
+			setup("service:"+serviceKey(svc), svc.ConcurrencyLimit)
+		}
+	}
+	for _, route := range ps.rm.GetRoutes() {
+		if route.ConcurrencyLimit != nil {
+			setup("route:"+routeKey(route), route.ConcurrencyLimit)
+		}
+	}
+
+	removed := []string{}
+	ps.concurrencyLimits.Each(func(key string, _ *concurrencyLimiter) bool {
+		if _, ok := active[key]; !ok {
+			removed = append(removed, key)
+		}
+		return true
+	})
+	for _, key := range removed {
+		ps.concurrencyLimits.Delete(key)
+	}
+}
+
+// serviceConcurrencyLimiter returns the concurrency limiter of the service, or nil if it has none
+func (ps *ProxyState) serviceConcurrencyLimiter(svc *spec.DGateService) *concurrency_limit.Limiter {
+	if l, ok := ps.concurrencyLimits.Find("service:" + serviceKey(svc)); ok {
+		return l.limiter
+	}
+	return nil
+}
+
+// routeConcurrencyLimiter returns the concurrency limiter of the route, or nil if it has none
+func (ps *ProxyState) routeConcurrencyLimiter(route *spec.DGateRoute) *concurrency_limit.Limiter {
+	if l, ok := ps.concurrencyLimits.Find("route:" + routeKey(route)); ok {
+		return l.limiter
+	}
+	return nil
+}
+
+// acquireConcurrency waits for the concurrency limiter, the request is shed with a 503
+// response when the limit is not available. The returned function must be called with
+// whether the request failed when it is done.
+func acquireConcurrency(
+	ps *ProxyState, reqCtx *RequestContext,
+	limiter *concurrency_limit.Limiter, scope string,
+) (func(failed bool), bool) {
+	if limiter == nil {
+		return func(bool) {}, true
+	}
+	// queued requests stop waiting when the client disconnects
+	release, err := limiter.Acquire(reqCtx.clientCtx)
+	if err != nil {
+		ps.logger.Debug("Request shed by concurrency limit",
+			zap.String("scope", scope),
+			zap.String("route", reqCtx.route.Name),
+			zap.String("namespace", reqCtx.route.Namespace.Name),
+			zap.Error(err),
+		)
+		ps.metrics.MeasureShedRequest(reqCtx.ctx, reqCtx, scope, err)
+		retrySecs := int((limiter.RetryAfter() + time.Second - 1) / time.Second)
+		reqCtx.rw.Header().Set("Retry-After", strconv.Itoa(retrySecs))
+		util.WriteStatusCodeError(reqCtx.rw, http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return release, true
+}
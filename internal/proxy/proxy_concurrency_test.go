@@ -0,0 +1,145 @@
+package proxy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProxyHandler_ConcurrencyLimit(t *testing.T) {
+	arrived := make(chan struct{})
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			arrived <- struct{}{}
+			<-unblock
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{{
+		Name:             "test",
+		URLs:             []string{server.URL},
+		NamespaceName:    "test",
+		ConcurrencyLimit: &spec.ConcurrencyLimit{Limit: 1},
+	}}
+	resources.Modules = nil
+	resources.Routes = []spec.Route{
+		{
+			Name:          "slow",
+			Paths:         []string{"/slow"},
+			Methods:       []string{"GET"},
+			ServiceName:   "test",
+			NamespaceName: "test",
+		},
+		{
+			Name:          "test",
+			Paths:         []string{"/test"},
+			Methods:       []string{"GET"},
+			ServiceName:   "test",
+			NamespaceName: "test",
+		},
+	}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		return wr
+	}
+	done := make(chan int)
+	go func() { done <- serve("/slow").Code }()
+	<-arrived
+
+	// the service limit is shared by all routes of the service
+	wr := serve("/test")
+	assert.Equal(t, http.StatusServiceUnavailable, wr.Code)
+	assert.Equal(t, "1", wr.Header().Get("Retry-After"))
+
+	close(unblock)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, http.StatusOK, serve("/test").Code)
+}
+
+func TestProxyHandler_ConcurrencyQueueCanceled(t *testing.T) {
+	arrived := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-unblock
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	release := sync.OnceFunc(func() { close(unblock) })
+	defer release()
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{{
+		Name:          "test",
+		URLs:          []string{server.URL},
+		NamespaceName: "test",
+		ConcurrencyLimit: &spec.ConcurrencyLimit{
+			Limit:        1,
+			QueueSize:    1,
+			QueueTimeout: time.Minute,
+		},
+	}}
+	resources.Modules = nil
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test"},
+		Methods:       []string{"GET"},
+		ServiceName:   "test",
+		NamespaceName: "test",
+	}}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(ctx context.Context) int {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req.WithContext(ctx))
+		return wr.Code
+	}
+	done := make(chan int, 2)
+	go func() { done <- serve(context.Background()) }()
+	<-arrived
+
+	// the queued request stops waiting when the client disconnects
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- serve(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case code := <-done:
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued request did not stop waiting")
+	}
+
+	// the queue slot of the canceled request is free
+	go func() { done <- serve(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+	release()
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, http.StatusOK, <-done)
+}
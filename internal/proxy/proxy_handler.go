@@ -1,12 +1,20 @@
 package proxy
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/dgate-io/dgate/internal/proxy/circuit_breaker"
+	"github.com/dgate-io/dgate/internal/proxy/proxy_transport"
+	"github.com/dgate-io/dgate/internal/proxy/response_cache"
+	"github.com/dgate-io/dgate/internal/proxy/reverse_proxy"
+	"github.com/dgate-io/dgate/pkg/modules/types"
 	"github.com/dgate-io/dgate/pkg/util"
 	"go.uber.org/zap"
 )
@@ -43,6 +51,18 @@ func proxyHandler(ps *ProxyState, reqCtx *RequestContext) {
 
 	defer ps.metrics.MeasureProxyRequest(reqCtx.ctx, reqCtx, time.Now())
 
+	if !rateLimitRequest(ps, reqCtx, nil) {
+		return
+	}
+	release, ok := acquireConcurrency(ps, reqCtx, reqCtx.provider.concurrency, "route")
+	if !ok {
+		return
+	}
+	defer func() {
+		release(reqCtx.rw.Status() >= http.StatusInternalServerError)
+	}()
+	mirrorRequest(ps, reqCtx)
+
 	var modExt ModuleExtractor
 	if len(reqCtx.route.Modules) != 0 {
 		runtimeStart := time.Now()
@@ -60,7 +80,13 @@ func proxyHandler(ps *ProxyState, reqCtx *RequestContext) {
 				util.WriteStatusCodeError(reqCtx.rw, http.StatusInternalServerError)
 				return
 			}
-			defer modPool.Return(modExt)
+			defer func() {
+				if reqCtx.moduleLimitExceeded {
+					modPool.Discard(modExt)
+				} else {
+					modPool.Return(modExt)
+				}
+			}()
 		}
 
 		modExt.Start(reqCtx)
@@ -81,7 +107,41 @@ func proxyHandler(ps *ProxyState, reqCtx *RequestContext) {
 }
 
 func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExtractor) {
+	var upstreamSent, upstreamFailed bool
+	if breaker := reqCtx.provider.breaker; breaker != nil {
+		done, retryAfter, ok := breaker.Allow()
+		if !ok {
+			ps.logger.Debug("Circuit breaker is open",
+				zap.String("route", reqCtx.route.Name),
+				zap.String("service", reqCtx.route.Service.Name),
+				zap.String("namespace", reqCtx.route.Namespace.Name),
+			)
+			retrySecs := int((retryAfter + time.Second - 1) / time.Second)
+			reqCtx.rw.Header().Set("Retry-After", strconv.Itoa(retrySecs))
+			util.WriteStatusCodeError(reqCtx.rw, http.StatusServiceUnavailable)
+			return
+		}
+		defer func() {
+			// only requests sent to the upstream change the state of the breaker
+			switch {
+			case !upstreamSent:
+				done(circuit_breaker.Skipped)
+			case upstreamFailed:
+				done(circuit_breaker.Failure)
+			default:
+				done(circuit_breaker.Success)
+			}
+		}()
+	}
+	release, ok := acquireConcurrency(ps, reqCtx, reqCtx.provider.serviceConcurrency, "service")
+	if !ok {
+		return
+	}
+	defer func() { release(upstreamFailed) }()
+
 	var host string
+	// selectedUrl is only set when the url was picked by the load balancer
+	var selectedUrl *url.URL
 	if fetchUpstreamUrl, ok := modExt.FetchUpstreamUrlFunc(); ok {
 		fetchUpstreamStart := time.Now()
 		hostUrl, err := fetchUpstreamUrl(modExt.ModuleContext())
@@ -95,7 +155,9 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 				zap.String("route", reqCtx.route.Name),
 				zap.String("namespace", reqCtx.route.Namespace.Name),
 			)
-			util.WriteStatusCodeError(reqCtx.rw, http.StatusInternalServerError)
+			setModuleErrorHeader(ps, reqCtx, err)
+			util.WriteStatusCodeError(reqCtx.rw,
+				moduleErrorStatus(ps, reqCtx, err, http.StatusInternalServerError))
 			return
 		}
 		host = hostUrl.String()
@@ -108,7 +170,25 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 			util.WriteStatusCodeError(reqCtx.rw, http.StatusInternalServerError)
 			return
 		}
-		host = reqCtx.route.Service.URLs[0].String()
+		hostUrl, err := nextUpstream(reqCtx, reqCtx.req)
+		if err != nil {
+			ps.logger.Error("Error selecting upstream",
+				zap.String("error", err.Error()),
+				zap.String("strategy", reqCtx.provider.lb.Strategy().String()),
+				zap.String("service", reqCtx.route.Service.Name),
+				zap.String("namespace", reqCtx.route.Namespace.Name),
+			)
+			util.WriteStatusCodeError(reqCtx.rw, http.StatusServiceUnavailable)
+			return
+		}
+		selectedUrl = hostUrl
+		host = hostUrl.String()
+	}
+	// the retry transport may move the request to another url,
+	// so the last url used is the one released and recorded
+	upstream := retryUpstream(reqCtx, selectedUrl)
+	if selectedUrl != nil {
+		defer func() { reqCtx.provider.lb.Release(upstream.URL) }()
 	}
 
 	if reqCtx.route.Service.HideDGateHeaders {
@@ -136,10 +216,15 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 		util.WriteStatusCodeError(reqCtx.rw, http.StatusBadGateway)
 		return
 	}
+	if modCtx := modExt.ModuleContext(); modCtx != nil {
+		types.ModuleContextWithUpstreamUrl(modCtx, upstreamUrl)
+	}
 
 	var upstreamErr error
+	var upstreamStatus int
 	rpb := reqCtx.provider.rpb.Clone().
 		ModifyResponse(func(res *http.Response) error {
+			upstreamStatus = res.StatusCode
 			if reqCtx.route.Service.HideDGateHeaders {
 				res.Header.Set("Via", "DGate Proxy")
 			}
@@ -158,6 +243,7 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 						zap.String("service", reqCtx.route.Service.Name),
 						zap.String("namespace", reqCtx.route.Namespace.Name),
 					)
+					setModuleErrorHeader(ps, reqCtx, err)
 					return err
 				}
 			}
@@ -175,6 +261,11 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 			if reqCtx.rw.HeadersSent() {
 				return
 			}
+			// the runtime of a module that broke its limits cannot run the error handlers
+			if status := moduleErrorStatus(ps, reqCtx, reqErr, 0); status != 0 {
+				util.WriteStatusCodeError(reqCtx.rw, status)
+				return
+			}
 			if errorHandler, ok := modExt.ErrorHandlerFunc(); ok {
 				errorHandlerStart := time.Now()
 				err = errorHandler(modExt.ModuleContext(), reqErr)
@@ -189,7 +280,9 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 						zap.String("service", reqCtx.route.Service.Name),
 						zap.String("namespace", reqCtx.route.Namespace.Name),
 					)
-					util.WriteStatusCodeError(reqCtx.rw, http.StatusInternalServerError)
+					setModuleErrorHeader(ps, reqCtx, err)
+					util.WriteStatusCodeError(reqCtx.rw,
+						moduleErrorStatus(ps, reqCtx, err, http.StatusInternalServerError))
 					return
 				}
 			}
@@ -213,10 +306,19 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 				zap.String("service", reqCtx.route.Service.Name),
 				zap.String("namespace", reqCtx.route.Namespace.Name),
 			)
-			util.WriteStatusCodeError(reqCtx.rw, http.StatusInternalServerError)
+			setModuleErrorHeader(ps, reqCtx, err)
+			util.WriteStatusCodeError(reqCtx.rw,
+				moduleErrorStatus(ps, reqCtx, err, http.StatusInternalServerError))
+			return
+		}
+		// a module can respond to the request without proxying it
+		if reqCtx.rw.HeadersSent() {
 			return
 		}
 	}
+	if !rateLimitRequest(ps, reqCtx, modExt) {
+		return
+	}
 
 	rp, err := rpb.Build(upstreamUrl, reqCtx.pattern)
 	if err != nil {
@@ -234,14 +336,38 @@ func handleServiceProxy(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExt
 		reqCtx.rw.Header().Set(k, v)
 	}
 
+	req := reqCtx.req
+	if len(reqCtx.route.Rewrite) > 0 {
+		req = req.WithContext(reverse_proxy.WithPathParams(req.Context(), reqCtx.params))
+	}
+	if cache := reqCtx.provider.cache; cache != nil {
+		if key := cache.Key(req); key != "" {
+			req = req.WithContext(response_cache.WithKey(req.Context(), key))
+		}
+	}
+	if selectedUrl != nil && reqCtx.route.Service.RetryPolicy != nil {
+		req = req.WithContext(proxy_transport.WithUpstream(req.Context(), upstream))
+	}
+
 	upstreamStart := time.Now()
-	rp.ServeHTTP(reqCtx.rw, reqCtx.req)
+	upstreamSent = true
+	rp.ServeHTTP(reqCtx.rw, req)
 	ps.metrics.MeasureUpstreamDuration(
 		reqCtx.ctx, reqCtx,
 		upstreamStart,
 		upstreamUrl.String(),
 		upstreamErr,
 	)
+
+	if upstreamStatus != 0 {
+		upstreamFailed = upstreamStatus >= http.StatusInternalServerError
+	} else {
+		// requests canceled by the client are not counted against the upstream
+		upstreamFailed = upstreamErr != nil && !errors.Is(upstreamErr, context.Canceled)
+	}
+	if outliers := reqCtx.provider.outliers; outliers != nil && selectedUrl != nil {
+		outliers.Record(upstream.URL, upstreamFailed)
+	}
 }
 
 func requestHandlerModule(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExtractor) {
@@ -260,10 +386,18 @@ func requestHandlerModule(ps *ProxyState, reqCtx *RequestContext, modExt ModuleE
 				zap.String("route", reqCtx.route.Name),
 				zap.String("namespace", reqCtx.route.Namespace.Name),
 			)
-			util.WriteStatusCodeError(reqCtx.rw, http.StatusInternalServerError)
+			setModuleErrorHeader(ps, reqCtx, err)
+			util.WriteStatusCodeError(reqCtx.rw,
+				moduleErrorStatus(ps, reqCtx, err, http.StatusInternalServerError))
+			return
+		}
+		if reqCtx.rw.HeadersSent() {
 			return
 		}
 	}
+	if !rateLimitRequest(ps, reqCtx, modExt) {
+		return
+	}
 	if requestHandler, ok := modExt.RequestHandlerFunc(); ok {
 		requestHandlerStart := time.Now()
 		err := requestHandler(modExt.ModuleContext())
@@ -278,7 +412,11 @@ func requestHandlerModule(ps *ProxyState, reqCtx *RequestContext, modExt ModuleE
 				zap.String("route", reqCtx.route.Name),
 				zap.String("namespace", reqCtx.route.Namespace.Name),
 			)
-			if errorHandler, ok := modExt.ErrorHandlerFunc(); ok {
+			setModuleErrorHeader(ps, reqCtx, err)
+			if status := moduleErrorStatus(ps, reqCtx, err, 0); status != 0 {
+				util.WriteStatusCodeError(reqCtx.rw, status)
+				return
+			} else if errorHandler, ok := modExt.ErrorHandlerFunc(); ok {
 				// extract error handler function from module
 				errorHandlerStart := time.Now()
 				err = errorHandler(modExt.ModuleContext(), err)
@@ -293,7 +431,9 @@ func requestHandlerModule(ps *ProxyState, reqCtx *RequestContext, modExt ModuleE
 						zap.String("route", reqCtx.route.Name),
 						zap.String("namespace", reqCtx.route.Namespace.Name),
 					)
-					util.WriteStatusCodeError(reqCtx.rw, http.StatusInternalServerError)
+					setModuleErrorHeader(ps, reqCtx, err)
+					util.WriteStatusCodeError(reqCtx.rw,
+						moduleErrorStatus(ps, reqCtx, err, http.StatusInternalServerError))
 					return
 				}
 			} else {
@@ -314,3 +454,12 @@ func requestHandlerModule(ps *ProxyState, reqCtx *RequestContext, modExt ModuleE
 		return
 	}
 }
+
+// setModuleErrorHeader sets the error of a module in the response headers
+// in debug mode, the error has the position of the error in the module source.
+func setModuleErrorHeader(ps *ProxyState, reqCtx *RequestContext, err error) {
+	if ps.debugMode && !reqCtx.rw.HeadersSent() {
+		reqCtx.rw.Header().Set("X-DGate-Module-Error",
+			strings.Join(strings.Fields(err.Error()), " "))
+	}
+}
@@ -44,6 +44,7 @@ func TestProxyHandler_ReverseProxy(t *testing.T) {
 			rt.PreserveHost,
 			rt.Service.DisableQueryParams,
 			conf.ProxyConfig.DisableXForwardedHeaders,
+			rt.Rewrite,
 		).Return(rpBuilder).Once()
 		rpe := proxytest.CreateMockReverseProxyExecutor()
 		rpe.On("ServeHTTP", mock.Anything, mock.Anything).Return().Once()
@@ -95,6 +96,8 @@ func TestProxyHandler_ProxyHandler(t *testing.T) {
 		ptBuilder.On("Transport", mock.Anything).Return(ptBuilder).Once()
 		ptBuilder.On("RequestTimeout", mock.Anything).Return(ptBuilder).Once()
 		ptBuilder.On("RetryTimeout", mock.Anything).Return(ptBuilder).Maybe()
+		ptBuilder.On("RetryPolicy", mock.Anything).Return(ptBuilder).Maybe()
+		ptBuilder.On("RetryBudget", mock.Anything).Return(ptBuilder).Maybe()
 		ptBuilder.On("Clone").Return(ptBuilder).Once()
 		tp := proxytest.CreateMockTransport()
 		resp := &http.Response{
@@ -152,6 +155,8 @@ func TestProxyHandler_ProxyHandlerError(t *testing.T) {
 		ptBuilder.On("Transport", mock.Anything).Return(ptBuilder).Maybe()
 		ptBuilder.On("RequestTimeout", mock.Anything).Return(ptBuilder).Maybe()
 		ptBuilder.On("RetryTimeout", mock.Anything).Return(ptBuilder).Maybe()
+		ptBuilder.On("RetryPolicy", mock.Anything).Return(ptBuilder).Maybe()
+		ptBuilder.On("RetryBudget", mock.Anything).Return(ptBuilder).Maybe()
 		ptBuilder.On("Clone").Return(ptBuilder).Maybe()
 		tp := proxytest.CreateMockTransport()
 		tp.On("RoundTrip", mock.Anything).Return(
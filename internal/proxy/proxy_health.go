@@ -0,0 +1,249 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/circuit_breaker"
+	"github.com/dgate-io/dgate/internal/proxy/health_check"
+	"github.com/dgate-io/dgate/internal/proxy/load_balancer"
+	"github.com/dgate-io/dgate/internal/proxy/outlier_detection"
+	"github.com/dgate-io/dgate/pkg/scheduler"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"go.uber.org/zap"
+)
+
+func serviceKey(svc *spec.DGateService) string {
+	return svc.Namespace.Name + "/" + svc.Name
+}
+
+func healthCheckTaskName(key string) string {
+	return "health-check:" + key
+}
+
+// setupHealthChecks syncs the health checkers with the current services,
+// checkers for unchanged services are kept so their state is not lost.
+func (ps *ProxyState) setupHealthChecks() error {
+	active := make(map[string]struct{})
+	for _, svc := range ps.rm.GetServices() {
+		if svc.HealthCheck == nil {
+			continue
+		}
+		key := serviceKey(svc)
+		active[key] = struct{}{}
+		if checker, ok := ps.healthChecks.Find(key); ok {
+			if checker.Matches(svc.HealthCheck, svc.URLs) {
+				checker.Rebind(svc.URLs)
+				continue
+			}
+		}
+		checker := health_check.New(svc.HealthCheck, svc.URLs, ps.serviceTransport(svc))
+		logger := ps.logger.With(
+			zap.String("service", svc.Name),
+			zap.String("namespace", svc.Namespace.Name),
+		)
+		err := ps.skdr.ScheduleTask(healthCheckTaskName(key), scheduler.TaskOptions{
+			Interval:  checker.Interval(),
+			Overwrite: true,
+			// checks are run in the background, a check is skipped while the previous check is running
+			Overlap: scheduler.OverlapSkip,
+			TaskFunc: func(ctx context.Context) {
+				checker.Check(ctx)
+				for _, status := range checker.Statuses() {
+					if !status.Healthy {
+						logger.Debug("upstream is down",
+							zap.String("url", status.URL),
+							zap.String("error", status.LastError),
+						)
+					}
+				}
+			},
+		})
+		if err != nil {
+			logger.Error("Error scheduling health check", zap.Error(err))
+			return err
+		}
+		ps.healthChecks.Insert(key, checker)
+	}
+
+	removed := []string{}
+	ps.healthChecks.Each(func(key string, _ *health_check.Checker) bool {
+		if _, ok := active[key]; !ok {
+			removed = append(removed, key)
+		}
+		return true
+	})
+	for _, key := range removed {
+		ps.skdr.StopTask(healthCheckTaskName(key))
+		ps.healthChecks.Delete(key)
+	}
+	return nil
+}
+
+func (ps *ProxyState) stopHealthChecks() {
+	ps.healthChecks.Each(func(key string, _ *health_check.Checker) bool {
+		ps.skdr.StopTask(healthCheckTaskName(key))
+		return true
+	})
+	ps.healthChecks.Clear()
+}
+
+// setupCircuitBreakers syncs the outlier detectors and circuit breakers with the
+// current services, like health checks their state is kept for unchanged services.
+func (ps *ProxyState) setupCircuitBreakers() {
+	outliers := make(map[string]struct{})
+	breakers := make(map[string]struct{})
+	for _, svc := range ps.rm.GetServices() {
+		key := serviceKey(svc)
+		logger := ps.logger.With(
+			zap.String("service", svc.Name),
+			zap.String("namespace", svc.Namespace.Name),
+		)
+		if svc.OutlierDetection != nil {
+			outliers[key] = struct{}{}
+			if detector, ok := ps.outliers.Find(key); ok &&
+				detector.Matches(svc.OutlierDetection, svc.URLs) {
+				detector.Rebind(svc.URLs)
+			} else {
+				ps.outliers.Insert(key, outlier_detection.New(
+					svc.OutlierDetection, svc.URLs,
+					func(u *url.URL, duration time.Duration) {
+						logger.Warn("upstream ejected",
+							zap.String("url", u.String()),
+							zap.Duration("duration", duration),
+						)
+						ps.metrics.MeasureUpstreamEjection(
+							context.Background(), svc,
+							u.String(), duration,
+						)
+					},
+				))
+			}
+		}
+		if svc.CircuitBreaker != nil {
+			breakers[key] = struct{}{}
+			if breaker, ok := ps.breakers.Find(key); ok &&
+				breaker.Matches(svc.CircuitBreaker) {
+				continue
+			}
+			ps.breakers.Insert(key, circuit_breaker.New(
+				svc.CircuitBreaker,
+				func(from, to circuit_breaker.State) {
+					logger.Warn("circuit breaker state changed",
+						zap.Stringer("from", from),
+						zap.Stringer("to", to),
+					)
+					ps.metrics.MeasureCircuitBreakerStateChange(
+						context.Background(), svc,
+						from.String(), to.String(),
+					)
+				},
+			))
+		}
+	}
+
+	removed := []string{}
+	ps.outliers.Each(func(key string, _ *outlier_detection.Detector) bool {
+		if _, ok := outliers[key]; !ok {
+			removed = append(removed, key)
+		}
+		return true
+	})
+	for _, key := range removed {
+		ps.outliers.Delete(key)
+	}
+	removed = removed[:0]
+	ps.breakers.Each(func(key string, _ *circuit_breaker.Breaker) bool {
+		if _, ok := breakers[key]; !ok {
+			removed = append(removed, key)
+		}
+		return true
+	})
+	for _, key := range removed {
+		ps.breakers.Delete(key)
+	}
+}
+
+// upstreamAvailableFunc returns a function that reports if a url of the service
+// is healthy and has not been ejected by outlier detection
+func (ps *ProxyState) upstreamAvailableFunc(svc *spec.DGateService) load_balancer.AvailableFunc {
+	key := serviceKey(svc)
+	checker, hasChecker := ps.healthChecks.Find(key)
+	detector, hasDetector := ps.outliers.Find(key)
+	switch {
+	case hasChecker && hasDetector:
+		return func(u *url.URL) bool {
+			return checker.Healthy(u) && detector.Available(u)
+		}
+	case hasChecker:
+		return checker.Healthy
+	case hasDetector:
+		return detector.Available
+	}
+	return nil
+}
+
+// serviceOutlierDetector returns the outlier detector of the service, or nil if it has none
+func (ps *ProxyState) serviceOutlierDetector(svc *spec.DGateService) *outlier_detection.Detector {
+	detector, _ := ps.outliers.Find(serviceKey(svc))
+	return detector
+}
+
+// nextUpstream selects an upstream url for the request, a url whose ejection has
+// expired is only used when the request takes its probe slot, otherwise another
+// url is selected.
+func nextUpstream(reqCtx *RequestContext, req *http.Request) (*url.URL, error) {
+	lb := reqCtx.provider.lb
+	outliers := reqCtx.provider.outliers
+	for i := 0; i < max(1, len(reqCtx.route.Service.URLs)); i++ {
+		u, err := lb.Next(req)
+		if err != nil {
+			return nil, err
+		}
+		if outliers == nil || outliers.TryProbe(u) {
+			return u, nil
+		}
+		lb.Release(u)
+	}
+	return nil, load_balancer.ErrNoUpstreams
+}
+
+// serviceCircuitBreaker returns the circuit breaker of the service, or nil if it has none
+func (ps *ProxyState) serviceCircuitBreaker(svc *spec.DGateService) *circuit_breaker.Breaker {
+	breaker, _ := ps.breakers.Find(serviceKey(svc))
+	return breaker
+}
+
+// ServiceHealth returns the health of each url of the service, ok is
+// false if the service does not exist or has no health check or outlier detection.
+func (ps *ProxyState) ServiceHealth(name, namespace string) ([]spec.UpstreamStatus, bool) {
+	svc, ok := ps.rm.GetService(name, namespace)
+	if !ok {
+		return nil, false
+	}
+	key := serviceKey(svc)
+	checker, hasChecker := ps.healthChecks.Find(key)
+	detector, hasDetector := ps.outliers.Find(key)
+	if !hasChecker {
+		if !hasDetector {
+			return nil, false
+		}
+		return detector.Statuses(), true
+	}
+	statuses := checker.Statuses()
+	if hasDetector {
+		ejected := make(map[string]spec.UpstreamStatus)
+		for _, status := range detector.Statuses() {
+			ejected[status.URL] = status
+		}
+		for i, status := range statuses {
+			if outlier, ok := ejected[status.URL]; ok && outlier.Ejected {
+				statuses[i].Ejected = true
+				statuses[i].EjectedUntil = outlier.EjectedUntil
+			}
+		}
+	}
+	return statuses, true
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/spec"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	api "go.opentelemetry.io/otel/metric"
@@ -19,6 +21,15 @@ type ProxyMetrics struct {
 	moduleRunCountInstrument      api.Int64Counter
 	upstreamDurInstrument         api.Float64Histogram
 	errorCountInstrument          api.Int64Counter
+	breakerStateInstrument        api.Int64Counter
+	ejectionCountInstrument       api.Int64Counter
+	mirrorDurInstrument           api.Float64Histogram
+	shedCountInstrument           api.Int64Counter
+	moduleLimitCountInstrument    api.Int64Counter
+	runtimeBorrowedInstrument     api.Int64UpDownCounter
+	runtimeIdleInstrument         api.Int64UpDownCounter
+	runtimeCreatedInstrument      api.Int64Counter
+	runtimeEvictedInstrument      api.Int64Counter
 }
 
 func NewProxyMetrics() *ProxyMetrics {
@@ -57,6 +68,24 @@ func (pm *ProxyMetrics) Setup(config *config.DGateConfig) {
 		"module_executions")
 	pm.errorCountInstrument, _ = meter.Int64Counter(
 		"error_count")
+	pm.breakerStateInstrument, _ = meter.Int64Counter(
+		"circuit_breaker_state_changes")
+	pm.ejectionCountInstrument, _ = meter.Int64Counter(
+		"upstream_ejections")
+	pm.mirrorDurInstrument, _ = meter.Float64Histogram(
+		"mirror_duration", api.WithUnit("ms"))
+	pm.shedCountInstrument, _ = meter.Int64Counter(
+		"requests_shed")
+	pm.moduleLimitCountInstrument, _ = meter.Int64Counter(
+		"module_limits_exceeded")
+	pm.runtimeBorrowedInstrument, _ = meter.Int64UpDownCounter(
+		"runtime_pool_borrowed")
+	pm.runtimeIdleInstrument, _ = meter.Int64UpDownCounter(
+		"runtime_pool_idle")
+	pm.runtimeCreatedInstrument, _ = meter.Int64Counter(
+		"runtime_pool_created")
+	pm.runtimeEvictedInstrument, _ = meter.Int64Counter(
+		"runtime_pool_evicted")
 }
 
 func (pm *ProxyMetrics) MeasureProxyRequest(
@@ -137,6 +166,10 @@ func (pm *ProxyMetrics) MeasureUpstreamDuration(
 		return
 	}
 	elasped := time.Since(start)
+	lbStrategy := spec.LoadBalancerRoundRobin
+	if reqCtx.provider != nil && reqCtx.provider.lb != nil {
+		lbStrategy = reqCtx.provider.lb.Strategy()
+	}
 	attrSet := attribute.NewSet(
 		attribute.Bool("error", err != nil),
 		attribute.String("route", reqCtx.route.Name),
@@ -147,6 +180,7 @@ func (pm *ProxyMetrics) MeasureUpstreamDuration(
 		attribute.String("host", reqCtx.req.Host),
 		attribute.String("service", reqCtx.route.Service.Name),
 		attribute.String("upstream_host", upstreamHost),
+		attribute.String("lb_strategy", lbStrategy.String()),
 	)
 	pm.addError(ctx, "upstream_request", err, attrSet)
 
@@ -155,6 +189,143 @@ func (pm *ProxyMetrics) MeasureUpstreamDuration(
 		api.WithAttributeSet(attrSet))
 }
 
+// MeasureMirrorRequest records the latency and status of a mirrored request,
+// primary is the service the original request was sent to.
+func (pm *ProxyMetrics) MeasureMirrorRequest(
+	ctx context.Context, reqCtx *RequestContext,
+	primary *spec.DGateService, start time.Time,
+	upstreamHost string, err error,
+) {
+	if pm.mirrorDurInstrument == nil {
+		return
+	}
+	elasped := time.Since(start)
+	primaryName := ""
+	if primary != nil {
+		primaryName = primary.Name
+	}
+	attrSet := attribute.NewSet(
+		attribute.Bool("error", err != nil),
+		attribute.String("route", reqCtx.route.Name),
+		attribute.String("namespace", reqCtx.route.Namespace.Name),
+		attribute.String("method", reqCtx.req.Method),
+		attribute.String("path", reqCtx.req.URL.Path),
+		attribute.String("pattern", reqCtx.pattern),
+		attribute.String("service", reqCtx.route.Service.Name),
+		attribute.String("primary_service", primaryName),
+		attribute.String("upstream_host", upstreamHost),
+		attribute.Int("status_code", reqCtx.rw.Status()),
+	)
+	pm.addError(ctx, "mirror_request", err, attrSet)
+
+	pm.mirrorDurInstrument.Record(reqCtx.ctx,
+		float64(elasped)/float64(time.Millisecond),
+		api.WithAttributeSet(attrSet))
+}
+
+// MeasureShedRequest counts the requests shed by a concurrency limit,
+// scope is route or service and err is the reason it was shed.
+func (pm *ProxyMetrics) MeasureShedRequest(
+	ctx context.Context, reqCtx *RequestContext,
+	scope string, err error,
+) {
+	if pm.shedCountInstrument == nil {
+		return
+	}
+	serviceName := ""
+	if reqCtx.route.Service != nil {
+		serviceName = reqCtx.route.Service.Name
+	}
+	attrSet := attribute.NewSet(
+		attribute.String("route", reqCtx.route.Name),
+		attribute.String("namespace", reqCtx.route.Namespace.Name),
+		attribute.String("service", serviceName),
+		attribute.String("scope", scope),
+		attribute.String("reason", err.Error()),
+	)
+	pm.shedCountInstrument.Add(ctx, 1,
+		api.WithAttributeSet(attrSet))
+}
+
+// MeasureModuleLimitExceeded counts the module calls that broke a limit of the module.
+func (pm *ProxyMetrics) MeasureModuleLimitExceeded(
+	ctx context.Context, reqCtx *RequestContext,
+	limitErr *extractors.LimitError,
+) {
+	if pm.moduleLimitCountInstrument == nil {
+		return
+	}
+	attrSet := attribute.NewSet(
+		attribute.String("route", reqCtx.route.Name),
+		attribute.String("namespace", reqCtx.route.Namespace.Name),
+		attribute.String("module", limitErr.Module),
+		attribute.String("limit", limitErr.Limit),
+	)
+	pm.moduleLimitCountInstrument.Add(ctx, 1,
+		api.WithAttributeSet(attrSet))
+}
+
+// MeasureRuntimePool adds the changes of the runtimes of a runtime pool.
+func (pm *ProxyMetrics) MeasureRuntimePool(
+	ctx context.Context, namespace, modules string,
+	borrowed, idle, created, evicted int64,
+) {
+	if pm.runtimeBorrowedInstrument == nil || pm.runtimeIdleInstrument == nil ||
+		pm.runtimeCreatedInstrument == nil || pm.runtimeEvictedInstrument == nil {
+		return
+	}
+	attrSet := api.WithAttributeSet(attribute.NewSet(
+		attribute.String("namespace", namespace),
+		attribute.String("modules", modules),
+	))
+	if borrowed != 0 {
+		pm.runtimeBorrowedInstrument.Add(ctx, borrowed, attrSet)
+	}
+	if idle != 0 {
+		pm.runtimeIdleInstrument.Add(ctx, idle, attrSet)
+	}
+	if created != 0 {
+		pm.runtimeCreatedInstrument.Add(ctx, created, attrSet)
+	}
+	if evicted != 0 {
+		pm.runtimeEvictedInstrument.Add(ctx, evicted, attrSet)
+	}
+}
+
+func (pm *ProxyMetrics) MeasureCircuitBreakerStateChange(
+	ctx context.Context, svc *spec.DGateService,
+	from, to string,
+) {
+	if pm.breakerStateInstrument == nil {
+		return
+	}
+	attrSet := attribute.NewSet(
+		attribute.String("service", svc.Name),
+		attribute.String("namespace", svc.Namespace.Name),
+		attribute.String("from", from),
+		attribute.String("to", to),
+	)
+	pm.breakerStateInstrument.Add(ctx, 1,
+		api.WithAttributeSet(attrSet))
+}
+
+func (pm *ProxyMetrics) MeasureUpstreamEjection(
+	ctx context.Context, svc *spec.DGateService,
+	upstreamHost string, duration time.Duration,
+) {
+	if pm.ejectionCountInstrument == nil {
+		return
+	}
+	attrSet := attribute.NewSet(
+		attribute.String("service", svc.Name),
+		attribute.String("namespace", svc.Namespace.Name),
+		attribute.String("upstream_host", upstreamHost),
+		attribute.Int64("ejection_ms", duration.Milliseconds()),
+	)
+	pm.ejectionCountInstrument.Add(ctx, 1,
+		api.WithAttributeSet(attrSet))
+}
+
 func (pm *ProxyMetrics) MeasureNamespaceResolutionDuration(
 	ctx context.Context, start time.Time,
 	host, namespace string, err error,
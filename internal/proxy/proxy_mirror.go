@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/reverse_proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"go.uber.org/zap"
+)
+
+// requestMirror sends copies of the requests of a route to the mirror service,
+// the provider of the mirror is used to proxy the copies like any other request.
+type requestMirror struct {
+	mirror   spec.DGateMirror
+	provider *RequestContextProvider
+	// inflight limits the mirrored requests in flight
+	inflight chan struct{}
+}
+
+func (ps *ProxyState) newRequestMirror(route *spec.DGateRoute) *requestMirror {
+	mirror := route.Mirror.WithDefaults()
+	mirrorRoute := *route
+	mirrorRoute.Service = mirror.Service
+	mirrorRoute.TrafficSplit = nil
+	mirrorRoute.Mirror = nil
+	mirrorRoute.Cache = nil
+	return &requestMirror{
+		mirror:   mirror,
+		provider: NewRequestContextProvider(&mirrorRoute, ps),
+		inflight: make(chan struct{}, mirror.MaxConcurrent),
+	}
+}
+
+// acquire takes a slot for a mirrored request, it
+// returns false when the max requests are in flight.
+func (m *requestMirror) acquire() bool {
+	select {
+	case m.inflight <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *requestMirror) release() {
+	<-m.inflight
+}
+
+// sample checks if the request should be mirrored
+func (m *requestMirror) sample() bool {
+	percent := *m.mirror.Percent
+	return percent >= 100 || rand.Float64()*100 < percent
+}
+
+// mirrorRequest sends a copy of the request to the mirror service of the route, if it has one.
+// The request body is buffered so it can be sent twice, requests with bodies larger than the
+// max body size are not mirrored. The copy is sent in the background and its response is discarded,
+// copies are dropped while the max mirrored requests are in flight.
+func mirrorRequest(ps *ProxyState, reqCtx *RequestContext) {
+	m := reqCtx.provider.mirror
+	if m == nil || !m.sample() {
+		return
+	}
+	if !m.acquire() {
+		ps.logger.Debug("Mirror limit reached, dropping mirrored request",
+			zap.String("route", reqCtx.route.Name),
+			zap.String("namespace", reqCtx.route.Namespace.Name),
+		)
+		return
+	}
+	sent := false
+	defer func() {
+		if !sent {
+			m.release()
+		}
+	}()
+	req := reqCtx.req
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > m.mirror.MaxBodySize {
+			return
+		}
+		buf, err := io.ReadAll(io.LimitReader(req.Body, m.mirror.MaxBodySize+1))
+		// the primary request still gets the whole body
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+		if err != nil || int64(len(buf)) > m.mirror.MaxBodySize {
+			return
+		}
+		body = buf
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.WithoutCancel(m.provider.ctx), m.mirror.Timeout)
+	if len(reqCtx.route.Rewrite) > 0 {
+		ctx = reverse_proxy.WithPathParams(ctx, reqCtx.params)
+	}
+	mirrorReq := req.Clone(ctx)
+	mirrorReq.Body = http.NoBody
+	if body != nil {
+		mirrorReq.Body = io.NopCloser(bytes.NewReader(body))
+		mirrorReq.ContentLength = int64(len(body))
+	}
+	mirrorCtx := m.provider.CreateRequestContext(
+		ctx, &discardResponseWriter{header: http.Header{}},
+		mirrorReq, reqCtx.pattern,
+	)
+	sent = true
+	go func() {
+		defer m.release()
+		defer cancel()
+		sendMirrorRequest(ps, mirrorCtx, reqCtx.route.Service)
+	}()
+}
+
+func sendMirrorRequest(ps *ProxyState, reqCtx *RequestContext, primary *spec.DGateService) {
+	start := time.Now()
+	lb := reqCtx.provider.lb
+	upstreamUrl, err := lb.Next(reqCtx.req)
+	if err != nil {
+		ps.metrics.MeasureMirrorRequest(reqCtx.ctx, reqCtx, primary, start, "", err)
+		return
+	}
+	defer lb.Release(upstreamUrl)
+
+	var mirrorErr error
+	rp, err := reqCtx.provider.rpb.Clone().
+		ErrorHandler(func(w http.ResponseWriter, r *http.Request, reqErr error) {
+			mirrorErr = reqErr
+			w.WriteHeader(http.StatusBadGateway)
+		}).
+		Build(upstreamUrl, reqCtx.pattern)
+	if err != nil {
+		ps.logger.Error("Error creating mirror reverse proxy",
+			zap.String("error", err.Error()),
+			zap.String("route", reqCtx.route.Name),
+			zap.String("service", reqCtx.route.Service.Name),
+			zap.String("namespace", reqCtx.route.Namespace.Name),
+		)
+		return
+	}
+	rp.ServeHTTP(reqCtx.rw, reqCtx.req)
+	if mirrorErr != nil && !errors.Is(mirrorErr, context.Canceled) {
+		ps.logger.Debug("Error mirroring request",
+			zap.String("error", mirrorErr.Error()),
+			zap.String("route", reqCtx.route.Name),
+			zap.String("service", reqCtx.route.Service.Name),
+			zap.String("namespace", reqCtx.route.Namespace.Name),
+		)
+	}
+	ps.metrics.MeasureMirrorRequest(reqCtx.ctx, reqCtx, primary,
+		start, upstreamUrl.String(), mirrorErr)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// discardResponseWriter is the response writer of mirrored requests
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,194 @@
+package proxy_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProxyHandler_Mirror(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "hello", string(body))
+		w.Write([]byte("primary"))
+	}))
+	defer primary.Close()
+	mirrored := make(chan string, 1)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirrored <- r.URL.Path + " " + string(body)
+		w.Write([]byte("mirror"))
+	}))
+	defer mirror.Close()
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{
+		{Name: "v1", URLs: []string{primary.URL}, NamespaceName: "test"},
+		{Name: "v2", URLs: []string{mirror.URL}, NamespaceName: "test"},
+	}
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test"},
+		Methods:       []string{"PUT"},
+		ServiceName:   "v1",
+		NamespaceName: "test",
+		Mirror:        &spec.Mirror{ServiceName: "v2"},
+	}}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	rt, ok := ps.ResourceManager().GetRoute("test", "test")
+	if !ok {
+		t.Fatal("route not found")
+	}
+
+	reqCtxProvider := proxy.NewRequestContextProvider(rt, ps)
+	defer reqCtxProvider.Close()
+	req := httptest.NewRequest(http.MethodPut, "http://localhost/test", strings.NewReader("hello"))
+	wr := httptest.NewRecorder()
+	ps.ProxyHandler(ps, reqCtxProvider.CreateRequestContext(
+		context.Background(), wr, req, "/test"))
+	assert.Equal(t, "primary", wr.Body.String())
+
+	select {
+	case got := <-mirrored:
+		assert.Equal(t, "/test hello", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestProxyHandler_MirrorLimits(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("primary"))
+	}))
+	defer primary.Close()
+	mirrored := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrored <- struct{}{}
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}))
+	defer mirror.Close()
+	defer close(unblock)
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{
+		{Name: "v1", URLs: []string{primary.URL}, NamespaceName: "test"},
+		{Name: "v2", URLs: []string{mirror.URL}, NamespaceName: "test"},
+	}
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test"},
+		Methods:       []string{"GET"},
+		ServiceName:   "v1",
+		NamespaceName: "test",
+		Mirror: &spec.Mirror{
+			ServiceName:   "v2",
+			Timeout:       200 * time.Millisecond,
+			MaxConcurrent: 1,
+		},
+	}}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	rt, ok := ps.ResourceManager().GetRoute("test", "test")
+	if !ok {
+		t.Fatal("route not found")
+	}
+
+	reqCtxProvider := proxy.NewRequestContextProvider(rt, ps)
+	defer reqCtxProvider.Close()
+	serve := func() {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr := httptest.NewRecorder()
+		ps.ProxyHandler(ps, reqCtxProvider.CreateRequestContext(
+			context.Background(), wr, req, "/test"))
+		assert.Equal(t, "primary", wr.Body.String())
+	}
+	serve()
+	select {
+	case <-mirrored:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+	// the mirror is stuck, so the copies are dropped
+	serve()
+	serve()
+	select {
+	case <-mirrored:
+		t.Fatal("request was mirrored over the limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the stuck request times out, which frees its slot
+	assert.Eventually(t, func() bool {
+		serve()
+		select {
+		case <-mirrored:
+			return true
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestProxyHandler_MirrorZeroPercent(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("primary"))
+	}))
+	defer primary.Close()
+	mirrored := make(chan struct{}, 10)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrored <- struct{}{}
+	}))
+	defer mirror.Close()
+
+	percent := 0.0
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{
+		{Name: "v1", URLs: []string{primary.URL}, NamespaceName: "test"},
+		{Name: "v2", URLs: []string{mirror.URL}, NamespaceName: "test"},
+	}
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test"},
+		Methods:       []string{"GET"},
+		ServiceName:   "v1",
+		NamespaceName: "test",
+		Mirror:        &spec.Mirror{ServiceName: "v2", Percent: &percent},
+	}}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	rt, ok := ps.ResourceManager().GetRoute("test", "test")
+	if !ok {
+		t.Fatal("route not found")
+	}
+
+	reqCtxProvider := proxy.NewRequestContextProvider(rt, ps)
+	defer reqCtxProvider.Close()
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr := httptest.NewRecorder()
+		ps.ProxyHandler(ps, reqCtxProvider.CreateRequestContext(
+			context.Background(), wr, req, "/test"))
+		assert.Equal(t, "primary", wr.Body.String())
+	}
+	// a percent of 0 is not defaulted to 100
+	select {
+	case <-mirrored:
+		t.Fatal("request was mirrored with a percent of 0")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/rate_limit"
+	"github.com/dgate-io/dgate/pkg/scheduler"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dgate-io/dgate/pkg/util"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+)
+
+const (
+	rateLimitSyncTask     = "rate-limit-sync"
+	rateLimitSyncInterval = time.Second
+)
+
+type rateLimiter struct {
+	spec    spec.RateLimit
+	limiter *rate_limit.Limiter
+}
+
+func namespaceLimiterKey(namespace string) string {
+	return "namespace:" + namespace
+}
+
+func routeLimiterKey(route *spec.DGateRoute) string {
+	return "route:" + routeKey(route)
+}
+
+// setupRateLimiters syncs the rate limiters with the current namespaces and routes,
+// the counts are kept for limiters with an unchanged config.
+func (ps *ProxyState) setupRateLimiters() {
+	active := make(map[string]struct{})
+	cluster := false
+	setup := func(key string, rl *spec.RateLimit) {
+		active[key] = struct{}{}
+		cluster = cluster || rl.Cluster
+		if l, ok := ps.rateLimiters.Find(key); ok && reflect.DeepEqual(l.spec, *rl) {
+			return
+		}
+		ps.rateLimiters.Insert(key, &rateLimiter{
+			spec:    *rl,
+			limiter: rate_limit.New(rl),
+		})
+	}
+	for _, ns := range ps.rm.GetNamespaces() {
+		if ns.RateLimit != nil {
+			setup(namespaceLimiterKey(ns.Name), ns.RateLimit)
+		}
+	}
+	for _, route := range ps.rm.GetRoutes() {
+		if route.RateLimit != nil {
+			setup(routeLimiterKey(route), route.RateLimit)
+		}
+	}
+
+	removed := []string{}
+	ps.rateLimiters.Each(func(key string, _ *rateLimiter) bool {
+		if _, ok := active[key]; !ok {
+			removed = append(removed, key)
+		}
+		return true
+	})
+	for _, key := range removed {
+		ps.rateLimiters.Delete(key)
+	}
+
+	if !cluster || !ps.raftEnabled {
+		ps.skdr.StopTask(rateLimitSyncTask)
+	} else if _, ok := ps.skdr.GetTask(rateLimitSyncTask); !ok {
+		err := ps.skdr.ScheduleTask(rateLimitSyncTask, scheduler.TaskOptions{
+			Interval: rateLimitSyncInterval,
+			Overlap:  scheduler.OverlapSkip,
+			TaskFunc: ps.syncRateLimits,
+		})
+		if err != nil {
+			ps.logger.Error("Error scheduling rate limit sync", zap.Error(err))
+		}
+	}
+}
+
+// routeRateLimiters returns the rate limiters of the namespace and of the route
+func (ps *ProxyState) routeRateLimiters(route *spec.DGateRoute) []*rate_limit.Limiter {
+	limiters := []*rate_limit.Limiter{}
+	if l, ok := ps.rateLimiters.Find(namespaceLimiterKey(route.Namespace.Name)); ok {
+		limiters = append(limiters, l.limiter)
+	}
+	if l, ok := ps.rateLimiters.Find(routeLimiterKey(route)); ok {
+		limiters = append(limiters, l.limiter)
+	}
+	return limiters
+}
+
+// rateLimitRequest checks the rate limits of the request and writes a 429 response
+// when a limit is reached. Limits keyed by consumer are checked separately, after the
+// request modifiers have run, modExt is nil before that. The headers of the most
+// restrictive limit are set.
+func rateLimitRequest(ps *ProxyState, reqCtx *RequestContext, modExt ModuleExtractor) bool {
+	var strictest *rate_limit.Result
+	consumer := modExt != nil
+	for _, l := range reqCtx.provider.rateLimiters {
+		if l.KeyByConsumer() != consumer {
+			continue
+		}
+		var consumerID string
+		if consumer {
+			if modCtx := modExt.ModuleContext(); modCtx != nil {
+				consumerID = modCtx.Consumer()
+			}
+		}
+		result := l.Allow(l.Key(reqCtx.req, ps.config.ProxyConfig.XForwardedForDepth, consumerID))
+		if !result.Allowed {
+			ps.logger.Debug("Rate limit reached",
+				zap.String("route", reqCtx.route.Name),
+				zap.String("namespace", reqCtx.route.Namespace.Name),
+			)
+			result.SetHeaders(reqCtx.rw.Header())
+			util.WriteStatusCodeError(reqCtx.rw, http.StatusTooManyRequests)
+			return false
+		}
+		if strictest == nil || result.Remaining < strictest.Remaining {
+			strictest = &result
+		}
+	}
+	if strictest != nil {
+		strictest.SetHeaders(reqCtx.rw.Header())
+	}
+	return true
+}
+
+// syncRateLimits sends the counts of the cluster rate limiters to the other nodes
+func (ps *ProxyState) syncRateLimits(ctx context.Context) {
+	counts := rate_limit.Counts{
+		Node:     ps.config.AdminConfig.Replication.RaftID,
+		Limiters: make(map[string]map[string]int),
+	}
+	ps.rateLimiters.Each(func(key string, l *rateLimiter) bool {
+		if l.limiter.Cluster() {
+			if hits := l.limiter.Hits(); len(hits) > 0 {
+				counts.Limiters[key] = hits
+			}
+		}
+		return true
+	})
+	if len(counts.Limiters) == 0 || ps.raft == nil {
+		return
+	}
+	payload, err := json.Marshal(counts)
+	if err != nil {
+		ps.logger.Error("Error encoding rate limit counts", zap.Error(err))
+		return
+	}
+	configFuture := ps.raft.GetConfiguration()
+	if err := configFuture.Error(); err != nil {
+		ps.logger.Error("Error getting raft configuration", zap.Error(err))
+		return
+	}
+	for _, server := range configFuture.Configuration().Servers {
+		if string(server.ID) == counts.Node {
+			continue
+		}
+		go ps.sendRateLimitCounts(ctx, server.Address, payload)
+	}
+}
+
+func (ps *ProxyState) sendRateLimitCounts(ctx context.Context, addr raft.ServerAddress, payload []byte) {
+	replConfig := ps.config.AdminConfig.Replication
+	scheme := replConfig.AdvertScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	ctx, cancel := context.WithTimeout(ctx, rateLimitSyncInterval)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		scheme+"://"+string(addr)+"/ratelimit/sync", bytes.NewReader(payload))
+	if err != nil {
+		ps.logger.Error("Error creating rate limit sync request", zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if replConfig.SharedKey != "" {
+		req.Header.Set("X-DGate-Shared-Key", replConfig.SharedKey)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		ps.logger.Debug("Error sending rate limit counts",
+			zap.String("address", string(addr)),
+			zap.Error(err),
+		)
+		return
+	}
+	resp.Body.Close()
+}
+
+// SyncRateLimits adds the requests allowed by another node of the cluster to the rate limiters
+func (ps *ProxyState) SyncRateLimits(counts *rate_limit.Counts) {
+	for key, hits := range counts.Limiters {
+		l, ok := ps.rateLimiters.Find(key)
+		if !ok || !l.limiter.Cluster() {
+			continue
+		}
+		for k, count := range hits {
+			l.limiter.Record(k, count)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/internal/proxy/rate_limit"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProxyHandler_RateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Namespaces = []spec.Namespace{{
+		Name:      "test",
+		RateLimit: &spec.RateLimit{Limit: 3, Window: time.Hour},
+	}}
+	resources.Services = []spec.Service{
+		{Name: "test", URLs: []string{server.URL}, NamespaceName: "test"},
+	}
+	resources.Modules = nil
+	resources.Routes = []spec.Route{
+		{
+			Name:          "limited",
+			Paths:         []string{"/limited"},
+			Methods:       []string{"GET"},
+			ServiceName:   "test",
+			NamespaceName: "test",
+			RateLimit: &spec.RateLimit{
+				Limit:  1,
+				Window: time.Hour,
+				KeyBy:  spec.RateLimitKeyHeader,
+				Header: "X-Api-Key",
+			},
+		},
+		{
+			Name:          "test",
+			Paths:         []string{"/test"},
+			Methods:       []string{"GET"},
+			ServiceName:   "test",
+			NamespaceName: "test",
+		},
+	}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		req.Header.Set("X-Api-Key", apiKey)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		return wr
+	}
+	wr := serve("/limited", "a")
+	assert.Equal(t, http.StatusOK, wr.Code)
+	assert.Equal(t, "0", wr.Header().Get("RateLimit-Remaining"))
+	wr = serve("/limited", "a")
+	assert.Equal(t, http.StatusTooManyRequests, wr.Code)
+	assert.NotEmpty(t, wr.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve("/limited", "b").Code)
+
+	// the namespace limit is shared by all routes of the namespace
+	wr = serve("/test", "")
+	assert.Equal(t, http.StatusTooManyRequests, wr.Code)
+	assert.Equal(t, "3", wr.Header().Get("RateLimit-Limit"))
+}
+
+func TestProxyHandler_RateLimitCluster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = []spec.Service{
+		{Name: "test", URLs: []string{server.URL}, NamespaceName: "test"},
+	}
+	resources.Modules = nil
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test"},
+		Methods:       []string{"GET"},
+		ServiceName:   "test",
+		NamespaceName: "test",
+		RateLimit: &spec.RateLimit{
+			Limit:   2,
+			Window:  time.Hour,
+			KeyBy:   spec.RateLimitKeyHeader,
+			Header:  "X-Api-Key",
+			Cluster: true,
+		},
+	}}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		req.Header.Set("X-Api-Key", apiKey)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		return wr.Code
+	}
+	// requests allowed by other nodes count against the limit
+	ps.SyncRateLimits(&rate_limit.Counts{
+		Node: "other",
+		Limiters: map[string]map[string]int{
+			"route:test/test": {"header:a": 1},
+		},
+	})
+	assert.Equal(t, http.StatusOK, serve("a"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("a"))
+	assert.Equal(t, http.StatusOK, serve("b"))
+	assert.Equal(t, http.StatusOK, serve("b"))
+}
+
+func TestProxyHandler_RateLimitConsumer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	conf := chainConfig(server.URL, chainModuleSpec("auth", `
+const requestModifier = (ctx) => {
+	const user = ctx.request().headers.get("X-User");
+	if (user) {
+		ctx.setConsumer(user);
+	}
+};
+module.exports = { requestModifier };
+`, spec.ModuleTypeJavascript))
+	conf.ProxyConfig.InitResources.Routes[0].RateLimit = &spec.RateLimit{
+		Limit:  1,
+		Window: time.Hour,
+		KeyBy:  spec.RateLimitKeyConsumer,
+	}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(user, consumer, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		req.RemoteAddr = remoteAddr
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+		// only the modules can set the consumer, the header is not used
+		req.Header.Set("X-Consumer-Id", consumer)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		return wr
+	}
+	assert.Equal(t, http.StatusOK, serve("a", "spoofed", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("a", "other", "10.0.0.2:1234").Code)
+	assert.Equal(t, http.StatusOK, serve("b", "", "10.0.0.1:1234").Code)
+
+	// requests without a consumer are limited by the client ip
+	assert.Equal(t, http.StatusOK, serve("", "c", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("", "d", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, serve("", "", "10.0.0.2:1234").Code)
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/dgate-io/dgate/internal/proxy/load_balancer"
+	"github.com/dgate-io/dgate/internal/proxy/proxy_transport"
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+type retryBudget struct {
+	spec   *spec.RetryBudget
+	budget *proxy_transport.RetryBudget
+}
+
+// setupRetryBudgets syncs the retry budgets with the current services, budgets are
+// shared by all routes of a service and kept for services with an unchanged budget.
+func (ps *ProxyState) setupRetryBudgets() {
+	active := make(map[string]struct{})
+	for _, svc := range ps.rm.GetServices() {
+		if svc.RetryPolicy == nil {
+			continue
+		}
+		key := serviceKey(svc)
+		active[key] = struct{}{}
+		if rb, ok := ps.retryBudgets.Find(key); ok &&
+			reflect.DeepEqual(rb.spec, svc.RetryPolicy.Budget) {
+			continue
+		}
+		ps.retryBudgets.Insert(key, &retryBudget{
+			spec:   svc.RetryPolicy.Budget,
+			budget: proxy_transport.NewRetryBudget(svc.RetryPolicy.Budget),
+		})
+	}
+
+	removed := []string{}
+	ps.retryBudgets.Each(func(key string, _ *retryBudget) bool {
+		if _, ok := active[key]; !ok {
+			removed = append(removed, key)
+		}
+		return true
+	})
+	for _, key := range removed {
+		ps.retryBudgets.Delete(key)
+	}
+}
+
+// serviceRetryBudget returns the retry budget of the service, or nil if it has no retry policy
+func (ps *ProxyState) serviceRetryBudget(svc *spec.DGateService) *proxy_transport.RetryBudget {
+	if rb, ok := ps.retryBudgets.Find(serviceKey(svc)); ok {
+		return rb.budget
+	}
+	return nil
+}
+
+// retryUpstream creates the upstream for the retry transport, retries are sent
+// to urls that were not tried yet when the load balancer has any available.
+func retryUpstream(reqCtx *RequestContext, selected *url.URL) *proxy_transport.Upstream {
+	lb := reqCtx.provider.lb
+	outliers := reqCtx.provider.outliers
+	numUrls := len(reqCtx.route.Service.URLs)
+	return &proxy_transport.Upstream{
+		URL: selected,
+		Next: func(req *http.Request, tried []*url.URL) (*url.URL, error) {
+			var next *url.URL
+			for i := 0; i < numUrls; i++ {
+				u, err := lb.Next(req)
+				if err != nil {
+					return nil, err
+				}
+				if next != nil {
+					lb.Release(next)
+				}
+				next = u
+				if !slices.Contains(tried, u) {
+					break
+				}
+			}
+			// the probe slot is only taken for the url the retry is sent to
+			if outliers != nil && !outliers.TryProbe(next) {
+				lb.Release(next)
+				return nil, load_balancer.ErrNoUpstreams
+			}
+			return next, nil
+		},
+		Done: func(u *url.URL, failed bool) {
+			lb.Release(u)
+			if outliers != nil {
+				outliers.Record(u, failed)
+			}
+		},
+	}
+}
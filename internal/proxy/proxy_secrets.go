@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/proxy/secret_keyring"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/hashicorp/raft"
+	"go.uber.org/zap"
+)
+
+// newSecretKeyring returns nil when no secret key is configured.
+func newSecretKeyring(conf *config.DGateSecretsConfig) (*secret_keyring.Keyring, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	current, err := conf.LoadKey()
+	if err != nil {
+		return nil, err
+	}
+	previous := make([][]byte, len(conf.PreviousKeys))
+	for i, keyConf := range conf.PreviousKeys {
+		if previous[i], err = keyConf.LoadKey(); err != nil {
+			return nil, err
+		}
+	}
+	return secret_keyring.New(current, previous...)
+}
+
+// secretAdditionalData binds the ciphertext to the secret, so
+// it cannot be copied to another secret or namespace.
+func secretAdditionalData(scrt *spec.Secret) []byte {
+	return []byte(scrt.NamespaceName + "/" + scrt.Name)
+}
+
+// encryptSecretChangeLog encrypts the data of a secret before
+// the change log is stored or replicated.
+func (ps *ProxyState) encryptSecretChangeLog(cl *spec.ChangeLog) error {
+	if cl == nil || cl.Cmd != spec.AddSecretCommand {
+		return nil
+	}
+	scrt, err := decode[spec.Secret](cl.Item)
+	if err != nil {
+		return err
+	}
+	if scrt.NamespaceName == "" {
+		scrt.NamespaceName = cl.Namespace
+	}
+	if ps.secretKeys == nil {
+		ps.logger.Warn("secret key is not configured, storing secret unencrypted",
+			zap.String("secret", scrt.Name),
+			zap.String("namespace", scrt.NamespaceName),
+		)
+		return nil
+	}
+	if !ps.secretKeys.NeedsRotation(scrt.Data) {
+		return nil
+	}
+	if err = ps.encryptSecret(&scrt); err != nil {
+		return err
+	}
+	cl.Item = &scrt
+	return nil
+}
+
+// encryptSecret encrypts the data of the secret with the current key, the
+// data is either base64 encoded or encrypted with a previous key.
+func (ps *ProxyState) encryptSecret(scrt *spec.Secret) error {
+	var plaintext []byte
+	var err error
+	if secret_keyring.IsEncrypted(scrt.Data) {
+		plaintext, err = ps.secretKeys.Decrypt(scrt.Data, secretAdditionalData(scrt))
+	} else {
+		plaintext, err = base64.RawStdEncoding.DecodeString(scrt.Data)
+	}
+	if err != nil {
+		return errors.New("secret (" + scrt.Name + ") " + err.Error())
+	}
+	scrt.Data, err = ps.secretKeys.Encrypt(plaintext, secretAdditionalData(scrt))
+	return err
+}
+
+// decryptSecret replaces encrypted data with the base64 encoded plaintext.
+func (ps *ProxyState) decryptSecret(scrt *spec.Secret) error {
+	if !secret_keyring.IsEncrypted(scrt.Data) {
+		return nil
+	}
+	if ps.secretKeys == nil {
+		return errors.New("secret (" + scrt.Name + ") is encrypted, but no secret key is configured")
+	}
+	plaintext, err := ps.secretKeys.Decrypt(scrt.Data, secretAdditionalData(scrt))
+	if err != nil {
+		return fmt.Errorf("secret (%s) %w", scrt.Name, err)
+	}
+	scrt.Data = base64.RawStdEncoding.EncodeToString(plaintext)
+	return nil
+}
+
+// rotateSecretKeys re-encrypts the stored secrets that are unencrypted or
+// encrypted with a previous key, the change logs keep their ids.
+func (ps *ProxyState) rotateSecretKeys() error {
+	if ps.secretKeys == nil {
+		return nil
+	}
+	ps.proxyLock.Lock()
+	defer ps.proxyLock.Unlock()
+	rotated := 0
+	for _, cl := range ps.changeLogs {
+		if cl.Cmd != spec.AddSecretCommand {
+			continue
+		}
+		scrt, err := decode[spec.Secret](cl.Item)
+		if err != nil {
+			return err
+		}
+		if scrt.NamespaceName == "" {
+			scrt.NamespaceName = cl.Namespace
+		}
+		if !ps.secretKeys.NeedsRotation(scrt.Data) {
+			continue
+		}
+		if err = ps.encryptSecret(&scrt); err != nil {
+			return err
+		}
+		cl.Item = &scrt
+		if err = ps.store.StoreChangeLog(cl); err != nil {
+			return err
+		}
+		rotated++
+	}
+	ps.logRotatedSecrets(rotated)
+	return nil
+}
+
+// rotateRaftSecretKeys re-encrypts the secrets that are unencrypted or encrypted
+// with a previous key when the node is the raft leader. The raft log cannot be
+// changed, so the secrets are applied again as new change logs.
+func (ps *ProxyState) rotateRaftSecretKeys() error {
+	r := ps.Raft()
+	if ps.secretKeys == nil || r == nil || r.State() != raft.Leader {
+		return nil
+	}
+	// the secrets of the log must be applied before they are checked
+	if err := ps.WaitForChanges(nil); err != nil {
+		return err
+	}
+	secrets, err := ps.secretsToRotate()
+	if err != nil {
+		return err
+	}
+	for _, scrt := range secrets {
+		if err = ps.encryptSecret(scrt); err != nil {
+			return err
+		}
+		cl := spec.NewChangeLog(scrt, scrt.NamespaceName, spec.AddSecretCommand)
+		if err = ps.ApplyChangeLog(cl); err != nil {
+			return err
+		}
+	}
+	ps.logRotatedSecrets(len(secrets))
+	return nil
+}
+
+// secretsToRotate returns the latest version of each secret in the change logs,
+// when it needs to be re-encrypted. Deleted secrets are not returned.
+func (ps *ProxyState) secretsToRotate() ([]*spec.Secret, error) {
+	ps.proxyLock.RLock()
+	defer ps.proxyLock.RUnlock()
+	latest := make(map[string]*spec.Secret)
+	keys := []string{}
+	for _, cl := range ps.changeLogs {
+		if cl.Cmd != spec.AddSecretCommand && cl.Cmd != spec.DeleteSecretCommand {
+			continue
+		}
+		scrt, err := decode[spec.Secret](cl.Item)
+		if err != nil {
+			return nil, err
+		}
+		if scrt.NamespaceName == "" {
+			scrt.NamespaceName = cl.Namespace
+		}
+		key := scrt.NamespaceName + "/" + scrt.Name
+		if cl.Cmd == spec.DeleteSecretCommand {
+			delete(latest, key)
+			continue
+		}
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = &scrt
+	}
+	secrets := []*spec.Secret{}
+	for _, key := range keys {
+		if scrt, ok := latest[key]; ok && ps.secretKeys.NeedsRotation(scrt.Data) {
+			secrets = append(secrets, scrt)
+		}
+	}
+	return secrets, nil
+}
+
+func (ps *ProxyState) logRotatedSecrets(rotated int) {
+	if rotated > 0 {
+		ps.logger.Info("re-encrypted secrets with the current key",
+			zap.Int("count", rotated),
+			zap.String("key_id", ps.secretKeys.CurrentKeyID()),
+		)
+	}
+}
@@ -0,0 +1,115 @@
+package proxy_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const secretsModuleJS = `
+const { getSecret } = require("dgate/secrets");
+exports.requestModifier = (ctx) => {
+	ctx.request().headers.set("X-Secret", String(getSecret("api-key")));
+};`
+
+func secretsConfig(t *testing.T, upstreamUrl string, permissions []string) *config.DGateConfig {
+	t.Setenv("DGATE_TEST_SECRET_KEY", base64.StdEncoding.
+		EncodeToString(bytes.Repeat([]byte{1}, 32)))
+	mod := chainModuleSpec("secrets", secretsModuleJS, spec.ModuleTypeJavascript)
+	mod.Permissions = permissions
+	conf := chainConfig(upstreamUrl, mod)
+	conf.SecretsConfig = &config.DGateSecretsConfig{
+		DGateSecretKeyConfig: config.DGateSecretKeyConfig{
+			KeyEnv: "DGATE_TEST_SECRET_KEY",
+		},
+	}
+	conf.ProxyConfig.InitResources.Secrets = []spec.Secret{{
+		Name:          "api-key",
+		NamespaceName: "test",
+		Data:          "hunter2",
+	}}
+	return conf
+}
+
+func TestProxyHandler_SecretsModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Secret")))
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		name        string
+		permissions []string
+		status      int
+		body        string
+	}{
+		{"allowed", []string{"resource:secret:read"}, http.StatusOK, "hunter2"},
+		{"scoped", []string{"resource:secret:read:api-key"}, http.StatusOK, "hunter2"},
+		{"denied", []string{"resource:secret:read:other"}, http.StatusInternalServerError, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ps := proxy.NewProxyState(zap.NewNop(),
+				secretsConfig(t, server.URL, test.permissions))
+			if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+			wr := httptest.NewRecorder()
+			ps.ServeHTTP(wr, req)
+			assert.Equal(t, test.status, wr.Code)
+			if test.status == http.StatusOK {
+				assert.Equal(t, test.body, wr.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyState_SecretsEncryptedAtRest(t *testing.T) {
+	conf := secretsConfig(t, "http://localhost", nil)
+	conf.Storage = config.DGateStorageConfig{
+		StorageType: config.StorageTypeFile,
+		Config:      map[string]any{"dir": t.TempDir()},
+	}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.Store().InitStore(); err != nil {
+		t.Fatal(err)
+	}
+	defer ps.Store().CloseStore()
+
+	scrt := &spec.Secret{
+		Name:          "db-password",
+		NamespaceName: "test",
+		Data:          base64.RawStdEncoding.EncodeToString([]byte("hunter3")),
+	}
+	cl := spec.NewChangeLog(scrt, scrt.NamespaceName, spec.AddSecretCommand)
+	if err := ps.ProcessChangeLog(cl, true); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := ps.Store().FetchChangeLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, logs, 1) {
+		item, ok := logs[0].Item.(map[string]any)
+		if assert.True(t, ok) {
+			assert.Contains(t, item["data"], "dgate:enc:v1:")
+			assert.NotEqual(t, scrt.Data, item["data"])
+		}
+	}
+
+	// secrets are decrypted in memory
+	sec, ok := ps.ResourceManager().GetSecret("db-password", "test")
+	if assert.True(t, ok) {
+		assert.Equal(t, "hunter3", sec.Data)
+	}
+}
@@ -16,12 +16,18 @@ import (
 
 	"github.com/dgate-io/dgate/internal/config"
 	"github.com/dgate-io/dgate/internal/pattern"
+	"github.com/dgate-io/dgate/internal/proxy/circuit_breaker"
+	"github.com/dgate-io/dgate/internal/proxy/health_check"
+	"github.com/dgate-io/dgate/internal/proxy/outlier_detection"
 	"github.com/dgate-io/dgate/internal/proxy/proxy_transport"
 	"github.com/dgate-io/dgate/internal/proxy/proxystore"
 	"github.com/dgate-io/dgate/internal/proxy/reverse_proxy"
+	"github.com/dgate-io/dgate/internal/proxy/secret_keyring"
 	"github.com/dgate-io/dgate/internal/router"
 	"github.com/dgate-io/dgate/pkg/cache"
 	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/native"
+	"github.com/dgate-io/dgate/pkg/modules/wasm"
 	"github.com/dgate-io/dgate/pkg/raftadmin"
 	"github.com/dgate-io/dgate/pkg/resources"
 	"github.com/dgate-io/dgate/pkg/scheduler"
@@ -44,6 +50,7 @@ type ProxyState struct {
 	config         *config.DGateConfig
 	store          *proxystore.ProxyStore
 	sharedCache    cache.TCache
+	httpTransport  http.RoundTripper
 	proxyLock      *sync.RWMutex
 	ready          *atomic.Bool
 	pendingChanges bool
@@ -52,9 +59,32 @@ type ProxyState struct {
 	rm          *resources.ResourceManager
 	skdr        scheduler.Scheduler
 	changeLogs  []*spec.ChangeLog
-	providers   avl.Tree[string, *RequestContextProvider]
-	modPrograms avl.Tree[string, *goja.Program]
-	routers     avl.Tree[string, *router.DynamicRouter]
+	providers    avl.Tree[string, *RequestContextProvider]
+	modPrograms  avl.Tree[string, *goja.Program]
+	routers      avl.Tree[string, *router.DynamicRouter]
+	healthChecks avl.Tree[string, *health_check.Checker]
+	outliers     avl.Tree[string, *outlier_detection.Detector]
+	breakers     avl.Tree[string, *circuit_breaker.Breaker]
+	retryBudgets avl.Tree[string, *retryBudget]
+	caches       avl.Tree[string, *responseCache]
+	rateLimiters avl.Tree[string, *rateLimiter]
+
+	concurrencyLimits avl.Tree[string, *concurrencyLimiter]
+	// modChainPrograms are the programs of modules chained with other modules
+	modChainPrograms avl.Tree[string, *goja.Program]
+	// modImports are the modules imported by a module, including indirect imports
+	modImports avl.Tree[string, []string]
+	// modWasm are the compiled wasm modules, they are instantiated by the module extractors
+	modWasm     avl.Tree[string, *wasm.Module]
+	wasmRuntime *wasm.Runtime
+	// nativeModules are the go plugins of the config, by name
+	nativeModules map[string]*native.Module
+	// runtimes are the module runtimes shared by the routes
+	runtimes *RuntimeManager
+	// moduleJobs are the jobs exported by the modules
+	moduleJobs avl.Tree[string, *moduleJobs]
+	// secretKeys is nil when secrets are not encrypted at rest
+	secretKeys *secret_keyring.Keyring
 
 	raft        *raft.Raft
 	raftClient  *raftadmin.Client
@@ -102,6 +132,26 @@ func NewProxyState(logger *zap.Logger, conf *config.DGateConfig) *ProxyState {
 	rpLogger := logger.Named("reverse-proxy")
 	storeLogger := logger.Named("store")
 	schedulerLogger := logger.Named("scheduler")
+	// the scheduler is shared by health checks, module jobs and cache expiry
+	skdr := scheduler.New(scheduler.Options{
+		Logger:  schedulerLogger,
+		AutoRun: true,
+	})
+
+	secretKeys, err := newSecretKeyring(conf.SecretsConfig)
+	if err != nil {
+		panic(fmt.Errorf("invalid secrets config: %s", err))
+	}
+	wasmRuntime, err := wasm.NewRuntime(context.Background(), printer)
+	if err != nil {
+		panic(fmt.Errorf("error creating wasm runtime: %s", err))
+	}
+	nativeModules, err := loadNativeModules(conf.ProxyConfig.NativeModules)
+	if err != nil {
+		panic(fmt.Errorf("error loading native modules: %s", err))
+	}
+
+	metrics := NewProxyMetrics()
 
 	raftEnabled := false
 	if conf.AdminConfig != nil && conf.AdminConfig.Replication != nil {
@@ -114,17 +164,36 @@ func NewProxyState(logger *zap.Logger, conf *config.DGateConfig) *ProxyState {
 		logger:     logger,
 		debugMode:  conf.Debug,
 		config:     conf,
-		metrics:    NewProxyMetrics(),
+		metrics:    metrics,
 		printer:    printer,
 		routers:    avl.NewTree[string, *router.DynamicRouter](),
 		rm:         resources.NewManager(opt),
-		skdr: scheduler.New(scheduler.Options{
-			Logger: schedulerLogger,
-		}),
-		providers:   avl.NewTree[string, *RequestContextProvider](),
-		modPrograms: avl.NewTree[string, *goja.Program](),
+		skdr: skdr,
+		providers:    avl.NewTree[string, *RequestContextProvider](),
+		modPrograms:  avl.NewTree[string, *goja.Program](),
+		healthChecks: avl.NewTree[string, *health_check.Checker](),
+		outliers:     avl.NewTree[string, *outlier_detection.Detector](),
+		breakers:     avl.NewTree[string, *circuit_breaker.Breaker](),
+		retryBudgets: avl.NewTree[string, *retryBudget](),
+		caches:       avl.NewTree[string, *responseCache](),
+		rateLimiters: avl.NewTree[string, *rateLimiter](),
+
+		concurrencyLimits: avl.NewTree[string, *concurrencyLimiter](),
+		modChainPrograms:  avl.NewTree[string, *goja.Program](),
+		modImports:        avl.NewTree[string, []string](),
+		modWasm:           avl.NewTree[string, *wasm.Module](),
+		moduleJobs:        avl.NewTree[string, *moduleJobs](),
+		wasmRuntime:       wasmRuntime,
+		nativeModules:     nativeModules,
+		runtimes: NewRuntimeManager(conf.ProxyConfig.RuntimePool,
+			logger.Named("runtimes"), metrics),
+		secretKeys:        secretKeys,
 		proxyLock:   new(sync.RWMutex),
-		sharedCache: cache.New(),
+		sharedCache: cache.NewWithOpts(cache.CacheOptions{Scheduler: skdr}),
+		httpTransport: setupTranportsFromConfig(
+			&conf.ProxyConfig.Transport,
+			func(*net.Dialer, *http.Transport) {},
+		),
 		store:       proxystore.New(dataStore, storeLogger),
 		raftEnabled: raftEnabled,
 		ReverseProxyBuilder: reverse_proxy.NewBuilder().
@@ -230,6 +299,13 @@ func (ps *ProxyState) SetupRaft(r *raft.Raft, client *raftadmin.Client) {
 					zap.String("leader_addr", string(ro.LeaderAddr)),
 					zap.String("leader_id", string(ro.LeaderID)),
 				)
+				if r.State() == raft.Leader {
+					go func() {
+						if err := ps.rotateRaftSecretKeys(); err != nil {
+							logger.Error("error rotating secret keys", zap.Error(err))
+						}
+					}()
+				}
 			}
 		}
 		panic("raft observer channel closed")
@@ -288,6 +364,9 @@ func (ps *ProxyState) ApplyChangeLog(log *spec.ChangeLog) error {
 	if !ps.Ready() {
 		return errors.New("proxy state not ready")
 	}
+	if err := ps.encryptSecretChangeLog(log); err != nil {
+		return err
+	}
 	if r := ps.Raft(); r != nil {
 		if r.State() != raft.Leader {
 			return raft.ErrNotLeader
@@ -328,10 +407,19 @@ func (ps *ProxyState) Scheduler() scheduler.Scheduler {
 	return ps.skdr
 }
 
+// RuntimePoolStats returns the module runtimes of the routes
+func (ps *ProxyState) RuntimePoolStats() RuntimePoolStats {
+	return ps.runtimes.Stats()
+}
+
 func (ps *ProxyState) SharedCache() cache.TCache {
 	return ps.sharedCache
 }
 
+func (ps *ProxyState) HttpTransport() http.RoundTripper {
+	return ps.httpTransport
+}
+
 // restartState - restart state clears the state and reloads the configuration
 // this is useful for rollbacks when broken changes are made.
 func (ps *ProxyState) restartState(fn func(error)) {
@@ -342,9 +430,20 @@ func (ps *ProxyState) restartState(fn func(error)) {
 	ps.pendingChanges = false
 	ps.rm.Empty()
 	ps.modPrograms.Clear()
-	ps.providers.Clear()
+	ps.modChainPrograms.Clear()
+	ps.modImports.Clear()
+	ps.modWasm.Clear()
+	releaseModulePools(ps.clearProviders())
 	ps.routers.Clear()
 	ps.sharedCache.Clear()
+	ps.stopHealthChecks()
+	ps.clearModuleJobs()
+	ps.outliers.Clear()
+	ps.breakers.Clear()
+	ps.retryBudgets.Clear()
+	ps.caches.Clear()
+	ps.rateLimiters.Clear()
+	ps.concurrencyLimits.Clear()
 	ps.skdr.Stop()
 	if err := ps.initConfigResources(ps.config.ProxyConfig.InitResources); err != nil {
 		go fn(err)
@@ -377,6 +476,10 @@ func (ps *ProxyState) ReloadState(check bool, logs ...*spec.ChangeLog) error {
 }
 
 func (ps *ProxyState) ProcessChangeLog(log *spec.ChangeLog, reload bool) error {
+	if err := ps.encryptSecretChangeLog(log); err != nil {
+		ps.logger.Error("processing error", zap.Error(err))
+		return err
+	}
 	if err := ps.processChangeLog(log, reload, true); err != nil {
 		ps.logger.Error("processing error", zap.Error(err))
 		return err
@@ -506,8 +609,7 @@ func (ps *ProxyState) initConfigResources(resources *config.DGateResources) erro
 					return err
 				}
 				mod.Payload = base64.StdEncoding.EncodeToString(payload)
-			}
-			if mod.Payload != "" {
+			} else if mod.Payload != "" {
 				mod.Payload = base64.StdEncoding.EncodeToString(
 					[]byte(mod.Payload),
 				)
@@ -561,6 +663,13 @@ func (ps *ProxyState) initConfigResources(resources *config.DGateResources) erro
 				return err
 			}
 		}
+		for _, sec := range resources.Secrets {
+			sec.Data = base64.RawStdEncoding.EncodeToString([]byte(sec.Data))
+			cl := spec.NewChangeLog(&sec, sec.NamespaceName, spec.AddSecretCommand)
+			if err := processCL(cl); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/pkg/spec"
 	"golang.org/x/net/http2"
 )
 
@@ -19,7 +20,9 @@ func validateAddress(c *config.DGateHttpTransportConfig, address string) error {
 		}
 		if ipAddr := net.ParseIP(ip); ipAddr == nil {
 			return errors.New("could not parse IP: " + ip)
-		} else if ipAddr.IsLoopback() || ipAddr.IsPrivate() {
+		} else if ipAddr.IsLoopback() || ipAddr.IsPrivate() || ipAddr.IsUnspecified() ||
+			// link-local addresses include cloud metadata endpoints (169.254.169.254)
+			ipAddr.IsLinkLocalUnicast() || ipAddr.IsLinkLocalMulticast() {
 			return errors.New("private IP address not allowed: " + ipAddr.String())
 		}
 	}
@@ -65,6 +68,12 @@ func setupTranportsFromConfig(
 	}
 	dailer.Resolver = resolver
 	t1.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		// ip addresses are checked before they are dialed
+		if host, _, err := net.SplitHostPort(address); err == nil && net.ParseIP(host) != nil {
+			if err := validateAddress(c, address); err != nil {
+				return nil, err
+			}
+		}
 		conn, err := dailer.DialContext(ctx, network, address)
 		if err != nil {
 			return nil, err
@@ -74,11 +83,24 @@ func setupTranportsFromConfig(
 		}
 		return conn, nil
 	}
-	t1.DialTLSContext = t1.DialContext
 	modifyTransport(dailer, t1)
 	return newRoundTripper(t1)
 }
 
+// serviceTransport creates the transport used to connect to the urls of the service
+func (ps *ProxyState) serviceTransport(svc *spec.DGateService) http.RoundTripper {
+	return setupTranportsFromConfig(
+		&ps.config.ProxyConfig.Transport,
+		func(dialer *net.Dialer, t *http.Transport) {
+			t.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: svc.TLSSkipVerify,
+			}
+			dialer.Timeout = svc.ConnectTimeout
+			t.ForceAttemptHTTP2 = svc.HTTP2Only
+		},
+	)
+}
+
 func newRoundTripper(transport *http.Transport) http.RoundTripper {
 	transportH2C := &h2cTransport{
 		transport: &http2.Transport{
@@ -8,6 +8,7 @@ import (
 	"errors"
 
 	"github.com/dgate-io/dgate/internal/proxy/proxyerrors"
+	"github.com/dgate-io/dgate/pkg/spec"
 )
 
 type Builder interface {
@@ -15,6 +16,9 @@ type Builder interface {
 	RequestTimeout(requestTimeout time.Duration) Builder
 	Retries(retries int) Builder
 	RetryTimeout(retryTimeout time.Duration) Builder
+	// RetryPolicy replaces the retries and retry timeout when it is set
+	RetryPolicy(policy *spec.RetryPolicy) Builder
+	RetryBudget(budget *RetryBudget) Builder
 	Clone() Builder
 	Build() (http.RoundTripper, error)
 }
@@ -24,6 +28,8 @@ type proxyTransportBuilder struct {
 	requestTimeout time.Duration
 	retries        int
 	retryTimeout   time.Duration
+	retryPolicy    *spec.RetryPolicy
+	retryBudget    *RetryBudget
 }
 
 var _ Builder = (*proxyTransportBuilder)(nil)
@@ -52,16 +58,31 @@ func (b *proxyTransportBuilder) RetryTimeout(retryTimeout time.Duration) Builder
 	return b
 }
 
+func (b *proxyTransportBuilder) RetryPolicy(policy *spec.RetryPolicy) Builder {
+	b.retryPolicy = policy
+	return b
+}
+
+func (b *proxyTransportBuilder) RetryBudget(budget *RetryBudget) Builder {
+	b.retryBudget = budget
+	return b
+}
+
 func (b *proxyTransportBuilder) Clone() Builder {
 	return &proxyTransportBuilder{
 		transport:      b.transport,
 		requestTimeout: b.requestTimeout,
 		retries:        b.retries,
-		retryTimeout:   b.requestTimeout,
+		retryTimeout:   b.retryTimeout,
+		retryPolicy:    b.retryPolicy,
+		retryBudget:    b.retryBudget,
 	}
 }
 
 func (b *proxyTransportBuilder) Build() (http.RoundTripper, error) {
+	if b.retryPolicy != nil {
+		return createWithPolicy(b.transport, b.requestTimeout, b.retryPolicy, b.retryBudget)
+	}
 	return create(b.transport, b.requestTimeout, b.retries, b.retryTimeout)
 }
 
@@ -94,6 +115,29 @@ func create(
 	}, nil
 }
 
+func createWithPolicy(
+	transport http.RoundTripper,
+	requestTimeout time.Duration,
+	policy *spec.RetryPolicy,
+	budget *RetryBudget,
+) (http.RoundTripper, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if requestTimeout < 0 {
+		return nil, errors.New("requestTimeout must be greater than or equal to 0")
+	}
+	return &policyRoundTripper{
+		transport:      transport,
+		requestTimeout: requestTimeout,
+		policy:         policy.WithDefaults(),
+		budget:         budget,
+	}, nil
+}
+
 type retryRoundTripper struct {
 	transport      http.RoundTripper
 	requestTimeout time.Duration
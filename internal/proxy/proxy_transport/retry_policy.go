@@ -0,0 +1,268 @@
+package proxy_transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/proxyerrors"
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+type upstreamCtxKey struct{}
+
+// Upstream is the upstream url a request is sent to. It is stored in the
+// request context so the retry transport can move to another url of the
+// service on each attempt.
+type Upstream struct {
+	URL *url.URL
+	// Next picks the url for a retry, tried are the urls already used by the request
+	Next func(req *http.Request, tried []*url.URL) (*url.URL, error)
+	// Done is called with each url that failed and was replaced by a retry
+	Done func(u *url.URL, failed bool)
+}
+
+func WithUpstream(ctx context.Context, up *Upstream) context.Context {
+	return context.WithValue(ctx, upstreamCtxKey{}, up)
+}
+
+func UpstreamFromContext(ctx context.Context) *Upstream {
+	up, _ := ctx.Value(upstreamCtxKey{}).(*Upstream)
+	return up
+}
+
+// RetryBudget limits retries to a percentage of the requests
+// in a sliding window, it is shared by all routes of a service.
+type RetryBudget struct {
+	mtx         sync.Mutex
+	budget      spec.RetryBudget
+	windowStart time.Time
+	requests    [2]int
+	retries     [2]int
+}
+
+func NewRetryBudget(budget *spec.RetryBudget) *RetryBudget {
+	rb := spec.RetryBudget{}
+	if budget != nil {
+		rb = *budget
+	}
+	return &RetryBudget{
+		budget:      rb.WithDefaults(),
+		windowStart: time.Now(),
+	}
+}
+
+// rotate moves the current window to the previous one once it is over,
+// counts are weighted by how much of the previous window overlaps.
+func (rb *RetryBudget) rotate(now time.Time) float64 {
+	elapsed := now.Sub(rb.windowStart)
+	if elapsed >= 2*rb.budget.Window {
+		rb.requests = [2]int{}
+		rb.retries = [2]int{}
+		rb.windowStart = now
+		elapsed = 0
+	} else if elapsed >= rb.budget.Window {
+		rb.requests = [2]int{0, rb.requests[0]}
+		rb.retries = [2]int{0, rb.retries[0]}
+		rb.windowStart = rb.windowStart.Add(rb.budget.Window)
+		elapsed -= rb.budget.Window
+	}
+	return 1 - float64(elapsed)/float64(rb.budget.Window)
+}
+
+// Request counts a request sent to the service.
+func (rb *RetryBudget) Request() {
+	if rb == nil {
+		return
+	}
+	rb.mtx.Lock()
+	defer rb.mtx.Unlock()
+	rb.rotate(time.Now())
+	rb.requests[0]++
+}
+
+// Retry checks if there is budget left for a retry, and uses it if there is.
+func (rb *RetryBudget) Retry() bool {
+	if rb == nil {
+		return true
+	}
+	rb.mtx.Lock()
+	defer rb.mtx.Unlock()
+	weight := rb.rotate(time.Now())
+	requests := float64(rb.requests[0]) + float64(rb.requests[1])*weight
+	retries := float64(rb.retries[0]) + float64(rb.retries[1])*weight
+	allowed := max(float64(rb.budget.MinRetries), requests*rb.budget.Percent/100)
+	if retries >= allowed {
+		return false
+	}
+	rb.retries[0]++
+	return true
+}
+
+type policyRoundTripper struct {
+	transport      http.RoundTripper
+	requestTimeout time.Duration
+	policy         spec.RetryPolicy
+	budget         *RetryBudget
+}
+
+func (m *policyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.budget.Request()
+
+	retryable := m.policy.RetriesMethod(req.Method)
+	var body []byte
+	if retryable && req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > m.policy.MaxBodySize {
+			retryable = false
+		} else {
+			buf, err := io.ReadAll(io.LimitReader(req.Body, m.policy.MaxBodySize+1))
+			if err != nil {
+				return nil, err
+			}
+			if int64(len(buf)) > m.policy.MaxBodySize {
+				// too large to replay, the rest of the body is still sent on the first attempt
+				retryable = false
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+			} else {
+				body = buf
+			}
+		}
+	}
+	if !retryable {
+		return m.roundTrip(req, m.requestTimeout)
+	}
+
+	up := UpstreamFromContext(req.Context())
+	var tried []*url.URL
+	backoff := m.policy.Backoff
+	for attempt := 0; ; attempt++ {
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		resp, err := m.roundTrip(req, m.tryTimeout())
+		if attempt >= m.policy.Attempts || !m.shouldRetry(req, resp, err) || !m.budget.Retry() {
+			return resp, err
+		}
+
+		if up != nil && up.URL != nil && up.Next != nil {
+			tried = append(tried, up.URL)
+			next, nextErr := up.Next(req, tried)
+			if nextErr != nil {
+				return resp, err
+			}
+			if up.Done != nil {
+				up.Done(up.URL, true)
+			}
+			req = withUpstreamUrl(req, up.URL, next)
+			up.URL = next
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if backoff > 0 {
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
+
+func (m *policyRoundTripper) tryTimeout() time.Duration {
+	if m.policy.PerTryTimeout > 0 {
+		return m.policy.PerTryTimeout
+	}
+	return m.requestTimeout
+}
+
+// roundTrip sends a single attempt, the timeout is only
+// canceled once the response body has been closed.
+func (m *policyRoundTripper) roundTrip(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return m.transport.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := m.transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelBody{resp.Body, cancel}
+	return resp, nil
+}
+
+func (m *policyRoundTripper) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		if pxyErr := proxyerrors.GetProxyError(err); pxyErr != nil && pxyErr.DisableRetry {
+			return false
+		}
+		return m.policy.RetriesOn(spec.RetryOnConnectError) && isConnectError(err)
+	}
+	return m.policy.RetriesOn(spec.RetryOnStatus) &&
+		slices.Contains(m.policy.StatusCodes, resp.StatusCode)
+}
+
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// withUpstreamUrl returns a copy of the request sent to the next url instead,
+// the base path of the previous url is replaced with the base path of the next.
+func withUpstreamUrl(req *http.Request, prev, next *url.URL) *http.Request {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = next.Scheme
+	out.URL.Host = next.Host
+	if prevPath := strings.TrimSuffix(prev.Path, "/"); strings.HasPrefix(out.URL.Path, prevPath) {
+		path := strings.TrimSuffix(next.Path, "/") + strings.TrimPrefix(out.URL.Path, prevPath)
+		out.URL.Path = path
+		out.URL.RawPath = ""
+	}
+	if out.Host == prev.Host {
+		out.Host = next.Host
+	}
+	return out
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,153 @@
+package proxy_transport_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy/proxy_transport"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPolicyTransport(t *testing.T, policy *spec.RetryPolicy, budget *proxy_transport.RetryBudget) http.RoundTripper {
+	tp, err := proxy_transport.NewBuilder().
+		RetryPolicy(policy).
+		RetryBudget(budget).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tp
+}
+
+func TestRetryPolicy_StatusCodes(t *testing.T) {
+	calls := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "hello", string(body))
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tp := newPolicyTransport(t, &spec.RetryPolicy{
+		Attempts: 3,
+		RetryOn:  []spec.RetryCondition{spec.RetryOnStatus},
+	}, nil)
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("hello"))
+	resp, err := tp.RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetryPolicy_NonIdempotent(t *testing.T) {
+	calls := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	tp := newPolicyTransport(t, &spec.RetryPolicy{
+		Attempts: 3,
+		RetryOn:  []spec.RetryCondition{spec.RetryOnStatus},
+	}, nil)
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("hello"))
+	resp, err := tp.RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryPolicy_BodyTooLarge(t *testing.T) {
+	calls := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "hello world", string(body))
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	tp := newPolicyTransport(t, &spec.RetryPolicy{
+		Attempts:    3,
+		RetryOn:     []spec.RetryCondition{spec.RetryOnStatus},
+		MaxBodySize: 5,
+	}, nil)
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("hello world"))
+	// unknown length, so the body is read up to the limit
+	req.ContentLength = -1
+	resp, err := tp.RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryPolicy_ConnectErrorNextUpstream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/base/test", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	downUrl, _ := url.Parse(down.URL + "/api")
+	down.Close()
+	upUrl, _ := url.Parse(server.URL + "/base")
+
+	failed := []*url.URL{}
+	up := &proxy_transport.Upstream{
+		URL: downUrl,
+		Next: func(req *http.Request, tried []*url.URL) (*url.URL, error) {
+			assert.Equal(t, []*url.URL{downUrl}, tried)
+			return upUrl, nil
+		},
+		Done: func(u *url.URL, f bool) {
+			assert.True(t, f)
+			failed = append(failed, u)
+		},
+	}
+	tp := newPolicyTransport(t, &spec.RetryPolicy{Attempts: 2}, nil)
+	ctx := proxy_transport.WithUpstream(context.Background(), up)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, down.URL+"/api/test", nil)
+	resp, err := tp.RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, upUrl, up.URL)
+	assert.Equal(t, []*url.URL{downUrl}, failed)
+}
+
+func TestRetryPolicy_Budget(t *testing.T) {
+	calls := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	budget := proxy_transport.NewRetryBudget(&spec.RetryBudget{
+		Percent:    10,
+		MinRetries: 1,
+	})
+	tp := newPolicyTransport(t, &spec.RetryPolicy{
+		Attempts: 5,
+		RetryOn:  []spec.RetryCondition{spec.RetryOnStatus},
+	}, budget)
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := tp.RoundTrip(req)
+		assert.Nil(t, err)
+		resp.Body.Close()
+	}
+	// 3 requests and only 1 retry allowed by the budget
+	assert.Equal(t, int32(4), calls.Load())
+}
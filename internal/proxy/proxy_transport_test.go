@@ -0,0 +1,66 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProxyHandler_HTTPSUpstream(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	skipVerify := true
+	resources.Services = []spec.Service{{
+		Name:          "test",
+		URLs:          []string{server.URL},
+		NamespaceName: "test",
+		TLSSkipVerify: &skipVerify,
+		HealthCheck: &spec.HealthCheck{
+			Path:     "/health",
+			Interval: time.Second,
+		},
+	}}
+	resources.Modules = nil
+	resources.Routes = []spec.Route{{
+		Name:          "test",
+		Paths:         []string{"/test"},
+		Methods:       []string{"GET"},
+		ServiceName:   "test",
+		NamespaceName: "test",
+	}}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	// the health checks connect to the https url over TLS
+	assert.Eventually(t, func() bool {
+		statuses, ok := ps.ServiceHealth("test", "test")
+		return ok && len(statuses) == 1 &&
+			statuses[0].LastStatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the scheme of the upstream url is used when the request url has no scheme
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Host = "localhost"
+	wr := httptest.NewRecorder()
+	ps.ServeHTTP(wr, req)
+	assert.Equal(t, http.StatusOK, wr.Code)
+	assert.Equal(t, "ok", wr.Body.String())
+}
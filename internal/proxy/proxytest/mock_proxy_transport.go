@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/dgate-io/dgate/internal/proxy/proxy_transport"
+	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -46,6 +47,20 @@ func (m *mockProxyTransportBuilder) RetryTimeout(
 	return m
 }
 
+func (m *mockProxyTransportBuilder) RetryPolicy(
+	policy *spec.RetryPolicy,
+) proxy_transport.Builder {
+	m.Called(policy)
+	return m
+}
+
+func (m *mockProxyTransportBuilder) RetryBudget(
+	budget *proxy_transport.RetryBudget,
+) proxy_transport.Builder {
+	m.Called(budget)
+	return m
+}
+
 func (b *mockProxyTransportBuilder) Clone() proxy_transport.Builder {
 	args := b.Called()
 	return args.Get(0).(proxy_transport.Builder)
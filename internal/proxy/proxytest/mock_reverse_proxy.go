@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/dgate-io/dgate/internal/proxy/reverse_proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -82,8 +83,9 @@ func (m *mockReverseProxyBuilder) ProxyRewrite(
 	preserveHost bool,
 	disableQueryParams bool,
 	disableXForwardedHeaders bool,
+	rewrites []spec.RewriteRule,
 ) reverse_proxy.Builder {
-	m.Called(stripPath, preserveHost, disableQueryParams, disableXForwardedHeaders)
+	m.Called(stripPath, preserveHost, disableQueryParams, disableXForwardedHeaders, rewrites)
 	return m
 }
 
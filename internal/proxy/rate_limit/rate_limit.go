@@ -0,0 +1,257 @@
+package rate_limit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dgate-io/dgate/pkg/util"
+)
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the limit is fully available again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, it is only set when denied
+	RetryAfter time.Duration
+	Window     time.Duration
+}
+
+// SetHeaders sets the RateLimit headers of the response
+func (r Result) SetHeaders(header http.Header) {
+	header.Set("RateLimit-Limit", strconv.Itoa(r.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(r.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(r.Reset)))
+	header.Set("RateLimit-Policy", strconv.Itoa(r.Limit)+
+		";w="+strconv.Itoa(ceilSeconds(r.Window)))
+	if !r.Allowed {
+		header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(r.RetryAfter), 1)))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// Limiter limits the requests of each key, the state of unused keys is
+// removed at most once per window.
+type Limiter struct {
+	mtx     sync.Mutex
+	config  spec.RateLimit
+	buckets map[string]*bucket
+	windows map[string]*window
+	// hits are the allowed requests since the last
+	// sync, they are only counted in cluster mode.
+	hits      map[string]int
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type window struct {
+	start time.Time
+	prev  int
+	curr  int
+}
+
+func New(config *spec.RateLimit) *Limiter {
+	cfg := spec.RateLimit{}
+	if config != nil {
+		cfg = *config
+	}
+	return &Limiter{
+		config:    cfg.WithDefaults(),
+		buckets:   make(map[string]*bucket),
+		windows:   make(map[string]*window),
+		hits:      make(map[string]int),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Cluster checks if the counts are shared with the other nodes of the cluster
+func (l *Limiter) Cluster() bool {
+	return l.config.Cluster
+}
+
+// KeyByConsumer checks if the limiter uses the consumer, which is
+// only known after the request modifiers authenticated the request.
+func (l *Limiter) KeyByConsumer() bool {
+	return l.config.KeyBy == spec.RateLimitKeyConsumer
+}
+
+// Key returns the key of the request, depth is the number of trusted proxies
+// in the X-Forwarded-For header, and consumer is the consumer set by the modules.
+// Requests without the header or consumer are limited by the client ip.
+func (l *Limiter) Key(req *http.Request, depth int, consumer string) string {
+	switch l.config.KeyBy {
+	case spec.RateLimitKeyHeader:
+		if value := req.Header.Get(l.config.Header); value != "" {
+			return "header:" + value
+		}
+	case spec.RateLimitKeyConsumer:
+		if consumer != "" {
+			return "consumer:" + consumer
+		}
+	}
+	return "ip:" + util.GetTrustedIP(req, depth)
+}
+
+// Allow checks if a request with the key is allowed, and counts it when it is.
+func (l *Limiter) Allow(key string) Result {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	now := l.now()
+	l.sweep(now)
+	var result Result
+	if l.config.Algorithm == spec.RateLimitSlidingWindow {
+		result = l.allowWindow(key, now)
+	} else {
+		result = l.allowBucket(key, now)
+	}
+	if result.Allowed && l.config.Cluster {
+		l.hits[key]++
+	}
+	return result
+}
+
+// Record counts requests that were allowed by other nodes of the cluster
+func (l *Limiter) Record(key string, count int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	now := l.now()
+	if l.config.Algorithm == spec.RateLimitSlidingWindow {
+		l.window(key, now).curr += count
+	} else {
+		b := l.bucket(key, now)
+		// the bucket can go into debt, so the tokens
+		// used by other nodes are paid back first.
+		b.tokens = max(b.tokens-float64(count), -float64(l.config.Burst))
+	}
+}
+
+// Hits returns the requests allowed since the last call, by key
+func (l *Limiter) Hits() map[string]int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	hits := l.hits
+	l.hits = make(map[string]int)
+	return hits
+}
+
+func (l *Limiter) rate() float64 {
+	return float64(l.config.Limit) / float64(l.config.Window)
+}
+
+func (l *Limiter) bucket(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.config.Burst), updated: now}
+		l.buckets[key] = b
+		return b
+	}
+	elapsed := now.Sub(b.updated)
+	b.tokens = min(b.tokens+float64(elapsed)*l.rate(), float64(l.config.Burst))
+	b.updated = now
+	return b
+}
+
+func (l *Limiter) allowBucket(key string, now time.Time) Result {
+	b := l.bucket(key, now)
+	result := Result{
+		Limit:  l.config.Burst,
+		Window: l.config.Window,
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / l.rate())
+	}
+	result.Remaining = max(int(math.Floor(b.tokens)), 0)
+	result.Reset = time.Duration((float64(l.config.Burst) - b.tokens) / l.rate())
+	return result
+}
+
+func (l *Limiter) window(key string, now time.Time) *window {
+	start := now.Truncate(l.config.Window)
+	w, ok := l.windows[key]
+	if !ok {
+		w = &window{start: start}
+		l.windows[key] = w
+	}
+	switch {
+	case w.start.Equal(start):
+	case w.start.Add(l.config.Window).Equal(start):
+		w.prev, w.curr = w.curr, 0
+		w.start = start
+	default:
+		w.prev, w.curr = 0, 0
+		w.start = start
+	}
+	return w
+}
+
+func (l *Limiter) allowWindow(key string, now time.Time) Result {
+	w := l.window(key, now)
+	size := l.config.Window
+	elapsed := now.Sub(w.start)
+	// the requests of the previous window are weighted by how much of it is still in the sliding window
+	weight := 1 - float64(elapsed)/float64(size)
+	count := float64(w.prev)*weight + float64(w.curr)
+	limit := float64(l.config.Limit)
+	result := Result{
+		Limit:  l.config.Limit,
+		Window: size,
+		Reset:  size - elapsed,
+	}
+	if count+1 <= limit {
+		w.curr++
+		count++
+		result.Allowed = true
+	} else if w.prev == 0 || float64(w.curr)+1 > limit {
+		result.RetryAfter = size - elapsed
+	} else {
+		// wait until enough of the previous window has slid out
+		needed := 1 - (limit-float64(w.curr)-1)/float64(w.prev)
+		result.RetryAfter = time.Duration(needed*float64(size)) - elapsed
+	}
+	result.Remaining = max(int(math.Floor(limit-count)), 0)
+	return result
+}
+
+// sweep removes the state of keys that are unused for a full window
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.config.Window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		// full buckets are the same as new ones
+		if b.tokens+float64(now.Sub(b.updated))*l.rate() >= float64(l.config.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= 2*l.config.Window {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// Counts are the requests allowed by a node since its last sync,
+// by limiter and key. Nodes send them to the other nodes of the cluster.
+type Counts struct {
+	Node     string                    `json:"node"`
+	Limiters map[string]map[string]int `json:"limiters"`
+}
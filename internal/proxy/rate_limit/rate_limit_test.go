@@ -0,0 +1,135 @@
+package rate_limit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/rate_limit"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_TokenBucket(t *testing.T) {
+	l := rate_limit.New(&spec.RateLimit{
+		Limit:  5,
+		Window: 100 * time.Millisecond,
+	})
+	for i := 0; i < 5; i++ {
+		result := l.Allow("client")
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 4-i, result.Remaining)
+	}
+	result := l.Allow("client")
+	assert.False(t, result.Allowed)
+	assert.Greater(t, result.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RetryAfter, 20*time.Millisecond)
+
+	// other keys have their own bucket
+	assert.True(t, l.Allow("other").Allowed)
+
+	// a token is added every 20ms
+	time.Sleep(25 * time.Millisecond)
+	assert.True(t, l.Allow("client").Allowed)
+	assert.False(t, l.Allow("client").Allowed)
+}
+
+func TestRateLimit_Burst(t *testing.T) {
+	l := rate_limit.New(&spec.RateLimit{
+		Limit:  1,
+		Burst:  3,
+		Window: time.Hour,
+	})
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow("client").Allowed)
+	}
+	result := l.Allow("client")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 3, result.Limit)
+}
+
+func TestRateLimit_SlidingWindow(t *testing.T) {
+	window := 400 * time.Millisecond
+	l := rate_limit.New(&spec.RateLimit{
+		Algorithm: spec.RateLimitSlidingWindow,
+		Limit:     4,
+		Window:    window,
+	})
+	// start at the beginning of a window, so all requests are in the same one
+	time.Sleep(time.Until(time.Now().Truncate(window).Add(window)))
+	for i := 0; i < 4; i++ {
+		assert.True(t, l.Allow("client").Allowed)
+	}
+	result := l.Allow("client")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.LessOrEqual(t, result.RetryAfter, window)
+
+	// the previous window still counts for the part that is in the sliding window
+	time.Sleep(window + window/4)
+	assert.True(t, l.Allow("client").Allowed)
+	assert.False(t, l.Allow("client").Allowed)
+}
+
+func TestRateLimit_Record(t *testing.T) {
+	for _, algorithm := range []spec.RateLimitAlgorithm{
+		spec.RateLimitTokenBucket, spec.RateLimitSlidingWindow,
+	} {
+		l := rate_limit.New(&spec.RateLimit{
+			Algorithm: algorithm,
+			Limit:     3,
+			Window:    time.Hour,
+			Cluster:   true,
+		})
+		l.Record("client", 2)
+		assert.True(t, l.Allow("client").Allowed, algorithm)
+		assert.False(t, l.Allow("client").Allowed, algorithm)
+		// only the requests allowed by this node are shared
+		assert.Equal(t, map[string]int{"client": 1}, l.Hits(), algorithm)
+		assert.Empty(t, l.Hits(), algorithm)
+	}
+
+	l := rate_limit.New(&spec.RateLimit{Limit: 3})
+	l.Allow("client")
+	assert.Empty(t, l.Hits())
+}
+
+func TestRateLimit_Key(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "192.168.0.1")
+	req.Header.Set("X-Api-Key", "key")
+	req.Header.Set("X-Consumer-Id", "consumer")
+
+	l := rate_limit.New(&spec.RateLimit{Limit: 1})
+	assert.Equal(t, "ip:10.0.0.1", l.Key(req, 0, ""))
+	assert.Equal(t, "ip:192.168.0.1", l.Key(req, 1, ""))
+
+	l = rate_limit.New(&spec.RateLimit{Limit: 1, KeyBy: spec.RateLimitKeyHeader, Header: "X-Api-Key"})
+	assert.Equal(t, "header:key", l.Key(req, 0, ""))
+	assert.False(t, l.KeyByConsumer())
+	req.Header.Del("X-Api-Key")
+	assert.Equal(t, "ip:10.0.0.1", l.Key(req, 0, ""))
+
+	// the consumer header sent by the client is not used
+	l = rate_limit.New(&spec.RateLimit{Limit: 1, KeyBy: spec.RateLimitKeyConsumer})
+	assert.Equal(t, "consumer:user", l.Key(req, 0, "user"))
+	assert.Equal(t, "ip:10.0.0.1", l.Key(req, 0, ""))
+	assert.True(t, l.KeyByConsumer())
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	l := rate_limit.New(&spec.RateLimit{Limit: 1, Window: time.Minute})
+	header := http.Header{}
+	l.Allow("client").SetHeaders(header)
+	assert.Equal(t, "1", header.Get("RateLimit-Limit"))
+	assert.Equal(t, "0", header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", header.Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", header.Get("RateLimit-Policy"))
+	assert.Empty(t, header.Get("Retry-After"))
+
+	header = http.Header{}
+	l.Allow("client").SetHeaders(header)
+	assert.Equal(t, "60", header.Get("Retry-After"))
+}
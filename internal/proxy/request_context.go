@@ -2,14 +2,20 @@ package proxy
 
 import (
 	"context"
-	"crypto/tls"
-	"net"
 	"net/http"
 	"sync"
 
 	"github.com/dgate-io/chi-router"
+	"github.com/dgate-io/dgate/internal/proxy/circuit_breaker"
+	"github.com/dgate-io/dgate/internal/proxy/concurrency_limit"
+	"github.com/dgate-io/dgate/internal/proxy/load_balancer"
+	"github.com/dgate-io/dgate/internal/proxy/outlier_detection"
+	"github.com/dgate-io/dgate/internal/proxy/rate_limit"
+	"github.com/dgate-io/dgate/internal/proxy/response_cache"
 	"github.com/dgate-io/dgate/internal/proxy/reverse_proxy"
+	"github.com/dgate-io/dgate/internal/proxy/traffic_split"
 	"github.com/dgate-io/dgate/pkg/spec"
+	"go.uber.org/zap"
 )
 
 type S string
@@ -19,18 +25,42 @@ type RequestContextProvider struct {
 	cancel context.CancelFunc
 	route  *spec.DGateRoute
 	rpb    reverse_proxy.Builder
-	mtx    *sync.Mutex
-	modBuf ModulePool
+	lb     load_balancer.Balancer
+	// outliers and breaker are nil when the service does not use them
+	outliers *outlier_detection.Detector
+	breaker  *circuit_breaker.Breaker
+	mtx      *sync.Mutex
+	modBuf   ModulePool
+	// split picks one of the split providers, there is one provider
+	// for each service in the traffic split of the route.
+	split          *traffic_split.Splitter
+	splitProviders []*RequestContextProvider
+	// mirror is nil when the route does not mirror requests
+	mirror *requestMirror
+	// cache is nil when the route does not cache responses
+	cache *response_cache.Cache
+	// rateLimiters are the rate limiters of the namespace and the route
+	rateLimiters []*rate_limit.Limiter
+	// concurrency and serviceConcurrency are nil when
+	// the route or service has no concurrency limit.
+	concurrency        *concurrency_limit.Limiter
+	serviceConcurrency *concurrency_limit.Limiter
 }
 
 type RequestContext struct {
-	pattern  string
-	ctx      context.Context
-	route    *spec.DGateRoute
-	rw       spec.ResponseWriterTracker
-	req      *http.Request
-	provider *RequestContextProvider
-	params   map[string]string
+	pattern string
+	ctx     context.Context
+	// clientCtx is the context of the inbound request, it is
+	// canceled when the client disconnects, unlike ctx.
+	clientCtx context.Context
+	route     *spec.DGateRoute
+	rw        spec.ResponseWriterTracker
+	req       *http.Request
+	provider  *RequestContextProvider
+	params    map[string]string
+	// moduleLimitExceeded is true when a module broke its limits,
+	// the runtime of the request is discarded instead of returned.
+	moduleLimitExceeded bool
 }
 
 func NewRequestContextProvider(route *spec.DGateRoute, ps *ProxyState) *RequestContextProvider {
@@ -40,27 +70,30 @@ func NewRequestContextProvider(route *spec.DGateRoute, ps *ProxyState) *RequestC
 	ctx = context.WithValue(ctx, spec.Name("namespace"), route.Namespace.Name)
 
 	var rpb reverse_proxy.Builder
+	var lb load_balancer.Balancer
+	var outliers *outlier_detection.Detector
+	var breaker *circuit_breaker.Breaker
+	var cache *response_cache.Cache
+	var serviceConcurrency *concurrency_limit.Limiter
 	if route.Service != nil {
 		ctx = context.WithValue(ctx, spec.Name("service"), route.Service.Name)
-		transport := setupTranportsFromConfig(
-			&ps.config.ProxyConfig.Transport,
-			func(dialer *net.Dialer, t *http.Transport) {
-				t.TLSClientConfig = &tls.Config{
-					InsecureSkipVerify: route.Service.TLSSkipVerify,
-				}
-				dialer.Timeout = route.Service.ConnectTimeout
-				t.ForceAttemptHTTP2 = route.Service.HTTP2Only
-			},
-		)
+		transport := ps.serviceTransport(route.Service)
 		proxy, err := ps.ProxyTransportBuilder.Clone().
 			Transport(transport).
 			Retries(route.Service.Retries).
 			RetryTimeout(route.Service.RetryTimeout).
 			RequestTimeout(route.Service.RequestTimeout).
+			RetryPolicy(route.Service.RetryPolicy).
+			RetryBudget(ps.serviceRetryBudget(route.Service)).
 			Build()
 		if err != nil {
 			panic(err)
 		}
+		if route.Cache != nil {
+			if cache = ps.routeResponseCache(route); cache != nil {
+				proxy = cache.Transport(proxy)
+			}
+		}
 		rpb = ps.ReverseProxyBuilder.Clone().
 			Transport(proxy).
 			ProxyRewrite(
@@ -68,17 +101,83 @@ func NewRequestContextProvider(route *spec.DGateRoute, ps *ProxyState) *RequestC
 				route.PreserveHost,
 				route.Service.DisableQueryParams,
 				ps.config.ProxyConfig.DisableXForwardedHeaders,
+				route.Rewrite,
+			)
+		available := ps.upstreamAvailableFunc(route.Service)
+		lb, err = load_balancer.New(
+			route.Service.LoadBalancer,
+			route.Service.URLs,
+			ps.config.ProxyConfig.XForwardedForDepth,
+			available,
+		)
+		if err != nil {
+			ps.logger.Error("Error creating load balancer, using round robin",
+				zap.Error(err),
+				zap.String("service", route.Service.Name),
+				zap.String("namespace", route.Namespace.Name),
 			)
+			lb, _ = load_balancer.New(nil, route.Service.URLs, 0, available)
+		}
+		outliers = ps.serviceOutlierDetector(route.Service)
+		breaker = ps.serviceCircuitBreaker(route.Service)
+		serviceConcurrency = ps.serviceConcurrencyLimiter(route.Service)
 	}
 	ctx, cancel := context.WithCancel(ctx)
 
-	return &RequestContextProvider{
-		ctx:    ctx,
-		cancel: cancel,
-		route:  route,
-		rpb:    rpb,
-		mtx:    &sync.Mutex{},
+	provider := &RequestContextProvider{
+		ctx:      ctx,
+		cancel:   cancel,
+		route:    route,
+		rpb:      rpb,
+		lb:       lb,
+		outliers: outliers,
+		breaker:  breaker,
+		cache:    cache,
+		mtx:      &sync.Mutex{},
+		// namespace limits apply to all routes of the namespace
+		rateLimiters:       ps.routeRateLimiters(route),
+		concurrency:        ps.routeConcurrencyLimiter(route),
+		serviceConcurrency: serviceConcurrency,
+	}
+	if route.Mirror != nil {
+		provider.mirror = ps.newRequestMirror(route)
 	}
+	if route.TrafficSplit != nil && len(route.TrafficSplit.Services) > 0 {
+		provider.split = traffic_split.New(route.TrafficSplit)
+		provider.splitProviders = make([]*RequestContextProvider, len(route.TrafficSplit.Services))
+		for i, ws := range route.TrafficSplit.Services {
+			if route.Service != nil && route.Service.Name == ws.Service.Name {
+				provider.splitProviders[i] = provider
+				continue
+			}
+			splitRoute := *route
+			splitRoute.Service = ws.Service
+			splitRoute.TrafficSplit = nil
+			splitRoute.Mirror = nil
+			provider.splitProviders[i] = NewRequestContextProvider(&splitRoute, ps)
+			provider.splitProviders[i].mirror = provider.mirror
+		}
+	}
+	return provider
+}
+
+// Providers returns the provider and the providers of its traffic split.
+func (reqCtxProvider *RequestContextProvider) Providers() []*RequestContextProvider {
+	providers := []*RequestContextProvider{reqCtxProvider}
+	for _, p := range reqCtxProvider.splitProviders {
+		if p != reqCtxProvider {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// Pick returns the provider for the service the request is sent to.
+func (reqCtxProvider *RequestContextProvider) Pick(req *http.Request) *RequestContextProvider {
+	if reqCtxProvider.split == nil {
+		return reqCtxProvider
+	}
+	return reqCtxProvider.splitProviders[reqCtxProvider.split.Pick(req)]
 }
 
 func (reqCtxProvider *RequestContextProvider) UpdateModulePool(mb ModulePool) {
@@ -107,13 +206,14 @@ func (reqCtxProvider *RequestContextProvider) CreateRequestContext(
 		}
 	}
 	return &RequestContext{
-		ctx:      ctx,
-		pattern:  pattern,
-		params:   pathParams,
-		provider: reqCtxProvider,
-		route:    reqCtxProvider.route,
-		req:      req.WithContext(ctx),
-		rw:       spec.NewResponseWriterTracker(rw),
+		ctx:       ctx,
+		clientCtx: req.Context(),
+		pattern:   pattern,
+		params:    pathParams,
+		provider:  reqCtxProvider,
+		route:     reqCtxProvider.route,
+		req:       req.WithContext(ctx),
+		rw:        spec.NewResponseWriterTracker(rw),
 	}
 }
 
@@ -125,6 +225,14 @@ func (reqCtxProvider *RequestContextProvider) Close() {
 		reqCtxProvider.modBuf = nil
 	}
 	reqCtxProvider.cancel()
+	for _, p := range reqCtxProvider.splitProviders {
+		if p != reqCtxProvider {
+			p.Close()
+		}
+	}
+	if reqCtxProvider.mirror != nil {
+		reqCtxProvider.mirror.provider.Close()
+	}
 }
 
 func (reqCtx *RequestContext) Context() context.Context {
@@ -0,0 +1,88 @@
+package response_cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheControl holds the directives of Cache-Control headers
+type cacheControl struct {
+	noStore              bool
+	noCache              bool
+	private              bool
+	public               bool
+	mustRevalidate       bool
+	maxAge               time.Duration
+	hasMaxAge            bool
+	sMaxAge              time.Duration
+	hasSMaxAge           bool
+	staleWhileRevalidate time.Duration
+	hasSWR               bool
+	staleIfError         time.Duration
+	hasSIE               bool
+}
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			arg = strings.Trim(arg, `"`)
+			switch strings.ToLower(name) {
+			case "no-store":
+				cc.noStore = true
+			case "no-cache":
+				cc.noCache = true
+			case "private":
+				cc.private = true
+			case "public":
+				cc.public = true
+			case "must-revalidate", "proxy-revalidate":
+				cc.mustRevalidate = true
+			case "max-age":
+				cc.maxAge, cc.hasMaxAge = parseSeconds(arg)
+			case "s-maxage":
+				cc.sMaxAge, cc.hasSMaxAge = parseSeconds(arg)
+			case "stale-while-revalidate":
+				cc.staleWhileRevalidate, cc.hasSWR = parseSeconds(arg)
+			case "stale-if-error":
+				cc.staleIfError, cc.hasSIE = parseSeconds(arg)
+			}
+		}
+	}
+	return cc
+}
+
+func parseSeconds(s string) (time.Duration, bool) {
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// freshness returns how long the response is fresh for, based on the
+// s-maxage and max-age directives or the Expires header.
+func freshness(header http.Header, cc cacheControl, now time.Time) (time.Duration, bool) {
+	if cc.hasSMaxAge {
+		return cc.sMaxAge, true
+	}
+	if cc.hasMaxAge {
+		return cc.maxAge, true
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil {
+			// invalid dates mean the response is already expired
+			return 0, true
+		}
+		date := now
+		if d, err := http.ParseTime(header.Get("Date")); err == nil {
+			date = d
+		}
+		return max(exp.Sub(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,55 @@
+package response_cache
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+type keyCtxKey struct{}
+
+// WithKey sets the cache key of the request, requests without
+// a key are sent upstream without using the cache.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyCtxKey{}, key)
+}
+
+func keyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyCtxKey{}).(string)
+	return key
+}
+
+// keyTemplate renders cache keys from requests
+type keyTemplate []spec.ResponseCacheKeyPart
+
+func (t keyTemplate) render(req *http.Request) string {
+	var sb strings.Builder
+	for _, part := range t {
+		switch part.Source {
+		case "":
+			sb.WriteString(part.Literal)
+		case "method":
+			sb.WriteString(req.Method)
+		case "host":
+			sb.WriteString(strings.ToLower(req.Host))
+		case "path":
+			sb.WriteString(req.URL.Path)
+		case "query":
+			if part.Name == "" {
+				// encoding sorts the params, so the order does not change the key
+				sb.WriteString(req.URL.Query().Encode())
+			} else {
+				sb.WriteString(req.URL.Query().Get(part.Name))
+			}
+		case "header":
+			sb.WriteString(strings.Join(req.Header.Values(part.Name), ","))
+		case "cookie":
+			if cookie, err := req.Cookie(part.Name); err == nil {
+				sb.WriteString(cookie.Value)
+			}
+		}
+	}
+	return sb.String()
+}
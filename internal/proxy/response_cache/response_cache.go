@@ -0,0 +1,291 @@
+package response_cache
+
+import (
+	"container/list"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+// Cache stores the upstream responses of a route in memory, the least
+// recently used responses are evicted when the max size is reached.
+type Cache struct {
+	mtx     sync.Mutex
+	config  spec.ResponseCache
+	key     keyTemplate
+	lru     *list.List
+	entries map[string]*list.Element
+	// vary maps the primary keys to the request headers
+	// their responses vary on, set by the Vary header.
+	vary map[string]*variants
+	size int64
+	// revalidating has the keys that are being revalidated in the background
+	revalidating map[string]struct{}
+	now          func() time.Time
+}
+
+type variants struct {
+	headers []string
+	count   int
+}
+
+type entry struct {
+	key     string
+	primary string
+	status  int
+	header  http.Header
+	body    []byte
+	tags    []string
+	// stored is when the response was received, age is the
+	// age the upstream reported with the Age header.
+	stored time.Time
+	age    time.Duration
+	// fresh is how long the response can be served without revalidating
+	fresh                time.Duration
+	noCache              bool
+	mustRevalidate       bool
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+}
+
+func New(config *spec.ResponseCache) (*Cache, error) {
+	cfg := spec.ResponseCache{}
+	if config != nil {
+		cfg = *config
+	}
+	cfg = cfg.WithDefaults()
+	key, err := spec.ParseResponseCacheKey(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{
+		config:       cfg,
+		key:          key,
+		lru:          list.New(),
+		entries:      make(map[string]*list.Element),
+		vary:         make(map[string]*variants),
+		revalidating: make(map[string]struct{}),
+		now:          time.Now,
+	}, nil
+}
+
+// Key returns the cache key of the request, it is empty if the request method is not cached.
+func (c *Cache) Key(req *http.Request) string {
+	if !slices.Contains(c.config.Methods, req.Method) {
+		return ""
+	}
+	return c.key.render(req)
+}
+
+// Len returns the number of cached responses
+func (c *Cache) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the total size of the cached responses in bytes
+func (c *Cache) Size() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.size
+}
+
+// Purge removes the cached responses with keys starting with the prefix and with
+// the tag, an empty prefix or tag matches all responses. It returns how many were removed.
+func (c *Cache) Purge(prefix, tag string) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	count := 0
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		e := el.Value.(*entry)
+		if strings.HasPrefix(e.primary, prefix) &&
+			(tag == "" || slices.Contains(e.tags, tag)) {
+			c.remove(el)
+			count++
+		}
+		el = next
+	}
+	return count
+}
+
+// lookup returns the cached response for the request, primary is the key of the request
+func (c *Cache) lookup(primary string, req *http.Request) *entry {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	el, ok := c.entries[variantKey(primary, c.varyHeaders(primary), req.Header)]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*entry)
+	if c.expired(e) && !e.hasValidators() {
+		c.remove(el)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return e
+}
+
+func (c *Cache) insert(e *entry, varyOn []string) {
+	size := e.size()
+	if size > c.config.MaxEntrySize {
+		return
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !slices.Equal(c.varyHeaders(e.primary), varyOn) {
+		// the variants of the old vary headers can not be found anymore
+		for el := c.lru.Front(); el != nil; {
+			next := el.Next()
+			if el.Value.(*entry).primary == e.primary {
+				c.remove(el)
+			}
+			el = next
+		}
+	}
+	if el, ok := c.entries[e.key]; ok {
+		c.remove(el)
+	}
+	if len(varyOn) > 0 {
+		v, ok := c.vary[e.primary]
+		if !ok {
+			v = &variants{headers: varyOn}
+			c.vary[e.primary] = v
+		}
+		v.count++
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	c.size += size
+	for c.size > c.config.MaxSize && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
+
+// refresh updates the entry after a successful revalidation
+func (c *Cache) refresh(e *entry, resp *http.Response) *entry {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	updated := *e
+	updated.header = e.header.Clone()
+	for k, v := range resp.Header {
+		// the body is not sent with 304 responses, so its headers are kept
+		if k == "Content-Length" || k == "Content-Encoding" || k == "Transfer-Encoding" {
+			continue
+		}
+		updated.header[k] = v
+	}
+	cc := parseCacheControl(updated.header)
+	updated.setFreshness(c.config, cc, c.now())
+	updated.tags = responseTags(c.config, updated.header)
+	if el, ok := c.entries[e.key]; ok && el.Value == e {
+		el.Value = &updated
+		c.size += updated.size() - e.size()
+	}
+	return &updated
+}
+
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.entries, e.key)
+	c.size -= e.size()
+	if v, ok := c.vary[e.primary]; ok {
+		if v.count--; v.count <= 0 {
+			delete(c.vary, e.primary)
+		}
+	}
+}
+
+func (c *Cache) varyHeaders(primary string) []string {
+	if v, ok := c.vary[primary]; ok {
+		return v.headers
+	}
+	return nil
+}
+
+func (c *Cache) currentAge(e *entry) time.Duration {
+	return e.age + c.now().Sub(e.stored)
+}
+
+func (c *Cache) isFresh(e *entry) bool {
+	return !e.noCache && c.currentAge(e) < e.fresh
+}
+
+// expired checks if the response can not be served anymore, even when stale
+func (c *Cache) expired(e *entry) bool {
+	if e.mustRevalidate || e.noCache {
+		return !c.isFresh(e)
+	}
+	return c.currentAge(e) >= e.fresh+max(e.staleWhileRevalidate, e.staleIfError)
+}
+
+func (c *Cache) canServeStale(e *entry, window time.Duration) bool {
+	if e.mustRevalidate || e.noCache {
+		return false
+	}
+	return c.currentAge(e) < e.fresh+window
+}
+
+func (e *entry) hasValidators() bool {
+	return e.header.Get("ETag") != "" || e.header.Get("Last-Modified") != ""
+}
+
+func (e *entry) size() int64 {
+	size := int64(len(e.key) + len(e.body))
+	for k, v := range e.header {
+		size += int64(len(k))
+		for _, s := range v {
+			size += int64(len(s))
+		}
+	}
+	return size
+}
+
+func (e *entry) setFreshness(config spec.ResponseCache, cc cacheControl, now time.Time) {
+	e.fresh, _ = freshness(e.header, cc, now)
+	if config.TTL > 0 {
+		e.fresh = config.TTL
+	}
+	e.noCache = cc.noCache
+	e.mustRevalidate = cc.mustRevalidate
+	e.staleWhileRevalidate = config.StaleWhileRevalidate
+	if cc.hasSWR {
+		e.staleWhileRevalidate = cc.staleWhileRevalidate
+	}
+	e.staleIfError = config.StaleIfError
+	if cc.hasSIE {
+		e.staleIfError = cc.staleIfError
+	}
+}
+
+// variantKey adds the values of the vary headers to the key
+func variantKey(primary string, varyOn []string, header http.Header) string {
+	if len(varyOn) == 0 {
+		return primary
+	}
+	var sb strings.Builder
+	sb.WriteString(primary)
+	for _, name := range varyOn {
+		sb.WriteString("\x00")
+		sb.WriteString(name)
+		sb.WriteString("=")
+		sb.WriteString(strings.Join(header.Values(name), ","))
+	}
+	return sb.String()
+}
+
+func responseTags(config spec.ResponseCache, header http.Header) []string {
+	tags := slices.Clone(config.Tags)
+	for _, value := range header.Values("Cache-Tag") {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,336 @@
+package response_cache_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/proxy/response_cache"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// upstream returns a transport that responds with the handler and counts the requests
+func upstream(calls *atomic.Int32, handler http.HandlerFunc) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Result(), nil
+	})
+}
+
+func newCache(t *testing.T, config *spec.ResponseCache) *response_cache.Cache {
+	cache, err := response_cache.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func send(t *testing.T, cache *response_cache.Cache, rt http.RoundTripper, req *http.Request) (*http.Response, string) {
+	req = req.WithContext(response_cache.WithKey(req.Context(), cache.Key(req)))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(body)
+}
+
+func TestResponseCache_HitAndMiss(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, nil)
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello"))
+	}))
+
+	resp, body := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test?b=2&a=1", nil))
+	assert.Equal(t, "MISS", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+
+	// the order of the query params does not change the key
+	resp, body = send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test?a=1&b=2", nil))
+	assert.Equal(t, "HIT", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// methods that are not cached are sent upstream untouched
+	resp, _ = send(t, cache, rt, httptest.NewRequest(http.MethodPost, "/test?a=1&b=2", nil))
+	assert.Empty(t, resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestResponseCache_NotCacheable(t *testing.T) {
+	headers := []map[string]string{
+		{"Cache-Control": "no-store, max-age=60"},
+		{"Cache-Control": "private, max-age=60"},
+		{"Cache-Control": "max-age=60", "Set-Cookie": "a=b"},
+		{"Cache-Control": "max-age=60", "Vary": "*"},
+		{},
+	}
+	for _, h := range headers {
+		calls := &atomic.Int32{}
+		cache := newCache(t, nil)
+		rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range h {
+				w.Header().Set(k, v)
+			}
+			w.Write([]byte("hello"))
+		}))
+		send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+		send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+		assert.Equal(t, int32(2), calls.Load(), h)
+		assert.Equal(t, 0, cache.Len(), h)
+	}
+}
+
+func TestResponseCache_Authorization(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, nil)
+	cacheControl := "max-age=60"
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", cacheControl)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	send(t, cache, rt, req)
+	assert.Equal(t, 0, cache.Len())
+
+	cacheControl = "public, max-age=60"
+	send(t, cache, rt, req)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestResponseCache_Vary(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, nil)
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	for _, lang := range []string{"en", "fr", "en", "fr"} {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Accept-Language", lang)
+		_, body := send(t, cache, rt, req)
+		assert.Equal(t, lang, body)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestResponseCache_Revalidate(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, nil)
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		// the age makes the response stale right away
+		w.Header().Set("Cache-Control", "max-age=10")
+		w.Header().Set("Age", "20")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	resp, _ := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, "MISS", resp.Header.Get(response_cache.CacheStatusHeader))
+	resp, body := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "REVALIDATED", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestResponseCache_StaleWhileRevalidate(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, nil)
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		w.Header().Set("Age", "20")
+		w.Write([]byte("hello"))
+	}))
+
+	send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	resp, body := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, "STALE", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+	assert.Eventually(t, func() bool {
+		return calls.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestResponseCache_StaleIfError(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, &spec.ResponseCache{
+		StaleIfError: time.Minute,
+	})
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Load() > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=10")
+		w.Header().Set("Age", "20")
+		w.Write([]byte("hello"))
+	}))
+
+	send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	resp, body := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "STALE", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+
+	failing := cache.Transport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))
+	resp, body = send(t, cache, failing, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, "STALE", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+}
+
+func TestResponseCache_TTL(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, &spec.ResponseCache{TTL: time.Minute})
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	resp, _ := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, "HIT", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestResponseCache_KeyTemplate(t *testing.T) {
+	cache := newCache(t, &spec.ResponseCache{
+		Key: "{path}:{query.page}:{header.X-Tenant}:{cookie.session}",
+	})
+	req := httptest.NewRequest(http.MethodGet, "/items?page=2&sort=asc", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	assert.Equal(t, "/items:2:acme:abc", cache.Key(req))
+	assert.Empty(t, cache.Key(httptest.NewRequest(http.MethodDelete, "/items", nil)))
+
+	for _, key := range []string{"{unknown}", "{path", "path}", "{header}", "{method.x}"} {
+		_, err := response_cache.New(&spec.ResponseCache{Key: key})
+		assert.Error(t, err, key)
+		// the route validation uses the same parser
+		assert.Error(t, (&spec.ResponseCache{Key: key}).Validate(), key)
+	}
+}
+
+func TestResponseCache_Head(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, &spec.ResponseCache{Key: "{path}"})
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.Method != http.MethodHead {
+			w.Write([]byte("hello"))
+		}
+	}))
+
+	// HEAD responses are not stored under the key of GET requests
+	resp, _ := send(t, cache, rt, httptest.NewRequest(http.MethodHead, "/test", nil))
+	assert.Equal(t, "MISS", resp.Header.Get(response_cache.CacheStatusHeader))
+	resp, body := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, "MISS", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+
+	// HEAD requests use the cached GET response without the body
+	resp, body = send(t, cache, rt, httptest.NewRequest(http.MethodHead, "/test", nil))
+	assert.Equal(t, "HIT", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Empty(t, body)
+	resp, body = send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, "HIT", resp.Header.Get(response_cache.CacheStatusHeader))
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestResponseCache_MaxSize(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, &spec.ResponseCache{
+		MaxSize:      1024,
+		MaxEntrySize: 512,
+	})
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		size := 200
+		if r.URL.Path == "/large" {
+			size = 600
+		}
+		w.Write([]byte(strings.Repeat("a", size)))
+	}))
+	for _, path := range []string{"/1", "/2", "/3", "/4", "/large"} {
+		_, body := send(t, cache, rt, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.NotEmpty(t, body)
+	}
+	assert.Equal(t, 3, cache.Len())
+	assert.LessOrEqual(t, cache.Size(), int64(1024))
+
+	// the least recently used response was evicted
+	resp, _ := send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/1", nil))
+	assert.Equal(t, "MISS", resp.Header.Get(response_cache.CacheStatusHeader))
+	resp, _ = send(t, cache, rt, httptest.NewRequest(http.MethodGet, "/4", nil))
+	assert.Equal(t, "HIT", resp.Header.Get(response_cache.CacheStatusHeader))
+}
+
+func TestResponseCache_Purge(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, &spec.ResponseCache{
+		Key:  "{path}",
+		Tags: []string{"route"},
+	})
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		if strings.HasPrefix(r.URL.Path, "/users") {
+			w.Header().Set("Cache-Tag", "users, people")
+		}
+	}))
+	for _, path := range []string{"/users/1", "/users/2", "/items/1", "/items/2"} {
+		send(t, cache, rt, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, 4, cache.Len())
+	assert.Equal(t, 1, cache.Purge("/items/1", ""))
+	assert.Equal(t, 2, cache.Purge("", "people"))
+	assert.Equal(t, 0, cache.Purge("/users", ""))
+	assert.Equal(t, 1, cache.Purge("", "route"))
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestResponseCache_NoKey(t *testing.T) {
+	calls := &atomic.Int32{}
+	cache := newCache(t, nil)
+	rt := cache.Transport(upstream(calls, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	}))
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil).
+			WithContext(context.Background())
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
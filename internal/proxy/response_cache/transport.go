@@ -0,0 +1,240 @@
+package response_cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CacheStatusHeader is set on the responses of cached routes, it is
+// HIT, MISS, STALE, REVALIDATED or BYPASS.
+const CacheStatusHeader = "X-Cache-Status"
+
+var cacheableStatus = []int{
+	http.StatusOK,
+	http.StatusNonAuthoritativeInfo,
+	http.StatusNoContent,
+	http.StatusMultipleChoices,
+	http.StatusMovedPermanently,
+	http.StatusNotFound,
+	http.StatusGone,
+}
+
+type transport struct {
+	cache *Cache
+	next  http.RoundTripper
+}
+
+// Transport wraps the transport of the route, responses are served from the cache when
+// the request has a cache key (see WithKey) and the cached response can be used.
+func (c *Cache) Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{cache: c, next: next}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := keyFromContext(req.Context())
+	if key == "" || !slices.Contains(t.cache.config.Methods, req.Method) {
+		return t.next.RoundTrip(req)
+	}
+	reqCC := parseCacheControl(req.Header)
+	if reqCC.noStore {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil {
+			resp.Header.Set(CacheStatusHeader, "BYPASS")
+		}
+		return resp, err
+	}
+
+	e := t.cache.lookup(key, req)
+	if e == nil {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		return t.store(key, req, resp), nil
+	}
+
+	if t.cache.isFresh(e) && !reqCC.noCache {
+		return t.cache.response(e, req, "HIT"), nil
+	}
+	if !reqCC.noCache && t.cache.canServeStale(e, e.staleWhileRevalidate) {
+		t.revalidateInBackground(key, e, req)
+		return t.cache.response(e, req, "STALE"), nil
+	}
+
+	resp, err := t.next.RoundTrip(conditionalRequest(req.Context(), e, req))
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		if t.cache.canServeStale(e, e.staleIfError) {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return t.cache.response(e, req, "STALE"), nil
+		}
+		return resp, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return t.cache.response(t.cache.refresh(e, resp), req, "REVALIDATED"), nil
+	}
+	return t.store(key, req, resp), nil
+}
+
+func (t *transport) revalidateInBackground(key string, e *entry, req *http.Request) {
+	t.cache.mtx.Lock()
+	if _, ok := t.cache.revalidating[e.key]; ok {
+		t.cache.mtx.Unlock()
+		return
+	}
+	t.cache.revalidating[e.key] = struct{}{}
+	t.cache.mtx.Unlock()
+
+	// the revalidation continues after the client request is done
+	revalReq := conditionalRequest(context.WithoutCancel(req.Context()), e, req)
+	go func() {
+		defer func() {
+			t.cache.mtx.Lock()
+			delete(t.cache.revalidating, e.key)
+			t.cache.mtx.Unlock()
+		}()
+		resp, err := t.next.RoundTrip(revalReq)
+		if err != nil {
+			return
+		}
+		if resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			t.cache.refresh(e, resp)
+			return
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			resp.Body.Close()
+			return
+		}
+		resp = t.store(key, revalReq, resp)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+}
+
+// store caches the response if it is cacheable, the returned
+// response must be used instead of the one passed in.
+func (t *transport) store(key string, req *http.Request, resp *http.Response) *http.Response {
+	resp.Header.Set(CacheStatusHeader, "MISS")
+	varyOn, ok := t.cacheable(req, resp)
+	if !ok {
+		return resp
+	}
+	limit := t.cache.config.MaxEntrySize
+	if resp.ContentLength > limit {
+		return resp
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil || int64(len(body)) > limit {
+		// the client still gets the whole response
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del(CacheStatusHeader)
+	now := t.cache.now()
+	e := &entry{
+		key:     variantKey(key, varyOn, req.Header),
+		primary: key,
+		status:  resp.StatusCode,
+		header:  header,
+		body:    body,
+		tags:    responseTags(t.cache.config, header),
+		stored:  now,
+	}
+	if age, ok := parseSeconds(header.Get("Age")); ok {
+		e.age = age
+	}
+	e.setFreshness(t.cache.config, parseCacheControl(header), now)
+	if !t.cache.isFresh(e) && !e.hasValidators() &&
+		!t.cache.canServeStale(e, max(e.staleWhileRevalidate, e.staleIfError)) {
+		return resp
+	}
+	t.cache.insert(e, varyOn)
+	return resp
+}
+
+// cacheable checks if the response can be stored in a shared cache, and returns the vary headers
+func (t *transport) cacheable(req *http.Request, resp *http.Response) ([]string, bool) {
+	// HEAD responses have no body, the key may not have the method,
+	// so storing them would serve an empty body to GET requests
+	if req.Method == http.MethodHead || !slices.Contains(cacheableStatus, resp.StatusCode) {
+		return nil, false
+	}
+	cc := parseCacheControl(resp.Header)
+	if cc.noStore || cc.private || resp.Header.Get("Set-Cookie") != "" {
+		return nil, false
+	}
+	if req.Header.Get("Authorization") != "" &&
+		!cc.public && !cc.hasSMaxAge && !cc.mustRevalidate {
+		return nil, false
+	}
+	varyOn := []string{}
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" && !slices.Contains(varyOn, name) {
+				varyOn = append(varyOn, name)
+			}
+		}
+	}
+	slices.Sort(varyOn)
+	return varyOn, true
+}
+
+// response creates a response from the cached entry
+func (c *Cache) response(e *entry, req *http.Request, cacheStatus string) *http.Response {
+	header := e.header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(c.currentAge(e).Seconds()), 10))
+	header.Set(CacheStatusHeader, cacheStatus)
+	status, body := e.status, e.body
+	if req.Method == http.MethodHead {
+		body = nil
+	}
+	if etag := e.header.Get("ETag"); etag != "" && req.Header.Get("If-None-Match") == etag {
+		// the client already has the cached response
+		status, body = http.StatusNotModified, nil
+		header.Del("Content-Length")
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// conditionalRequest creates a request that revalidates the cached response with its validators
+func conditionalRequest(ctx context.Context, e *entry, req *http.Request) *http.Request {
+	condReq := req.Clone(ctx)
+	if etag := e.header.Get("ETag"); etag != "" {
+		condReq.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := e.header.Get("Last-Modified"); lastModified != "" {
+		condReq.Header.Set("If-Modified-Since", lastModified)
+	}
+	return condReq
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
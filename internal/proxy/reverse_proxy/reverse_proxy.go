@@ -10,6 +10,8 @@ import (
 	"path"
 	"strings"
 	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
 )
 
 type RewriteFunc func(*http.Request, *http.Request)
@@ -37,12 +39,14 @@ type Builder interface {
 	// ErrorLogger sets the (go) logger for the reverse proxy.
 	ErrorLogger(*log.Logger) Builder
 
-	// ProxyRewrite sets the proxy rewrite function for the reverse proxy.
+	// ProxyRewrite sets the proxy rewrite function for the reverse proxy,
+	// the rewrite rules are applied after the other rewrites.
 	ProxyRewrite(
 		stripPath bool,
 		preserveHost bool,
 		disableQueryParams bool,
 		xForwardedHeaders bool,
+		rewrites []spec.RewriteRule,
 	) Builder
 
 	// Build builds the reverse proxy executor.
@@ -71,6 +75,8 @@ type reverseProxyBuilder struct {
 	preserveHost       bool
 	disableQueryParams bool
 	xForwardedHeaders  bool
+	rewrites           []rewriteRule
+	rewritesErr        error
 }
 
 func NewBuilder() Builder {
@@ -117,11 +123,13 @@ func (b *reverseProxyBuilder) ProxyRewrite(
 	preserveHost bool,
 	disableQueryParams bool,
 	xForwardedHeaders bool,
+	rewrites []spec.RewriteRule,
 ) Builder {
 	b.stripPath = stripPath
 	b.preserveHost = preserveHost
 	b.disableQueryParams = disableQueryParams
 	b.xForwardedHeaders = xForwardedHeaders
+	b.rewrites, b.rewritesErr = compileRewriteRules(rewrites)
 	return b
 }
 
@@ -222,6 +230,9 @@ func (b *reverseProxyBuilder) Build(upstreamUrl *url.URL, proxyPattern string) (
 	if upstreamUrl == nil {
 		return nil, ErrNilUpstreamUrl
 	}
+	if b.rewritesErr != nil {
+		return nil, b.rewritesErr
+	}
 	b.upstreamUrl = upstreamUrl
 
 	if proxyPattern == "" {
@@ -249,10 +260,15 @@ func (b *reverseProxyBuilder) Build(upstreamUrl *url.URL, proxyPattern string) (
 	proxy.Transport = b.transport
 	proxy.ErrorLog = b.errorLogger
 	proxy.Rewrite = func(pr *httputil.ProxyRequest) {
+		// the strip path rewrite replaces the url of the incoming request
+		reqUrl := pr.In.URL
 		b.rewriteStripPath(b.stripPath)(pr.In, pr.Out)
 		b.rewritePreserveHost(b.preserveHost)(pr.In, pr.Out)
 		b.rewriteDisableQueryParams(b.disableQueryParams)(pr.In, pr.Out)
 		b.rewriteXForwardedHeaders(b.xForwardedHeaders)(pr.In, pr.Out)
+		if len(b.rewrites) > 0 {
+			b.rewriteRules(reqUrl, pr.In, pr.Out)
+		}
 		if b.customRewrite != nil {
 			b.customRewrite(pr.In, pr.Out)
 		}
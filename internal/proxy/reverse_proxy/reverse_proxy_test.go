@@ -11,6 +11,7 @@ import (
 
 	"github.com/dgate-io/dgate/internal/proxy/proxytest"
 	"github.com/dgate-io/dgate/internal/proxy/reverse_proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -31,6 +32,8 @@ type RewriteParams struct {
 	preserveHost       bool
 	disableQueryParams bool
 	xForwardedHeaders  bool
+	rewrites           []spec.RewriteRule
+	pathParams         map[string]string
 }
 
 func testDGateProxyRewrite(
@@ -56,6 +59,7 @@ func testDGateProxyRewrite(
 			rewriteParams.preserveHost,
 			rewriteParams.disableQueryParams,
 			rewriteParams.xForwardedHeaders,
+			rewriteParams.rewrites,
 		).Build(params.upstreamUrl, params.proxyPattern)
 	if err != nil {
 		t.Fatal(err)
@@ -72,6 +76,10 @@ func testDGateProxyRewrite(
 	mockRw.On("Write", mock.Anything).Return(0, nil)
 	req = req.WithContext(context.WithValue(
 		context.Background(), proxytest.S("testing"), "testing"))
+	if rewriteParams.pathParams != nil {
+		req = req.WithContext(reverse_proxy.WithPathParams(
+			req.Context(), rewriteParams.pathParams))
+	}
 
 	mockTp.On("RoundTrip", mock.Anything).Run(func(args mock.Arguments) {
 		req := args.Get(0).(*http.Request)
@@ -155,7 +163,7 @@ func TestDGateProxyError(t *testing.T) {
 		Transport(mockTp).
 		ProxyRewrite(
 			true, true,
-			true, true,
+			true, true, nil,
 		).Build(upstreamUrl, "/test/*")
 	if err != nil {
 		t.Fatal(err)
@@ -320,3 +328,101 @@ func TestReverseProxy_SameClone(t *testing.T) {
 	builder2.CustomRewrite(nil)
 	assert.Equal(t, builder1, builder2)
 }
+
+func TestDGateProxyRewriteRules(t *testing.T) {
+	// path parameters and query parameters
+	testDGateProxyRewrite(t, ProxyParams{
+		upstreamUrl:   mustParseURL(t, "http://example.com/api"),
+		newUpsteamURL: mustParseURL(t, "http://example.com/api/internal/user?id=1&format=json&fmt=json"),
+
+		host:    "test.net",
+		newHost: "example.com",
+
+		proxyPattern: "/v1/users/{id}",
+		proxyPath:    "/v1/users/1?fmt=json",
+	}, RewriteParams{
+		rewrites: []spec.RewriteRule{{
+			Path: "/internal/user?id={id}&format={query.fmt}",
+		}},
+		pathParams: map[string]string{"id": "1"},
+	})
+
+	// regex capture groups, only the first matching rule is applied
+	testDGateProxyRewrite(t, ProxyParams{
+		upstreamUrl:   mustParseURL(t, "http://example.com"),
+		newUpsteamURL: mustParseURL(t, "http://example.com/files/2024/report.pdf"),
+
+		host:    "test.net",
+		newHost: "files.example.com",
+
+		proxyPattern: "/docs/*",
+		proxyPath:    "/docs/report-2024.pdf",
+	}, RewriteParams{
+		rewrites: []spec.RewriteRule{
+			{Regex: `^/other/(.*)$`, Path: "/other/{1}"},
+			{
+				Regex: `^/docs/(?P<name>\w+)-(\d+)\.pdf$`,
+				Path:  "/files/{2}/{name}.pdf",
+				Host:  "files.example.com",
+			},
+		},
+	})
+
+	// rules that do not match leave the request as is
+	testDGateProxyRewrite(t, ProxyParams{
+		upstreamUrl:   mustParseURL(t, "http://example.com"),
+		newUpsteamURL: mustParseURL(t, "http://example.com/test"),
+
+		host:    "test.net",
+		newHost: "example.com",
+
+		proxyPattern: "/test",
+		proxyPath:    "/test",
+	}, RewriteParams{
+		rewrites: []spec.RewriteRule{{Regex: `^/other$`, Path: "/other"}},
+	})
+}
+
+func TestDGateProxyRewriteRulesDotSegments(t *testing.T) {
+	// values cannot move the path above the segment they are used in
+	for id, path := range map[string]string{
+		"..":             "/svc/v1",
+		".":              "/svc/v1",
+		"../../admin":    "/svc/v1/admin",
+		"a/../../../etc": "/svc/v1/etc",
+		"a/b":            "/svc/v1/a/b",
+	} {
+		testDGateProxyRewrite(t, ProxyParams{
+			upstreamUrl:   mustParseURL(t, "http://example.com/svc"),
+			newUpsteamURL: mustParseURL(t, "http://example.com"+path),
+
+			host:    "test.net",
+			newHost: "example.com",
+
+			proxyPattern: "/users/{id}",
+			proxyPath:    "/users/1",
+		}, RewriteParams{
+			rewrites:   []spec.RewriteRule{{Path: "/v1/{id}"}},
+			pathParams: map[string]string{"id": id},
+		})
+	}
+}
+
+func TestDGateProxyRewriteRulesInvalid(t *testing.T) {
+	upstreamUrl, _ := url.Parse("http://example.com")
+	_, err := reverse_proxy.NewBuilder().
+		ProxyRewrite(false, false, false, false, []spec.RewriteRule{
+			{Path: "/test/{2}", Regex: `^/(.*)$`},
+		}).Build(upstreamUrl, "/test")
+	assert.NotNil(t, err)
+
+	// the spec validation and the proxy use the same template parser
+	for _, tpl := range []string{"/{id", "/id}", "/{a{b}}", "/{}"} {
+		rules := []spec.RewriteRule{{Path: tpl}}
+		assert.Error(t, spec.ValidateRewriteRules(rules), tpl)
+		_, err := reverse_proxy.NewBuilder().
+			ProxyRewrite(false, false, false, false, rules).
+			Build(upstreamUrl, "/test")
+		assert.Error(t, err, tpl)
+	}
+}
@@ -0,0 +1,190 @@
+package reverse_proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+type pathParamsCtxKey struct{}
+
+// WithPathParams adds the path parameters of the route to the context, so they can be used by rewrite rules.
+func WithPathParams(ctx context.Context, params map[string]string) context.Context {
+	return context.WithValue(ctx, pathParamsCtxKey{}, params)
+}
+
+func pathParamsFromContext(ctx context.Context) map[string]string {
+	params, _ := ctx.Value(pathParamsCtxKey{}).(map[string]string)
+	return params
+}
+
+type rewriteRule struct {
+	regex *regexp.Regexp
+	path  template
+	query template
+	host  template
+}
+
+type varKind int
+
+const (
+	varLiteral varKind = iota
+	varPathParam
+	varQueryParam
+	varCapture
+)
+
+type templatePart struct {
+	kind  varKind
+	value string
+	index int
+}
+
+// template is a parsed rewrite template, nil templates are not set.
+type template []templatePart
+
+// compileRewriteRules parses the rewrite rules once, so
+// only the templates are rendered for each request.
+func compileRewriteRules(rules []spec.RewriteRule) ([]rewriteRule, error) {
+	compiled := make([]rewriteRule, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		rr := rewriteRule{}
+		if rule.Regex != "" {
+			rr.regex = regexp.MustCompile(rule.Regex)
+		}
+		var err error
+		if rule.Path != "" {
+			pathTpl, queryTpl, _ := strings.Cut(rule.Path, "?")
+			if rr.path, err = parseTemplate(pathTpl, rr.regex); err != nil {
+				return nil, err
+			}
+			if rr.query, err = parseTemplate(queryTpl, rr.regex); err != nil {
+				return nil, err
+			}
+		}
+		if rule.Host != "" {
+			if rr.host, err = parseTemplate(rule.Host, rr.regex); err != nil {
+				return nil, err
+			}
+		}
+		compiled[i] = rr
+	}
+	return compiled, nil
+}
+
+func parseTemplate(tpl string, regex *regexp.Regexp) (template, error) {
+	if tpl == "" {
+		return nil, nil
+	}
+	tplParts, err := spec.ParseRewriteTemplate(tpl)
+	if err != nil {
+		return nil, err
+	}
+	parts := make(template, 0, len(tplParts))
+	for _, tplPart := range tplParts {
+		if !tplPart.Variable {
+			parts = append(parts, templatePart{kind: varLiteral, value: tplPart.Value})
+			continue
+		}
+		part, err := parseVar(tplPart.Value, regex)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+func parseVar(name string, regex *regexp.Regexp) (templatePart, error) {
+	if query, ok := strings.CutPrefix(name, "query."); ok {
+		return templatePart{kind: varQueryParam, value: query}, nil
+	}
+	if index, err := strconv.Atoi(name); err == nil {
+		if regex == nil || index < 0 || index > regex.NumSubexp() {
+			return templatePart{}, errors.New("rewrite template references missing capture group: " + name)
+		}
+		return templatePart{kind: varCapture, index: index}, nil
+	}
+	if regex != nil {
+		if index := regex.SubexpIndex(name); index >= 0 {
+			return templatePart{kind: varCapture, index: index}, nil
+		}
+	}
+	return templatePart{kind: varPathParam, value: name}, nil
+}
+
+// render builds the template, the values of variables are passed to escape when it is set.
+func (t template) render(params map[string]string, query url.Values, captures []string, escape func(string) string) string {
+	var sb strings.Builder
+	for _, part := range t {
+		var value string
+		switch part.kind {
+		case varLiteral:
+			sb.WriteString(part.value)
+			continue
+		case varPathParam:
+			value = params[part.value]
+		case varQueryParam:
+			value = query.Get(part.value)
+		case varCapture:
+			if part.index < len(captures) {
+				value = captures[part.index]
+			}
+		}
+		if escape != nil {
+			value = escape(value)
+		}
+		sb.WriteString(value)
+	}
+	return sb.String()
+}
+
+// cleanPathValue removes the dot segments of a value used in the path, so a value
+// (e.g. an {id} of "..") cannot move the path above the segment it is used in.
+func cleanPathValue(value string) string {
+	if value == "" {
+		return value
+	}
+	return strings.TrimPrefix(path.Clean("/"+value), "/")
+}
+
+// rewriteRules applies the first rule matching the request path to the upstream request,
+// the rewritten path is joined to the path of the upstream url like any other path.
+func (b *reverseProxyBuilder) rewriteRules(reqUrl *url.URL, in, out *http.Request) {
+	for _, rule := range b.rewrites {
+		var captures []string
+		if rule.regex != nil {
+			if captures = rule.regex.FindStringSubmatch(reqUrl.Path); captures == nil {
+				continue
+			}
+		}
+		params := pathParamsFromContext(in.Context())
+		query := reqUrl.Query()
+		if rule.path != nil {
+			out.URL.Path = path.Join(b.upstreamUrl.Path, rule.path.render(params, query, captures, cleanPathValue))
+			out.URL.RawPath = ""
+		}
+		if rule.query != nil {
+			rewriteQuery := rule.query.render(params, query, captures, url.QueryEscape)
+			if out.URL.RawQuery == "" {
+				out.URL.RawQuery = rewriteQuery
+			} else {
+				out.URL.RawQuery = rewriteQuery + "&" + out.URL.RawQuery
+			}
+		}
+		if rule.host != nil {
+			out.Host = rule.host.render(params, query, captures, nil)
+		}
+		return
+	}
+}
@@ -0,0 +1,132 @@
+package route_match
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+// Matcher checks if a request matches the header, query and cookie conditions of a route.
+type Matcher struct {
+	headers []valueMatcher
+	query   []valueMatcher
+	cookies []valueMatcher
+}
+
+type valueMatcher struct {
+	name   string
+	exact  string
+	prefix string
+	regex  *regexp.Regexp
+}
+
+// New compiles the route match, a nil route match returns a nil matcher which matches any request.
+func New(rm *spec.RouteMatch) (*Matcher, error) {
+	if rm == nil {
+		return nil, nil
+	}
+	if err := rm.Validate(); err != nil {
+		return nil, err
+	}
+	m := &Matcher{
+		headers: make([]valueMatcher, len(rm.Headers)),
+		query:   make([]valueMatcher, len(rm.Query)),
+		cookies: make([]valueMatcher, len(rm.Cookies)),
+	}
+	for _, group := range []struct {
+		in  []spec.ValueMatch
+		out []valueMatcher
+	}{
+		{rm.Headers, m.headers},
+		{rm.Query, m.query},
+		{rm.Cookies, m.cookies},
+	} {
+		for i, vm := range group.in {
+			group.out[i] = valueMatcher{
+				name:   vm.Name,
+				exact:  vm.Exact,
+				prefix: vm.Prefix,
+			}
+			if vm.Regex != "" {
+				group.out[i].regex = regexp.MustCompile(vm.Regex)
+			}
+		}
+	}
+	return m, nil
+}
+
+// Matches checks if the request matches all conditions.
+func (m *Matcher) Matches(req *http.Request) bool {
+	if m == nil {
+		return true
+	}
+	for _, vm := range m.headers {
+		if !vm.matchesAny(req.Header.Values(vm.name)) {
+			return false
+		}
+	}
+	if len(m.query) > 0 {
+		query := req.URL.Query()
+		for _, vm := range m.query {
+			if !vm.matchesAny(query[vm.name]) {
+				return false
+			}
+		}
+	}
+	for _, vm := range m.cookies {
+		cookie, err := req.Cookie(vm.name)
+		if err != nil || !vm.matches(cookie.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Specificity scores how specific the conditions are, so the most
+// specific of the routes matching a request can be picked.
+// Exact matches score higher than prefix and regex matches,
+// and conditions that only check for a value score the lowest.
+func (m *Matcher) Specificity() int {
+	if m == nil {
+		return 0
+	}
+	score := 0
+	for _, group := range [][]valueMatcher{m.headers, m.query, m.cookies} {
+		for _, vm := range group {
+			switch {
+			case vm.exact != "":
+				score += 4
+			case vm.prefix != "":
+				score += 3
+			case vm.regex != nil:
+				score += 2
+			default:
+				score += 1
+			}
+		}
+	}
+	return score
+}
+
+func (vm *valueMatcher) matchesAny(values []string) bool {
+	for _, v := range values {
+		if vm.matches(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (vm *valueMatcher) matches(value string) bool {
+	switch {
+	case vm.exact != "":
+		return value == vm.exact
+	case vm.prefix != "":
+		return strings.HasPrefix(value, vm.prefix)
+	case vm.regex != nil:
+		return vm.regex.MatchString(value)
+	}
+	return true
+}
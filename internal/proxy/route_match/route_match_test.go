@@ -0,0 +1,95 @@
+package route_match_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy/route_match"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_Nil(t *testing.T) {
+	m, err := route_match.New(nil)
+	assert.Nil(t, err)
+	assert.True(t, m.Matches(httptest.NewRequest(http.MethodGet, "/", nil)))
+	assert.Equal(t, 0, m.Specificity())
+}
+
+func TestMatcher_Matches(t *testing.T) {
+	m, err := route_match.New(&spec.RouteMatch{
+		Headers: []spec.ValueMatch{{Name: "X-Api-Version", Exact: "2"}},
+		Query:   []spec.ValueMatch{{Name: "debug"}},
+		Cookies: []spec.ValueMatch{{Name: "session", Regex: "^[a-f0-9]+$"}},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/?debug", nil)
+	req.Header.Set("X-Api-Version", "2")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+	assert.True(t, m.Matches(req))
+
+	req.Header.Set("X-Api-Version", "1")
+	assert.False(t, m.Matches(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Version", "2")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc123"})
+	assert.False(t, m.Matches(req), "query parameter is missing")
+
+	req = httptest.NewRequest(http.MethodGet, "/?debug=1", nil)
+	req.Header.Set("X-Api-Version", "2")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "xyz"})
+	assert.False(t, m.Matches(req), "cookie does not match regex")
+}
+
+func TestMatcher_Prefix(t *testing.T) {
+	m, err := route_match.New(&spec.RouteMatch{
+		Headers: []spec.ValueMatch{{Name: "User-Agent", Prefix: "curl/"}},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	assert.True(t, m.Matches(req))
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	assert.False(t, m.Matches(req))
+}
+
+func TestMatcher_Specificity(t *testing.T) {
+	exact, _ := route_match.New(&spec.RouteMatch{
+		Headers: []spec.ValueMatch{{Name: "a", Exact: "1"}},
+	})
+	prefix, _ := route_match.New(&spec.RouteMatch{
+		Headers: []spec.ValueMatch{{Name: "a", Prefix: "1"}},
+	})
+	present, _ := route_match.New(&spec.RouteMatch{
+		Headers: []spec.ValueMatch{{Name: "a"}},
+	})
+	both, _ := route_match.New(&spec.RouteMatch{
+		Headers: []spec.ValueMatch{{Name: "a"}},
+		Query:   []spec.ValueMatch{{Name: "b", Regex: "."}},
+	})
+	assert.Greater(t, exact.Specificity(), prefix.Specificity())
+	assert.Greater(t, prefix.Specificity(), present.Specificity())
+	assert.Greater(t, both.Specificity(), present.Specificity())
+}
+
+func TestMatcher_Invalid(t *testing.T) {
+	_, err := route_match.New(&spec.RouteMatch{
+		Headers: []spec.ValueMatch{{Name: "a", Exact: "1", Prefix: "1"}},
+	})
+	assert.NotNil(t, err)
+	_, err = route_match.New(&spec.RouteMatch{
+		Query: []spec.ValueMatch{{Name: "a", Regex: "("}},
+	})
+	assert.NotNil(t, err)
+	_, err = route_match.New(&spec.RouteMatch{
+		Cookies: []spec.ValueMatch{{Exact: "1"}},
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,86 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSetupRoutes_Match(t *testing.T) {
+	conf := configtest.NewTestDGateConfig()
+	resources := conf.ProxyConfig.InitResources
+	resources.Services = nil
+	for _, name := range []string{"v1", "v2", "beta"} {
+		name := name
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+		defer server.Close()
+		resources.Services = append(resources.Services, spec.Service{
+			Name: name, URLs: []string{server.URL}, NamespaceName: "test",
+		})
+	}
+	resources.Modules = nil
+	resources.Routes = []spec.Route{
+		{
+			Name:          "v1",
+			Paths:         []string{"/test"},
+			Methods:       []string{"*"},
+			ServiceName:   "v1",
+			NamespaceName: "test",
+		},
+		{
+			Name:          "v2",
+			Paths:         []string{"/test"},
+			Methods:       []string{"GET"},
+			ServiceName:   "v2",
+			NamespaceName: "test",
+			Match: &spec.RouteMatch{
+				Headers: []spec.ValueMatch{{Name: "X-Api-Version", Exact: "2"}},
+			},
+		},
+		{
+			Name:          "beta",
+			Paths:         []string{"/test"},
+			Methods:       []string{"GET"},
+			ServiceName:   "beta",
+			NamespaceName: "test",
+			Priority:      1,
+			Match: &spec.RouteMatch{
+				Cookies: []spec.ValueMatch{{Name: "beta"}},
+			},
+		},
+	}
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	if err := ps.ProcessChangeLog(spec.NewNoopChangeLog(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(method string, fn func(*http.Request)) string {
+		req := httptest.NewRequest(method, "http://localhost/test", nil)
+		if fn != nil {
+			fn(req)
+		}
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		return wr.Body.String()
+	}
+	assert.Equal(t, "v1", serve(http.MethodGet, nil))
+	assert.Equal(t, "v1", serve(http.MethodPost, func(r *http.Request) {
+		r.Header.Set("X-Api-Version", "2")
+	}))
+	assert.Equal(t, "v2", serve(http.MethodGet, func(r *http.Request) {
+		r.Header.Set("X-Api-Version", "2")
+	}))
+	// higher priority routes are matched first
+	assert.Equal(t, "beta", serve(http.MethodGet, func(r *http.Request) {
+		r.Header.Set("X-Api-Version", "2")
+		r.AddCookie(&http.Cookie{Name: "beta", Value: "1"})
+	}))
+}
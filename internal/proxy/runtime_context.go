@@ -13,16 +13,21 @@ import (
 	"github.com/dgate-io/dgate/pkg/typescript"
 	"github.com/dop251/goja"
 	"github.com/dop251/goja_nodejs/require"
+	"go.uber.org/zap"
 )
 
 // RuntimeContext is the context for the runtime. one per request
 type runtimeContext struct {
-	reqCtx  *RequestContext
+	reqCtx *RequestContext
+	// ctx is used when the runtime is not used by a request
+	ctx     context.Context
 	loop    *eventloop.EventLoop
 	state   modules.StateManager
 	rm      *resources.ResourceManager
 	route   *spec.Route
 	modules []*spec.Module
+	// audit logs the permissions denied to the modules
+	audit *zap.Logger
 }
 
 var _ modules.RuntimeContext = &runtimeContext{}
@@ -33,10 +38,19 @@ func NewRuntimeContext(
 	modules ...*spec.DGateModule,
 ) *runtimeContext {
 	rtCtx := &runtimeContext{
+		ctx:     context.Background(),
 		state:   proxyState,
 		rm:      proxyState.ResourceManager(),
 		modules: spec.TransformDGateModules(modules...),
-		route:   spec.TransformDGateRoute(route),
+		audit:   proxyState.logger.Named("audit"),
+	}
+	// the runtimes of module jobs do not have a route,
+	// so they use the namespace of the module
+	if route != nil {
+		rtCtx.route = spec.TransformDGateRoute(route)
+	} else if len(modules) > 0 && modules[0].Namespace != nil {
+		rtCtx.ctx = context.WithValue(rtCtx.ctx,
+			spec.Name("namespace"), modules[0].Namespace.Name)
 	}
 
 	reg := require.NewRegistryWithLoader(func(path string) ([]byte, error) {
@@ -51,17 +65,19 @@ func NewRuntimeContext(
 		} else {
 			if mod.Type == spec.ModuleTypeJavascript {
 				return []byte(mod.Payload), nil
+			} else if mod.Type == spec.ModuleTypeWasm || mod.Type == spec.ModuleTypeNative {
+				return nil, errors.New(requireMod + " is a " + mod.Type.String() + " module and cannot be required")
 			}
 			var err error
 			var key string
 			transpileBucket := proxyState.sharedCache.Bucket("ts-transpile")
-			if key, err = HashString(0, mod.Payload); err == nil {
+			if key, err = HashString(0, mod.Name, mod.Payload); err == nil {
 				if code, ok := transpileBucket.Get(key); ok {
 					return code.([]byte), nil
 				}
 			}
-			payload, err := typescript.Transpile(
-				context.TODO(), mod.Payload)
+			payload, err := typescript.TranspileModule(
+				context.TODO(), mod.Name, mod.Payload)
 			if err != nil {
 				return nil, err
 			}
@@ -95,6 +111,9 @@ func (rtCtx *runtimeContext) Clean() {
 }
 
 func (rtCtx *runtimeContext) Context() context.Context {
+	if rtCtx.reqCtx == nil {
+		return rtCtx.ctx
+	}
 	return rtCtx.reqCtx.ctx
 }
 
@@ -109,3 +128,63 @@ func (rtCtx *runtimeContext) Runtime() *goja.Runtime {
 func (rtCtx *runtimeContext) State() modules.StateManager {
 	return rtCtx.state
 }
+
+// callerModule returns the module of the script calling into the runtime context,
+// it returns nil when the module can not be found from the call stack.
+func (rtCtx *runtimeContext) callerModule() *spec.Module {
+	if rtCtx.loop == nil {
+		return nil
+	}
+	// the programs of the modules are compiled with the module name
+	for _, frame := range rtCtx.Runtime().CaptureCallStack(0, nil) {
+		name := strings.TrimPrefix(frame.SrcName(), "node_modules/")
+		for _, mod := range rtCtx.modules {
+			if mod.Name == name {
+				return mod
+			}
+		}
+	}
+	return nil
+}
+
+func (rtCtx *runtimeContext) HasPermission(permission string) bool {
+	_, ok := rtCtx.permissionModule(permission)
+	return ok
+}
+
+// permissionModule returns the module the permission is checked for, when the caller is not
+// known the permission must be granted to all the modules of the runtime context.
+func (rtCtx *runtimeContext) permissionModule(permission string) (*spec.Module, bool) {
+	if mod := rtCtx.callerModule(); mod != nil {
+		return mod, spec.ModulePermissionGranted(mod.Permissions, permission)
+	}
+	for _, mod := range rtCtx.modules {
+		if !spec.ModulePermissionGranted(mod.Permissions, permission) {
+			return mod, false
+		}
+	}
+	return nil, true
+}
+
+func (rtCtx *runtimeContext) CheckPermission(permission string) error {
+	mod, ok := rtCtx.permissionModule(permission)
+	if ok {
+		return nil
+	}
+	event := rtCtx.audit.With(
+		zap.String("module", mod.Name),
+		zap.String("namespace", mod.NamespaceName),
+		zap.String("permission", permission),
+	)
+	// runtimes are shared by the routes that use the same modules
+	if rtCtx.reqCtx != nil && rtCtx.reqCtx.route != nil {
+		event = event.With(zap.String("route", rtCtx.reqCtx.route.Name))
+	} else if rtCtx.route != nil {
+		event = event.With(zap.String("route", rtCtx.route.Name))
+	}
+	event.Warn("module permission denied")
+	return &modules.PermissionError{
+		Module:     mod.Name,
+		Permission: permission,
+	}
+}
@@ -0,0 +1,124 @@
+package secret_keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// prefix marks encrypted data, it is followed by the key id and the
+// base64 encoded nonce and ciphertext: dgate:enc:v1:<key id>:<data>
+const prefix = "dgate:enc:v1:"
+
+var ErrUnknownKey = errors.New("secret is encrypted with an unknown key")
+
+// Keyring encrypts data with the current key and decrypts data
+// that is encrypted with the current key or one of the previous keys.
+type Keyring struct {
+	current *key
+	keys    map[string]*key
+}
+
+type key struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// New returns a keyring, the keys must be 32 bytes (AES-256).
+func New(current []byte, previous ...[]byte) (*Keyring, error) {
+	kr := &Keyring{keys: make(map[string]*key)}
+	for i, k := range append([][]byte{current}, previous...) {
+		if len(k) != 32 {
+			return nil, errors.New("secret key must be 32 bytes")
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		kk := &key{id: KeyID(k), aead: aead}
+		if i == 0 {
+			kr.current = kk
+		}
+		if _, ok := kr.keys[kk.id]; !ok {
+			kr.keys[kk.id] = kk
+		}
+	}
+	return kr, nil
+}
+
+// KeyID returns a short identifier of the key, it does not reveal the key.
+func KeyID(k []byte) string {
+	sum := sha256.Sum256(k)
+	return hex.EncodeToString(sum[:8])
+}
+
+// CurrentKeyID returns the id of the key used for encryption.
+func (kr *Keyring) CurrentKeyID() string {
+	return kr.current.id
+}
+
+// Encrypt encrypts the plaintext with the current key, the
+// additional data is authenticated but not encrypted.
+func (kr *Keyring) Encrypt(plaintext, additionalData []byte) (string, error) {
+	nonce := make([]byte, kr.current.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := kr.current.aead.Seal(nonce, nonce, plaintext, additionalData)
+	return prefix + kr.current.id + ":" +
+		base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts data that was returned by Encrypt.
+func (kr *Keyring) Decrypt(data string, additionalData []byte) ([]byte, error) {
+	keyID, encoded, ok := splitEncrypted(data)
+	if !ok {
+		return nil, errors.New("secret is not encrypted")
+	}
+	k, ok := kr.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := k.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("secret ciphertext is too short")
+	}
+	plaintext, err := k.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], additionalData)
+	if err != nil {
+		return nil, errors.New("error decrypting secret: " + err.Error())
+	}
+	return plaintext, nil
+}
+
+// NeedsRotation returns true if the data is not encrypted
+// or is encrypted with a key that is not the current key.
+func (kr *Keyring) NeedsRotation(data string) bool {
+	keyID, _, ok := splitEncrypted(data)
+	return !ok || keyID != kr.current.id
+}
+
+// IsEncrypted returns true if the data was returned by Encrypt.
+func IsEncrypted(data string) bool {
+	_, _, ok := splitEncrypted(data)
+	return ok
+}
+
+func splitEncrypted(data string) (keyID, encoded string, ok bool) {
+	if !strings.HasPrefix(data, prefix) {
+		return "", "", false
+	}
+	return strings.Cut(data[len(prefix):], ":")
+}
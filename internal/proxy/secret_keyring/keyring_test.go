@@ -0,0 +1,71 @@
+package secret_keyring_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy/secret_keyring"
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestKeyring_EncryptDecrypt(t *testing.T) {
+	kr, err := secret_keyring.New(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := kr.Encrypt([]byte("hunter2"), []byte("ns/name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, secret_keyring.IsEncrypted(data))
+	assert.NotContains(t, data, "hunter2")
+	assert.False(t, kr.NeedsRotation(data))
+
+	plaintext, err := kr.Decrypt(data, []byte("ns/name"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hunter2", string(plaintext))
+	}
+	// the ciphertext is bound to the additional data
+	_, err = kr.Decrypt(data, []byte("ns/other"))
+	assert.Error(t, err)
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKr, err := secret_keyring.New(testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := oldKr.Encrypt([]byte("hunter2"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kr, err := secret_keyring.New(testKey(2), testKey(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, kr.NeedsRotation(data))
+	assert.True(t, kr.NeedsRotation("aHVudGVyMg"))
+	plaintext, err := kr.Decrypt(data, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hunter2", string(plaintext))
+	}
+
+	newKr, err := secret_keyring.New(testKey(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newKr.Decrypt(data, nil)
+	assert.ErrorIs(t, err, secret_keyring.ErrUnknownKey)
+}
+
+func TestKeyring_InvalidKey(t *testing.T) {
+	_, err := secret_keyring.New([]byte("short"))
+	assert.Error(t, err)
+	_, err = secret_keyring.New(testKey(1), []byte("short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,31 @@
+;; filter.wasm is assembled from this module, it uses the host functions of dgate.
+(module
+  (import "dgate" "request_get_header" (func $request_get_header (param i32 i32 i32 i32) (result i32)))
+  (import "dgate" "request_set_header" (func $request_set_header (param i32 i32 i32 i32) (result i32)))
+  (import "dgate" "upstream_set_header" (func $upstream_set_header (param i32 i32 i32 i32) (result i32)))
+  (import "dgate" "response_set_status" (func $response_set_status (param i32) (result i32)))
+  (import "dgate" "response_write" (func $response_write (param i32 i32) (result i32)))
+  (memory (export "memory") 1)
+  (data (i32.const 0) "X-Api-Key")
+  (data (i32.const 16) "unauthorized")
+  (data (i32.const 32) "X-Wasm")
+  (data (i32.const 48) "true")
+  (data (i32.const 64) "X-Wasm-Response")
+  (data (i32.const 80) "ok")
+
+  ;; requests without an X-Api-Key header are rejected,
+  ;; other requests are sent with the X-Wasm header.
+  (func (export "requestModifier") (result i32)
+    (if (i32.lt_s
+          (call $request_get_header (i32.const 0) (i32.const 9) (i32.const 1024) (i32.const 64))
+          (i32.const 0))
+      (then
+        (drop (call $response_set_status (i32.const 401)))
+        (drop (call $response_write (i32.const 16) (i32.const 12)))
+        (return (i32.const 0))))
+    (drop (call $request_set_header (i32.const 32) (i32.const 6) (i32.const 48) (i32.const 4)))
+    (i32.const 0))
+
+  (func (export "responseModifier") (result i32)
+    (drop (call $upstream_set_header (i32.const 64) (i32.const 15) (i32.const 80) (i32.const 2)))
+    (i32.const 0)))
@@ -0,0 +1,6 @@
+;; spin.wasm is assembled from this module, requestModifier never returns.
+(module
+  (memory (export "memory") 1)
+  (func (export "requestModifier") (result i32)
+    (loop $spin (br $spin))
+    (i32.const 0)))
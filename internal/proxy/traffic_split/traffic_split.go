@@ -0,0 +1,96 @@
+package traffic_split
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+// Splitter picks which service of a traffic split a request is sent to.
+type Splitter struct {
+	weights   []int
+	total     int
+	sticky    *spec.StickySession
+	overrides []override
+}
+
+type override struct {
+	header string
+	value  string
+	index  int
+}
+
+func New(split *spec.DGateTrafficSplit) *Splitter {
+	s := &Splitter{
+		weights: make([]int, len(split.Services)),
+		sticky:  split.Sticky,
+	}
+	indexes := make(map[string]int, len(split.Services))
+	for i, ws := range split.Services {
+		s.weights[i] = max(ws.Weight, 0)
+		s.total += s.weights[i]
+		indexes[ws.Service.Name] = i
+	}
+	for _, o := range split.Overrides {
+		if i, ok := indexes[o.ServiceName]; ok {
+			s.overrides = append(s.overrides, override{
+				header: o.Header,
+				value:  o.Value,
+				index:  i,
+			})
+		}
+	}
+	return s
+}
+
+// Pick returns the index of the service the request is sent to. Overrides are
+// checked first, then sticky requests are hashed into the weights so the same
+// key always goes to the same service, other requests are picked at random.
+func (s *Splitter) Pick(req *http.Request) int {
+	for _, o := range s.overrides {
+		if values, ok := req.Header[http.CanonicalHeaderKey(o.header)]; ok {
+			if o.value == "" {
+				return o.index
+			}
+			for _, v := range values {
+				if v == o.value {
+					return o.index
+				}
+			}
+		}
+	}
+	if s.total <= 0 {
+		return 0
+	}
+	if key := s.stickyKey(req); key != "" {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		return s.bucket(int(h.Sum32() % uint32(s.total)))
+	}
+	return s.bucket(rand.Intn(s.total))
+}
+
+func (s *Splitter) stickyKey(req *http.Request) string {
+	if s.sticky == nil {
+		return ""
+	}
+	if s.sticky.Header != "" {
+		return req.Header.Get(s.sticky.Header)
+	}
+	if cookie, err := req.Cookie(s.sticky.Cookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func (s *Splitter) bucket(n int) int {
+	for i, w := range s.weights {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	return len(s.weights) - 1
+}
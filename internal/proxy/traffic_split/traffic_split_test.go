@@ -0,0 +1,74 @@
+package traffic_split_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/proxy/traffic_split"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSplit(weights ...int) *spec.DGateTrafficSplit {
+	split := &spec.DGateTrafficSplit{}
+	for i, w := range weights {
+		split.Services = append(split.Services, &spec.DGateWeightedService{
+			Service: &spec.DGateService{Name: "svc" + strconv.Itoa(i)},
+			Weight:  w,
+		})
+	}
+	return split
+}
+
+func TestTrafficSplit_Weights(t *testing.T) {
+	s := traffic_split.New(testSplit(90, 10, 0))
+	counts := make([]int, 3)
+	for i := 0; i < 2000; i++ {
+		counts[s.Pick(httptest.NewRequest(http.MethodGet, "/", nil))]++
+	}
+	assert.Greater(t, counts[0], counts[1])
+	assert.Greater(t, counts[1], 0)
+	assert.Equal(t, 0, counts[2])
+}
+
+func TestTrafficSplit_Sticky(t *testing.T) {
+	split := testSplit(50, 50)
+	split.Sticky = &spec.StickySession{Cookie: "session"}
+	s := traffic_split.New(split)
+	seen := make(map[int]struct{})
+	for _, user := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: user})
+		expected := s.Pick(req)
+		seen[expected] = struct{}{}
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, expected, s.Pick(req))
+		}
+	}
+	assert.Equal(t, 2, len(seen))
+
+	split.Sticky = &spec.StickySession{Header: "X-User-Id"}
+	s = traffic_split.New(split)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User-Id", "user-1")
+	expected := s.Pick(req)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, expected, s.Pick(req))
+	}
+}
+
+func TestTrafficSplit_Overrides(t *testing.T) {
+	split := testSplit(100, 0)
+	split.Overrides = []spec.SplitOverride{
+		{Header: "X-Canary", Value: "true", ServiceName: "svc1"},
+	}
+	s := traffic_split.New(split)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, 0, s.Pick(req))
+	req.Header.Set("X-Canary", "false")
+	assert.Equal(t, 0, s.Pick(req))
+	req.Header.Set("X-Canary", "true")
+	assert.Equal(t, 1, s.Pick(req))
+}
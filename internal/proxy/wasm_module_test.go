@@ -0,0 +1,97 @@
+package proxy_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/config"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func wasmModuleSpec(name, file string) config.ModuleSpec {
+	return config.ModuleSpec{
+		Module: spec.Module{
+			Name:          name,
+			NamespaceName: "test",
+			Type:          spec.ModuleTypeWasm,
+		},
+		PayloadFile: "testdata/wasm/" + file,
+	}
+}
+
+func TestProxyHandler_WasmModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Wasm")))
+	}))
+	defer server.Close()
+
+	// wasm modules can be chained with javascript modules
+	for _, conf := range []*config.DGateConfig{
+		chainConfig(server.URL, wasmModuleSpec("filter", "filter.wasm")),
+		chainConfig(server.URL,
+			wasmModuleSpec("filter", "filter.wasm"),
+			chainModuleSpec("transform", transformModuleJS, spec.ModuleTypeJavascript),
+		),
+	} {
+		ps := proxy.NewProxyState(zap.NewNop(), conf)
+		require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		assert.Equal(t, http.StatusUnauthorized, wr.Code)
+		assert.Equal(t, "unauthorized", wr.Body.String())
+
+		req = httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		req.Header.Set("X-Api-Key", "key")
+		wr = httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		assert.Equal(t, http.StatusOK, wr.Code)
+		assert.Equal(t, "true", wr.Body.String())
+		assert.Equal(t, "ok", wr.Header().Get("X-Wasm-Response"))
+	}
+}
+
+func TestProxyHandler_WasmModuleLimits(t *testing.T) {
+	mod := wasmModuleSpec("spin", "spin.wasm")
+	cpuTime := 100 * time.Millisecond
+	mod.Limits = &spec.ModuleLimits{CPUTime: &cpuTime}
+	conf := chainConfig("http://localhost:8080", mod)
+	conf.Debug = true
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/test", nil)
+		wr := httptest.NewRecorder()
+		ps.ServeHTTP(wr, req)
+		assert.Equal(t, http.StatusInternalServerError, wr.Code)
+		assert.Contains(t, wr.Header().Get("X-DGate-Module-Error"), "cpu_time")
+	}
+}
+
+func TestProxyHandler_WasmModuleInvalid(t *testing.T) {
+	conf := chainConfig("http://localhost:8080")
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	require.NoError(t, ps.ProcessChangeLog(spec.NewNoopChangeLog(), true))
+
+	payload, err := os.ReadFile("testdata/wasm/spin.wat")
+	require.NoError(t, err)
+	mod := &spec.Module{
+		Name:          "spin",
+		NamespaceName: "test",
+		Type:          spec.ModuleTypeWasm,
+		Payload:       base64.StdEncoding.EncodeToString(payload),
+	}
+	err = ps.ProcessChangeLog(spec.NewChangeLog(
+		mod, mod.NamespaceName, spec.AddModuleCommand), true)
+	assert.ErrorContains(t, err, "wasm module spin")
+}
@@ -60,6 +60,8 @@ type cacheEntry struct {
 type CacheOptions struct {
 	CheckInterval time.Duration
 	Logger        *zap.Logger
+	// Scheduler runs the expiry of the buckets, if not set the cache creates its own scheduler.
+	Scheduler scheduler.Scheduler
 }
 
 var (
@@ -68,11 +70,14 @@ var (
 )
 
 func NewWithOpts(opts CacheOptions) TCache {
-	sch := scheduler.New(scheduler.Options{
-		Logger:   opts.Logger,
-		Interval: opts.CheckInterval,
-		AutoRun:  true,
-	})
+	sch := opts.Scheduler
+	if sch == nil {
+		sch = scheduler.New(scheduler.Options{
+			Logger:   opts.Logger,
+			Interval: opts.CheckInterval,
+			AutoRun:  true,
+		})
+	}
 
 	if opts.CheckInterval == 0 {
 		opts.CheckInterval = time.Second * 5
@@ -94,8 +99,10 @@ func (cache *cacheImpl) newBucket(
 	name string,
 	opts BucketOptions,
 ) Bucket {
-	cache.sch.ScheduleTask(name, scheduler.TaskOptions{
+	cache.sch.ScheduleTask("cache:"+name, scheduler.TaskOptions{
 		Interval: cache.interval,
+		// expiry waits for the bucket lock, so it does not block the other tasks
+		Overlap: scheduler.OverlapSkip,
 		TaskFunc: func(_ context.Context) {
 			cache.mutex.RLock()
 			b := cache.buckets[name].(*bucketImpl)
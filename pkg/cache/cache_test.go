@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/dgate-io/dgate/pkg/cache"
+	"github.com/dgate-io/dgate/pkg/scheduler"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -117,3 +118,21 @@ func TestCache_MaxItems_Overwrite(t *testing.T) {
 	_, ok = c.Bucket("test").Get("key")
 	assert.False(t, ok, "expected key to be deleted")
 }
+
+func TestCache_SharedScheduler(t *testing.T) {
+	sch := scheduler.New(scheduler.Options{
+		Interval: time.Millisecond * 10,
+		AutoRun:  true,
+	})
+	c := cache.NewWithOpts(cache.CacheOptions{
+		CheckInterval: time.Millisecond * 50,
+		Scheduler:     sch,
+	})
+	c.Bucket("test").SetWithTTL("key", 5, time.Millisecond*100)
+	assert.Equal(t, 1, c.Bucket("test").Len())
+	_, ok := sch.GetTask("cache:test")
+	assert.True(t, ok, "expected the expiry task to be scheduled")
+	assert.Eventually(t, func() bool {
+		return c.Bucket("test").Len() == 0
+	}, time.Second, time.Millisecond*10, "expected key to be expired")
+}
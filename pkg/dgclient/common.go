@@ -105,6 +105,27 @@ func commonPut[T any](client clientDoer, uri string, item T) error {
 	return nil
 }
 
+func commonDeleteWithResponse[T any](client clientDoer, uri string) (*T, error) {
+	req, err := http.NewRequest("DELETE", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err = validateStatusCode(resp.StatusCode); err != nil {
+		return nil, parseApiError(resp.Body, err)
+	}
+	var item ResponseWrapper[T]
+	err = json.NewDecoder(resp.Body).Decode(&item)
+	if err != nil {
+		return nil, err
+	}
+	return &item.Data, nil
+}
+
 func basicDelete(client clientDoer, uri string, rdr io.Reader) error {
 	req, err := http.NewRequest("DELETE", uri, rdr)
 	if err != nil {
@@ -11,6 +11,7 @@ type DGateModuleClient interface {
 	CreateModule(mod *spec.Module) error
 	DeleteModule(name, namespace string) error
 	ListModule(namespace string) ([]*spec.Module, error)
+	GetModuleJobs(name, namespace string) (*spec.ModuleJobs, error)
 }
 
 var _ DGateModuleClient = &dgateClient{}
@@ -52,3 +53,14 @@ func (d *dgateClient) ListModule(namespace string) ([]*spec.Module, error) {
 	}
 	return commonGetList[*spec.Module](d.client, uri)
 }
+
+func (d *dgateClient) GetModuleJobs(name, namespace string) (*spec.ModuleJobs, error) {
+	query := d.baseUrl.Query()
+	query.Set("namespace", namespace)
+	d.baseUrl.RawQuery = query.Encode()
+	uri, err := url.JoinPath(d.baseUrl.String(), "/api/v1/module", name, "jobs")
+	if err != nil {
+		return nil, err
+	}
+	return commonGet[spec.ModuleJobs](d.client, uri)
+}
@@ -95,3 +95,32 @@ func TestDGClient_ListModule(t *testing.T) {
 	assert.Equal(t, 1, len(Modules))
 	assert.Equal(t, "test", Modules[0].Name)
 }
+
+func TestDGClient_GetModuleJobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/module/test/jobs", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&dgclient.ResponseWrapper[*spec.ModuleJobs]{
+			Data: &spec.ModuleJobs{
+				Module:    "test",
+				Namespace: "test",
+				Jobs: []spec.ModuleJobStatus{
+					{Name: "cleanup", Schedule: "@daily", LastError: "failed"},
+				},
+			},
+		})
+	}))
+	client := dgclient.NewDGateClient()
+	err := client.Init(server.URL, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := client.GetModuleJobs("test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(jobs.Jobs))
+	assert.Equal(t, "cleanup", jobs.Jobs[0].Name)
+	assert.Equal(t, "failed", jobs.Jobs[0].LastError)
+}
@@ -11,6 +11,7 @@ type DGateRouteClient interface {
 	CreateRoute(rt *spec.Route) error
 	DeleteRoute(name, namespace string) error
 	ListRoute(namespace string) ([]*spec.Route, error)
+	PurgeRouteCache(name, namespace, prefix, tag string) (int, error)
 }
 
 var _ DGateRouteClient = &dgateClient{}
@@ -52,3 +53,27 @@ func (d *dgateClient) ListRoute(namespace string) ([]*spec.Route, error) {
 	}
 	return commonGetList[*spec.Route](d.client, uri)
 }
+
+func (d *dgateClient) PurgeRouteCache(name, namespace, prefix, tag string) (int, error) {
+	baseUrl := *d.baseUrl
+	query := baseUrl.Query()
+	query.Set("namespace", namespace)
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	baseUrl.RawQuery = query.Encode()
+	uri, err := url.JoinPath(baseUrl.String(), "/api/v1/route", name, "cache")
+	if err != nil {
+		return 0, err
+	}
+	resp, err := commonDeleteWithResponse[struct {
+		Purged int `json:"purged"`
+	}](d.client, uri)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Purged, nil
+}
@@ -11,6 +11,7 @@ type DGateServiceClient interface {
 	CreateService(svc *spec.Service) error
 	DeleteService(name, namespace string) error
 	ListService(namespace string) ([]*spec.Service, error)
+	GetServiceHealth(name, namespace string) ([]*spec.UpstreamStatus, error)
 }
 
 func (d *dgateClient) GetService(name, namespace string) (*spec.Service, error) {
@@ -50,3 +51,14 @@ func (d *dgateClient) ListService(namespace string) ([]*spec.Service, error) {
 	}
 	return commonGetList[*spec.Service](d.client, uri)
 }
+
+func (d *dgateClient) GetServiceHealth(name, namespace string) ([]*spec.UpstreamStatus, error) {
+	query := d.baseUrl.Query()
+	query.Set("namespace", namespace)
+	d.baseUrl.RawQuery = query.Encode()
+	uri, err := url.JoinPath(d.baseUrl.String(), "/api/v1/service", name, "health")
+	if err != nil {
+		return nil, err
+	}
+	return commonGetList[*spec.UpstreamStatus](d.client, uri)
+}
@@ -95,3 +95,30 @@ func TestDGClient_ListService(t *testing.T) {
 	assert.Equal(t, 1, len(Services))
 	assert.Equal(t, "test", Services[0].Name)
 }
+
+func TestDGClient_GetServiceHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/service/test/health", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&dgclient.ResponseWrapper[[]*spec.UpstreamStatus]{
+			Data: []*spec.UpstreamStatus{
+				{
+					URL:     "http://localhost:8080",
+					Healthy: true,
+				},
+			},
+		})
+	}))
+	client := dgclient.NewDGateClient()
+	err := client.Init(server.URL, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := client.GetServiceHealth("test", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(statuses))
+	assert.True(t, statuses[0].Healthy)
+}
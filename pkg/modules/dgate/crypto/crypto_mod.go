@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/dgate-io/dgate/pkg/modules"
+	"github.com/dgate-io/dgate/pkg/modules/dgate/crypto/jwt"
 	"github.com/dgate-io/dgate/pkg/util"
 	"github.com/dop251/goja"
 	"github.com/google/uuid"
@@ -46,8 +47,10 @@ func (c *CryptoModule) Exports() *modules.Exports {
 	namedExports := map[string]any{
 		"createHash":   c.createHash,
 		"createHmac":   c.createHmac,
-		"createSign":   nil, // TODO: not implemented
-		"createVerify": nil, // TODO: not implemented
+		"createSign":   c.createSign,
+		"createVerify": c.createVerify,
+		"sign":         c.sign,
+		"verify":       c.verify,
 		"hmac":         c.hmac,
 		"randomBytes":  c.randomBytes,
 		"randomInt":    c.randomInt,
@@ -59,6 +62,9 @@ func (c *CryptoModule) Exports() *modules.Exports {
 			return keys
 		},
 		"hexEncode": c.hexEncode,
+
+		// Submodules
+		"jwt": jwt.New(c.modCtx),
 	}
 
 	for k, v := range hashAlgos {
@@ -230,25 +236,50 @@ func (h *GojaHash) Update(data any) (*GojaHash, error) {
 }
 
 func (h *GojaHash) Digest(enc string) (any, error) {
-	sum := h.hash.Sum(nil)
+	return encode(h.rt, h.hash.Sum(nil), enc), nil
+}
 
+func encode(rt *goja.Runtime, data []byte, enc string) any {
 	switch enc {
 	case "hex":
-		return hex.EncodeToString(sum), nil
+		return hex.EncodeToString(data)
 	case "base64", "b64":
 		return base64.StdEncoding.
-			EncodeToString(sum), nil
+			EncodeToString(data)
 	case "base64raw", "b64raw":
 		return base64.RawStdEncoding.
-			EncodeToString(sum), nil
+			EncodeToString(data)
 	case "base64url", "b64url":
 		return base64.URLEncoding.
-			EncodeToString(sum), nil
+			EncodeToString(data)
 	case "base64rawurl", "b64rawurl":
 		return base64.RawURLEncoding.
-			EncodeToString(sum), nil
+			EncodeToString(data)
 	default: // default to 'binary' (same behavior as Node.js)
-		ab := h.rt.NewArrayBuffer(sum)
-		return &ab, nil
+		ab := rt.NewArrayBuffer(data)
+		return &ab
+	}
+}
+
+// decode is the reverse of encode, strings without
+// an encoding are used as is.
+func decode(data any, enc string) ([]byte, error) {
+	str, ok := data.(string)
+	if !ok {
+		return util.ToBytes(data)
+	}
+	switch enc {
+	case "hex":
+		return hex.DecodeString(str)
+	case "base64", "b64":
+		return base64.StdEncoding.DecodeString(str)
+	case "base64raw", "b64raw":
+		return base64.RawStdEncoding.DecodeString(str)
+	case "base64url", "b64url":
+		return base64.URLEncoding.DecodeString(str)
+	case "base64rawurl", "b64rawurl":
+		return base64.RawURLEncoding.DecodeString(str)
+	default:
+		return []byte(str), nil
 	}
 }
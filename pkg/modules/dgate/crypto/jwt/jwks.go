@@ -0,0 +1,126 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dop251/goja"
+)
+
+const (
+	jwksCacheBucket = "crypto:jwks"
+	jwksDefaultTTL  = 5 * time.Minute
+	jwksMaxSize     = 1 << 20
+	jwksTimeout     = 10 * time.Second
+)
+
+type JwksOptions struct {
+	// TTL is the number of seconds the key set is cached, defaults to 300
+	TTL int `json:"ttl"`
+}
+
+// FetchJwks fetches a JWK set, the set is cached in the shared cache so it is
+// fetched once for all modules. The url requires the os:net:http permission.
+func (j *JwtModule) FetchJwks(jwksUrl string, opts JwksOptions) (*goja.Promise, error) {
+	u, err := url.Parse(jwksUrl)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("jwks url must be http or https")
+	}
+	if err = j.modCtx.CheckPermission(spec.PermissionNetHTTP + ":" + u.Hostname()); err != nil {
+		return nil, err
+	}
+	if opts.TTL < 0 {
+		return nil, errors.New("ttl cannot be negative")
+	}
+	ttl := jwksDefaultTTL
+	if opts.TTL > 0 {
+		ttl = time.Duration(opts.TTL) * time.Second
+	}
+
+	loop := j.modCtx.EventLoop()
+	promise, resolve, reject := loop.Runtime().NewPromise()
+	bucket := j.modCtx.State().SharedCache().Bucket(jwksCacheBucket)
+	if cached, ok := bucket.Get(jwksUrl); ok {
+		// the raw set is cached, so each runtime gets its own copy
+		if set, err := parseJwks(cached.([]byte)); err == nil {
+			resolve(set)
+			return promise, nil
+		}
+	}
+
+	anyHost := j.modCtx.HasPermission(spec.PermissionNetHTTP)
+	transport := j.modCtx.State().HttpTransport()
+	resultsChan := make(chan []byte, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		if raw, err := fetchJwks(transport, u, anyHost); err != nil {
+			errChan <- err
+		} else {
+			resultsChan <- raw
+		}
+	}()
+	loop.RunOnLoop(func(rt *goja.Runtime) {
+		select {
+		case err := <-errChan:
+			reject(rt.NewGoError(err))
+		case raw := <-resultsChan:
+			set, err := parseJwks(raw)
+			if err != nil {
+				reject(rt.NewGoError(err))
+				return
+			}
+			bucket.SetWithTTL(jwksUrl, raw, ttl)
+			resolve(set)
+		}
+	})
+	return promise, nil
+}
+
+// fetchJwks sends the request with the transport shared by the modules,
+// so the transport config (e.g. disable_private_ips) applies to it.
+func fetchJwks(transport http.RoundTripper, u *url.URL, anyHost bool) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "DGate-Client/1.0")
+	req.Header.Set("Accept", "application/json")
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   jwksTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !anyHost && req.URL.Hostname() != via[0].URL.Hostname() {
+				return errors.New("redirect to another host is not permitted")
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("error fetching jwks: status " + strconv.Itoa(resp.StatusCode))
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+}
+
+func parseJwks(raw []byte) (map[string]any, error) {
+	var set map[string]any
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, errors.New("invalid jwks: " + err.Error())
+	}
+	if _, ok := set["keys"].([]any); !ok {
+		return nil, errors.New("invalid jwks: keys must be an array")
+	}
+	return set, nil
+}
@@ -0,0 +1,318 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/modules"
+	"github.com/dgate-io/dgate/pkg/util/signature"
+)
+
+type JwtModule struct {
+	modCtx modules.RuntimeContext
+}
+
+var _ modules.GoModule = &JwtModule{}
+
+func New(modCtx modules.RuntimeContext) modules.GoModule {
+	return &JwtModule{modCtx}
+}
+
+func (j *JwtModule) Exports() *modules.Exports {
+	return &modules.Exports{
+		Named: map[string]any{
+			"decode":    j.Decode,
+			"verify":    j.Verify,
+			"sign":      j.Sign,
+			"fetchJwks": j.FetchJwks,
+		},
+	}
+}
+
+var (
+	ErrMalformed        = errors.New("jwt malformed")
+	ErrInvalidSignature = errors.New("jwt signature invalid")
+	ErrExpired          = errors.New("jwt expired")
+	ErrNotActive        = errors.New("jwt not active")
+	ErrAudience         = errors.New("jwt audience invalid")
+	ErrIssuer           = errors.New("jwt issuer invalid")
+	ErrSubject          = errors.New("jwt subject invalid")
+)
+
+type DecodedToken struct {
+	Header    map[string]any `json:"header"`
+	Payload   map[string]any `json:"payload"`
+	Signature string         `json:"signature"`
+}
+
+type VerifyOptions struct {
+	// Algorithms are the allowed algorithms, when empty the HMAC algorithms are
+	// allowed for secrets and the other algorithms for public keys.
+	Algorithms []string `json:"algorithms"`
+	// Audience and Issuer can be a string or an array of strings,
+	// one of the values must match the claim of the token.
+	Audience any    `json:"audience"`
+	Issuer   any    `json:"issuer"`
+	Subject  string `json:"subject"`
+	// ClockTolerance is the number of seconds allowed
+	// for clock skew when checking exp and nbf.
+	ClockTolerance   int  `json:"clockTolerance"`
+	IgnoreExpiration bool `json:"ignoreExpiration"`
+	IgnoreNotBefore  bool `json:"ignoreNotBefore"`
+}
+
+type SignOptions struct {
+	// Algorithm defaults to HS256
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyid"`
+	// ExpiresIn and NotBefore are seconds from now
+	ExpiresIn   int            `json:"expiresIn"`
+	NotBefore   int            `json:"notBefore"`
+	Audience    any            `json:"audience"`
+	Issuer      string         `json:"issuer"`
+	Subject     string         `json:"subject"`
+	Header      map[string]any `json:"header"`
+	NoTimestamp bool           `json:"noTimestamp"`
+}
+
+// Decode returns the header and payload of the token without verifying it.
+func (j *JwtModule) Decode(token string) (*DecodedToken, error) {
+	decoded, _, err := decodeToken(token)
+	return decoded, err
+}
+
+// Verify returns the payload of the token if the signature and the claims are valid,
+// key is a secret, a public key (PEM or JWK) or a JWK set ({"keys": [...]}).
+func (j *JwtModule) Verify(token string, key any, opts VerifyOptions) (map[string]any, error) {
+	decoded, signed, err := decodeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	algName, _ := decoded.Header["alg"].(string)
+	alg, err := signature.ParseAlgorithm(algName)
+	if err != nil {
+		return nil, errors.New("jwt algorithm not supported: " + algName)
+	}
+	if !algorithmAllowed(alg, key, opts.Algorithms) {
+		return nil, errors.New("jwt algorithm not allowed: " + algName)
+	}
+	if set, ok := key.(map[string]any); ok {
+		if _, isSet := set["keys"]; isSet {
+			kid, _ := decoded.Header["kid"].(string)
+			if key, err = signature.FindJWK(set, kid, alg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(decoded.Signature)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if err = signature.Verify(alg, key, []byte(signed), sig); err != nil {
+		if errors.Is(err, signature.ErrInvalidSignature) {
+			return nil, ErrInvalidSignature
+		}
+		return nil, err
+	}
+	if err = verifyClaims(decoded.Payload, opts, time.Now()); err != nil {
+		return nil, err
+	}
+	return decoded.Payload, nil
+}
+
+// Sign returns a signed token with the payload, key is a
+// secret or a private key (PEM or JWK).
+func (j *JwtModule) Sign(payload map[string]any, key any, opts SignOptions) (string, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = string(signature.HS256)
+	}
+	alg, err := signature.ParseAlgorithm(opts.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	header := map[string]any{}
+	for k, v := range opts.Header {
+		header[k] = v
+	}
+	header["alg"] = string(alg)
+	header["typ"] = "JWT"
+	if opts.KeyID != "" {
+		header["kid"] = opts.KeyID
+	}
+
+	claims := make(map[string]any, len(payload))
+	for k, v := range payload {
+		claims[k] = v
+	}
+	now := time.Now().Unix()
+	if _, ok := claims["iat"]; !ok && !opts.NoTimestamp {
+		claims["iat"] = now
+	}
+	if opts.ExpiresIn > 0 {
+		claims["exp"] = now + int64(opts.ExpiresIn)
+	}
+	if opts.NotBefore > 0 {
+		claims["nbf"] = now + int64(opts.NotBefore)
+	}
+	if opts.Audience != nil {
+		claims["aud"] = opts.Audience
+	}
+	if opts.Issuer != "" {
+		claims["iss"] = opts.Issuer
+	}
+	if opts.Subject != "" {
+		claims["sub"] = opts.Subject
+	}
+
+	headerJson, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJson, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(headerJson) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJson)
+	sig, err := signature.Sign(alg, key, []byte(signed))
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// decodeToken returns the decoded token and the signed part of the token.
+func decodeToken(token string) (*DecodedToken, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, "", ErrMalformed
+	}
+	decoded := &DecodedToken{Signature: parts[2]}
+	if err := decodeSegment(parts[0], &decoded.Header); err != nil {
+		return nil, "", err
+	}
+	if err := decodeSegment(parts[1], &decoded.Payload); err != nil {
+		return nil, "", err
+	}
+	return decoded, parts[0] + "." + parts[1], nil
+}
+
+func decodeSegment(seg string, v *map[string]any) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err = json.Unmarshal(data, v); err != nil || *v == nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+// algorithmAllowed checks that the algorithm is in the allowed list, and
+// prevents a public key from being used as an HMAC secret, even when
+// the list has both HMAC and public key algorithms.
+func algorithmAllowed(alg signature.Algorithm, key any, allowed []string) bool {
+	if len(allowed) > 0 {
+		found := false
+		for _, a := range allowed {
+			if a == string(alg) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return alg.IsHMAC() == isSecret(key)
+}
+
+func isSecret(key any) bool {
+	switch k := key.(type) {
+	case string:
+		trimmed := strings.TrimSpace(k)
+		return !strings.HasPrefix(trimmed, "-----BEGIN") &&
+			!strings.HasPrefix(trimmed, "{")
+	case map[string]any:
+		if keys, ok := k["keys"].([]any); ok {
+			for _, jwk := range keys {
+				if jwk, ok := jwk.(map[string]any); ok && jwk["kty"] == "oct" {
+					return true
+				}
+			}
+			return false
+		}
+		return k["kty"] == "oct"
+	}
+	return true
+}
+
+func verifyClaims(claims map[string]any, opts VerifyOptions, now time.Time) error {
+	tolerance := time.Duration(opts.ClockTolerance) * time.Second
+	if exp, ok, err := numericDate(claims, "exp"); err != nil {
+		return err
+	} else if ok && !opts.IgnoreExpiration && !now.Before(exp.Add(tolerance)) {
+		return ErrExpired
+	}
+	if nbf, ok, err := numericDate(claims, "nbf"); err != nil {
+		return err
+	} else if ok && !opts.IgnoreNotBefore && now.Add(tolerance).Before(nbf) {
+		return ErrNotActive
+	}
+	if opts.Audience != nil && !claimMatches(claims["aud"], opts.Audience) {
+		return ErrAudience
+	}
+	if opts.Issuer != nil && !claimMatches(claims["iss"], opts.Issuer) {
+		return ErrIssuer
+	}
+	if opts.Subject != "" && claims["sub"] != opts.Subject {
+		return ErrSubject
+	}
+	return nil
+}
+
+func numericDate(claims map[string]any, name string) (time.Time, bool, error) {
+	val, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	secs, ok := val.(float64)
+	if !ok {
+		return time.Time{}, false, errors.New("jwt " + name + " must be a number")
+	}
+	return time.Unix(0, int64(secs*float64(time.Second))), true, nil
+}
+
+// claimMatches returns true if one of the values of the claim is expected.
+func claimMatches(claim, expected any) bool {
+	claimValues, expectedValues := stringValues(claim), stringValues(expected)
+	for _, c := range claimValues {
+		for _, e := range expectedValues {
+			if c == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func stringValues(val any) []string {
+	switch v := val.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
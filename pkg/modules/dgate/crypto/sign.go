@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dgate-io/dgate/pkg/util"
+	"github.com/dgate-io/dgate/pkg/util/signature"
+	"github.com/dop251/goja"
+)
+
+// GojaSign signs the data written with update, the algorithm is a
+// JWA name (e.g. RS256, PS256, ES256 or EdDSA).
+type GojaSign struct {
+	alg  signature.Algorithm
+	data *bytes.Buffer
+	rt   *goja.Runtime
+}
+
+// GojaVerify verifies the signature of the data written with update.
+type GojaVerify struct {
+	alg  signature.Algorithm
+	data *bytes.Buffer
+}
+
+func (c *CryptoModule) createSign(algorithm string) (*GojaSign, error) {
+	alg, err := signature.ParseAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &GojaSign{alg, &bytes.Buffer{}, c.modCtx.Runtime()}, nil
+}
+
+func (c *CryptoModule) createVerify(algorithm string) (*GojaVerify, error) {
+	alg, err := signature.ParseAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &GojaVerify{alg, &bytes.Buffer{}}, nil
+}
+
+func (c *CryptoModule) sign(algorithm string, key, data any, encoding string) (any, error) {
+	s, err := c.createSign(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.Update(data); err != nil {
+		return nil, err
+	}
+	return s.Sign(key, encoding)
+}
+
+func (c *CryptoModule) verify(algorithm string, key, data, sig any, encoding string) (bool, error) {
+	v, err := c.createVerify(algorithm)
+	if err != nil {
+		return false, err
+	}
+	if _, err = v.Update(data); err != nil {
+		return false, err
+	}
+	return v.Verify(key, sig, encoding)
+}
+
+func (s *GojaSign) Update(data any) (*GojaSign, error) {
+	d, err := util.ToBytes(data)
+	if err != nil {
+		return s, err
+	}
+	s.data.Write(d)
+	return s, nil
+}
+
+// Sign signs the data with a private key (PEM or JWK),
+// or the secret for HMAC algorithms.
+func (s *GojaSign) Sign(key any, enc string) (any, error) {
+	sig, err := signature.Sign(s.alg, key, s.data.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return encode(s.rt, sig, enc), nil
+}
+
+func (v *GojaVerify) Update(data any) (*GojaVerify, error) {
+	d, err := util.ToBytes(data)
+	if err != nil {
+		return v, err
+	}
+	v.data.Write(d)
+	return v, nil
+}
+
+// Verify returns false if the signature is invalid, sig is
+// decoded with the encoding when it is a string.
+func (v *GojaVerify) Verify(key, sig any, enc string) (bool, error) {
+	sigBytes, err := decode(sig, enc)
+	if err != nil {
+		return false, err
+	}
+	err = signature.Verify(v.alg, key, v.data.Bytes(), sigBytes)
+	if errors.Is(err, signature.ErrInvalidSignature) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/dgate-io/dgate/pkg/modules/dgate/crypto"
 	"github.com/dgate-io/dgate/pkg/modules/dgate/exp"
 	"github.com/dgate-io/dgate/pkg/modules/dgate/http"
+	"github.com/dgate-io/dgate/pkg/modules/dgate/secrets"
 	"github.com/dgate-io/dgate/pkg/modules/dgate/state"
 	"github.com/dgate-io/dgate/pkg/modules/dgate/storage"
 	"github.com/dgate-io/dgate/pkg/modules/dgate/util"
@@ -41,6 +42,7 @@ func (x *DGateModule) Exports() *modules.Exports {
 			"state":   state.New(x.modCtx),
 			"crypto":  crypto.New(x.modCtx),
 			"storage": storage.New(x.modCtx),
+			"secrets": secrets.New(x.modCtx),
 		},
 	}
 }
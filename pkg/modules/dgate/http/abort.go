@@ -0,0 +1,180 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// AbortController aborts its signal, the signal can be passed to fetch to abort the request.
+type AbortController struct {
+	signal *goja.Object
+}
+
+// AbortSignal is aborted once, the abort listeners are called on the event loop.
+type AbortSignal struct {
+	hp        *HttpModule
+	this      *goja.Object
+	aborted   bool
+	reason    goja.Value
+	onabort   goja.Value
+	listeners []goja.Value
+	// ctx is canceled when the signal is aborted,
+	// it can be canceled before the listeners are called.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// dependents are aborted with the signal (see AbortSignal.any)
+	dependents []*AbortSignal
+}
+
+func (hp *HttpModule) newAbortSignal() (*AbortSignal, *goja.Object) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signal := &AbortSignal{hp: hp, ctx: ctx, cancel: cancel}
+	signal.this = hp.newObject(signal, hp.signalProto)
+	return signal, signal.this
+}
+
+func (hp *HttpModule) createAbortControllerClass() *goja.Object {
+	rt := hp.modCtx.Runtime()
+	ctor := hp.newClass(func(call goja.ConstructorCall) *goja.Object {
+		_, signal := hp.newAbortSignal()
+		return hp.newObject(&AbortController{signal}, call.This.Prototype())
+	})
+	proto := ctor.Get("prototype").(*goja.Object)
+	defineGetters(rt, proto, map[string]func(*AbortController) any{
+		"signal": func(c *AbortController) any { return c.signal },
+	})
+	defineSymbolTag(rt, proto, "AbortController")
+	return ctor
+}
+
+func (hp *HttpModule) createAbortSignalClass() *goja.Object {
+	rt := hp.modCtx.Runtime()
+	ctor := hp.newClass(func(goja.ConstructorCall) *goja.Object {
+		panic(rt.NewTypeError("Illegal constructor"))
+	})
+	hp.signalProto = ctor.Get("prototype").(*goja.Object)
+	defineGetters(rt, hp.signalProto, map[string]func(*AbortSignal) any{
+		"aborted": func(s *AbortSignal) any { return s.aborted },
+		"reason":  func(s *AbortSignal) any { return s.reason },
+	})
+	hp.signalProto.DefineAccessorProperty("onabort",
+		rt.ToValue(func(call goja.FunctionCall) goja.Value {
+			return toThis[*AbortSignal](rt, call.This).onabort
+		}),
+		rt.ToValue(func(call goja.FunctionCall) goja.Value {
+			toThis[*AbortSignal](rt, call.This).onabort = call.Argument(0)
+			return goja.Undefined()
+		}),
+		goja.FLAG_TRUE, goja.FLAG_TRUE,
+	)
+	defineSymbolTag(rt, hp.signalProto, "AbortSignal")
+
+	ctor.Set("abort", func(reason goja.Value) *goja.Object {
+		signal, obj := hp.newAbortSignal()
+		signal.abort(reason)
+		return obj
+	})
+	ctor.Set("timeout", func(ms int64) *goja.Object {
+		if ms < 0 {
+			panic(rt.NewTypeError("timeout must not be negative"))
+		}
+		signal, obj := hp.newAbortSignal()
+		loop := hp.modCtx.EventLoop()
+		time.AfterFunc(time.Duration(ms)*time.Millisecond, func() {
+			// the context is canceled right away, so requests
+			// are aborted even when the loop is busy.
+			signal.cancel()
+			loop.RunOnLoop(func(rt *goja.Runtime) {
+				signal.abort(hp.newDOMException("TimeoutError",
+					"signal timed out"))
+			})
+		})
+		return obj
+	})
+	ctor.Set("any", func(signals []*AbortSignal) *goja.Object {
+		signal, obj := hp.newAbortSignal()
+		for _, s := range signals {
+			if s.aborted {
+				signal.abort(s.reason)
+				break
+			}
+			s.dependents = append(s.dependents, signal)
+		}
+		return obj
+	})
+	return ctor
+}
+
+// Abort aborts the signal of the controller, reason defaults to an AbortError.
+func (c *AbortController) Abort(reason goja.Value) {
+	c.signal.Export().(*AbortSignal).abort(reason)
+}
+
+func (s *AbortSignal) abort(reason goja.Value) {
+	if s.aborted {
+		return
+	}
+	if reason == nil || goja.IsUndefined(reason) {
+		reason = s.hp.newDOMException("AbortError",
+			"signal is aborted without reason")
+	}
+	s.aborted = true
+	s.reason = reason
+	s.cancel()
+
+	rt := s.hp.modCtx.Runtime()
+	event := rt.NewObject()
+	event.Set("type", "abort")
+	event.Set("target", s.this)
+	listeners := s.listeners
+	if s.onabort != nil {
+		listeners = append([]goja.Value{s.onabort}, listeners...)
+	}
+	for _, listener := range listeners {
+		if fn, ok := goja.AssertFunction(listener); ok {
+			fn(s.this, event)
+		}
+	}
+	for _, dep := range s.dependents {
+		dep.abort(reason)
+	}
+}
+
+// ThrowIfAborted throws the reason of the signal if it is aborted.
+func (s *AbortSignal) ThrowIfAborted() {
+	if s.aborted {
+		panic(s.reason)
+	}
+}
+
+// AddEventListener adds a listener for the abort event, other events are ignored.
+func (s *AbortSignal) AddEventListener(event string, listener goja.Value) {
+	if event != "abort" || !callable(listener) {
+		return
+	}
+	for _, l := range s.listeners {
+		if l.StrictEquals(listener) {
+			return
+		}
+	}
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *AbortSignal) RemoveEventListener(event string, listener goja.Value) {
+	if event != "abort" {
+		return
+	}
+	for i, l := range s.listeners {
+		if l.StrictEquals(listener) {
+			s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func callable(val goja.Value) bool {
+	_, ok := goja.AssertFunction(val)
+	return ok
+}
@@ -0,0 +1,320 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/dop251/goja"
+)
+
+// bodyChunkSize is the max size of the chunks read from a body stream
+const bodyChunkSize = 32 * 1024
+
+// body is the body of a Request or a Response, it can be read once with
+// text, json, arrayBuffer or bytes, or as a stream with body.getReader().
+type body struct {
+	hp *HttpModule
+	// reader is nil when there is no body
+	reader io.ReadCloser
+	// data is set when the body is in memory
+	data []byte
+	// signal aborts the reads of the body, it can be nil
+	signal *AbortSignal
+
+	stream *goja.Object
+	locked bool
+	used   bool
+	// lastRead is closed when the last read of the stream
+	// is scheduled on the loop, so chunks stay in order.
+	lastRead chan struct{}
+}
+
+func (hp *HttpModule) newBody(data []byte) *body {
+	if data == nil {
+		return &body{hp: hp}
+	}
+	return &body{
+		hp:     hp,
+		data:   data,
+		reader: io.NopCloser(bytes.NewReader(data)),
+	}
+}
+
+// toBody converts the body of a Request or Response, it returns the
+// content type of the body, or an empty string if it can not be inferred.
+func (hp *HttpModule) toBody(val goja.Value) (*body, string, error) {
+	if val == nil || goja.IsUndefined(val) || goja.IsNull(val) {
+		return hp.newBody(nil), "", nil
+	}
+	rt := hp.modCtx.Runtime()
+	switch v := val.Export().(type) {
+	case string:
+		return hp.newBody([]byte(v)), "text/plain;charset=UTF-8", nil
+	case goja.ArrayBuffer:
+		return hp.newBody(bytes.Clone(v.Bytes())), "", nil
+	case []byte:
+		return hp.newBody(bytes.Clone(v)), "", nil
+	case *ReadableStream:
+		if v.body.locked || v.body.used {
+			return nil, "", errors.New("body stream is locked or disturbed")
+		}
+		return v.body.move(), "", nil
+	}
+	if params, ok := rt.Get("URLSearchParams").(*goja.Object); ok {
+		if val.ToObject(rt).Get("constructor").SameAs(params) {
+			return hp.newBody([]byte(val.String())),
+				"application/x-www-form-urlencoded;charset=UTF-8", nil
+		}
+	}
+	return hp.newBody([]byte(val.String())), "text/plain;charset=UTF-8", nil
+}
+
+// move returns a body with the reader of the body, the body is used after.
+func (b *body) move() *body {
+	b.used = true
+	return &body{hp: b.hp, reader: b.reader, data: b.data, signal: b.signal}
+}
+
+// clone returns a copy of the body, a streamed body is split in two streams
+// which are read at the same pace, so both streams should be read.
+func (b *body) clone() (*body, error) {
+	if b.used || b.locked {
+		return nil, errBodyUsed
+	}
+	if b.reader == nil || b.data != nil {
+		clone := b.hp.newBody(b.data)
+		clone.signal = b.signal
+		return clone, nil
+	}
+	r1, r2 := teeReader(b.reader)
+	b.reader = r1
+	return &body{hp: b.hp, reader: r2, signal: b.signal}, nil
+}
+
+func teeReader(r io.ReadCloser) (io.ReadCloser, io.ReadCloser) {
+	pr1, pw1 := io.Pipe()
+	pr2, pw2 := io.Pipe()
+	go func() {
+		defer r.Close()
+		_, err := io.Copy(io.MultiWriter(pw1, pw2), r)
+		pw1.CloseWithError(err)
+		pw2.CloseWithError(err)
+	}()
+	return pr1, pr2
+}
+
+var errBodyUsed = errors.New("body already used")
+
+// consume reads the whole body and converts it on the loop
+func (b *body) consume(convert func(rt *goja.Runtime, data []byte) (goja.Value, error)) *goja.Promise {
+	rt := b.hp.modCtx.Runtime()
+	promise, resolve, reject := rt.NewPromise()
+	if b.used || b.locked {
+		reject(rt.NewTypeError(errBodyUsed.Error()))
+		return promise
+	}
+	b.used = true
+	settle := func(rt *goja.Runtime, data []byte, err error) {
+		if err != nil {
+			reject(b.readError(err))
+		} else if val, err := convert(rt, data); err != nil {
+			reject(err)
+		} else {
+			resolve(val)
+		}
+	}
+	if b.reader == nil || b.data != nil {
+		settle(rt, b.data, nil)
+		return promise
+	}
+	go func() {
+		data, err := io.ReadAll(b.reader)
+		b.reader.Close()
+		b.hp.modCtx.EventLoop().RunOnLoop(func(rt *goja.Runtime) {
+			settle(rt, data, err)
+		})
+	}()
+	return promise
+}
+
+func (b *body) text() *goja.Promise {
+	return b.consume(func(rt *goja.Runtime, data []byte) (goja.Value, error) {
+		return rt.ToValue(string(data)), nil
+	})
+}
+
+func (b *body) json() *goja.Promise {
+	return b.consume(func(rt *goja.Runtime, data []byte) (goja.Value, error) {
+		parse, _ := goja.AssertFunction(rt.Get("JSON").ToObject(rt).Get("parse"))
+		return parse(goja.Undefined(), rt.ToValue(string(data)))
+	})
+}
+
+func (b *body) arrayBuffer() *goja.Promise {
+	return b.consume(func(rt *goja.Runtime, data []byte) (goja.Value, error) {
+		return rt.ToValue(rt.NewArrayBuffer(data)), nil
+	})
+}
+
+func (b *body) bytes() *goja.Promise {
+	return b.consume(func(rt *goja.Runtime, data []byte) (goja.Value, error) {
+		return newUint8Array(rt, data)
+	})
+}
+
+// readError returns the reason of the signal if the read was aborted
+func (b *body) readError(err error) goja.Value {
+	if b.signal != nil && b.signal.aborted {
+		return b.signal.reason
+	}
+	return b.hp.modCtx.Runtime().NewTypeError("error reading body: " + err.Error())
+}
+
+// streamObject returns the body as a ReadableStream, or null if there is no body
+func (b *body) streamObject() goja.Value {
+	if b.reader == nil {
+		return goja.Null()
+	}
+	if b.stream == nil {
+		b.stream = b.hp.newObject(&ReadableStream{b}, b.hp.streamProto)
+	}
+	return b.stream
+}
+
+func (b *body) bodyUsed() bool {
+	return b.used
+}
+
+// ReadableStream is the stream of a body, it is read with a reader from getReader().
+type ReadableStream struct {
+	body *body
+}
+
+// ReadableStreamReader reads the chunks of a stream as Uint8Arrays.
+type ReadableStreamReader struct {
+	body     *body
+	released bool
+	closed   bool
+}
+
+func (hp *HttpModule) createStreamPrototypes() {
+	rt := hp.modCtx.Runtime()
+	hp.streamProto = rt.NewObject()
+	defineGetters(rt, hp.streamProto, map[string]func(*ReadableStream) any{
+		"locked": func(s *ReadableStream) any { return s.body.locked },
+	})
+	defineSymbolTag(rt, hp.streamProto, "ReadableStream")
+	hp.readerProto = rt.NewObject()
+	defineSymbolTag(rt, hp.readerProto, "ReadableStreamDefaultReader")
+}
+
+// GetReader locks the stream to a reader
+func (s *ReadableStream) GetReader() *goja.Object {
+	b := s.body
+	if b.locked {
+		panic(b.hp.modCtx.Runtime().NewTypeError("stream is already locked"))
+	}
+	b.locked = true
+	return b.hp.newObject(&ReadableStreamReader{body: b}, b.hp.readerProto)
+}
+
+// Cancel closes the stream, the body is discarded.
+func (s *ReadableStream) Cancel() *goja.Promise {
+	rt := s.body.hp.modCtx.Runtime()
+	if s.body.locked {
+		return rejectedPromise(rt, rt.NewTypeError("stream is locked"))
+	}
+	s.body.used = true
+	s.body.reader.Close()
+	return resolvedPromise(rt, goja.Undefined())
+}
+
+// Read returns a promise of the next chunk ({ value, done }) of the stream.
+func (r *ReadableStreamReader) Read() *goja.Promise {
+	b := r.body
+	rt := b.hp.modCtx.Runtime()
+	if r.released {
+		return rejectedPromise(rt, rt.NewTypeError("reader is released"))
+	}
+	b.used = true
+	promise, resolve, reject := rt.NewPromise()
+	if r.closed {
+		resolve(readResult(rt, goja.Undefined(), true))
+		return promise
+	}
+	prev, done := b.lastRead, make(chan struct{})
+	b.lastRead = done
+	go func() {
+		if prev != nil {
+			<-prev
+		}
+		buf := make([]byte, bodyChunkSize)
+		n, err := b.reader.Read(buf)
+		b.hp.modCtx.EventLoop().RunOnLoop(func(rt *goja.Runtime) {
+			if n > 0 {
+				chunk, err := newUint8Array(rt, buf[:n])
+				if err != nil {
+					reject(err)
+					return
+				}
+				resolve(readResult(rt, chunk, false))
+			} else if err == io.EOF {
+				r.closed = true
+				b.reader.Close()
+				resolve(readResult(rt, goja.Undefined(), true))
+			} else if err != nil {
+				reject(b.readError(err))
+			} else {
+				resolve(readResult(rt, newEmptyUint8Array(rt), false))
+			}
+		})
+		close(done)
+	}()
+	return promise
+}
+
+// Cancel closes the stream of the reader, the body is discarded.
+func (r *ReadableStreamReader) Cancel() *goja.Promise {
+	rt := r.body.hp.modCtx.Runtime()
+	r.closed = true
+	r.body.used = true
+	r.body.reader.Close()
+	return resolvedPromise(rt, goja.Undefined())
+}
+
+// ReleaseLock unlocks the stream, so another reader can be used.
+func (r *ReadableStreamReader) ReleaseLock() {
+	if !r.released {
+		r.released = true
+		r.body.locked = false
+	}
+}
+
+func readResult(rt *goja.Runtime, value goja.Value, done bool) *goja.Object {
+	res := rt.NewObject()
+	res.Set("value", value)
+	res.Set("done", done)
+	return res
+}
+
+func newUint8Array(rt *goja.Runtime, data []byte) (goja.Value, error) {
+	return rt.New(rt.Get("Uint8Array"), rt.ToValue(rt.NewArrayBuffer(data)))
+}
+
+func newEmptyUint8Array(rt *goja.Runtime) goja.Value {
+	arr, _ := newUint8Array(rt, []byte{})
+	return arr
+}
+
+func resolvedPromise(rt *goja.Runtime, val any) *goja.Promise {
+	promise, resolve, _ := rt.NewPromise()
+	resolve(val)
+	return promise
+}
+
+func rejectedPromise(rt *goja.Runtime, reason any) *goja.Promise {
+	promise, _, reject := rt.NewPromise()
+	reject(reason)
+	return promise
+}
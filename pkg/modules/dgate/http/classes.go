@@ -0,0 +1,67 @@
+package http
+
+import (
+	"github.com/dop251/goja"
+)
+
+// newClass creates a constructor with an empty prototype, the methods of the
+// classes are the methods of the Go values and the properties are getters.
+func (hp *HttpModule) newClass(construct func(goja.ConstructorCall) *goja.Object) *goja.Object {
+	rt := hp.modCtx.Runtime()
+	ctor := rt.ToValue(construct).(*goja.Object)
+	proto := rt.NewObject()
+	proto.DefineDataProperty("constructor", ctor,
+		goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	ctor.Set("prototype", proto)
+	return ctor
+}
+
+// newObject wraps the Go value in an object with the prototype
+func (hp *HttpModule) newObject(val any, proto *goja.Object) *goja.Object {
+	obj := hp.modCtx.Runtime().ToValue(val).(*goja.Object)
+	obj.SetPrototype(proto)
+	return obj
+}
+
+// defineGetters defines read only properties on the prototype,
+// the getters are called with the Go value of this.
+func defineGetters[T any](rt *goja.Runtime, proto *goja.Object, getters map[string]func(T) any) {
+	for name, get := range getters {
+		proto.DefineAccessorProperty(name, rt.ToValue(func(call goja.FunctionCall) goja.Value {
+			return rt.ToValue(get(toThis[T](rt, call.This)))
+		}), nil, goja.FLAG_TRUE, goja.FLAG_TRUE)
+	}
+}
+
+func defineSymbolTag(rt *goja.Runtime, proto *goja.Object, tag string) {
+	proto.DefineDataPropertySymbol(goja.SymToStringTag, rt.ToValue(tag),
+		goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+}
+
+func toThis[T any](rt *goja.Runtime, this goja.Value) T {
+	if val, ok := this.Export().(T); ok {
+		return val
+	}
+	panic(rt.NewTypeError("Illegal invocation"))
+}
+
+// iterator returns an iterator of the items
+func (hp *HttpModule) iterator(items []any) goja.Value {
+	rt := hp.modCtx.Runtime()
+	arr := rt.NewArray(items...)
+	values, _ := goja.AssertFunction(arr.Get("values"))
+	iter, err := values(arr)
+	if err != nil {
+		panic(err)
+	}
+	return iter
+}
+
+// newDOMException returns an error with the name (e.g. AbortError),
+// the same way as the DOMException errors of the browsers.
+func (hp *HttpModule) newDOMException(name, msg string) *goja.Object {
+	rt := hp.modCtx.Runtime()
+	err, _ := rt.New(rt.Get("Error"), rt.ToValue(msg))
+	err.Set("name", name)
+	return err
+}
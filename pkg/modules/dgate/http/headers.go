@@ -0,0 +1,211 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja"
+	"golang.org/x/net/http/httpguts"
+)
+
+// Headers is a list of http headers, the names are case-insensitive
+// and are iterated in lowercase and sorted (same as the fetch standard).
+type Headers struct {
+	hp     *HttpModule
+	this   *goja.Object
+	header http.Header
+	// immutable is true for the headers of the responses of fetch
+	immutable bool
+}
+
+func (hp *HttpModule) newHeaders(header http.Header) (*Headers, *goja.Object) {
+	if header == nil {
+		header = http.Header{}
+	}
+	h := &Headers{hp: hp, header: header}
+	h.this = hp.newObject(h, hp.headersProto)
+	return h, h.this
+}
+
+func (hp *HttpModule) createHeadersClass() *goja.Object {
+	rt := hp.modCtx.Runtime()
+	ctor := hp.newClass(func(call goja.ConstructorCall) *goja.Object {
+		header, err := hp.toHeader(call.Argument(0))
+		if err != nil {
+			panic(rt.NewTypeError(err.Error()))
+		}
+		h := &Headers{hp: hp, header: header}
+		h.this = hp.newObject(h, call.This.Prototype())
+		return h.this
+	})
+	hp.headersProto = ctor.Get("prototype").(*goja.Object)
+	hp.headersProto.DefineDataPropertySymbol(goja.SymIterator,
+		rt.ToValue(func(call goja.FunctionCall) goja.Value {
+			return toThis[*Headers](rt, call.This).Entries()
+		}), goja.FLAG_TRUE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	defineSymbolTag(rt, hp.headersProto, "Headers")
+	return ctor
+}
+
+// toHeader converts a Headers object, an iterable of [name, value] pairs or a
+// record of names to values to an http.Header, undefined and null are empty.
+func (hp *HttpModule) toHeader(val goja.Value) (http.Header, error) {
+	header := http.Header{}
+	if val == nil || goja.IsUndefined(val) || goja.IsNull(val) {
+		return header, nil
+	}
+	rt := hp.modCtx.Runtime()
+	if h, ok := val.Export().(*Headers); ok {
+		return h.header.Clone(), nil
+	}
+	obj, ok := val.(*goja.Object)
+	if !ok {
+		return nil, errInvalidHeaders
+	}
+	var err error
+	if iter := obj.GetSymbol(goja.SymIterator); iter != nil && !goja.IsUndefined(iter) {
+		rt.ForOf(obj, func(pair goja.Value) bool {
+			var kv []string
+			if rt.ExportTo(pair, &kv) != nil || len(kv) != 2 {
+				err = errInvalidHeaders
+				return false
+			}
+			err = appendHeader(header, kv[0], kv[1])
+			return err == nil
+		})
+		return header, err
+	}
+	for _, key := range obj.Keys() {
+		if err = appendHeader(header, key, obj.Get(key).String()); err != nil {
+			return nil, err
+		}
+	}
+	return header, nil
+}
+
+func appendHeader(header http.Header, name, value string) error {
+	value = strings.Trim(value, " \t\r\n")
+	if !httpguts.ValidHeaderFieldName(name) {
+		return errors.New("invalid header name: " + name)
+	} else if !httpguts.ValidHeaderFieldValue(value) {
+		return errors.New("invalid header value for " + name)
+	}
+	header.Add(name, value)
+	return nil
+}
+
+func (h *Headers) checkMutable() {
+	if h.immutable {
+		panic(h.hp.modCtx.Runtime().NewTypeError("headers are immutable"))
+	}
+}
+
+func (h *Headers) Append(name, value string) {
+	h.checkMutable()
+	if err := appendHeader(h.header, name, value); err != nil {
+		panic(h.hp.modCtx.Runtime().NewTypeError(err.Error()))
+	}
+}
+
+func (h *Headers) Set(name, value string) {
+	h.checkMutable()
+	header := http.Header{}
+	if err := appendHeader(header, name, value); err != nil {
+		panic(h.hp.modCtx.Runtime().NewTypeError(err.Error()))
+	}
+	h.header.Del(name)
+	h.header.Add(name, header.Get(name))
+}
+
+func (h *Headers) Delete(name string) {
+	h.checkMutable()
+	h.header.Del(name)
+}
+
+func (h *Headers) Has(name string) bool {
+	_, ok := h.header[http.CanonicalHeaderKey(name)]
+	return ok
+}
+
+// Get returns the values of the header joined with a comma, or null.
+func (h *Headers) Get(name string) goja.Value {
+	values := h.header.Values(name)
+	if len(values) == 0 {
+		return goja.Null()
+	}
+	return h.hp.modCtx.Runtime().ToValue(strings.Join(values, ", "))
+}
+
+// GetSetCookie returns the values of the Set-Cookie headers, they are not joined.
+func (h *Headers) GetSetCookie() []string {
+	return append([]string{}, h.header.Values("Set-Cookie")...)
+}
+
+func (h *Headers) ForEach(fn goja.Callable, thisArg goja.Value) error {
+	rt := h.hp.modCtx.Runtime()
+	for _, pair := range h.pairs() {
+		if _, err := fn(thisArg, rt.ToValue(pair[1]),
+			rt.ToValue(pair[0]), h.this); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Headers) Entries() goja.Value {
+	rt := h.hp.modCtx.Runtime()
+	pairs := h.pairs()
+	items := make([]any, len(pairs))
+	for i, pair := range pairs {
+		items[i] = rt.NewArray(pair[0], pair[1])
+	}
+	return h.hp.iterator(items)
+}
+
+func (h *Headers) Keys() goja.Value {
+	pairs := h.pairs()
+	items := make([]any, len(pairs))
+	for i, pair := range pairs {
+		items[i] = pair[0]
+	}
+	return h.hp.iterator(items)
+}
+
+func (h *Headers) Values() goja.Value {
+	pairs := h.pairs()
+	items := make([]any, len(pairs))
+	for i, pair := range pairs {
+		items[i] = pair[1]
+	}
+	return h.hp.iterator(items)
+}
+
+// pairs returns the sorted lowercase names and the values of the headers,
+// the values are combined except for Set-Cookie.
+func (h *Headers) pairs() [][2]string {
+	names := make(map[string]string, len(h.header))
+	lowerNames := make([]string, 0, len(h.header))
+	for name := range h.header {
+		lower := strings.ToLower(name)
+		names[lower] = name
+		lowerNames = append(lowerNames, lower)
+	}
+	sort.Strings(lowerNames)
+	pairs := make([][2]string, 0, len(lowerNames))
+	for _, lower := range lowerNames {
+		values := h.header[names[lower]]
+		if lower == "set-cookie" {
+			for _, v := range values {
+				pairs = append(pairs, [2]string{lower, v})
+			}
+			continue
+		}
+		pairs = append(pairs, [2]string{lower, strings.Join(values, ", ")})
+	}
+	return pairs
+}
+
+var errInvalidHeaders = errors.New("headers must be a Headers object, " +
+	"an iterable of [name, value] pairs or a record")
@@ -2,182 +2,224 @@ package http
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/dgate-io/dgate/pkg/modules"
+	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/dop251/goja"
 )
 
+// maxRedirects is the max number of redirects followed by fetch
+const maxRedirects = 20
+
 type HttpModule struct {
 	modCtx modules.RuntimeContext
+
+	// classes are created once per runtime
+	headersClass, requestClass, responseClass,
+	abortControllerClass, abortSignalClass *goja.Object
+
+	headersProto, requestProto, responseProto,
+	signalProto, streamProto, readerProto *goja.Object
 }
 
 var _ modules.GoModule = &HttpModule{}
 
 func New(modCtx modules.RuntimeContext) modules.GoModule {
-	return &HttpModule{
-		modCtx,
+	hp := &HttpModule{
+		modCtx: modCtx,
 	}
+	hp.createStreamPrototypes()
+	hp.headersClass = hp.createHeadersClass()
+	hp.requestClass = hp.createRequestClass()
+	hp.responseClass = hp.createResponseClass()
+	hp.abortSignalClass = hp.createAbortSignalClass()
+	hp.abortControllerClass = hp.createAbortControllerClass()
+	return hp
 }
 
 func (hp *HttpModule) Exports() *modules.Exports {
 	return &modules.Exports{
 		Named: map[string]any{
-			"fetch": hp.FetchAsync,
+			"fetch":           hp.FetchAsync,
+			"Headers":         hp.headersClass,
+			"Request":         hp.requestClass,
+			"Response":        hp.responseClass,
+			"AbortController": hp.abortControllerClass,
+			"AbortSignal":     hp.abortSignalClass,
 		},
 	}
 }
 
-type FetchOptionsRedirect string
-
-const (
-	Follow FetchOptionsRedirect = "follow"
-	Error  FetchOptionsRedirect = "error"
-	Manual FetchOptionsRedirect = "manual"
-)
+// FetchAsync sends a request with the transport shared by the modules, input is a
+// url or a Request, and init has the same options as the Request constructor.
+func (hp *HttpModule) FetchAsync(input, init goja.Value) (*goja.Promise, error) {
+	rt := hp.modCtx.Runtime()
+	req, err := hp.newRequest(input, init)
+	if err != nil {
+		panic(rt.NewTypeError(err.Error()))
+	} else if err = hp.modCtx.CheckPermission(
+		spec.PermissionNetHTTP + ":" + req.url.Hostname(),
+	); err != nil {
+		return nil, err
+	}
+	loop := hp.modCtx.EventLoop()
+	promise, resolve, reject := rt.NewPromise()
+	signal := req.abortSignal()
+	if signal != nil && signal.aborted {
+		req.body.used = true
+		reject(signal.reason)
+		return promise, nil
+	}
 
-type FetchOptions struct {
-	Method             string               `json:"method"`
-	Body               string               `json:"body"`
-	Headers            map[string]string    `json:"headers"`
-	Redirect           FetchOptionsRedirect `json:"redirects"`
-	Follow             int                  `json:"follow"`
-	Compress           bool                 `json:"compress"`
-	Size               int                  `json:"size"`
-	Agent              string               `json:"agent"`
-	HighWaterMark      int                  `json:"highWaterMark"`
-	InsecureHTTPParser bool                 `json:"insecureHTTPParser"`
-	// TODO: add options for timeout (signal which would require AbortController support)
-}
+	// cancel releases the context once the response body is closed
+	ctx, cancel := context.WithCancel(hp.modCtx.Context())
+	if signal != nil {
+		stop := context.AfterFunc(signal.ctx, cancel)
+		cancel = cancelAll(cancel, func() { stop() })
+	}
+	var timedOut func() bool
+	if req.timeout > 0 {
+		timeoutCtx, timeoutCancel := context.WithTimeout(ctx,
+			time.Duration(req.timeout)*time.Millisecond)
+		ctx, cancel = timeoutCtx, cancelAll(cancel, timeoutCancel)
+		timedOut = func() bool {
+			return errors.Is(timeoutCtx.Err(), context.DeadlineExceeded)
+		}
+	}
 
-func (hp *HttpModule) FetchAsync(url string, fetchOpts FetchOptions) (*goja.Promise, error) {
-	loop := hp.modCtx.EventLoop()
-	promise, resolve, reject := loop.Runtime().NewPromise()
+	httpReq, err := hp.httpRequest(ctx, req)
+	if err != nil {
+		cancel()
+		panic(rt.NewTypeError(err.Error()))
+	}
 	redirected := false
-	var reader io.Reader
-	client := http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if fetchOpts.Redirect == Manual {
+	// modules with permissions for some hosts can not be redirected to other hosts
+	anyHost := hp.modCtx.HasPermission(spec.PermissionNetHTTP)
+	client := &http.Client{
+		Transport: hp.modCtx.State().HttpTransport(),
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			if req.redirect == Manual {
 				return http.ErrUseLastResponse
-			} else if fetchOpts.Redirect == Error {
+			} else if req.redirect == Error {
 				return errors.New("redirects not allowed")
+			} else if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			} else if !anyHost && r.URL.Hostname() != via[0].URL.Hostname() {
+				return errors.New("redirect to another host is not permitted")
 			}
 			redirected = true
 			return nil
 		},
 	}
-	if fetchOpts.Body != "" {
-		reader = bytes.NewReader([]byte(fetchOpts.Body))
+
+	go func() {
+		resp, err := client.Do(httpReq)
+		loop.RunOnLoop(func(rt *goja.Runtime) {
+			if err != nil {
+				cancel()
+				if signal != nil && signal.aborted {
+					reject(signal.reason)
+				} else if timedOut != nil && timedOut() {
+					reject(hp.newDOMException("TimeoutError",
+						"request timed out"))
+				} else {
+					reject(rt.NewTypeError("fetch failed: " + err.Error()))
+				}
+				return
+			}
+			resolve(hp.fetchResponse(resp, redirected, signal, cancel))
+		})
+	}()
+	return promise, nil
+}
+
+func cancelAll(fns ...context.CancelFunc) context.CancelFunc {
+	return func() {
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
+
+func (hp *HttpModule) httpRequest(ctx context.Context, req *Request) (*http.Request, error) {
+	var reader io.Reader
+	if req.body.reader != nil {
+		req.body.used = true
+		if req.body.data != nil {
+			// the content length is set for in memory bodies
+			reader = bytes.NewReader(req.body.data)
+		} else {
+			reader = req.body.reader
+		}
 	}
-	req, err := http.NewRequest(fetchOpts.Method, url, reader)
+	httpReq, err := http.NewRequestWithContext(
+		ctx, req.method, req.url.String(), reader)
 	if err != nil {
 		return nil, err
+	}
+	httpReq.Header = req.headers.Export().(*Headers).header.Clone()
+	if httpReq.Header.Get("User-Agent") == "" {
+		httpReq.Header.Set("User-Agent", "DGate-Client/1.0")
+	}
+	return httpReq, nil
+}
+
+func (hp *HttpModule) fetchResponse(
+	resp *http.Response,
+	redirected bool,
+	signal *AbortSignal,
+	cancel context.CancelFunc,
+) *goja.Object {
+	h, headers := hp.newHeaders(resp.Header)
+	h.immutable = true
+	b := &body{hp: hp, signal: signal}
+	if resp.Body != http.NoBody {
+		b.reader = &responseBody{body: resp.Body, cancel: cancel}
 	} else {
-		req.Header.Set("User-Agent", "DGate-Client/1.0")
-		for k, v := range fetchOpts.Headers {
-			req.Header.Set(k, v)
-		}
+		resp.Body.Close()
+		cancel()
 	}
-	resultsChan := asyncDo(client, req)
-	bodyUsed := false
-	loop.RunOnLoop(func(rt *goja.Runtime) {
-		results := <-resultsChan
-		if results.Error != nil {
-			reject(rt.NewGoError(results.Error))
-			return
-		}
-		resp := results.Data
-		resolve(map[string]any{
-			"_debug_time": results.Time.Seconds(),
-			"status":      resp.StatusCode,
-			"statusText":  resp.Status,
-			"headers":     resp.Header,
-			"body":        resp.Body,
-			"bodyUsed":    &bodyUsed,
-			"ok":          resp.StatusCode >= 200 && resp.StatusCode < 300,
-			"url":         resp.Request.URL.String(),
-			"redirected":  redirected,
-			"json": func() (*goja.Promise, error) {
-				bodyPromise, bodyResolve, bodyReject := rt.NewPromise()
-				loop.RunOnLoop(func(_ *goja.Runtime) {
-					if bodyUsed {
-						bodyReject(rt.NewGoError(errors.New("body already used")))
-						return
-					}
-					defer resp.Body.Close()
-					var jsonData interface{}
-					err := json.NewDecoder(resp.Body).Decode(&jsonData)
-					if err != nil {
-						bodyReject(err)
-						return
-					}
-					bodyUsed = true
-					bodyResolve(jsonData)
-				})
-				return bodyPromise, nil
-			},
-			"text": func() (*goja.Promise, error) {
-				bodyPromise, bodyResolve, bodyReject := rt.NewPromise()
-				loop.RunOnLoop(func(_ *goja.Runtime) {
-					if bodyUsed {
-						bodyReject(rt.NewGoError(errors.New("body already used")))
-						return
-					}
-					defer resp.Body.Close()
-					body, err := io.ReadAll(resp.Body)
-					if err != nil {
-						bodyReject(err)
-						return
-					}
-					bodyResolve(string(body))
-				})
-				return bodyPromise, nil
-			},
-			"arrayBuffer": func() (*goja.Promise, error) {
-				bodyPromise, bodyResolve, bodyReject := rt.NewPromise()
-				loop.RunOnLoop(func(_ *goja.Runtime) {
-					if bodyUsed {
-						bodyReject(rt.NewGoError(errors.New("body already used")))
-						return
-					}
-					defer resp.Body.Close()
-					body, err := io.ReadAll(resp.Body)
-					if err != nil {
-						bodyReject(err)
-						return
-					}
-					bodyUsed = true
-					bodyResolve(rt.NewArrayBuffer(body))
-				})
-				return bodyPromise, nil
-			},
-		})
-	})
-	return promise, nil
+	statusText := http.StatusText(resp.StatusCode)
+	if len(resp.Status) > 4 {
+		statusText = resp.Status[4:]
+	}
+	return hp.newObject(&Response{
+		hp:         hp,
+		typ:        "basic",
+		url:        resp.Request.URL.String(),
+		redirected: redirected,
+		status:     resp.StatusCode,
+		statusText: statusText,
+		headers:    headers,
+		body:       b,
+	}, hp.responseProto)
 }
 
-type AsyncResults[T any] struct {
-	Data  T
-	Error error
-	Time  time.Duration
+// responseBody closes the body and releases the context of the
+// request once the body is read to the end or closed.
+type responseBody struct {
+	body   io.ReadCloser
+	cancel context.CancelFunc
+	err    error
 }
 
-func asyncDo(client http.Client, req *http.Request) chan AsyncResults[*http.Response] {
-	ch := make(chan AsyncResults[*http.Response], 1)
-	go func() {
-		start := time.Now()
-		if resp, err := client.Do(req); err != nil {
-			ch <- AsyncResults[*http.Response]{Error: err}
-		} else {
-			ch <- AsyncResults[*http.Response]{
-				Data: resp,
-				Time: time.Since(start),
-			}
-		}
-	}()
-	return ch
+func (r *responseBody) Read(p []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if n, err = r.body.Read(p); err != nil {
+		r.err = err
+		r.Close()
+	}
+	return n, err
+}
+
+func (r *responseBody) Close() error {
+	defer r.cancel()
+	return r.body.Close()
 }
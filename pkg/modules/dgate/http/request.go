@@ -0,0 +1,202 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dop251/goja"
+	"golang.org/x/net/http/httpguts"
+)
+
+type FetchOptionsRedirect string
+
+const (
+	Follow FetchOptionsRedirect = "follow"
+	Error  FetchOptionsRedirect = "error"
+	Manual FetchOptionsRedirect = "manual"
+)
+
+// Request is a fetch request, it can be passed to fetch or used to build other requests.
+type Request struct {
+	hp       *HttpModule
+	method   string
+	url      *url.URL
+	headers  *goja.Object
+	body     *body
+	redirect FetchOptionsRedirect
+	signal   *goja.Object
+	// timeout is the max duration of the request in milliseconds, 0 means no timeout
+	timeout int64
+}
+
+func (hp *HttpModule) createRequestClass() *goja.Object {
+	rt := hp.modCtx.Runtime()
+	ctor := hp.newClass(func(call goja.ConstructorCall) *goja.Object {
+		req, err := hp.newRequest(call.Argument(0), call.Argument(1))
+		if err != nil {
+			panic(rt.NewTypeError(err.Error()))
+		}
+		return hp.newObject(req, call.This.Prototype())
+	})
+	hp.requestProto = ctor.Get("prototype").(*goja.Object)
+	defineGetters(rt, hp.requestProto, map[string]func(*Request) any{
+		"method":   func(r *Request) any { return r.method },
+		"url":      func(r *Request) any { return r.url.String() },
+		"headers":  func(r *Request) any { return r.headers },
+		"redirect": func(r *Request) any { return string(r.redirect) },
+		"signal":   func(r *Request) any { return r.signal },
+		"body":     func(r *Request) any { return r.body.streamObject() },
+		"bodyUsed": func(r *Request) any { return r.body.bodyUsed() },
+	})
+	defineSymbolTag(rt, hp.requestProto, "Request")
+	return ctor
+}
+
+// newRequest creates a request from a url or a Request, and
+// the init options (method, headers, body, redirect, signal and timeout).
+func (hp *HttpModule) newRequest(input, init goja.Value) (*Request, error) {
+	rt := hp.modCtx.Runtime()
+	req := &Request{hp: hp, method: http.MethodGet, redirect: Follow}
+	var header http.Header
+	var base *Request
+	if r, ok := input.Export().(*Request); ok {
+		base = r
+		req.method = base.method
+		req.url = base.url
+		req.redirect = base.redirect
+		req.signal = base.signal
+		req.timeout = base.timeout
+		req.body = hp.newBody(nil)
+		header = base.headers.Export().(*Headers).header.Clone()
+	} else {
+		u, err := url.Parse(input.String())
+		if err != nil {
+			return nil, errors.New("invalid url: " + input.String())
+		} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("invalid url, an absolute http or https url is required: " + input.String())
+		}
+		req.url = u
+		req.body = hp.newBody(nil)
+		header = http.Header{}
+	}
+
+	var opts *goja.Object
+	if init != nil && !goja.IsUndefined(init) && !goja.IsNull(init) {
+		opts = init.ToObject(rt)
+	}
+	if opts != nil {
+		if method := opts.Get("method"); method != nil && !goja.IsUndefined(method) {
+			m, err := normalizeMethod(method.String())
+			if err != nil {
+				return nil, err
+			}
+			req.method = m
+		}
+		if headers := opts.Get("headers"); headers != nil && !goja.IsUndefined(headers) {
+			h, err := hp.toHeader(headers)
+			if err != nil {
+				return nil, err
+			}
+			header = h
+		}
+		if redirect := opts.Get("redirect"); redirect != nil && !goja.IsUndefined(redirect) {
+			switch r := FetchOptionsRedirect(redirect.String()); r {
+			case Follow, Error, Manual:
+				req.redirect = r
+			default:
+				return nil, errors.New("invalid redirect option: " + string(r))
+			}
+		}
+		if signal := opts.Get("signal"); signal != nil && !goja.IsUndefined(signal) {
+			if goja.IsNull(signal) {
+				req.signal = nil
+			} else if _, ok := signal.Export().(*AbortSignal); ok {
+				req.signal = signal.ToObject(rt)
+			} else {
+				return nil, errors.New("signal must be an AbortSignal")
+			}
+		}
+		if timeout := opts.Get("timeout"); timeout != nil && !goja.IsUndefined(timeout) {
+			if req.timeout = timeout.ToInteger(); req.timeout < 0 {
+				return nil, errors.New("timeout must not be negative")
+			}
+		}
+		if bodyVal := opts.Get("body"); bodyVal != nil && !goja.IsUndefined(bodyVal) {
+			b, contentType, err := hp.toBody(bodyVal)
+			if err != nil {
+				return nil, err
+			}
+			if contentType != "" && header.Get("Content-Type") == "" {
+				header.Set("Content-Type", contentType)
+			}
+			req.body = b
+			base = nil
+		}
+	}
+	if base != nil && base.body.reader != nil {
+		// the body of the request is moved to the new request
+		if base.body.used || base.body.locked {
+			return nil, errBodyUsed
+		}
+		req.body = base.body.move()
+	}
+	if req.body.reader != nil && (req.method == http.MethodGet || req.method == http.MethodHead) {
+		return nil, errors.New("request with GET/HEAD method cannot have body")
+	}
+	_, req.headers = hp.newHeaders(header)
+	return req, nil
+}
+
+// normalizeMethod uppercases the standard methods, other methods are case-sensitive.
+func normalizeMethod(method string) (string, error) {
+	if method == "" || strings.IndexFunc(method, func(r rune) bool {
+		return !httpguts.IsTokenRune(r)
+	}) != -1 {
+		return "", errors.New("invalid method: " + method)
+	}
+	switch upper := strings.ToUpper(method); upper {
+	case "CONNECT", "TRACE", "TRACK":
+		return "", errors.New("method is forbidden: " + method)
+	case http.MethodDelete, http.MethodGet, http.MethodHead,
+		http.MethodOptions, http.MethodPost, http.MethodPut:
+		return upper, nil
+	}
+	return method, nil
+}
+
+func (r *Request) abortSignal() *AbortSignal {
+	if r.signal == nil {
+		return nil
+	}
+	return r.signal.Export().(*AbortSignal)
+}
+
+func (r *Request) Clone() *goja.Object {
+	b, err := r.body.clone()
+	if err != nil {
+		panic(r.hp.modCtx.Runtime().NewTypeError(err.Error()))
+	}
+	clone := *r
+	clone.body = b
+	_, clone.headers = r.hp.newHeaders(
+		r.headers.Export().(*Headers).header.Clone())
+	return r.hp.newObject(&clone, r.hp.requestProto)
+}
+
+func (r *Request) Text() *goja.Promise {
+	return r.body.text()
+}
+
+func (r *Request) Json() *goja.Promise {
+	return r.body.json()
+}
+
+func (r *Request) ArrayBuffer() *goja.Promise {
+	return r.body.arrayBuffer()
+}
+
+func (r *Request) Bytes() *goja.Promise {
+	return r.body.bytes()
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/dop251/goja"
+)
+
+// Response is a fetch response, or a response created by a module.
+type Response struct {
+	hp         *HttpModule
+	typ        string
+	url        string
+	redirected bool
+	status     int
+	statusText string
+	headers    *goja.Object
+	body       *body
+}
+
+func (hp *HttpModule) createResponseClass() *goja.Object {
+	rt := hp.modCtx.Runtime()
+	ctor := hp.newClass(func(call goja.ConstructorCall) *goja.Object {
+		resp, err := hp.newResponse(call.Argument(0), call.Argument(1), "")
+		if err != nil {
+			panic(rt.NewTypeError(err.Error()))
+		}
+		return hp.newObject(resp, call.This.Prototype())
+	})
+	hp.responseProto = ctor.Get("prototype").(*goja.Object)
+	defineGetters(rt, hp.responseProto, map[string]func(*Response) any{
+		"type":       func(r *Response) any { return r.typ },
+		"url":        func(r *Response) any { return r.url },
+		"redirected": func(r *Response) any { return r.redirected },
+		"status":     func(r *Response) any { return r.status },
+		"ok":         func(r *Response) any { return r.status >= 200 && r.status < 300 },
+		"statusText": func(r *Response) any { return r.statusText },
+		"headers":    func(r *Response) any { return r.headers },
+		"body":       func(r *Response) any { return r.body.streamObject() },
+		"bodyUsed":   func(r *Response) any { return r.body.bodyUsed() },
+	})
+	defineSymbolTag(rt, hp.responseProto, "Response")
+
+	ctor.Set("error", func() *goja.Object {
+		_, headers := hp.newHeaders(nil)
+		headers.Export().(*Headers).immutable = true
+		return hp.newObject(&Response{
+			hp: hp, typ: "error", headers: headers, body: hp.newBody(nil),
+		}, hp.responseProto)
+	})
+	ctor.Set("redirect", func(location string, status goja.Value) *goja.Object {
+		code := http.StatusFound
+		if status != nil && !goja.IsUndefined(status) {
+			code = int(status.ToInteger())
+		}
+		switch code {
+		case 301, 302, 303, 307, 308:
+		default:
+			panic(rt.NewTypeError("invalid redirect status"))
+		}
+		u, err := url.Parse(location)
+		if err != nil {
+			panic(rt.NewTypeError("invalid url: " + location))
+		}
+		header := http.Header{}
+		header.Set("Location", u.String())
+		_, headers := hp.newHeaders(header)
+		return hp.newObject(&Response{
+			hp: hp, typ: "default", status: code,
+			headers: headers, body: hp.newBody(nil),
+		}, hp.responseProto)
+	})
+	ctor.Set("json", func(data, init goja.Value) *goja.Object {
+		stringify, _ := goja.AssertFunction(rt.Get("JSON").ToObject(rt).Get("stringify"))
+		str, err := stringify(goja.Undefined(), data)
+		if err != nil {
+			panic(err)
+		} else if goja.IsUndefined(str) {
+			panic(rt.NewTypeError("data is not JSON serializable"))
+		}
+		resp, err := hp.newResponse(str, init, "application/json")
+		if err != nil {
+			panic(rt.NewTypeError(err.Error()))
+		}
+		return hp.newObject(resp, hp.responseProto)
+	})
+	return ctor
+}
+
+// newResponse creates a response from a body and the init options (status, statusText
+// and headers), contentType replaces the content type inferred from the body.
+func (hp *HttpModule) newResponse(bodyVal, init goja.Value, contentType string) (*Response, error) {
+	rt := hp.modCtx.Runtime()
+	resp := &Response{hp: hp, typ: "default", status: http.StatusOK}
+	header := http.Header{}
+	if init != nil && !goja.IsUndefined(init) && !goja.IsNull(init) {
+		opts := init.ToObject(rt)
+		if status := opts.Get("status"); status != nil && !goja.IsUndefined(status) {
+			if resp.status = int(status.ToInteger()); resp.status < 200 || resp.status > 599 {
+				return nil, errors.New("status must be between 200 and 599")
+			}
+		}
+		if statusText := opts.Get("statusText"); statusText != nil && !goja.IsUndefined(statusText) {
+			resp.statusText = statusText.String()
+		}
+		if headers := opts.Get("headers"); headers != nil && !goja.IsUndefined(headers) {
+			h, err := hp.toHeader(headers)
+			if err != nil {
+				return nil, err
+			}
+			header = h
+		}
+	}
+	b, bodyType, err := hp.toBody(bodyVal)
+	if err != nil {
+		return nil, err
+	} else if contentType == "" {
+		contentType = bodyType
+	}
+	if b.reader != nil {
+		if nullBodyStatus(resp.status) {
+			return nil, errors.New("response with null body status cannot have body")
+		}
+		if contentType != "" && header.Get("Content-Type") == "" {
+			header.Set("Content-Type", contentType)
+		}
+	}
+	resp.body = b
+	_, resp.headers = hp.newHeaders(header)
+	return resp, nil
+}
+
+func nullBodyStatus(status int) bool {
+	return status == 101 || status == 103 || status == 204 ||
+		status == 205 || status == 304
+}
+
+func (r *Response) Clone() *goja.Object {
+	b, err := r.body.clone()
+	if err != nil {
+		panic(r.hp.modCtx.Runtime().NewTypeError(err.Error()))
+	}
+	clone := *r
+	clone.body = b
+	h, headers := r.hp.newHeaders(
+		r.headers.Export().(*Headers).header.Clone())
+	h.immutable = r.headers.Export().(*Headers).immutable
+	clone.headers = headers
+	return r.hp.newObject(&clone, r.hp.responseProto)
+}
+
+func (r *Response) Text() *goja.Promise {
+	return r.body.text()
+}
+
+func (r *Response) Json() *goja.Promise {
+	return r.body.json()
+}
+
+func (r *Response) ArrayBuffer() *goja.Promise {
+	return r.body.arrayBuffer()
+}
+
+func (r *Response) Bytes() *goja.Promise {
+	return r.body.bytes()
+}
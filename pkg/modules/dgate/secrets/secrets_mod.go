@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"errors"
+
+	"github.com/dgate-io/dgate/pkg/modules"
+	"github.com/dgate-io/dgate/pkg/spec"
+)
+
+type SecretsModule struct {
+	modCtx modules.RuntimeContext
+}
+
+var _ modules.GoModule = &SecretsModule{}
+
+func New(modCtx modules.RuntimeContext) modules.GoModule {
+	return &SecretsModule{modCtx}
+}
+
+func (sm *SecretsModule) Exports() *modules.Exports {
+	return &modules.Exports{
+		Named: map[string]any{
+			"getSecret": sm.GetSecret,
+		},
+	}
+}
+
+// GetSecret returns the data of a secret in the namespace of the module,
+// undefined is returned when the secret does not exist.
+func (sm *SecretsModule) GetSecret(name string) (any, error) {
+	if name == "" {
+		return nil, errors.New("secret name cannot be empty")
+	}
+	if err := sm.modCtx.CheckPermission(spec.PermissionSecretRead + ":" + name); err != nil {
+		return nil, err
+	}
+	namespace := sm.modCtx.Context().
+		Value(spec.Name("namespace"))
+	if namespace == nil || namespace.(string) == "" {
+		return nil, errors.New("namespace is not set")
+	}
+	rm := sm.modCtx.State().ResourceManager()
+	if scrt, ok := rm.GetSecret(name, namespace.(string)); ok {
+		return scrt.Data, nil
+	}
+	return nil, nil
+}
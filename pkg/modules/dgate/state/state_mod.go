@@ -25,15 +25,18 @@ func (hp *ResourcesModule) Exports() *modules.Exports {
 			"getCollection":    hp.fetchCollection,
 			"getDocument":      hp.getDocument,
 			"getDocuments":     hp.getDocuments,
-			"addCollection":    writeFunc[*spec.Collection](hp, spec.AddCollectionCommand),
-			"addDocument":      writeFunc[*spec.Document](hp, spec.AddDocumentCommand),
-			"deleteCollection": writeFunc[*spec.Collection](hp, spec.DeleteCollectionCommand),
-			"deleteDocument":   writeFunc[*spec.Document](hp, spec.DeleteDocumentCommand),
+			"addCollection":    writeFunc[*spec.Collection](hp, spec.AddCollectionCommand, spec.PermissionCollectionWrite),
+			"addDocument":      writeFunc[*spec.Document](hp, spec.AddDocumentCommand, spec.PermissionDocumentWrite),
+			"deleteCollection": writeFunc[*spec.Collection](hp, spec.DeleteCollectionCommand, spec.PermissionCollectionWrite),
+			"deleteDocument":   writeFunc[*spec.Document](hp, spec.DeleteDocumentCommand, spec.PermissionDocumentWrite),
 		},
 	}
 }
 
-func (hp *ResourcesModule) fetchCollection(name string) *goja.Promise {
+func (hp *ResourcesModule) fetchCollection(name string) (*goja.Promise, error) {
+	if err := hp.modCtx.CheckPermission(spec.PermissionCollectionRead); err != nil {
+		return nil, err
+	}
 	ctx := hp.modCtx.Context()
 	state := hp.modCtx.State()
 	loop := hp.modCtx.EventLoop()
@@ -55,10 +58,13 @@ func (hp *ResourcesModule) fetchCollection(name string) *goja.Promise {
 		}
 		resolve(rt.ToValue(collection))
 	})
-	return docPromise
+	return docPromise, nil
 }
 
-func (hp *ResourcesModule) getDocument(docId, collection string) *goja.Promise {
+func (hp *ResourcesModule) getDocument(docId, collection string) (*goja.Promise, error) {
+	if err := hp.modCtx.CheckPermission(spec.PermissionDocumentRead); err != nil {
+		return nil, err
+	}
 	ctx := hp.modCtx.Context()
 	state := hp.modCtx.State()
 	loop := hp.modCtx.EventLoop()
@@ -78,7 +84,7 @@ func (hp *ResourcesModule) getDocument(docId, collection string) *goja.Promise {
 		}
 		resolve(rt.ToValue(doc))
 	})
-	return docPromise
+	return docPromise, nil
 }
 
 type FetchDocumentsPayload struct {
@@ -88,6 +94,9 @@ type FetchDocumentsPayload struct {
 }
 
 func (hp *ResourcesModule) getDocuments(payload FetchDocumentsPayload) (*goja.Promise, error) {
+	if err := hp.modCtx.CheckPermission(spec.PermissionDocumentRead); err != nil {
+		return nil, err
+	}
 	ctx := hp.modCtx.Context()
 	state := hp.modCtx.State()
 	loop := hp.modCtx.EventLoop()
@@ -121,8 +130,13 @@ func (hp *ResourcesModule) getDocuments(payload FetchDocumentsPayload) (*goja.Pr
 	return prom, nil
 }
 
-func writeFunc[T spec.Named](hp *ResourcesModule, cmd spec.Command) func(map[string]any) (*goja.Promise, error) {
+func writeFunc[T spec.Named](
+	hp *ResourcesModule, cmd spec.Command, permission string,
+) func(map[string]any) (*goja.Promise, error) {
 	return func(item map[string]any) (*goja.Promise, error) {
+		if err := hp.modCtx.CheckPermission(permission); err != nil {
+			return nil, err
+		}
 		if item == nil {
 			return nil, errors.New("item is nil")
 		}
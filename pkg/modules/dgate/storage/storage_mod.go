@@ -38,6 +38,9 @@ type CacheOptions struct {
 }
 
 func (sm *StorageModule) SetCache(cacheId string, val any, opts CacheOptions) error {
+	if err := sm.modCtx.CheckPermission(spec.PermissionCacheWrite); err != nil {
+		return err
+	}
 	if cacheId == "" {
 		return errors.New("cache id cannot be empty")
 	}
@@ -57,6 +60,9 @@ func (sm *StorageModule) SetCache(cacheId string, val any, opts CacheOptions) er
 }
 
 func (sm *StorageModule) GetCache(cacheId string) (any, error) {
+	if err := sm.modCtx.CheckPermission(spec.PermissionCacheRead); err != nil {
+		return nil, err
+	}
 	if cacheId == "" {
 		return nil, errors.New("cache id cannot be empty")
 	}
@@ -0,0 +1,255 @@
+package extractors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dgate-io/dgate/pkg/modules"
+	"github.com/dgate-io/dgate/pkg/modules/types"
+	"github.com/dgate-io/dgate/pkg/typescript"
+	"github.com/dop251/goja"
+	"github.com/dop251/goja_nodejs/console"
+)
+
+var moduleFunctionNames = []string{
+	"fetchUpstream",
+	"requestModifier",
+	"responseModifier",
+	"errorHandler",
+	"requestHandler",
+}
+
+// ChainModuleSource wraps the source of a module that is chained with other modules.
+// The module is scoped to a function, so the top level declarations of the modules
+// in a chain do not conflict, and the function returns the functions of the module.
+// The inline source map of the payload is moved after the function, so it is kept.
+func ChainModuleSource(payload string) string {
+	payload, sourceMap := typescript.SplitSourceMap(payload)
+	locals := make([]string, len(moduleFunctionNames))
+	for i, name := range moduleFunctionNames {
+		locals[i] = fmt.Sprintf(
+			"%s: typeof %s === 'function' ? %s : void 0",
+			name, name, name,
+		)
+	}
+	// the payload starts on the first line, so line numbers are not changed
+	return "(function (module, exports) {" + payload +
+		"\n;return { exports: module.exports, locals: { " +
+		strings.Join(locals, ", ") + " } };\n})\n" + sourceMap
+}
+
+// ChainModule is a module of a chain, the program must be compiled from ChainModuleSource.
+// Modules that do not run in the event loop (e.g. wasm modules) set Functions instead of Program.
+type ChainModule struct {
+	Name      string
+	Program   *goja.Program
+	Functions *ModuleFunctions
+	Limits    Limits
+}
+
+// ModuleFunctions are the functions defined by a module of a chain,
+// the functions that are not defined by the module are nil.
+type ModuleFunctions struct {
+	Module           string
+	FetchUpstream    FetchUpstreamUrlFunc
+	RequestModifier  RequestModifierFunc
+	ResponseModifier ResponseModifierFunc
+	ErrorHandler     ErrorHandlerFunc
+	RequestHandler   RequestHandlerFunc
+}
+
+// SetupModuleChainEventLoop sets up the event loop with all the modules of a chain and
+// extracts the functions of each module. Exported functions take precedence over the
+// functions declared by the module. fetchUpstream and requestHandler can only be defined
+// by one module of a chain, the chain has an export conflict when more modules define them.
+func SetupModuleChainEventLoop(
+	printer console.Printer,
+	rtCtx modules.RuntimeContext,
+	chain ...ChainModule,
+) (ModuleChain, error) {
+	if err := SetupModuleEventLoop(printer, rtCtx); err != nil {
+		return nil, err
+	}
+	rt := rtCtx.EventLoop().Runtime()
+	modChain := make(ModuleChain, len(chain))
+	for i, mod := range chain {
+		if mod.Functions != nil {
+			modChain[i] = mod.Functions
+			continue
+		}
+		fns, err := extractModuleFunctions(rt, mod)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", mod.Name, err)
+		}
+		modChain[i] = fns
+	}
+
+	owners := map[string][]string{}
+	for _, fns := range modChain {
+		if fns.FetchUpstream != nil {
+			owners["fetchUpstream"] = append(owners["fetchUpstream"], fns.Module)
+		}
+		if fns.RequestHandler != nil {
+			owners["requestHandler"] = append(owners["requestHandler"], fns.Module)
+		}
+	}
+	for _, name := range []string{"fetchUpstream", "requestHandler"} {
+		if len(owners[name]) > 1 {
+			return nil, fmt.Errorf(
+				"export conflict: %s is defined by modules %s, only one module of a route can define it",
+				name, strings.Join(owners[name], ", "),
+			)
+		}
+	}
+	return modChain, nil
+}
+
+func extractModuleFunctions(rt *goja.Runtime, mod ChainModule) (*ModuleFunctions, error) {
+	wrapper, err := rt.RunProgram(mod.Program)
+	if err != nil {
+		return nil, err
+	}
+	call, ok := goja.AssertFunction(wrapper)
+	if !ok {
+		return nil, errors.New("program is not a chain module")
+	}
+	module := rt.NewObject()
+	exports := rt.NewObject()
+	module.Set("exports", exports)
+	res, err := call(goja.Undefined(), module, exports)
+	if err != nil {
+		return nil, err
+	}
+	resObj := res.ToObject(rt)
+	locals := resObj.Get("locals").ToObject(rt)
+	var exported *goja.Object
+	if val := resObj.Get("exports"); !nully(val) {
+		exported = val.ToObject(rt)
+	}
+
+	fns := &ModuleFunctions{Module: mod.Name}
+	for _, name := range moduleFunctionNames {
+		val := locals.Get(name)
+		if exported != nil {
+			if exp := exported.Get(name); !nully(exp) {
+				val = exp
+			}
+		}
+		if nully(val) {
+			continue
+		}
+		fn, ok := goja.AssertFunction(val)
+		if !ok {
+			return nil, errors.New("extractors: invalid function -> " + name)
+		}
+		switch name {
+		case "fetchUpstream":
+			fns.FetchUpstream = fetchUpstreamFunc(rt, mod.Limits, fn)
+		case "requestModifier":
+			fns.RequestModifier = requestModifierFunc(rt, mod.Limits, fn)
+		case "responseModifier":
+			fns.ResponseModifier = responseModifierFunc(rt, mod.Limits, fn)
+		case "errorHandler":
+			fns.ErrorHandler = errorHandlerFunc(rt, mod.Limits, fn)
+		case "requestHandler":
+			fns.RequestHandler = requestHandlerFunc(rt, mod.Limits, fn)
+		}
+	}
+	return fns, nil
+}
+
+// ModuleChain is the functions of the modules of a route, in the order they were declared.
+type ModuleChain []*ModuleFunctions
+
+// FetchUpstream returns the fetchUpstream function of the chain, or the default when no module defines it.
+func (mc ModuleChain) FetchUpstream() FetchUpstreamUrlFunc {
+	for _, fns := range mc {
+		if fns.FetchUpstream != nil {
+			return fns.FetchUpstream
+		}
+	}
+	return DefaultFetchUpstreamFunction()
+}
+
+// RequestHandler returns the requestHandler function of the chain, or nil when no module defines it.
+func (mc ModuleChain) RequestHandler() RequestHandlerFunc {
+	for _, fns := range mc {
+		if fns.RequestHandler != nil {
+			return fns.RequestHandler
+		}
+	}
+	return nil
+}
+
+// RequestModifier runs the request modifiers in order, the chain stops
+// when a modifier returns an error or when it sends a response.
+func (mc ModuleChain) RequestModifier() RequestModifierFunc {
+	modifiers := []RequestModifierFunc{}
+	for _, fns := range mc {
+		if fns.RequestModifier != nil {
+			modifiers = append(modifiers, fns.RequestModifier)
+		}
+	}
+	if len(modifiers) == 0 {
+		return nil
+	}
+	return func(modCtx *types.ModuleContext) error {
+		for _, modifier := range modifiers {
+			if err := modifier(modCtx); err != nil {
+				return err
+			}
+			if types.ModuleContextResponseSent(modCtx) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// ResponseModifier runs the response modifiers in order, the chain stops when a modifier returns an error.
+func (mc ModuleChain) ResponseModifier() ResponseModifierFunc {
+	modifiers := []ResponseModifierFunc{}
+	for _, fns := range mc {
+		if fns.ResponseModifier != nil {
+			modifiers = append(modifiers, fns.ResponseModifier)
+		}
+	}
+	if len(modifiers) == 0 {
+		return nil
+	}
+	return func(modCtx *types.ModuleContext, res *http.Response) error {
+		for _, modifier := range modifiers {
+			if err := modifier(modCtx, res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ErrorHandler runs the error handlers in order until one of them sends a response or returns
+// an error, the default error handler is used when no module defines an error handler.
+func (mc ModuleChain) ErrorHandler() ErrorHandlerFunc {
+	handlers := []ErrorHandlerFunc{}
+	for _, fns := range mc {
+		if fns.ErrorHandler != nil {
+			handlers = append(handlers, fns.ErrorHandler)
+		}
+	}
+	if len(handlers) == 0 {
+		return DefaultErrorHandlerFunction()
+	}
+	return func(modCtx *types.ModuleContext, upstreamErr error) error {
+		for _, handler := range handlers {
+			if err := handler(modCtx, upstreamErr); err != nil {
+				return err
+			}
+			if types.ModuleContextResponseSent(modCtx) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
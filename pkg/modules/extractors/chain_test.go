@@ -0,0 +1,103 @@
+package extractors_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/testutil"
+	"github.com/dgate-io/dgate/pkg/modules/types"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dgate-io/dgate/pkg/typescript"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chainModule(t *testing.T, name, payload string) extractors.ChainModule {
+	program, err := goja.Compile(name, extractors.ChainModuleSource(payload), true)
+	require.NoError(t, err)
+	return extractors.ChainModule{Name: name, Program: program}
+}
+
+func TestSetupModuleChainEventLoop(t *testing.T) {
+	tsPayload, err := typescript.Transpile(context.Background(), `
+const requestModifier = async (ctx: any) => {
+	globalThis.calls.push("ts");
+};
+export { requestModifier };
+`)
+	require.NoError(t, err)
+	rtCtx := testutil.NewMockRuntimeContext()
+	chain, err := extractors.SetupModuleChainEventLoop(nil, rtCtx,
+		chainModule(t, "auth", `
+// declared, not exported
+function requestModifier(ctx) {
+	globalThis.calls.push("auth");
+}`),
+		chainModule(t, "transform", tsPayload),
+		chainModule(t, "logging", `
+const requestModifier = (ctx) => {
+	globalThis.calls.push("logging");
+};
+module.exports = { requestModifier, responseModifier: () => {} };`),
+	)
+	require.NoError(t, err)
+	require.Len(t, chain, 3)
+	assert.Equal(t, "auth", chain[0].Module)
+	assert.NotNil(t, chain[2].ResponseModifier)
+	assert.Nil(t, chain[0].ResponseModifier)
+	assert.Nil(t, chain.RequestHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	rw := httptest.NewRecorder()
+	modCtx := types.NewModuleContext(rtCtx.EventLoop(), rw, req,
+		&spec.DGateRoute{Namespace: &spec.DGateNamespace{}}, nil)
+	rtCtx.Runtime().Set("calls", []any{})
+	require.NoError(t, chain.RequestModifier()(modCtx))
+	assert.Equal(t, []any{"auth", "ts", "logging"},
+		rtCtx.Runtime().Get("calls").Export())
+}
+
+func TestSetupModuleChainEventLoop_ShortCircuit(t *testing.T) {
+	rtCtx := testutil.NewMockRuntimeContext()
+	chain, err := extractors.SetupModuleChainEventLoop(nil, rtCtx,
+		chainModule(t, "auth", `
+exports.requestModifier = (ctx) => {
+	ctx.response().status(401).end("unauthorized");
+};`),
+		chainModule(t, "transform", `
+exports.requestModifier = (ctx) => {
+	throw new Error("should not run");
+};`),
+	)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	rw := httptest.NewRecorder()
+	modCtx := types.NewModuleContext(rtCtx.EventLoop(), rw, req,
+		&spec.DGateRoute{Namespace: &spec.DGateNamespace{}}, nil)
+	require.NoError(t, chain.RequestModifier()(modCtx))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+}
+
+func TestSetupModuleChainEventLoop_ExportConflict(t *testing.T) {
+	rtCtx := testutil.NewMockRuntimeContext()
+	_, err := extractors.SetupModuleChainEventLoop(nil, rtCtx,
+		chainModule(t, "a", `exports.requestHandler = () => {};`),
+		chainModule(t, "b", `exports.requestModifier = () => {};`),
+		chainModule(t, "c", `function requestHandler() {}`),
+	)
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "export conflict: requestHandler"))
+		assert.Contains(t, err.Error(), "a, c")
+	}
+
+	_, err = extractors.SetupModuleChainEventLoop(nil, rtCtx,
+		chainModule(t, "a", `exports.requestModifier = "not a function";`),
+	)
+	assert.ErrorContains(t, err, "module a")
+}
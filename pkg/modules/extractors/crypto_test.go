@@ -0,0 +1,184 @@
+package extractors_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/testutil"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func ecKeyPem(t *testing.T) (*ecdsa.PrivateKey, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	privDer, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pubDer, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return key,
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}))
+}
+
+func TestCryptoModule_SignVerify(t *testing.T) {
+	_, privPem, pubPem := ecKeyPem(t)
+	rtCtx := testutil.NewMockRuntimeContext()
+	require.NoError(t, extractors.SetupModuleEventLoop(nil, rtCtx))
+	rt := rtCtx.Runtime()
+	rt.Set("privateKey", privPem)
+	rt.Set("publicKey", pubPem)
+
+	val, err := rt.RunString(`
+const { createSign, createVerify, sign, verify } = require("dgate/crypto");
+const sig = createSign("ES256").update("hello ").update("world").sign(privateKey, "base64url");
+[
+	createVerify("ES256").update("hello world").verify(publicKey, sig, "base64url"),
+	verify("ES256", publicKey, "hello world", sign("ES256", privateKey, "hello world", "hex"), "hex"),
+	verify("ES256", publicKey, "tampered", sig, "base64url"),
+]`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{true, true, false}, val.Export())
+
+	_, err = rt.RunString(`createSign("none")`)
+	assert.ErrorContains(t, err, "unknown signature algorithm")
+}
+
+// hmacToken returns a HS256 token signed with the secret
+func hmacToken(t *testing.T, secret []byte) string {
+	header, err := json.Marshal(map[string]any{"alg": "HS256", "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(map[string]any{"user": "abc"})
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestCryptoModule_JWT(t *testing.T) {
+	_, privPem, pubPem := ecKeyPem(t)
+	rtCtx := testutil.NewMockRuntimeContext()
+	require.NoError(t, extractors.SetupModuleEventLoop(nil, rtCtx))
+	rt := rtCtx.Runtime()
+	rt.Set("privateKey", privPem)
+	rt.Set("publicKey", pubPem)
+	rt.Set("forged", hmacToken(t, []byte(pubPem)))
+
+	_, err := rt.RunString(`
+const jwt = require("dgate/crypto/jwt");
+const token = jwt.sign({ user: "abc" }, privateKey, {
+	algorithm: "ES256", keyid: "key-1", expiresIn: 60,
+	audience: ["api"], issuer: "dgate",
+});
+const expired = jwt.sign({ exp: 1 }, privateKey, { algorithm: "ES256" });
+const hmac = jwt.sign({ user: "abc" }, "secret");`)
+	require.NoError(t, err)
+
+	val, err := rt.RunString(`jwt.verify(token, publicKey, { audience: "api", issuer: "dgate" }).user`)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", val.String())
+
+	val, err = rt.RunString(`jwt.decode(token).header`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"alg": "ES256", "typ": "JWT", "kid": "key-1"}, val.Export())
+
+	val, err = rt.RunString(`jwt.verify(hmac, "secret", {}).user`)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", val.String())
+
+	for script, errMsg := range map[string]string{
+		`jwt.verify(token, publicKey, { audience: "other" })`:     "jwt audience invalid",
+		`jwt.verify(token, publicKey, { issuer: "other" })`:       "jwt issuer invalid",
+		`jwt.verify(expired, publicKey, {})`:                      "jwt expired",
+		`jwt.verify(token, publicKey, { algorithms: ["RS256"] })`: "jwt algorithm not allowed",
+		`jwt.verify(hmac, "other", {})`:                           "jwt signature invalid",
+		// a public key can not be used as an HMAC secret
+		`jwt.sign({}, publicKey)`:                                           "HMAC algorithms cannot use a PEM encoded key",
+		`jwt.verify(forged, publicKey, {})`:                                 "jwt algorithm not allowed",
+		`jwt.verify(forged, publicKey, { algorithms: ["HS256", "ES256"] })`: "jwt algorithm not allowed",
+		`jwt.verify("a.b", publicKey, {})`:                                  "jwt malformed",
+	} {
+		_, err = rt.RunString(script)
+		assert.ErrorContains(t, err, errMsg, script)
+	}
+}
+
+func TestCryptoModule_FetchJwks(t *testing.T) {
+	key, privPem, _ := ecKeyPem(t)
+	b64 := base64.RawURLEncoding.EncodeToString
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []any{
+			map[string]any{
+				"kid": "key-1", "kty": "EC", "crv": "P-256",
+				"x": b64(key.X.FillBytes(make([]byte, 32))),
+				"y": b64(key.Y.FillBytes(make([]byte, 32))),
+			},
+		}})
+	}))
+	defer server.Close()
+
+	ps := proxy.NewProxyState(zap.NewNop(), configtest.NewTestDGateConfig())
+	route := &spec.DGateRoute{Namespace: &spec.DGateNamespace{}}
+	for i := 0; i < 2; i++ {
+		rtCtx := proxy.NewRuntimeContext(ps, route)
+		require.NoError(t, extractors.SetupModuleEventLoop(nil, rtCtx))
+		rt := rtCtx.EventLoop().Start()
+		rt.Set("privateKey", privPem)
+		rt.Set("jwksUrl", server.URL)
+
+		val, err := rt.RunString(`
+const jwt = require("dgate/crypto/jwt");
+(async () => {
+	const token = jwt.sign({ user: "abc" }, privateKey, { algorithm: "ES256", keyid: "key-1" });
+	const jwks = await jwt.fetchJwks(jwksUrl, { ttl: 60 });
+	return jwt.verify(token, jwks, {}).user;
+})`)
+		require.NoError(t, err)
+		fn, ok := goja.AssertFunction(val)
+		require.True(t, ok)
+		val, err = extractors.RunAndWaitForResult(rt, fn)
+		require.NoError(t, err)
+		assert.Equal(t, "abc", val.String())
+		rtCtx.EventLoop().Stop()
+	}
+	// the key set is shared by the runtimes
+	assert.Equal(t, int32(1), fetches.Load())
+}
+
+func TestCryptoModule_FetchJwksDisablePrivateIPs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer server.Close()
+
+	// the key set is fetched with the transport of the modules
+	conf := configtest.NewTestDGateConfig()
+	conf.ProxyConfig.Transport.DisablePrivateIPs = true
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	rtCtx := proxy.NewRuntimeContext(ps, &spec.DGateRoute{Namespace: &spec.DGateNamespace{}})
+	_, err := runAsync(t, rtCtx, `
+const jwt = require("dgate/crypto/jwt");
+return await jwt.fetchJwks("`+server.URL+`", {});`)
+	assert.ErrorContains(t, err, "private IP address not allowed")
+}
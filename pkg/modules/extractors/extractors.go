@@ -12,6 +12,7 @@ import (
 
 	"github.com/dgate-io/dgate/pkg/eventloop"
 	"github.com/dgate-io/dgate/pkg/modules/types"
+	"github.com/dgate-io/dgate/pkg/util"
 	"github.com/dop251/goja"
 )
 
@@ -38,6 +39,16 @@ func RunAndWait(
 	return err
 }
 
+func runAndWaitWithLimits(
+	rt *goja.Runtime,
+	limits Limits,
+	fn goja.Callable,
+	args ...goja.Value,
+) error {
+	_, err := RunAndWaitForResultWithLimits(rt, limits, fn, args...)
+	return err
+}
+
 // RunAndWaitForResult can execute a goja function and wait for the result
 // if the result is a promise, it will wait for the promise to resolve
 func RunAndWaitForResult(
@@ -45,29 +56,44 @@ func RunAndWaitForResult(
 	fn goja.Callable,
 	args ...goja.Value,
 ) (res goja.Value, err error) {
-	if res, err = fn(nil, args...); err != nil {
+	return RunAndWaitForResultWithLimits(rt, Limits{}, fn, args...)
+}
+
+// RunAndWaitForResultWithLimits is RunAndWaitForResult with the limits of the module,
+// the function is interrupted when it breaks a limit, and a *LimitError is returned.
+func RunAndWaitForResultWithLimits(
+	rt *goja.Runtime,
+	limits Limits,
+	fn goja.Callable,
+	args ...goja.Value,
+) (res goja.Value, err error) {
+	ctx, cancel := context.WithTimeout(
+		context.TODO(), limits.wallTime())
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	stop := limits.watchSync(rt, time.Until(deadline))
+	res, err = fn(nil, args...)
+	if limitErr := stop(); limitErr != nil {
+		return nil, limitErr
+	} else if err != nil {
 		return nil, err
 	} else if prom, ok := res.Export().(*goja.Promise); ok {
-		ctx, cancel := context.WithTimeout(
-			context.TODO(), 30*time.Second)
-		defer cancel()
 		if err = waitTimeout(ctx, func() bool {
 			return prom.State() != goja.PromiseStatePending
 		}); err != nil {
-			rt.Interrupt(err.Error())
-			return nil, errors.New("promise timed out: " + err.Error())
+			limitErr := limits.error(LimitWallTime)
+			rt.Interrupt(limitErr)
+			return nil, limitErr
 		}
 		if prom.State() == goja.PromiseStateRejected {
 			// no need to interrupt the runtime here
-			return nil, errors.New(prom.Result().String())
+			return nil, util.RejectionError(prom.Result())
 		}
 		results := prom.Result()
 		if nully(results) {
 			return nil, nil
 		}
 		return results, nil
-	} else if err != nil {
-		return nil, err
 	} else {
 		return res, nil
 	}
@@ -117,30 +143,11 @@ func DefaultFetchUpstreamFunction() FetchUpstreamUrlFunc {
 }
 
 func ExtractFetchUpstreamFunction(
-	loop *eventloop.EventLoop,
+	loop *eventloop.EventLoop, limits Limits,
 ) (fetchUpstream FetchUpstreamUrlFunc, err error) {
 	rt := loop.Runtime()
 	if fn, ok, err := functionExtractor(rt, "fetchUpstream"); ok {
-		fetchUpstream = func(modCtx *types.ModuleContext) (*url.URL, error) {
-			if res, err := RunAndWaitForResult(
-				rt, fn, rt.ToValue(modCtx),
-			); err != nil {
-				return nil, err
-			} else if nully(res) || res.String() == "" {
-				return nil, errors.New("fetchUpstream returned an invalid URL")
-			} else {
-				upstreamUrlString := res.String()
-				if !strings.Contains(upstreamUrlString, "://") {
-					upstreamUrlString += "http://"
-				}
-				upstreamUrl, err := url.Parse(upstreamUrlString)
-				if err != nil {
-					return nil, err
-				}
-				// perhaps add default scheme if not present
-				return upstreamUrl, err
-			}
-		}
+		fetchUpstream = fetchUpstreamFunc(rt, limits, fn)
 	} else if err != nil {
 		return nil, err
 	} else {
@@ -150,13 +157,11 @@ func ExtractFetchUpstreamFunction(
 }
 
 func ExtractRequestModifierFunction(
-	loop *eventloop.EventLoop,
+	loop *eventloop.EventLoop, limits Limits,
 ) (requestModifier RequestModifierFunc, err error) {
 	rt := loop.Runtime()
 	if fn, ok, err := functionExtractor(rt, "requestModifier"); ok {
-		requestModifier = func(modCtx *types.ModuleContext) error {
-			return RunAndWait(rt, fn, rt.ToValue(modCtx))
-		}
+		requestModifier = requestModifierFunc(rt, limits, fn)
 	} else if err != nil {
 		return nil, err
 	} else {
@@ -166,14 +171,11 @@ func ExtractRequestModifierFunction(
 }
 
 func ExtractResponseModifierFunction(
-	loop *eventloop.EventLoop,
+	loop *eventloop.EventLoop, limits Limits,
 ) (responseModifier ResponseModifierFunc, err error) {
 	rt := loop.Runtime()
 	if fn, ok, err := functionExtractor(rt, "responseModifier"); ok {
-		responseModifier = func(modCtx *types.ModuleContext, res *http.Response) error {
-			modCtx = types.ModuleContextWithResponse(modCtx, res)
-			return RunAndWait(rt, fn, rt.ToValue(modCtx))
-		}
+		responseModifier = responseModifierFunc(rt, limits, fn)
 	} else if err != nil {
 		return nil, err
 	} else {
@@ -204,17 +206,11 @@ func DefaultErrorHandlerFunction() ErrorHandlerFunc {
 }
 
 func ExtractErrorHandlerFunction(
-	loop *eventloop.EventLoop,
+	loop *eventloop.EventLoop, limits Limits,
 ) (errorHandler ErrorHandlerFunc, err error) {
 	rt := loop.Runtime()
 	if fn, ok, err := functionExtractor(rt, "errorHandler"); ok {
-		errorHandler = func(modCtx *types.ModuleContext, upstreamErr error) error {
-			modCtx = types.ModuleContextWithError(modCtx, upstreamErr)
-			return RunAndWait(
-				rt, fn, rt.ToValue(modCtx),
-				rt.ToValue(rt.NewGoError(upstreamErr)),
-			)
-		}
+		errorHandler = errorHandlerFunc(rt, limits, fn)
 	} else if err != nil {
 		return nil, err
 	} else {
@@ -224,15 +220,11 @@ func ExtractErrorHandlerFunction(
 }
 
 func ExtractRequestHandlerFunction(
-	loop *eventloop.EventLoop,
+	loop *eventloop.EventLoop, limits Limits,
 ) (requestHandler RequestHandlerFunc, err error) {
 	rt := loop.Runtime()
 	if fn, ok, err := functionExtractor(rt, "requestHandler"); ok {
-		requestHandler = func(modCtx *types.ModuleContext) error {
-			return RunAndWait(
-				rt, fn, rt.ToValue(modCtx),
-			)
-		}
+		requestHandler = requestHandlerFunc(rt, limits, fn)
 	} else if err != nil {
 		return nil, err
 	} else {
@@ -241,6 +233,60 @@ func ExtractRequestHandlerFunction(
 	return requestHandler, nil
 }
 
+func fetchUpstreamFunc(rt *goja.Runtime, limits Limits, fn goja.Callable) FetchUpstreamUrlFunc {
+	return func(modCtx *types.ModuleContext) (*url.URL, error) {
+		if res, err := RunAndWaitForResultWithLimits(
+			rt, limits, fn, rt.ToValue(modCtx),
+		); err != nil {
+			return nil, err
+		} else if nully(res) || res.String() == "" {
+			return nil, errors.New("fetchUpstream returned an invalid URL")
+		} else {
+			upstreamUrlString := res.String()
+			if !strings.Contains(upstreamUrlString, "://") {
+				upstreamUrlString += "http://"
+			}
+			upstreamUrl, err := url.Parse(upstreamUrlString)
+			if err != nil {
+				return nil, err
+			}
+			// perhaps add default scheme if not present
+			return upstreamUrl, err
+		}
+	}
+}
+
+func requestModifierFunc(rt *goja.Runtime, limits Limits, fn goja.Callable) RequestModifierFunc {
+	return func(modCtx *types.ModuleContext) error {
+		return runAndWaitWithLimits(rt, limits, fn, rt.ToValue(modCtx))
+	}
+}
+
+func responseModifierFunc(rt *goja.Runtime, limits Limits, fn goja.Callable) ResponseModifierFunc {
+	return func(modCtx *types.ModuleContext, res *http.Response) error {
+		modCtx = types.ModuleContextWithResponse(modCtx, res)
+		return runAndWaitWithLimits(rt, limits, fn, rt.ToValue(modCtx))
+	}
+}
+
+func errorHandlerFunc(rt *goja.Runtime, limits Limits, fn goja.Callable) ErrorHandlerFunc {
+	return func(modCtx *types.ModuleContext, upstreamErr error) error {
+		modCtx = types.ModuleContextWithError(modCtx, upstreamErr)
+		return runAndWaitWithLimits(
+			rt, limits, fn, rt.ToValue(modCtx),
+			rt.ToValue(rt.NewGoError(upstreamErr)),
+		)
+	}
+}
+
+func requestHandlerFunc(rt *goja.Runtime, limits Limits, fn goja.Callable) RequestHandlerFunc {
+	return func(modCtx *types.ModuleContext) error {
+		return runAndWaitWithLimits(
+			rt, limits, fn, rt.ToValue(modCtx),
+		)
+	}
+}
+
 func functionExtractor(rt *goja.Runtime, varName string) (goja.Callable, bool, error) {
 	check := fmt.Sprintf(
 		"exports?.%s ?? (typeof %s === 'function' ? %s : void 0)",
@@ -0,0 +1,222 @@
+package extractors_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/internal/config/configtest"
+	"github.com/dgate-io/dgate/internal/proxy"
+	"github.com/dgate-io/dgate/pkg/modules"
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/testutil"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// runAsync runs the async function of the script on a started event loop
+func runAsync(t *testing.T, rtCtx modules.RuntimeContext, script string) (goja.Value, error) {
+	require.NoError(t, extractors.SetupModuleEventLoop(nil, rtCtx))
+	rt := rtCtx.EventLoop().Start()
+	t.Cleanup(func() { rtCtx.EventLoop().Stop() })
+	val, err := rt.RunString("(async () => {" + script + "})")
+	require.NoError(t, err)
+	fn, ok := goja.AssertFunction(val)
+	require.True(t, ok)
+	return extractors.RunAndWaitForResult(rt, fn)
+}
+
+func fetchTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.Write(body)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/echo", http.StatusFound)
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			w.Write([]byte("chunk;"))
+			w.(http.Flusher).Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetch_Classes(t *testing.T) {
+	val, err := runAsync(t, testutil.NewMockRuntimeContext(), `
+const headers = new Headers([["X-B", "2"], ["x-a", "1"]]);
+headers.append("X-A", "3");
+const req = new Request("http://example.com/path", {
+	method: "post", headers, body: JSON.stringify({ a: 1 }),
+});
+const res = Response.json({ ok: true }, { status: 201, headers: { "X-Test": "yes" } });
+const clone = res.clone();
+return [
+	[...headers], headers.get("x-a"), headers.has("X-B"), headers.get("missing"),
+	req.method, req.url, req.headers.get("content-type"), (await req.json()).a, req.bodyUsed,
+	res.status, res.ok, res.headers.get("content-type"), res.headers.get("x-test"),
+	(await res.json()).ok, await clone.text(), res instanceof Response,
+	Object.prototype.toString.call(headers),
+];`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		[]any{[]any{"x-a", "1, 3"}, []any{"x-b", "2"}}, "1, 3", true, nil,
+		"POST", "http://example.com/path", "text/plain;charset=UTF-8", int64(1), true,
+		int64(201), true, "application/json", "yes",
+		true, `{"ok":true}`, true, "[object Headers]",
+	}, val.Export())
+
+	for script, errMsg := range map[string]string{
+		`new Request("/relative")`:                                    "an absolute http or https url is required",
+		`new Request("http://example.com", { body: "a" })`:            "GET/HEAD method cannot have body",
+		`new Request("http://example.com", { method: "CONNECT" })`:    "method is forbidden",
+		`new Headers({ "bad header": "a" })`:                          "invalid header name",
+		`new Response("a", { status: 204 })`:                          "null body status cannot have body",
+		`const r = new Response("a"); await r.text(); await r.text()`: "body already used",
+	} {
+		_, err = runAsync(t, testutil.NewMockRuntimeContext(), script)
+		assert.ErrorContains(t, err, errMsg, script)
+	}
+}
+
+func TestFetch_Request(t *testing.T) {
+	server := fetchTestServer(t)
+	rtCtx := testutil.NewMockRuntimeContext()
+	rtCtx.EventLoop().Runtime().Set("baseUrl", server.URL)
+	val, err := runAsync(t, rtCtx, `
+const res = await fetch(new Request(baseUrl + "/echo", {
+	method: "PUT", body: "hello", headers: { "Content-Type": "text/plain" },
+}));
+const redirect = await fetch(baseUrl + "/redirect", { redirect: "manual" });
+const followed = await fetch(baseUrl + "/redirect");
+let immutable;
+try { res.headers.set("x-a", "1") } catch (e) { immutable = e.message }
+return [
+	res.status, res.ok, res.statusText, res.headers.get("x-method"),
+	res.headers.getSetCookie(), await res.text(), immutable,
+	redirect.status, redirect.headers.get("location"),
+	followed.redirected, followed.url,
+];`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{
+		int64(200), true, "OK", "PUT",
+		[]string{"a=1", "b=2"}, "hello", "headers are immutable",
+		int64(302), "/echo",
+		true, server.URL + "/echo",
+	}, val.Export())
+
+	_, err = runAsync(t, testutil.NewMockRuntimeContext(), `
+await fetch("`+server.URL+`/redirect", { redirect: "error" })`)
+	assert.ErrorContains(t, err, "redirects not allowed")
+}
+
+func TestFetch_StreamBody(t *testing.T) {
+	server := fetchTestServer(t)
+	val, err := runAsync(t, testutil.NewMockRuntimeContext(), `
+const res = await fetch("`+server.URL+`/stream");
+const reader = res.body.getReader();
+const decoder = [];
+let locked = res.body.locked, chunks = 0;
+for (;;) {
+	const { done, value } = await reader.read();
+	if (done) break;
+	chunks++;
+	decoder.push(String.fromCharCode(...value));
+}
+return [locked, chunks > 0, decoder.join(""), res.bodyUsed];`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{true, true, "chunk;chunk;chunk;", true}, val.Export())
+}
+
+func TestFetch_AbortAndTimeout(t *testing.T) {
+	server := fetchTestServer(t)
+	for script, errMsg := range map[string]string{
+		`const ctrl = new AbortController();
+		const res = fetch("` + server.URL + `/slow", { signal: ctrl.signal });
+		setTimeout(() => ctrl.abort(), 10);
+		await res;`: "AbortError",
+		`const ctrl = new AbortController();
+		ctrl.abort(new Error("custom reason"));
+		await fetch("` + server.URL + `/slow", { signal: ctrl.signal });`: "custom reason",
+		`await fetch("` + server.URL + `/slow", { timeout: 20 });`:                     "TimeoutError",
+		`await fetch("` + server.URL + `/slow", { signal: AbortSignal.timeout(20) });`: "TimeoutError",
+	} {
+		start := time.Now()
+		_, err := runAsync(t, testutil.NewMockRuntimeContext(), script)
+		assert.ErrorContains(t, err, errMsg, script)
+		assert.Less(t, time.Since(start), 2*time.Second)
+	}
+
+	val, err := runAsync(t, testutil.NewMockRuntimeContext(), `
+const ctrl = new AbortController();
+const events = [];
+ctrl.signal.onabort = (e) => events.push("onabort:" + e.type);
+ctrl.signal.addEventListener("abort", () => events.push("listener"));
+ctrl.abort();
+ctrl.abort();
+return [ctrl.signal.aborted, ctrl.signal.reason.name, events];`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{true, "AbortError", []any{"onabort:abort", "listener"}}, val.Export())
+}
+
+func TestFetch_DisablePrivateIPs(t *testing.T) {
+	server := fetchTestServer(t)
+	conf := configtest.NewTestDGateConfig()
+	conf.ProxyConfig.Transport.DisablePrivateIPs = true
+	ps := proxy.NewProxyState(zap.NewNop(), conf)
+	rtCtx := proxy.NewRuntimeContext(ps, &spec.DGateRoute{Namespace: &spec.DGateNamespace{}})
+	_, err := runAsync(t, rtCtx, `await fetch("`+server.URL+`/echo")`)
+	assert.ErrorContains(t, err, "private IP address not allowed")
+
+	// link-local (e.g. cloud metadata endpoints) and unspecified addresses
+	for _, u := range []string{
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]:8080",
+		"http://0.0.0.0:8080",
+		"http://[::]:8080",
+	} {
+		rtCtx := proxy.NewRuntimeContext(ps, &spec.DGateRoute{Namespace: &spec.DGateNamespace{}})
+		_, err := runAsync(t, rtCtx, `await fetch("`+u+`")`)
+		assert.ErrorContains(t, err, "private IP address not allowed", u)
+	}
+}
+
+func TestFetch_TLS(t *testing.T) {
+	errLog := &bytes.Buffer{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ErrorLog = log.New(errLog, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	ps := proxy.NewProxyState(zap.NewNop(), configtest.NewTestDGateConfig())
+	rtCtx := proxy.NewRuntimeContext(ps, &spec.DGateRoute{Namespace: &spec.DGateNamespace{}})
+	// the certificate of the test server is not trusted, so the handshake fails
+	_, err := runAsync(t, rtCtx, `await fetch("`+server.URL+`")`)
+	assert.ErrorContains(t, err, "certificate")
+	server.Close()
+	assert.NotContains(t, errLog.String(), "client sent an HTTP request to an HTTPS server")
+}
@@ -0,0 +1,80 @@
+package extractors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dgate-io/dgate/pkg/eventloop"
+	"github.com/dgate-io/dgate/pkg/scheduler"
+	"github.com/dop251/goja"
+)
+
+// Job is a job exported by a module, the job is run by the scheduler of the proxy.
+type Job struct {
+	Name     string
+	Spec     string
+	Schedule scheduler.Schedule
+	Run      func() error
+}
+
+// JobContext is the argument of the handler of a job
+type JobContext struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+}
+
+// ExtractJobs returns the jobs exported by the module, jobs are exported by name:
+//
+//	export const jobs = {
+//		cleanup: { schedule: "@every 1m", handler: async (job) => {} },
+//	};
+//
+// The jobs are sorted by name, an invalid job or schedule is an error.
+func ExtractJobs(loop *eventloop.EventLoop, limits Limits) ([]Job, error) {
+	rt := loop.Runtime()
+	jobsVal, err := rt.RunString(
+		"module?.exports?.jobs ?? exports?.jobs ?? " +
+			"(typeof jobs === 'object' ? jobs : void 0)",
+	)
+	if err != nil {
+		return nil, err
+	} else if nully(jobsVal) {
+		return nil, nil
+	}
+	jobsObj, ok := jobsVal.(*goja.Object)
+	if !ok {
+		return nil, errors.New("extractors: jobs must be an object")
+	}
+	names := jobsObj.Keys()
+	sort.Strings(names)
+	jobs := make([]Job, 0, len(names))
+	for _, name := range names {
+		jobObj, ok := jobsObj.Get(name).(*goja.Object)
+		if !ok {
+			return nil, fmt.Errorf("extractors: job %s must be an object", name)
+		}
+		spec := jobObj.Get("schedule")
+		if nully(spec) {
+			return nil, fmt.Errorf("extractors: job %s has no schedule", name)
+		}
+		schedule, err := scheduler.ParseSchedule(spec.String())
+		if err != nil {
+			return nil, fmt.Errorf("extractors: job %s: %w", name, err)
+		}
+		handler, ok := goja.AssertFunction(jobObj.Get("handler"))
+		if !ok {
+			return nil, fmt.Errorf("extractors: job %s has no handler function", name)
+		}
+		jobCtx := &JobContext{Name: name, Schedule: spec.String()}
+		jobs = append(jobs, Job{
+			Name:     name,
+			Spec:     jobCtx.Schedule,
+			Schedule: schedule,
+			Run: func() error {
+				return runAndWaitWithLimits(rt, limits, handler, rt.ToValue(jobCtx))
+			},
+		})
+	}
+	return jobs, nil
+}
@@ -0,0 +1,83 @@
+package extractors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgate-io/dgate/pkg/modules"
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/testutil"
+	"github.com/dgate-io/dgate/pkg/typescript"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const TS_PAYLOAD_JOBS = `
+export let runs: string[] = [];
+export const jobs = {
+	refresh: {
+		schedule: "@every 1m",
+		handler: async (job: any) => {
+			runs.push(job.name + ":" + job.schedule);
+		},
+	},
+	cleanup: {
+		schedule: "@daily",
+		handler: (job: any) => {
+			throw new Error("cleanup failed");
+		},
+	},
+};
+`
+
+func setupJobsRuntime(t *testing.T, payload string) modules.RuntimeContext {
+	src, err := typescript.Transpile(context.Background(), payload)
+	require.NoError(t, err)
+	program, err := goja.Compile("test", src, true)
+	require.NoError(t, err)
+	rtCtx := testutil.NewMockRuntimeContext()
+	require.NoError(t, extractors.SetupModuleEventLoop(
+		testutil.NewMockPrinter(), rtCtx, program))
+	return rtCtx
+}
+
+func TestExtractJobs(t *testing.T) {
+	rtCtx := setupJobsRuntime(t, TS_PAYLOAD_JOBS)
+	rt := rtCtx.EventLoop().Start()
+	defer rtCtx.EventLoop().Stop()
+
+	jobs, err := extractors.ExtractJobs(rtCtx.EventLoop(), extractors.Limits{})
+	require.NoError(t, err)
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, "cleanup", jobs[0].Name)
+		assert.Equal(t, "@daily", jobs[0].Spec)
+		assert.ErrorContains(t, jobs[0].Run(), "cleanup failed")
+
+		assert.Equal(t, "refresh", jobs[1].Name)
+		assert.NoError(t, jobs[1].Run())
+		runs, err := rt.RunString("exports.runs.join(',')")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh:@every 1m", runs.String())
+	}
+}
+
+func TestExtractJobs_None(t *testing.T) {
+	rtCtx := setupJobsRuntime(t, `export const requestModifier = (ctx: any) => {};`)
+	jobs, err := extractors.ExtractJobs(rtCtx.EventLoop(), extractors.Limits{})
+	assert.NoError(t, err)
+	assert.Empty(t, jobs)
+}
+
+func TestExtractJobs_Invalid(t *testing.T) {
+	for payload, msg := range map[string]string{
+		`export const jobs = "test";`:                                               "jobs must be an object",
+		`export const jobs = { a: { handler: () => {} } };`:                         "job a has no schedule",
+		`export const jobs = { a: { schedule: "@every 1m" } };`:                     "job a has no handler function",
+		`export const jobs = { a: { schedule: "@sometimes", handler: () => {} } };`: "invalid schedule",
+	} {
+		rtCtx := setupJobsRuntime(t, payload)
+		_, err := extractors.ExtractJobs(rtCtx.EventLoop(), extractors.Limits{})
+		assert.ErrorContains(t, err, msg, payload)
+	}
+}
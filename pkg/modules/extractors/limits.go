@@ -0,0 +1,99 @@
+package extractors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// DefaultWallTime is the wall time limit of the module functions without one
+const DefaultWallTime = 30 * time.Second
+
+const (
+	LimitCPUTime        = "cpu_time"
+	LimitWallTime       = "wall_time"
+	LimitMaxAllocations = "max_allocations"
+)
+
+// Limits limit the execution of the functions of a module, zero values are not limited.
+type Limits struct {
+	// Module is the name of the module, used in the errors
+	Module string
+	// CPUTime is the max time a function can run synchronously,
+	// until it returns or awaits a promise.
+	CPUTime time.Duration
+	// WallTime is the max time of a call, including the promises it awaits,
+	// DefaultWallTime is used when it is zero.
+	WallTime time.Duration
+	// MaxAllocations is the max bytes of memory of wasm module instances. It does not
+	// limit javascript modules, the allocations of a runtime cannot be measured apart
+	// from the allocations of the other requests of the process.
+	MaxAllocations uint64
+}
+
+// LimitError is returned when a function breaks one of the limits of the module,
+// the runtime stays interrupted, so it cannot be used again.
+type LimitError struct {
+	Module string
+	Limit  string
+	Value  string
+}
+
+func (e *LimitError) Error() string {
+	if e.Module == "" {
+		return fmt.Sprintf("module exceeded the %s limit (%s)", e.Limit, e.Value)
+	}
+	return fmt.Sprintf("module %s exceeded the %s limit (%s)", e.Module, e.Limit, e.Value)
+}
+
+func (l Limits) wallTime() time.Duration {
+	if l.WallTime > 0 {
+		return l.WallTime
+	}
+	return DefaultWallTime
+}
+
+func (l Limits) error(limit string) *LimitError {
+	err := &LimitError{Module: l.Module, Limit: limit}
+	switch limit {
+	case LimitCPUTime:
+		err.Value = l.CPUTime.String()
+	case LimitWallTime:
+		err.Value = l.wallTime().String()
+	}
+	return err
+}
+
+// watchSync interrupts the runtime when the synchronous part of a call runs
+// longer than timeout, stop returns the broken limit.
+func (l Limits) watchSync(rt *goja.Runtime, timeout time.Duration) (stop func() *LimitError) {
+	var (
+		mu       sync.Mutex
+		stopped  bool
+		exceeded *LimitError
+	)
+	interrupt := func(err *LimitError) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped && exceeded == nil {
+			exceeded = err
+			rt.Interrupt(err)
+		}
+	}
+	limit := LimitWallTime
+	if l.CPUTime > 0 && l.CPUTime < timeout {
+		limit, timeout = LimitCPUTime, l.CPUTime
+	}
+	timer := time.AfterFunc(timeout, func() {
+		interrupt(l.error(limit))
+	})
+	return func() *LimitError {
+		timer.Stop()
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		return exceeded
+	}
+}
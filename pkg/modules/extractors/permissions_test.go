@@ -0,0 +1,68 @@
+package extractors_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/testutil"
+	"github.com/dgate-io/dgate/pkg/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModulePermissions_Env(t *testing.T) {
+	if _, ok := extractors.EnvVarMap["PATH"]; !ok {
+		t.Skip("PATH is not set")
+	}
+	rtCtx := testutil.NewMockRuntimeContext().
+		WithPermissions(spec.PermissionEnvRead + ":PATH")
+	require.NoError(t, extractors.SetupModuleEventLoop(nil, rtCtx))
+	rt := rtCtx.Runtime()
+
+	val, err := rt.RunString(`process.env.PATH`)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getenv("PATH"), val.String())
+
+	val, err = rt.RunString(`Object.keys(process.env)`)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"PATH"}, val.Export())
+
+	_, err = rt.RunString(`process.env.HOME`)
+	assert.ErrorContains(t, err, "permission denied: module 'mock' requires the permission 'os:env:read:HOME'")
+
+	val, err = rt.RunString(`process.env.PATH = "changed"; process.env.PATH`)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getenv("PATH"), val.String())
+}
+
+func TestModulePermissions_Modules(t *testing.T) {
+	rtCtx := testutil.NewMockRuntimeContext().
+		WithPermissions(spec.PermissionNetHTTP+":allowed.test", "resource:*:read")
+	require.NoError(t, extractors.SetupModuleEventLoop(nil, rtCtx))
+	rt := rtCtx.Runtime()
+
+	_, err := rt.RunString(`fetch("http://denied.test/path", {})`)
+	assert.ErrorContains(t, err, "requires the permission 'os:net:http:denied.test'")
+
+	_, err = rt.RunString(`require("dgate/state").addDocument({id: "1"})`)
+	assert.ErrorContains(t, err, "requires the permission 'resource:document:write'")
+
+	_, err = rt.RunString(`require("dgate/storage").setCache("id", 1, {ttl: 1})`)
+	assert.ErrorContains(t, err, "requires the permission 'resource:cache:write'")
+}
+
+func TestModulePermissionGranted(t *testing.T) {
+	assert.True(t, spec.ModulePermissionGranted(nil, spec.PermissionDocumentWrite))
+	assert.False(t, spec.ModulePermissionGranted([]string{}, spec.PermissionDocumentRead))
+	granted := []string{"resource:*:read", spec.PermissionNetHTTP}
+	assert.True(t, spec.ModulePermissionGranted(granted, spec.PermissionCollectionRead))
+	assert.False(t, spec.ModulePermissionGranted(granted, spec.PermissionDocumentWrite))
+	assert.True(t, spec.ModulePermissionGranted(granted, spec.PermissionNetHTTP+":example.com"))
+
+	assert.NoError(t, spec.ValidateModulePermissions([]string{
+		"*", "resource:*", spec.PermissionEnvRead + ":API_KEY",
+	}))
+	assert.Error(t, spec.ValidateModulePermissions([]string{"os:file:write"}))
+	assert.Error(t, spec.ValidateModulePermissions([]string{"resource:document:read:x"}))
+}
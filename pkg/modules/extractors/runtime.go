@@ -8,6 +8,7 @@ import (
 
 	"github.com/dgate-io/dgate/pkg/modules"
 	"github.com/dgate-io/dgate/pkg/modules/dgate"
+	"github.com/dgate-io/dgate/pkg/spec"
 	"github.com/dop251/goja"
 	"github.com/dop251/goja_nodejs/buffer"
 	"github.com/dop251/goja_nodejs/console"
@@ -42,26 +43,71 @@ func getEnvVarMap() map[string]string {
 	return envMap
 }
 
-func prepareRuntime(rt *goja.Runtime) {
+func prepareRuntime(rtCtx modules.RuntimeContext) {
+	rt := rtCtx.Runtime()
 	rt.SetFieldNameMapper(&smartMapper{})
 	module := rt.NewObject()
 	exports := rt.NewObject()
 	module.Set("exports", exports)
-	po := processObject(rt)
+	po := processObject(rtCtx)
 	rt.GlobalObject().
 		Set("process", po)
 	rt.Set("module", module)
 	rt.Set("exports", exports)
 }
 
-func processObject(rt *goja.Runtime) *goja.Object {
+func processObject(rtCtx modules.RuntimeContext) *goja.Object {
+	rt := rtCtx.Runtime()
 	obj := rt.NewObject()
-	obj.Set("env", EnvVarMap)
+	obj.Set("env", rt.NewDynamicObject(&envObject{rtCtx}))
 	hostname, _ := os.Hostname()
 	obj.Set("host", hostname)
 	return obj
 }
 
+// envObject is process.env, reading a variable requires the os:env:read
+// permission for the variable. The variables can not be changed.
+type envObject struct {
+	rtCtx modules.RuntimeContext
+}
+
+var _ goja.DynamicObject = &envObject{}
+
+func (e *envObject) Get(key string) goja.Value {
+	rt := e.rtCtx.Runtime()
+	if err := e.rtCtx.CheckPermission(spec.PermissionEnvRead + ":" + key); err != nil {
+		panic(rt.NewGoError(err))
+	}
+	if val, ok := EnvVarMap[key]; ok {
+		return rt.ToValue(val)
+	}
+	return goja.Undefined()
+}
+
+func (e *envObject) Has(key string) bool {
+	_, ok := EnvVarMap[key]
+	return ok && e.rtCtx.HasPermission(spec.PermissionEnvRead+":"+key)
+}
+
+func (e *envObject) Keys() []string {
+	all := e.rtCtx.HasPermission(spec.PermissionEnvRead)
+	keys := make([]string, 0, len(EnvVarMap))
+	for key := range EnvVarMap {
+		if all || e.rtCtx.HasPermission(spec.PermissionEnvRead+":"+key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (e *envObject) Set(string, goja.Value) bool {
+	return false
+}
+
+func (e *envObject) Delete(string) bool {
+	return false
+}
+
 func SetupModuleEventLoop(
 	printer console.Printer,
 	rtCtx modules.RuntimeContext,
@@ -69,7 +115,7 @@ func SetupModuleEventLoop(
 ) error {
 	loop := rtCtx.EventLoop()
 	rt := loop.Runtime()
-	prepareRuntime(rt)
+	prepareRuntime(rtCtx)
 
 	req := loop.Registry()
 	if registerModules(
@@ -88,7 +134,13 @@ func SetupModuleEventLoop(
 	buffer.Enable(rt)
 	console.Enable(rt)
 
-	rt.Set("fetch", require.Require(rt, "dgate/http").ToObject(rt).Get("fetch"))
+	httpMod := require.Require(rt, "dgate/http").ToObject(rt)
+	for _, name := range []string{
+		"fetch", "Headers", "Request", "Response",
+		"AbortController", "AbortSignal",
+	} {
+		rt.Set(name, httpMod.Get(name))
+	}
 	rt.Set("console", require.Require(rt, "dgate_internal:console").ToObject(rt))
 	rt.Set("disableSetInterval", disableSetInterval)
 
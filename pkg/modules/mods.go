@@ -2,6 +2,7 @@ package modules
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/dgate-io/dgate/pkg/cache"
 	"github.com/dgate-io/dgate/pkg/eventloop"
@@ -32,6 +33,8 @@ type StateManager interface {
 	DocumentManager() resources.DocumentManager
 	Scheduler() scheduler.Scheduler
 	SharedCache() cache.TCache
+	// HttpTransport is the transport shared by the modules to send requests
+	HttpTransport() http.RoundTripper
 }
 
 type RuntimeContext interface {
@@ -39,4 +42,21 @@ type RuntimeContext interface {
 	EventLoop() *eventloop.EventLoop
 	Runtime() *goja.Runtime
 	State() StateManager
+	// HasPermission returns true if the module calling into
+	// the runtime context has the permission.
+	HasPermission(permission string) bool
+	// CheckPermission returns a *PermissionError if the module calling
+	// into the runtime context does not have the permission.
+	CheckPermission(permission string) error
+}
+
+// PermissionError is returned when a module uses something it has no permission for
+type PermissionError struct {
+	Module     string
+	Permission string
+}
+
+func (e *PermissionError) Error() string {
+	return "permission denied: module '" + e.Module +
+		"' requires the permission '" + e.Permission + "'"
 }
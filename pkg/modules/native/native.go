@@ -0,0 +1,152 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"plugin"
+	"reflect"
+	"sync"
+
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/types"
+)
+
+// SymbolName is the name of the symbol plugins export, the value
+// of the symbol implements one or more of the hook interfaces.
+const SymbolName = "Module"
+
+type (
+	FetchUpstream interface {
+		FetchUpstream(*types.ModuleContext) (*url.URL, error)
+	}
+	RequestModifier interface {
+		RequestModifier(*types.ModuleContext) error
+	}
+	ResponseModifier interface {
+		ResponseModifier(*types.ModuleContext, *http.Response) error
+	}
+	ErrorHandler interface {
+		ErrorHandler(*types.ModuleContext, error) error
+	}
+	RequestHandler interface {
+		RequestHandler(*types.ModuleContext) error
+	}
+)
+
+// Module is a native module, the hooks are called
+// by many requests at the same time.
+type Module struct {
+	name string
+	fns  extractors.ModuleFunctions
+}
+
+var (
+	registry   = map[string]*Module{}
+	registryMu sync.RWMutex
+)
+
+// New creates a native module from a value that implements one or more of the hook interfaces.
+func New(name string, v any) (*Module, error) {
+	mod := &Module{name: name, fns: extractors.ModuleFunctions{Module: name}}
+	if fn, ok := v.(FetchUpstream); ok {
+		mod.fns.FetchUpstream = func(modCtx *types.ModuleContext) (_ *url.URL, err error) {
+			defer recoverHook(name, "FetchUpstream", &err)
+			return fn.FetchUpstream(modCtx)
+		}
+	}
+	if fn, ok := v.(RequestModifier); ok {
+		mod.fns.RequestModifier = func(modCtx *types.ModuleContext) (err error) {
+			defer recoverHook(name, "RequestModifier", &err)
+			return fn.RequestModifier(modCtx)
+		}
+	}
+	if fn, ok := v.(ResponseModifier); ok {
+		mod.fns.ResponseModifier = func(modCtx *types.ModuleContext, res *http.Response) (err error) {
+			defer recoverHook(name, "ResponseModifier", &err)
+			return fn.ResponseModifier(types.ModuleContextWithResponse(modCtx, res), res)
+		}
+	}
+	if fn, ok := v.(ErrorHandler); ok {
+		mod.fns.ErrorHandler = func(modCtx *types.ModuleContext, upstreamErr error) (err error) {
+			defer recoverHook(name, "ErrorHandler", &err)
+			return fn.ErrorHandler(types.ModuleContextWithError(modCtx, upstreamErr), upstreamErr)
+		}
+	}
+	if fn, ok := v.(RequestHandler); ok {
+		mod.fns.RequestHandler = func(modCtx *types.ModuleContext) (err error) {
+			defer recoverHook(name, "RequestHandler", &err)
+			return fn.RequestHandler(modCtx)
+		}
+	}
+	if mod.fns.FetchUpstream == nil && mod.fns.RequestModifier == nil &&
+		mod.fns.ResponseModifier == nil && mod.fns.ErrorHandler == nil &&
+		mod.fns.RequestHandler == nil {
+		return nil, fmt.Errorf("native module %s: %T does not implement any hook", name, v)
+	}
+	return mod, nil
+}
+
+// Open loads a plugin built with -buildmode=plugin, the plugin must export SymbolName.
+func Open(name, path string) (*Module, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("native module %s: %w", name, err)
+	}
+	sym, err := p.Lookup(SymbolName)
+	if err != nil {
+		return nil, fmt.Errorf("native module %s: %w", name, err)
+	}
+	// the symbol of a variable is a pointer to the variable, the
+	// hooks can be implemented by the pointer or by the value.
+	mod, err := New(name, sym)
+	if err != nil {
+		if val := reflect.ValueOf(sym); val.Kind() == reflect.Pointer && !val.IsNil() {
+			return New(name, val.Elem().Interface())
+		}
+	}
+	return mod, err
+}
+
+// Register registers a module that is built into the binary, registered
+// modules can be used like the modules loaded from plugins.
+func Register(name string, v any) error {
+	mod, err := New(name, v)
+	if err != nil {
+		return err
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		return errors.New("native module already registered: " + name)
+	}
+	registry[name] = mod
+	return nil
+}
+
+// Registered returns a module registered with Register
+func Registered(name string) (*Module, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	mod, ok := registry[name]
+	return mod, ok
+}
+
+// Name returns the name of the module
+func (mod *Module) Name() string {
+	return mod.name
+}
+
+// Functions returns the hooks of the module, hooks that are not implemented are nil.
+func (mod *Module) Functions() *extractors.ModuleFunctions {
+	fns := mod.fns
+	return &fns
+}
+
+// recoverHook returns a panic of a hook as an error, so a hook does not stop the proxy
+func recoverHook(name, hook string, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("native module %s: %s panicked: %v", name, hook, r)
+	}
+}
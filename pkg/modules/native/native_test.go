@@ -0,0 +1,59 @@
+package native_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgate-io/dgate/pkg/modules/native"
+	"github.com/dgate-io/dgate/pkg/modules/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requestModifier struct {
+	err error
+}
+
+func (m *requestModifier) RequestModifier(*types.ModuleContext) error {
+	if m.err == nil {
+		panic("no error")
+	}
+	return m.err
+}
+
+func TestNew(t *testing.T) {
+	mod, err := native.New("test", &requestModifier{err: errors.New("test error")})
+	require.NoError(t, err)
+	fns := mod.Functions()
+	assert.Equal(t, "test", fns.Module)
+	assert.Nil(t, fns.FetchUpstream)
+	assert.Nil(t, fns.ResponseModifier)
+	require.NotNil(t, fns.RequestModifier)
+	assert.EqualError(t, fns.RequestModifier(nil), "test error")
+
+	// the hooks implemented by the pointer are not implemented by the value
+	_, err = native.New("test", requestModifier{})
+	assert.ErrorContains(t, err, "does not implement any hook")
+}
+
+func TestNew_Panic(t *testing.T) {
+	mod, err := native.New("test", &requestModifier{})
+	require.NoError(t, err)
+	err = mod.Functions().RequestModifier(nil)
+	assert.EqualError(t, err, "native module test: RequestModifier panicked: no error")
+}
+
+func TestRegister(t *testing.T) {
+	require.NoError(t, native.Register("registered", &requestModifier{}))
+	assert.Error(t, native.Register("registered", &requestModifier{}))
+	mod, ok := native.Registered("registered")
+	require.True(t, ok)
+	assert.Equal(t, "registered", mod.Name())
+	_, ok = native.Registered("missing")
+	assert.False(t, ok)
+}
+
+func TestOpen_NotFound(t *testing.T) {
+	_, err := native.Open("missing", "testdata/missing.so")
+	assert.ErrorContains(t, err, "native module missing")
+}
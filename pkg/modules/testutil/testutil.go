@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"context"
+	"net/http"
 	"sync"
 
 	"github.com/dgate-io/dgate/pkg/cache"
@@ -25,6 +26,8 @@ type mockRuntimeContext struct {
 	loop  *eventloop.EventLoop
 	data  any
 	state modules.StateManager
+	// permissions are nil when every permission is granted
+	permissions []string
 }
 
 type mockState struct {
@@ -56,6 +59,10 @@ func (m *mockState) SharedCache() cache.TCache {
 	return args.Get(0).(cache.TCache)
 }
 
+func (m *mockState) HttpTransport() http.RoundTripper {
+	return http.DefaultTransport
+}
+
 var _ modules.RuntimeContext = &mockRuntimeContext{}
 
 func NewMockRuntimeContext() *mockRuntimeContext {
@@ -93,6 +100,23 @@ func (m *mockRuntimeContext) Registry() *require.Registry {
 	return m.req
 }
 
+// WithPermissions limits the permissions of the runtime context
+func (m *mockRuntimeContext) WithPermissions(permissions ...string) *mockRuntimeContext {
+	m.permissions = append([]string{}, permissions...)
+	return m
+}
+
+func (m *mockRuntimeContext) HasPermission(permission string) bool {
+	return spec.ModulePermissionGranted(m.permissions, permission)
+}
+
+func (m *mockRuntimeContext) CheckPermission(permission string) error {
+	if !m.HasPermission(permission) {
+		return &modules.PermissionError{Module: "mock", Permission: permission}
+	}
+	return nil
+}
+
 type mockPrinter struct {
 	mock.Mock
 	logs map[string][]string
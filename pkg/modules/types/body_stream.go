@@ -0,0 +1,247 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/dgate-io/dgate/pkg/eventloop"
+	"github.com/dgate-io/dgate/pkg/util"
+	"github.com/dop251/goja"
+)
+
+// bodyChunkSize is the max size of the chunks read from a body
+const bodyChunkSize = 32 * 1024
+
+// BodyStream is a ReadableStream of a request or response body, the chunks
+// are read as they are received, so the body is never held in memory.
+type BodyStream struct {
+	body   io.ReadCloser
+	loop   *eventloop.EventLoop
+	closed bool
+	// lastRead is closed when the last read of the stream
+	// is scheduled on the loop, so chunks stay in order.
+	lastRead chan struct{}
+
+	Locked bool `json:"locked"`
+}
+
+// BodyStreamReader reads the chunks of a stream as Uint8Arrays.
+type BodyStreamReader struct {
+	stream   *BodyStream
+	released bool
+}
+
+func newBodyStream(body io.ReadCloser, loop *eventloop.EventLoop) *BodyStream {
+	if body == nil {
+		body = http.NoBody
+	}
+	return &BodyStream{body: body, loop: loop}
+}
+
+// GetReader locks the stream to a reader
+func (s *BodyStream) GetReader() (*BodyStreamReader, error) {
+	if s.Locked {
+		return nil, errors.New("stream is already locked")
+	}
+	s.Locked = true
+	return &BodyStreamReader{stream: s}, nil
+}
+
+// Cancel closes the stream, the rest of the body is discarded.
+func (s *BodyStream) Cancel() error {
+	if s.Locked {
+		return errors.New("stream is locked")
+	}
+	s.closed = true
+	return s.body.Close()
+}
+
+// Read returns a promise of the next chunk ({ value, done }) of the stream.
+func (r *BodyStreamReader) Read() *goja.Promise {
+	s := r.stream
+	rt := s.loop.Runtime()
+	promise, resolve, reject := rt.NewPromise()
+	if r.released {
+		reject(rt.NewTypeError("reader is released"))
+		return promise
+	} else if s.closed {
+		resolve(readResult(rt, goja.Undefined(), true))
+		return promise
+	}
+	prev, done := s.lastRead, make(chan struct{})
+	s.lastRead = done
+	go func() {
+		if prev != nil {
+			<-prev
+		}
+		buf := make([]byte, bodyChunkSize)
+		n, err := s.body.Read(buf)
+		s.loop.RunOnLoop(func(rt *goja.Runtime) {
+			if n > 0 {
+				chunk, err := newUint8Array(rt, buf[:n])
+				if err != nil {
+					reject(err)
+					return
+				}
+				resolve(readResult(rt, chunk, false))
+			} else if err == io.EOF {
+				s.closed = true
+				s.body.Close()
+				resolve(readResult(rt, goja.Undefined(), true))
+			} else if err != nil {
+				reject(rt.NewGoError(err))
+			} else {
+				chunk, _ := newUint8Array(rt, []byte{})
+				resolve(readResult(rt, chunk, false))
+			}
+		})
+		close(done)
+	}()
+	return promise
+}
+
+// Cancel closes the stream of the reader, the rest of the body is discarded.
+func (r *BodyStreamReader) Cancel() error {
+	r.stream.closed = true
+	return r.stream.body.Close()
+}
+
+// ReleaseLock unlocks the stream, so another reader can be used.
+func (r *BodyStreamReader) ReleaseLock() {
+	if !r.released {
+		r.released = true
+		r.stream.Locked = false
+	}
+}
+
+func readResult(rt *goja.Runtime, value goja.Value, done bool) *goja.Object {
+	res := rt.NewObject()
+	res.Set("value", value)
+	res.Set("done", done)
+	return res
+}
+
+func newUint8Array(rt *goja.Runtime, data []byte) (goja.Value, error) {
+	return rt.New(rt.Get("Uint8Array"), rt.ToValue(rt.NewArrayBuffer(data)))
+}
+
+// transformReader passes the chunks of the body to the transform function
+// as they are read, the results of the function are read instead of the chunk.
+// ctx is canceled when the module context is closed, so the functions are
+// not called once the runtime is used by another request.
+type transformReader struct {
+	ctx       context.Context
+	body      io.ReadCloser
+	loop      *eventloop.EventLoop
+	transform goja.Callable
+	flush     goja.Callable
+
+	buf []byte
+	err error
+}
+
+func newTransformReader(
+	ctx context.Context,
+	body io.ReadCloser,
+	loop *eventloop.EventLoop,
+	transform, flush goja.Value,
+) (*transformReader, error) {
+	tr := &transformReader{ctx: ctx, body: body, loop: loop}
+	var ok bool
+	if tr.transform, ok = goja.AssertFunction(transform); !ok {
+		return nil, errors.New("transform must be a function")
+	}
+	if flush != nil && !goja.IsUndefined(flush) && !goja.IsNull(flush) {
+		if tr.flush, ok = goja.AssertFunction(flush); !ok {
+			return nil, errors.New("flush must be a function")
+		}
+	}
+	return tr, nil
+}
+
+func (t *transformReader) Read(p []byte) (int, error) {
+	for len(t.buf) == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+		chunk := make([]byte, bodyChunkSize)
+		n, err := t.body.Read(chunk)
+		if n > 0 {
+			if t.buf, t.err = t.call(t.transform, chunk[:n]); t.err != nil {
+				return 0, t.err
+			}
+		}
+		if err == io.EOF && t.flush != nil {
+			var data []byte
+			if data, err = t.call(t.flush, nil); err == nil {
+				t.buf, err = append(t.buf, data...), io.EOF
+			}
+		}
+		if err != nil {
+			t.err = err
+		}
+	}
+	n := copy(p, t.buf)
+	t.buf = t.buf[n:]
+	return n, nil
+}
+
+func (t *transformReader) Close() error {
+	return t.body.Close()
+}
+
+// call runs the function on the loop and waits for the result,
+// a promise is awaited, and null or undefined drops the chunk.
+func (t *transformReader) call(fn goja.Callable, chunk []byte) ([]byte, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+	resultChan := make(chan result, 1)
+	t.loop.RunOnLoop(func(rt *goja.Runtime) {
+		if t.ctx.Err() != nil {
+			return
+		}
+		settle := func(val goja.Value, err error) {
+			if err == nil && val != nil && !goja.IsUndefined(val) && !goja.IsNull(val) {
+				var data []byte
+				data, err = util.ToBytes(val.Export())
+				resultChan <- result{data, err}
+				return
+			}
+			resultChan <- result{nil, err}
+		}
+		var args []goja.Value
+		if chunk != nil {
+			arr, err := newUint8Array(rt, chunk)
+			if err != nil {
+				settle(nil, err)
+				return
+			}
+			args = append(args, arr)
+		}
+		val, err := fn(goja.Undefined(), args...)
+		if err != nil {
+			settle(nil, err)
+			return
+		} else if _, ok := val.Export().(*goja.Promise); !ok {
+			settle(val, nil)
+			return
+		}
+		prom := val.ToObject(rt)
+		then, _ := goja.AssertFunction(prom.Get("then"))
+		then(prom, rt.ToValue(func(val goja.Value) {
+			settle(val, nil)
+		}), rt.ToValue(func(reason goja.Value) {
+			settle(nil, util.RejectionError(reason))
+		}))
+	})
+	select {
+	case res := <-resultChan:
+		return res.data, res.err
+	case <-t.ctx.Done():
+		return nil, t.ctx.Err()
+	}
+}
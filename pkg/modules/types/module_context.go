@@ -1,6 +1,8 @@
 package types
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -13,6 +15,9 @@ import (
 type ModuleContext struct {
 	ID string `json:"id"`
 
+	// ctx is canceled when the request is done with the module context
+	ctx    context.Context
+	cancel context.CancelFunc
 	ns     *spec.Namespace
 	svc    *spec.Service
 	route  *spec.Route
@@ -21,7 +26,10 @@ type ModuleContext struct {
 	req    *RequestWrapper
 	rwt    *ResponseWriterWrapper
 	upResp *ResponseWrapper
+	upUrl  *url.URL
 	cache  map[string]interface{}
+	// consumer is the id of the authenticated consumer, it is set by the modules
+	consumer string
 }
 
 func NewModuleContext(
@@ -33,15 +41,21 @@ func NewModuleContext(
 ) *ModuleContext {
 	t := time.Now().UnixNano()
 	id := strconv.FormatUint(uint64(t), 36)
+	ctx, cancel := context.WithCancel(req.Context())
+	reqWrapper := NewRequestWrapper(req, loop)
+	reqWrapper.ctx = ctx
 	return &ModuleContext{
 		ID:     id,
+		ctx:    ctx,
+		cancel: cancel,
 		loop:   loop,
-		req:    NewRequestWrapper(req, loop),
+		req:    reqWrapper,
 		rwt:    NewResponseWriterWrapper(rw, req),
 		route:  spec.TransformDGateRoute(route),
 		svc:    spec.TransformDGateService(route.Service),
 		ns:     spec.TransformDGateNamespace(route.Namespace),
 		params: params,
+		cache:  make(map[string]interface{}),
 	}
 }
 
@@ -53,6 +67,18 @@ func (modCtx *ModuleContext) Get(key string) any {
 	return modCtx.cache[key]
 }
 
+// SetConsumer sets the consumer of the request, modules call it
+// after authenticating the request, so rate limits keyed by
+// consumer are applied to the consumer instead of the client ip.
+func (modCtx *ModuleContext) SetConsumer(id string) {
+	modCtx.consumer = id
+}
+
+// Consumer returns the consumer of the request, it is empty until a module sets it.
+func (modCtx *ModuleContext) Consumer() string {
+	return modCtx.consumer
+}
+
 func (modCtx *ModuleContext) Query() url.Values {
 	return modCtx.req.Query
 }
@@ -89,15 +115,32 @@ func (modCtx *ModuleContext) Response() *ResponseWriterWrapper {
 	return modCtx.rwt
 }
 
+// UpstreamUrl returns the upstream url selected for the request,
+// it is empty until an upstream has been selected.
+func (modCtx *ModuleContext) UpstreamUrl() string {
+	if modCtx.upUrl == nil {
+		return ""
+	}
+	return modCtx.upUrl.String()
+}
+
 func ModuleContextWithResponse(
 	modCtx *ModuleContext,
 	resp *http.Response,
 ) *ModuleContext {
 	modCtx.upResp = NewResponseWrapper(resp, modCtx.loop)
+	modCtx.upResp.ctx = modCtx.ctx
 	modCtx.rwt = nil
 	return modCtx
 }
 
+func ModuleContextWithUpstreamUrl(
+	modCtx *ModuleContext, upstreamUrl *url.URL,
+) *ModuleContext {
+	modCtx.upUrl = upstreamUrl
+	return modCtx
+}
+
 func ModuleContextWithError(
 	modCtx *ModuleContext, err error,
 ) *ModuleContext {
@@ -105,6 +148,12 @@ func ModuleContextWithError(
 	return modCtx
 }
 
+// CloseModuleContext stops the body transforms of the
+// request, it is called before the runtime is reused.
+func CloseModuleContext(modCtx *ModuleContext) {
+	modCtx.cancel()
+}
+
 // Helper functions to expose private fields
 
 func GetModuleContextRoute(modCtx *ModuleContext) *spec.Route {
@@ -127,6 +176,29 @@ func GetModuleContextResponse(modCtx *ModuleContext) *ResponseWrapper {
 	return modCtx.upResp
 }
 
+// GetModuleContextRequestURL returns the url of the request
+func GetModuleContextRequestURL(modCtx *ModuleContext) *url.URL {
+	return modCtx.req.req.URL
+}
+
+// ReadModuleContextRequestBody reads the body of the request, the body is still sent to the upstream
+func ReadModuleContextRequestBody(modCtx *ModuleContext) ([]byte, error) {
+	return modCtx.req.readBytes()
+}
+
+// ReadModuleContextUpstreamBody reads the body of the upstream response, the body is still sent to the client
+func ReadModuleContextUpstreamBody(modCtx *ModuleContext) ([]byte, error) {
+	if modCtx.upResp == nil {
+		return nil, errors.New("upstream response is not set")
+	}
+	return modCtx.upResp.readBytes()
+}
+
+// ModuleContextResponseSent returns true if the response has been sent by a module
+func ModuleContextResponseSent(modCtx *ModuleContext) bool {
+	return modCtx.rwt != nil && modCtx.rwt.rw.HeadersSent()
+}
+
 func GetModuleContextResponseWriterTracker(modCtx *ModuleContext) spec.ResponseWriterTracker {
 	return modCtx.rwt.rw
 }
@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -15,8 +16,10 @@ import (
 )
 
 type RequestWrapper struct {
-	req  *http.Request
-	loop *eventloop.EventLoop
+	req    *http.Request
+	loop   *eventloop.EventLoop
+	ctx    context.Context
+	stream *BodyStream
 
 	Method        string
 	Path          string
@@ -39,6 +42,7 @@ func NewRequestWrapper(
 	return &RequestWrapper{
 		loop:  loop,
 		req:   req,
+		ctx:   req.Context(),
 		Query: req.URL.Query(),
 		Path:  req.URL.Path,
 
@@ -53,11 +57,11 @@ func NewRequestWrapper(
 
 func (g *RequestWrapper) clearBody() {
 	if g.req.Body != nil {
-		// read all data from body
-		io.ReadAll(g.req.Body)
+		// the body is closed without reading it, so large bodies are not read
 		g.req.Body.Close()
 		g.req.Body = nil
 	}
+	g.stream = nil
 }
 
 func (g *RequestWrapper) WriteJson(data any) error {
@@ -106,3 +110,46 @@ func (g *RequestWrapper) ReadBody() (*goja.ArrayBuffer, error) {
 	arrBuf := g.loop.Runtime().NewArrayBuffer(buf)
 	return &arrBuf, nil
 }
+
+// readBytes reads the body, the body is replaced with the
+// data read, so the body is still sent to the upstream.
+func (g *RequestWrapper) readBytes() ([]byte, error) {
+	if g.req.Body == nil {
+		return []byte{}, nil
+	}
+	buf, err := io.ReadAll(g.req.Body)
+	g.req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	g.req.Body = io.NopCloser(bytes.NewReader(buf))
+	return buf, nil
+}
+
+// Body returns the body as a ReadableStream, the chunks read
+// from the stream are not sent to the upstream.
+func (g *RequestWrapper) Body() *BodyStream {
+	if g.stream == nil {
+		g.stream = newBodyStream(g.req.Body, g.loop)
+	}
+	return g.stream
+}
+
+// TransformBody passes the chunks of the body to transform as they are sent to the
+// upstream, the optional flush is called at the end of the body. The functions return
+// the data to send (string, ArrayBuffer or Uint8Array), null or undefined sends nothing.
+func (g *RequestWrapper) TransformBody(transform, flush goja.Value) error {
+	if g.req.Body == nil || g.req.Body == http.NoBody {
+		return nil
+	}
+	body, err := newTransformReader(g.ctx, g.req.Body, g.loop, transform, flush)
+	if err != nil {
+		return err
+	}
+	// the length of the transformed body is unknown, so it is sent chunked
+	g.req.Body = body
+	g.req.ContentLength = -1
+	g.req.Header.Del("Content-Length")
+	g.ContentLength = -1
+	return nil
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -17,6 +18,8 @@ import (
 type ResponseWrapper struct {
 	response *http.Response
 	loop     *eventloop.EventLoop
+	ctx      context.Context
+	stream   *BodyStream
 
 	Headers          http.Header `json:"headers"`
 	StatusCode       int         `json:"statusCode"`
@@ -32,9 +35,14 @@ func NewResponseWrapper(
 	resp *http.Response,
 	loop *eventloop.EventLoop,
 ) *ResponseWrapper {
+	ctx := context.Background()
+	if resp.Request != nil {
+		ctx = resp.Request.Context()
+	}
 	return &ResponseWrapper{
 		response:         resp,
 		loop:             loop,
+		ctx:              ctx,
 		Headers:          resp.Header,
 		Protocol:         resp.Proto,
 		StatusText:       resp.Status,
@@ -48,22 +56,47 @@ func NewResponseWrapper(
 
 func (rw *ResponseWrapper) clearBody() {
 	if rw.response.Body != nil {
-		io.ReadAll(rw.response.Body)
+		// the upstream body is closed without reading it, so streams are not read
 		rw.response.Body.Close()
 		rw.response.Body = nil
 	}
 	rw.response.ContentLength = 0
+	rw.stream = nil
+}
+
+// readAll reads the body off the loop, so the loop is not blocked by the upstream
+func (rw *ResponseWrapper) readAll(settle func(*goja.Runtime, []byte, error)) {
+	go func() {
+		buf, err := io.ReadAll(rw.response.Body)
+		rw.response.Body.Close()
+		rw.loop.RunOnLoop(func(r *goja.Runtime) {
+			settle(r, buf, err)
+		})
+	}()
+}
+
+// readBytes reads the body, the body is replaced with the
+// data read, so the body is still sent to the client.
+func (rw *ResponseWrapper) readBytes() ([]byte, error) {
+	if rw.response.Body == nil {
+		return []byte{}, nil
+	}
+	buf, err := io.ReadAll(rw.response.Body)
+	rw.response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	rw.response.Body = io.NopCloser(bytes.NewReader(buf))
+	return buf, nil
 }
 
 func (rw *ResponseWrapper) ReadBody() *goja.Promise {
 	prom, res, rej := rw.loop.Runtime().NewPromise()
-	rw.loop.RunOnLoop(func(r *goja.Runtime) {
-		buf, err := io.ReadAll(rw.response.Body)
+	rw.readAll(func(r *goja.Runtime, buf []byte, err error) {
 		if err != nil {
 			rej(r.ToValue(errors.New(err.Error())))
 			return
 		}
-		defer rw.response.Body.Close()
 		res(r.ToValue(r.NewArrayBuffer(buf)))
 	})
 	return prom
@@ -71,14 +104,12 @@ func (rw *ResponseWrapper) ReadBody() *goja.Promise {
 
 func (rw *ResponseWrapper) ReadJson() *goja.Promise {
 	prom, res, rej := rw.loop.Runtime().NewPromise()
-	rw.loop.RunOnLoop(func(r *goja.Runtime) {
-		var data any
-		buf, err := io.ReadAll(rw.response.Body)
+	rw.readAll(func(r *goja.Runtime, buf []byte, err error) {
 		if err != nil {
 			rej(r.ToValue(errors.New(err.Error())))
 			return
 		}
-		rw.response.Body.Close()
+		var data any
 		err = json.Unmarshal(buf, &data)
 		if err != nil {
 			rej(r.ToValue(errors.New(err.Error())))
@@ -89,6 +120,34 @@ func (rw *ResponseWrapper) ReadJson() *goja.Promise {
 	return prom
 }
 
+// Body returns the body as a ReadableStream, the chunks read
+// from the stream are not sent to the client.
+func (rw *ResponseWrapper) Body() *BodyStream {
+	if rw.stream == nil {
+		rw.stream = newBodyStream(rw.response.Body, rw.loop)
+	}
+	return rw.stream
+}
+
+// TransformBody passes the chunks of the body to transform as they are sent to the
+// client, the optional flush is called at the end of the body. The functions return
+// the data to send (string, ArrayBuffer or Uint8Array), null or undefined sends nothing.
+func (rw *ResponseWrapper) TransformBody(transform, flush goja.Value) error {
+	if rw.response.Body == nil || rw.response.Body == http.NoBody {
+		return nil
+	}
+	body, err := newTransformReader(rw.ctx, rw.response.Body, rw.loop, transform, flush)
+	if err != nil {
+		return err
+	}
+	// the length of the transformed body is unknown, so it is sent chunked
+	rw.response.Body = body
+	rw.response.ContentLength = -1
+	rw.response.Header.Del("Content-Length")
+	rw.ContentLength = -1
+	return nil
+}
+
 func (rw *ResponseWrapper) WriteJson(data any) error {
 	rw.Headers.Set("Content-Type", "application/json")
 	b, err := json.Marshal(data)
@@ -0,0 +1,281 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dgate-io/dgate/pkg/modules/types"
+	"github.com/tetratelabs/wazero/api"
+)
+
+var errOutOfBounds = errors.New("memory access out of bounds")
+
+// instantiateHostModule instantiates the host functions of the ABI, see the README of the modules.
+// Getters copy the value to the buffer of the guest when it fits, and return the length of the
+// value, so the guest can call them again with a larger buffer. Functions return -1 when the value
+// is not available in the hook, errors of the host fail the hook after the guest returns.
+func (r *Runtime) instantiateHostModule(ctx context.Context) error {
+	b := r.rt.NewHostModuleBuilder(HostModule)
+	export := func(name string, fn any) {
+		b.NewFunctionBuilder().WithFunc(fn).Export(name)
+	}
+
+	export("log", func(ctx context.Context, m api.Module, level, ptr, size uint32) {
+		msg, ok := m.Memory().Read(ptr, size)
+		if !ok {
+			return
+		}
+		switch level {
+		case 1:
+			r.printer.Warn(string(msg))
+		case 2:
+			r.printer.Error(string(msg))
+		default:
+			r.printer.Log(string(msg))
+		}
+	})
+
+	export("request_get_method", func(ctx context.Context, m api.Module, buf, bufLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			return c.copyOut(m, []byte(c.modCtx.Request().Method), buf, bufLen)
+		})
+	})
+	export("request_get_url", func(ctx context.Context, m api.Module, buf, bufLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			reqUrl := types.GetModuleContextRequestURL(c.modCtx)
+			return c.copyOut(m, []byte(reqUrl.RequestURI()), buf, bufLen)
+		})
+	})
+	export("request_get_header", func(ctx context.Context, m api.Module, name, nameLen, buf, bufLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			return c.getHeader(m, c.modCtx.Request().Headers, name, nameLen, buf, bufLen)
+		})
+	})
+	export("request_set_header", func(ctx context.Context, m api.Module, name, nameLen, val, valLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			return c.setHeader(m, c.modCtx.Request().Headers, name, nameLen, val, valLen)
+		})
+	})
+	export("request_delete_header", func(ctx context.Context, m api.Module, name, nameLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			return c.deleteHeader(m, c.modCtx.Request().Headers, name, nameLen)
+		})
+	})
+	export("request_get_body", func(ctx context.Context, m api.Module, buf, bufLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			body, err := types.ReadModuleContextRequestBody(c.modCtx)
+			if err != nil {
+				return c.fail(err)
+			}
+			return c.copyOut(m, body, buf, bufLen)
+		})
+	})
+	// the body is copied, because the memory of the guest can change after the call
+	export("request_set_body", func(ctx context.Context, m api.Module, ptr, size uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			body, ok := m.Memory().Read(ptr, size)
+			if !ok {
+				return c.fail(errOutOfBounds)
+			} else if err := c.modCtx.Request().WriteBody(bytes.Clone(body)); err != nil {
+				return c.fail(err)
+			}
+			return 0
+		})
+	})
+
+	// the response is not available in responseModifier, the upstream response is changed instead
+	export("response_set_status", func(ctx context.Context, m api.Module, status uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Response() == nil {
+				return -1
+			}
+			c.modCtx.Response().Status(int(status))
+			return 0
+		})
+	})
+	export("response_set_header", func(ctx context.Context, m api.Module, name, nameLen, val, valLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Response() == nil {
+				return -1
+			}
+			return c.setHeader(m, c.modCtx.Response().Headers, name, nameLen, val, valLen)
+		})
+	})
+	export("response_write", func(ctx context.Context, m api.Module, ptr, size uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Response() == nil {
+				return -1
+			}
+			data, ok := m.Memory().Read(ptr, size)
+			if !ok {
+				return c.fail(errOutOfBounds)
+			} else if err := c.modCtx.Response().End(data); err != nil {
+				return c.fail(err)
+			}
+			return 0
+		})
+	})
+
+	// the upstream response is only available in responseModifier
+	export("upstream_get_status", func(ctx context.Context, m api.Module) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Upstream() == nil {
+				return -1
+			}
+			return int32(c.modCtx.Upstream().StatusCode)
+		})
+	})
+	export("upstream_set_status", func(ctx context.Context, m api.Module, status uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Upstream() == nil {
+				return -1
+			}
+			c.modCtx.Upstream().Status(int(status))
+			return 0
+		})
+	})
+	export("upstream_get_header", func(ctx context.Context, m api.Module, name, nameLen, buf, bufLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Upstream() == nil {
+				return -1
+			}
+			return c.getHeader(m, c.modCtx.Upstream().Headers, name, nameLen, buf, bufLen)
+		})
+	})
+	export("upstream_set_header", func(ctx context.Context, m api.Module, name, nameLen, val, valLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Upstream() == nil {
+				return -1
+			}
+			return c.setHeader(m, c.modCtx.Upstream().Headers, name, nameLen, val, valLen)
+		})
+	})
+	export("upstream_delete_header", func(ctx context.Context, m api.Module, name, nameLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Upstream() == nil {
+				return -1
+			}
+			return c.deleteHeader(m, c.modCtx.Upstream().Headers, name, nameLen)
+		})
+	})
+	export("upstream_get_body", func(ctx context.Context, m api.Module, buf, bufLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Upstream() == nil {
+				return -1
+			}
+			body, err := types.ReadModuleContextUpstreamBody(c.modCtx)
+			if err != nil {
+				return c.fail(err)
+			}
+			return c.copyOut(m, body, buf, bufLen)
+		})
+	})
+	export("upstream_set_body", func(ctx context.Context, m api.Module, ptr, size uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.modCtx.Upstream() == nil {
+				return -1
+			}
+			body, ok := m.Memory().Read(ptr, size)
+			if !ok {
+				return c.fail(errOutOfBounds)
+			} else if err := c.modCtx.Upstream().WriteBody(bytes.Clone(body)); err != nil {
+				return c.fail(err)
+			}
+			return 0
+		})
+	})
+
+	export("set_upstream_url", func(ctx context.Context, m api.Module, ptr, size uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			data, ok := m.Memory().Read(ptr, size)
+			if !ok {
+				return c.fail(errOutOfBounds)
+			}
+			upstreamUrlString := string(data)
+			if !strings.Contains(upstreamUrlString, "://") {
+				upstreamUrlString = "http://" + upstreamUrlString
+			}
+			upstreamUrl, err := url.Parse(upstreamUrlString)
+			if err != nil {
+				return c.fail(err)
+			}
+			c.upstreamUrl = upstreamUrl
+			return 0
+		})
+	})
+	// the error is only available in errorHandler
+	export("get_error", func(ctx context.Context, m api.Module, buf, bufLen uint32) int32 {
+		return withModuleContext(ctx, func(c *call) int32 {
+			if c.err == nil {
+				return -1
+			}
+			return c.copyOut(m, []byte(c.err.Error()), buf, bufLen)
+		})
+	})
+
+	_, err := b.Instantiate(ctx)
+	return err
+}
+
+// withModuleContext runs fn with the call of the hook, host functions
+// called outside of a hook (e.g. by _initialize) return -1.
+func withModuleContext(ctx context.Context, fn func(c *call) int32) int32 {
+	c := callFromContext(ctx)
+	if c == nil || c.modCtx == nil {
+		return -1
+	}
+	return fn(c)
+}
+
+func (c *call) fail(err error) int32 {
+	if c.hostErr == nil {
+		c.hostErr = err
+	}
+	return -1
+}
+
+// copyOut copies data to the buffer when it fits, and returns the length of data
+func (c *call) copyOut(m api.Module, data []byte, buf, bufLen uint32) int32 {
+	if uint32(len(data)) <= bufLen && !m.Memory().Write(buf, data) {
+		return c.fail(errOutOfBounds)
+	}
+	return int32(len(data))
+}
+
+func (c *call) getHeader(m api.Module, header http.Header, name, nameLen, buf, bufLen uint32) int32 {
+	key, ok := m.Memory().Read(name, nameLen)
+	if !ok {
+		return c.fail(errOutOfBounds)
+	}
+	values := header.Values(string(key))
+	if len(values) == 0 {
+		return -1
+	}
+	return c.copyOut(m, []byte(strings.Join(values, ", ")), buf, bufLen)
+}
+
+func (c *call) setHeader(m api.Module, header http.Header, name, nameLen, val, valLen uint32) int32 {
+	key, ok := m.Memory().Read(name, nameLen)
+	if !ok {
+		return c.fail(errOutOfBounds)
+	}
+	value, ok := m.Memory().Read(val, valLen)
+	if !ok {
+		return c.fail(errOutOfBounds)
+	}
+	header.Set(string(key), string(value))
+	return 0
+}
+
+func (c *call) deleteHeader(m api.Module, header http.Header, name, nameLen uint32) int32 {
+	key, ok := m.Memory().Read(name, nameLen)
+	if !ok {
+		return c.fail(errOutOfBounds)
+	}
+	header.Del(string(key))
+	return 0
+}
@@ -0,0 +1,211 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+
+	"github.com/dgate-io/dgate/pkg/modules/extractors"
+	"github.com/dgate-io/dgate/pkg/modules/types"
+	"github.com/dop251/goja_nodejs/console"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// HostModule is the name of the module of the host functions
+const HostModule = "dgate"
+
+var hookNames = []string{
+	"fetchUpstream",
+	"requestModifier",
+	"responseModifier",
+	"errorHandler",
+	"requestHandler",
+}
+
+// Runtime runs the wasm modules, the host functions and WASI are shared by the modules.
+type Runtime struct {
+	rt      wazero.Runtime
+	printer console.Printer
+}
+
+// NewRuntime creates the runtime of the wasm modules, calls
+// are stopped when the context of the call is done.
+func NewRuntime(ctx context.Context, printer console.Printer) (*Runtime, error) {
+	r := &Runtime{
+		rt: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			WithCloseOnContextDone(true)),
+		printer: printer,
+	}
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r.rt); err != nil {
+		r.rt.Close(ctx)
+		return nil, err
+	}
+	if err := r.instantiateHostModule(ctx); err != nil {
+		r.rt.Close(ctx)
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close closes the runtime and all the modules compiled by it
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.rt.Close(ctx)
+}
+
+// Module is a compiled wasm module, it can be instantiated by many module extractors.
+type Module struct {
+	name     string
+	runtime  *Runtime
+	compiled wazero.CompiledModule
+}
+
+// Compile compiles the binary of a module, the hooks exported by the module must have the signature () -> i32.
+func (r *Runtime) Compile(ctx context.Context, name string, binary []byte) (*Module, error) {
+	compiled, err := r.rt.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, fmt.Errorf("wasm module %s: %w", name, err)
+	}
+	exported := compiled.ExportedFunctions()
+	for _, hook := range hookNames {
+		def, ok := exported[hook]
+		if !ok {
+			continue
+		}
+		if len(def.ParamTypes()) != 0 || len(def.ResultTypes()) != 1 ||
+			def.ResultTypes()[0] != api.ValueTypeI32 {
+			compiled.Close(ctx)
+			return nil, fmt.Errorf("wasm module %s: %s must have the signature () -> i32", name, hook)
+		}
+	}
+	mod := &Module{name: name, runtime: r, compiled: compiled}
+	// the module can be replaced while routes still use it, so it is closed when it is not used
+	runtime.SetFinalizer(mod, func(mod *Module) {
+		mod.compiled.Close(context.Background())
+	})
+	return mod, nil
+}
+
+// instance is an instance of a module, it is used by one request at a time
+type instance struct {
+	mod    *Module
+	api    api.Module
+	limits extractors.Limits
+}
+
+// Functions instantiates the module, and returns the hooks exported by the module.
+func (mod *Module) Functions(ctx context.Context, limits extractors.Limits) (*extractors.ModuleFunctions, error) {
+	// the start function of reactors (_initialize) is called, the name is
+	// cleared so the module can be instantiated more than once.
+	apiMod, err := mod.runtime.rt.InstantiateModule(ctx, mod.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("wasm module %s: %w", mod.name, err)
+	}
+	inst := &instance{mod: mod, api: apiMod, limits: limits}
+	runtime.SetFinalizer(inst, func(inst *instance) {
+		inst.api.Close(context.Background())
+	})
+
+	fns := &extractors.ModuleFunctions{Module: mod.name}
+	if inst.api.ExportedFunction("fetchUpstream") != nil {
+		fns.FetchUpstream = func(modCtx *types.ModuleContext) (*url.URL, error) {
+			c := &call{modCtx: modCtx}
+			if err := inst.call("fetchUpstream", c); err != nil {
+				return nil, err
+			} else if c.upstreamUrl == nil {
+				return nil, errors.New("fetchUpstream did not set an upstream url")
+			}
+			return c.upstreamUrl, nil
+		}
+	}
+	if inst.api.ExportedFunction("requestModifier") != nil {
+		fns.RequestModifier = func(modCtx *types.ModuleContext) error {
+			return inst.call("requestModifier", &call{modCtx: modCtx})
+		}
+	}
+	if inst.api.ExportedFunction("responseModifier") != nil {
+		fns.ResponseModifier = func(modCtx *types.ModuleContext, res *http.Response) error {
+			modCtx = types.ModuleContextWithResponse(modCtx, res)
+			return inst.call("responseModifier", &call{modCtx: modCtx})
+		}
+	}
+	if inst.api.ExportedFunction("errorHandler") != nil {
+		fns.ErrorHandler = func(modCtx *types.ModuleContext, upstreamErr error) error {
+			modCtx = types.ModuleContextWithError(modCtx, upstreamErr)
+			return inst.call("errorHandler", &call{modCtx: modCtx, err: upstreamErr})
+		}
+	}
+	if inst.api.ExportedFunction("requestHandler") != nil {
+		fns.RequestHandler = func(modCtx *types.ModuleContext) error {
+			return inst.call("requestHandler", &call{modCtx: modCtx})
+		}
+	}
+	return fns, nil
+}
+
+// call runs the hook, the calls of wasm modules are synchronous, so the cpu time limit of
+// the module is used when it is lower than the wall time limit. The memory of the module is
+// checked against the allocation limit after the call, the instance is closed when it breaks a limit.
+func (inst *instance) call(hook string, c *call) error {
+	timeout, limit := inst.limits.WallTime, extractors.LimitWallTime
+	if timeout <= 0 {
+		timeout = extractors.DefaultWallTime
+	}
+	if cpuTime := inst.limits.CPUTime; cpuTime > 0 && cpuTime <= timeout {
+		timeout, limit = cpuTime, extractors.LimitCPUTime
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res, err := inst.api.ExportedFunction(hook).Call(withCall(ctx, c))
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return inst.limitError(limit, timeout.String())
+	} else if err != nil {
+		return fmt.Errorf("wasm module %s: %s: %w", inst.mod.name, hook, err)
+	}
+	if maxAlloc := inst.limits.MaxAllocations; maxAlloc > 0 {
+		if mem := inst.api.Memory(); mem != nil && uint64(mem.Size()) > maxAlloc {
+			return inst.limitError(extractors.LimitMaxAllocations, fmt.Sprintf("%d bytes", maxAlloc))
+		}
+	}
+	if c.hostErr != nil {
+		return fmt.Errorf("wasm module %s: %s: %w", inst.mod.name, hook, c.hostErr)
+	} else if status := int32(res[0]); status != 0 {
+		return fmt.Errorf("wasm module %s: %s returned %d", inst.mod.name, hook, status)
+	}
+	return nil
+}
+
+func (inst *instance) limitError(limit, value string) *extractors.LimitError {
+	inst.api.Close(context.Background())
+	return &extractors.LimitError{
+		Module: inst.limits.Module,
+		Limit:  limit,
+		Value:  value,
+	}
+}
+
+// call is the state of a hook call, the host functions get it from the context of the call.
+type call struct {
+	modCtx      *types.ModuleContext
+	err         error
+	upstreamUrl *url.URL
+	// hostErr is set when a host function fails, it is returned by the hook
+	hostErr error
+}
+
+type callKey struct{}
+
+func withCall(ctx context.Context, c *call) context.Context {
+	return context.WithValue(ctx, callKey{}, c)
+}
+
+func callFromContext(ctx context.Context) *call {
+	c, _ := ctx.Value(callKey{}).(*call)
+	return c
+}
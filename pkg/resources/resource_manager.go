@@ -103,8 +103,9 @@ func (rm *ResourceManager) GetNamespaces() []*spec.DGateNamespace {
 
 func (rm *ResourceManager) transformNamespace(ns *spec.Namespace) *spec.DGateNamespace {
 	return &spec.DGateNamespace{
-		Name: ns.Name,
-		Tags: ns.Tags,
+		Name:      ns.Name,
+		Tags:      ns.Tags,
+		RateLimit: ns.RateLimit,
 	}
 }
 
@@ -183,6 +184,27 @@ func (rm *ResourceManager) updateRouteRef(
 			rt.Service = svc.Item().Read()
 		}
 	}
+	if rt.TrafficSplit != nil {
+		split := *rt.TrafficSplit
+		split.Services = make([]*spec.DGateWeightedService, len(rt.TrafficSplit.Services))
+		for i, ws := range rt.TrafficSplit.Services {
+			split.Services[i] = ws
+			if svc, ok := rm.services.Find(ws.Service.Name + "/" + rt.Namespace.Name); ok {
+				split.Services[i] = &spec.DGateWeightedService{
+					Service: svc.Item().Read(),
+					Weight:  ws.Weight,
+				}
+			}
+		}
+		rt.TrafficSplit = &split
+	}
+	if rt.Mirror != nil {
+		mirror := *rt.Mirror
+		if svc, ok := rm.services.Find(mirror.Service.Name + "/" + rt.Namespace.Name); ok {
+			mirror.Service = svc.Item().Read()
+		}
+		rt.Mirror = &mirror
+	}
 	return rt
 }
 
@@ -243,7 +265,7 @@ func (rm *ResourceManager) AddRoute(route *spec.Route) (rt *spec.DGateRoute, err
 		return rt, nil
 	} else {
 		rtLk := linker.NewNamedVertexWithValue(
-			safe.NewRef(rt), "namespace", "service", "modules")
+			safe.NewRef(rt), "namespace", "service", "modules", "splits", "mirror")
 		err = rm.relinkRoute(rtLk, nsLk, route, route.Name, route.NamespaceName, false)
 		if err != nil {
 			return nil, err
@@ -258,9 +280,41 @@ func (rm *ResourceManager) transformRoute(route *spec.Route) (*spec.DGateRoute,
 		return nil, ErrNamespaceNotFound(route.NamespaceName)
 	} else {
 		var svc *spec.DGateService
-		if route.ServiceName != "" {
-			if svc, ok = rm.getService(route.ServiceName, route.NamespaceName); !ok {
-				return nil, ErrServiceNotFound(route.ServiceName)
+		if svcName := routeServiceName(route); svcName != "" {
+			if svc, ok = rm.getService(svcName, route.NamespaceName); !ok {
+				return nil, ErrServiceNotFound(svcName)
+			}
+		}
+		var split *spec.DGateTrafficSplit
+		if route.TrafficSplit != nil {
+			split = &spec.DGateTrafficSplit{
+				Services:  make([]*spec.DGateWeightedService, len(route.TrafficSplit.Services)),
+				Sticky:    route.TrafficSplit.Sticky,
+				Overrides: route.TrafficSplit.Overrides,
+			}
+			for i, ws := range route.TrafficSplit.Services {
+				if splitSvc, ok := rm.getService(ws.ServiceName, route.NamespaceName); ok {
+					split.Services[i] = &spec.DGateWeightedService{
+						Service: splitSvc,
+						Weight:  ws.Weight,
+					}
+				} else {
+					return nil, ErrServiceNotFound(ws.ServiceName)
+				}
+			}
+		}
+		var mirror *spec.DGateMirror
+		if route.Mirror != nil {
+			if mirrorSvc, ok := rm.getService(route.Mirror.ServiceName, route.NamespaceName); ok {
+				mirror = &spec.DGateMirror{
+					Service:       mirrorSvc,
+					Percent:       route.Mirror.Percent,
+					MaxBodySize:   route.Mirror.MaxBodySize,
+					Timeout:       route.Mirror.Timeout,
+					MaxConcurrent: route.Mirror.MaxConcurrent,
+				}
+			} else {
+				return nil, ErrServiceNotFound(route.Mirror.ServiceName)
 			}
 		}
 		mods := make([]*spec.DGateModule, len(route.Modules))
@@ -273,15 +327,23 @@ func (rm *ResourceManager) transformRoute(route *spec.Route) (*spec.DGateRoute,
 		}
 
 		return &spec.DGateRoute{
-			Name:         route.Name,
-			Namespace:    ns,
-			Paths:        route.Paths,
-			Methods:      route.Methods,
-			Service:      svc,
-			Modules:      mods,
-			StripPath:    route.StripPath,
-			PreserveHost: route.PreserveHost,
-			Tags:         route.Tags,
+			Name:             route.Name,
+			Namespace:        ns,
+			Paths:            route.Paths,
+			Methods:          route.Methods,
+			Service:          svc,
+			Modules:          mods,
+			StripPath:        route.StripPath,
+			PreserveHost:     route.PreserveHost,
+			Tags:             route.Tags,
+			TrafficSplit:     split,
+			Mirror:           mirror,
+			Match:            route.Match,
+			Priority:         route.Priority,
+			Rewrite:          route.Rewrite,
+			Cache:            route.Cache,
+			RateLimit:        route.RateLimit,
+			ConcurrencyLimit: route.ConcurrencyLimit,
 		}, nil
 	}
 }
@@ -316,6 +378,21 @@ func (rm *ResourceManager) unlinkRoute(
 	rtLk.Each("modules", func(_ string, modLk linker.Linker[string]) {
 		modLk.UnlinkOneMany("routes", name)
 	})
+	for _, svcLk := range rtLk.UnlinkAllOneMany("splits") {
+		svcLk.UnlinkOneMany("routes", name)
+	}
+	if svcLk, ok := rtLk.UnlinkOneOne("mirror"); ok {
+		svcLk.UnlinkOneMany("routes", name)
+	}
+}
+
+// routeServiceName returns the name of the route service, which
+// is the first service of the traffic split when not set.
+func routeServiceName(route *spec.Route) string {
+	if route.ServiceName == "" && route.TrafficSplit != nil && len(route.TrafficSplit.Services) > 0 {
+		return route.TrafficSplit.Services[0].ServiceName
+	}
+	return route.ServiceName
 }
 
 func (rm *ResourceManager) relinkRoute(
@@ -332,18 +409,53 @@ func (rm *ResourceManager) relinkRoute(
 		}
 	}
 
-	if route.ServiceName != "" {
-		if svcLk, ok := rm.services.Find(route.ServiceName + "/" + route.NamespaceName); ok {
-			if exists {
-				rm.unlinkRoute(rtLk, nsLk, name, namespace)
+	splitLks := make(map[string]linker.Linker[string])
+	if route.TrafficSplit != nil {
+		for _, ws := range route.TrafficSplit.Services {
+			if svcLk, ok := rm.services.Find(ws.ServiceName + "/" + route.NamespaceName); ok {
+				splitLks[ws.ServiceName] = svcLk
+			} else {
+				return ErrServiceNotFound(ws.ServiceName)
 			}
-			rtLk.LinkOneOne("service", route.ServiceName, svcLk)
-			svcLk.LinkOneMany("routes", route.Name, rtLk)
+		}
+	}
+
+	var mirrorLk linker.Linker[string]
+	if route.Mirror != nil {
+		if svcLk, ok := rm.services.Find(route.Mirror.ServiceName + "/" + route.NamespaceName); ok {
+			mirrorLk = svcLk
 		} else {
-			return ErrServiceNotFound(route.ServiceName)
+			return ErrServiceNotFound(route.Mirror.ServiceName)
+		}
+	}
+
+	var svcLk linker.Linker[string]
+	if svcName := routeServiceName(route); svcName != "" {
+		var ok bool
+		if svcLk, ok = rm.services.Find(svcName + "/" + route.NamespaceName); !ok {
+			return ErrServiceNotFound(svcName)
 		}
 	}
 
+	if exists {
+		rm.unlinkRoute(rtLk, nsLk, name, namespace)
+	}
+
+	if svcLk != nil {
+		rtLk.LinkOneOne("service", routeServiceName(route), svcLk)
+		svcLk.LinkOneMany("routes", route.Name, rtLk)
+	}
+
+	for svcName, svcLk := range splitLks {
+		rtLk.LinkOneMany("splits", svcName, svcLk)
+		svcLk.LinkOneMany("routes", route.Name, rtLk)
+	}
+
+	if mirrorLk != nil {
+		rtLk.LinkOneOne("mirror", route.Mirror.ServiceName, mirrorLk)
+		mirrorLk.LinkOneMany("routes", route.Name, rtLk)
+	}
+
 	rtLk.LinkOneOne("namespace", route.NamespaceName, nsLk)
 	nsLk.LinkOneMany("routes", route.Name, rtLk)
 
@@ -427,7 +539,7 @@ func (rm *ResourceManager) RemoveService(name, namespace string) error {
 	defer rm.mutex.Lock(namespace)()
 	if lk, ok := rm.services.Find(name + "/" + namespace); ok {
 		if nsLk, ok := rm.namespaces.Find(namespace); ok {
-			if rtsLk := lk.Get("routes"); rtsLk != nil {
+			if lk.Len("routes") > 0 {
 				return ErrCannotDeleteService(name, "routes still linked")
 			}
 			nsLk.UnlinkOneMany("services", name)
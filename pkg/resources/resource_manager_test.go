@@ -643,3 +643,105 @@ func BenchmarkRM_ReadingWriting(b *testing.B) {
 		})
 	})
 }
+
+func TestResourceManager_TrafficSplit(t *testing.T) {
+	rm := resources.NewManager()
+	rm.AddNamespace(&spec.Namespace{Name: "test"})
+	for _, name := range []string{"stable", "canary"} {
+		_, err := rm.AddService(&spec.Service{
+			Name:          name,
+			URLs:          []string{"http://" + name + ":8080"},
+			NamespaceName: "test",
+		})
+		assert.Nil(t, err)
+	}
+
+	route := &spec.Route{
+		Name:          "test",
+		Paths:         []string{"/"},
+		Methods:       []string{"GET"},
+		NamespaceName: "test",
+		TrafficSplit: &spec.TrafficSplit{
+			Services: []spec.WeightedService{
+				{ServiceName: "stable", Weight: 95},
+				{ServiceName: "canary", Weight: 5},
+			},
+		},
+	}
+	rt, err := rm.AddRoute(route)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "stable", rt.Service.Name)
+		assert.Equal(t, 2, len(rt.TrafficSplit.Services))
+		assert.Equal(t, "canary", rt.TrafficSplit.Services[1].Service.Name)
+		assert.Equal(t, 5, rt.TrafficSplit.Services[1].Weight)
+	}
+
+	// route updates are reflected in the split services
+	_, err = rm.AddService(&spec.Service{
+		Name:          "canary",
+		URLs:          []string{"http://canary-v2:8080"},
+		NamespaceName: "test",
+	})
+	assert.Nil(t, err)
+	rt, ok := rm.GetRoute("test", "test")
+	if assert.True(t, ok) {
+		assert.Equal(t, "canary-v2:8080", rt.TrafficSplit.Services[1].Service.URLs[0].Host)
+	}
+
+	assert.NotNil(t, rm.RemoveService("canary", "test"))
+
+	route.TrafficSplit = nil
+	route.ServiceName = "stable"
+	_, err = rm.AddRoute(route)
+	assert.Nil(t, err)
+	assert.Nil(t, rm.RemoveService("canary", "test"))
+
+	route.TrafficSplit = &spec.TrafficSplit{
+		Services: []spec.WeightedService{{ServiceName: "missing", Weight: 1}},
+	}
+	_, err = rm.AddRoute(route)
+	assert.NotNil(t, err)
+}
+
+func TestResourceManager_Mirror(t *testing.T) {
+	rm := resources.NewManager()
+	rm.AddNamespace(&spec.Namespace{Name: "test"})
+	for _, name := range []string{"v1", "v2"} {
+		_, err := rm.AddService(&spec.Service{
+			Name:          name,
+			URLs:          []string{"http://" + name + ":8080"},
+			NamespaceName: "test",
+		})
+		assert.Nil(t, err)
+	}
+
+	percent := 10.0
+	route := &spec.Route{
+		Name:          "test",
+		Paths:         []string{"/"},
+		Methods:       []string{"GET"},
+		ServiceName:   "v1",
+		NamespaceName: "test",
+		Mirror: &spec.Mirror{
+			ServiceName: "v2",
+			Percent:     &percent,
+		},
+	}
+	rt, err := rm.AddRoute(route)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "v1", rt.Service.Name)
+		assert.Equal(t, "v2", rt.Mirror.Service.Name)
+		assert.Equal(t, 10.0, *rt.Mirror.Percent)
+	}
+	assert.NotNil(t, rm.RemoveService("v2", "test"))
+
+	// the mirror service link is removed when the route stops mirroring
+	route.Mirror = nil
+	_, err = rm.AddRoute(route)
+	assert.Nil(t, err)
+	assert.Nil(t, rm.RemoveService("v2", "test"))
+
+	route.Mirror = &spec.Mirror{ServiceName: "v2"}
+	_, err = rm.AddRoute(route)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the time of the next run of a task after the given time.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+var (
+	ErrInvalidSchedule  = errors.New("invalid schedule")
+	ErrScheduleTooShort = errors.New("schedule interval must be at least 1 second")
+)
+
+var (
+	cronMonths = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	cronWeekdays = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+)
+
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses an interval (@every 1m), a descriptor (@hourly, @daily, @weekly,
+// @monthly, @yearly) or a cron expression with 5 fields (minute hour day month weekday)
+// or 6 fields (second minute hour day month weekday), the cron expression can be prefixed
+// with @cron. Months and weekdays can be names (JAN-DEC, SUN-SAT). Cron schedules use the location of the time passed to Next (the local time
+// for the scheduler), unless the schedule starts with a time zone (CRON_TZ=UTC @daily).
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	var loc *time.Location
+	if tz, ok := cutTimeZone(spec); ok {
+		name, rest, _ := strings.Cut(tz, " ")
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidSchedule, spec, err)
+		}
+		spec = strings.TrimSpace(rest)
+	}
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidSchedule, spec, err)
+		} else if d < time.Second {
+			return nil, fmt.Errorf("%w: %s", ErrScheduleTooShort, spec)
+		}
+		return everySchedule(d), nil
+	}
+	expr := spec
+	if cron, ok := strings.CutPrefix(spec, "@cron "); ok {
+		expr = strings.TrimSpace(cron)
+	} else if cron, ok := scheduleDescriptors[spec]; ok {
+		expr = cron
+	}
+	fields := strings.Fields(expr)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	} else if len(fields) != 6 {
+		return nil, fmt.Errorf("%w: %s: expected 5 or 6 fields", ErrInvalidSchedule, spec)
+	}
+	sched := &cronSchedule{loc: loc}
+	for i, field := range []struct {
+		bits      *uint64
+		low, high int
+		names     map[string]int
+	}{
+		{&sched.second, 0, 59, nil},
+		{&sched.minute, 0, 59, nil},
+		{&sched.hour, 0, 23, nil},
+		{&sched.dom, 1, 31, nil},
+		{&sched.month, 1, 12, cronMonths},
+		{&sched.dow, 0, 7, cronWeekdays},
+	} {
+		bits, err := parseCronField(fields[i], field.low, field.high, field.names)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidSchedule, spec, err)
+		}
+		*field.bits = bits
+	}
+	// sunday is 0 or 7
+	if sched.dow&(1<<7) != 0 {
+		sched.dow |= 1
+	}
+	// like cron, day fields starting with * (e.g. */2) are not restricted
+	sched.anyDom = strings.HasPrefix(fields[3], "*")
+	sched.anyDow = strings.HasPrefix(fields[5], "*")
+	return sched, nil
+}
+
+func cutTimeZone(spec string) (string, bool) {
+	if tz, ok := strings.CutPrefix(spec, "CRON_TZ="); ok {
+		return tz, true
+	}
+	return strings.CutPrefix(spec, "TZ=")
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// cronSchedule has a bit for each value of the fields that matches
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	anyDom, anyDow                        bool
+	// loc is the time zone of the schedule, nil uses the location of the time
+	loc *time.Location
+}
+
+// maxCronYears is the number of years searched for the next run, schedules
+// that never run (e.g. February 30) do not have a next run.
+const maxCronYears = 5
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	if s.loc != nil {
+		t = t.In(s.loc)
+	}
+	t = t.Truncate(time.Second).Add(time.Second)
+	end := t.AddDate(maxCronYears, 0, 0)
+	for t.Before(end) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			// the next hour can be before t when the clock is turned back (DST)
+			if next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location()); next.After(t) {
+				t = next
+			} else {
+				t = t.Truncate(time.Minute).Add(time.Minute)
+			}
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if s.second&(1<<uint(t.Second())) == 0 {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay matches the day of the month or the weekday when both are restricted, like cron
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseCronField parses a list of values, ranges (1-5) and steps (*/5, 1-30/5),
+// values can be one of the names (case insensitive).
+func parseCronField(field string, low, high int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		valueRange, step, hasStep := strings.Cut(part, "/")
+		start, end := low, high
+		if valueRange != "*" {
+			from, to, isRange := strings.Cut(valueRange, "-")
+			var err error
+			if start, err = parseCronValue(from, low, high, names); err != nil {
+				return 0, err
+			}
+			if isRange {
+				if end, err = parseCronValue(to, low, high, names); err != nil {
+					return 0, err
+				}
+			} else if !hasStep {
+				end = start
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range: %s", valueRange)
+			}
+		}
+		inc := 1
+		if hasStep {
+			var err error
+			if inc, err = strconv.Atoi(step); err != nil || inc <= 0 {
+				return 0, fmt.Errorf("invalid step: %s", step)
+			}
+		}
+		for i := start; i <= end; i += inc {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, low, high int, names map[string]int) (int, error) {
+	if i, ok := names[strings.ToUpper(value)]; ok {
+		return i, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < low || i > high {
+		return 0, fmt.Errorf("value %q must be between %d and %d", value, low, high)
+	}
+	return i, nil
+}
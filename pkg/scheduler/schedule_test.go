@@ -0,0 +1,131 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgate-io/dgate/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// 2024-01-31 is a wednesday
+	now := time.Date(2024, 1, 31, 10, 30, 15, 0, time.UTC)
+	for spec, next := range map[string]time.Time{
+		"@every 90s":         now.Add(90 * time.Second),
+		"@hourly":            time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC),
+		"@daily":             time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		"@weekly":            time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC),
+		"@monthly":           time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		"@yearly":            time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		"@cron 0 0 * * *":    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		"*/15 * * * *":       time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC),
+		"5,35 9-17 * * *":    time.Date(2024, 1, 31, 10, 35, 0, 0, time.UTC),
+		"0 12 * * 1-5":       time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+		"0 0 * * 7":          time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":         time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 31 * *":         time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		"0 0 15 * 5":         time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC),
+		"30 10-12/2 * * *":   time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC),
+		"*/20 * * * * *":     time.Date(2024, 1, 31, 10, 30, 20, 0, time.UTC),
+		"10 0 0 * * *":       time.Date(2024, 2, 1, 0, 0, 10, 0, time.UTC),
+		"@cron 0 31 * * * *": time.Date(2024, 1, 31, 10, 31, 0, 0, time.UTC),
+		// day fields starting with * are not restricted, so both days must match
+		"0 0 */2 * 1":       time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC),
+		"0 0 1 * */3":       time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"0 9 * * MON-FRI":   time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC),
+		"0 0 * * sun":       time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC),
+		"0 0 1 MAR,jun *":   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 1 FEB-APR/2 *": time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		sched, err := scheduler.ParseSchedule(spec)
+		if assert.NoError(t, err, spec) {
+			assert.Equal(t, next, sched.Next(now), spec)
+		}
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"", "@every", "@every 1x", "@sometimes",
+		"* * * *", "60 * * * *", "* 24 * * *",
+		"* * 0 * *", "* * * 13 *", "* * * * 8",
+		"5-1 * * * *", "*/0 * * * *", "a * * * *",
+		"60 * * * * *", "* * * * * * *", "CRON_TZ=Mars/Base @daily",
+		"* * * FOO *", "* * * * MONDAY", "* * MON * *",
+	} {
+		_, err := scheduler.ParseSchedule(spec)
+		assert.ErrorIs(t, err, scheduler.ErrInvalidSchedule, spec)
+	}
+	_, err := scheduler.ParseSchedule("@every 10ms")
+	assert.ErrorIs(t, err, scheduler.ErrScheduleTooShort)
+
+	// the schedule never runs, february has no 30th day
+	sched, err := scheduler.ParseSchedule("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, sched.Next(time.Now()).IsZero())
+}
+
+func TestParseSchedule_TimeZone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)
+	for _, spec := range []string{
+		"CRON_TZ=America/New_York @daily",
+		"TZ=America/New_York 0 0 * * *",
+	} {
+		sched, err := scheduler.ParseSchedule(spec)
+		if assert.NoError(t, err, spec) {
+			next := sched.Next(now)
+			assert.True(t, next.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, ny)), spec)
+			assert.Equal(t, ny, next.Location(), spec)
+		}
+	}
+
+	// 2:30 does not exist when the clock is turned forward
+	sched, err := scheduler.ParseSchedule("CRON_TZ=America/New_York 30 2 * * *")
+	require.NoError(t, err)
+	next := sched.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, ny))
+	assert.True(t, next.After(time.Date(2024, 3, 10, 0, 0, 0, 0, ny)))
+
+	// 1:30 happens twice when the clock is turned back
+	sched, err = scheduler.ParseSchedule("CRON_TZ=America/New_York 30 1 * * *")
+	require.NoError(t, err)
+	start := time.Date(2024, 11, 3, 1, 45, 0, 0, ny).Add(time.Hour)
+	next = sched.Next(start)
+	assert.True(t, next.After(start))
+	assert.Equal(t, time.Date(2024, 11, 4, 1, 30, 0, 0, ny), next)
+}
+
+type testSchedule time.Duration
+
+func (s testSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+func TestScheduleTask_Schedule(t *testing.T) {
+	exeCount := atomic.Int32{}
+	sch := scheduler.New(scheduler.Options{
+		Interval: time.Millisecond * 5,
+		AutoRun:  true,
+	})
+	err := sch.ScheduleTask("test", scheduler.TaskOptions{
+		Schedule: testSchedule(time.Millisecond * 20),
+		TaskFunc: func(_ context.Context) { exeCount.Add(1) },
+	})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return exeCount.Load() >= 3
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, 1, sch.TotalTasks())
+
+	err = sch.ScheduleTask("test2", scheduler.TaskOptions{
+		Schedule: testSchedule(time.Second),
+		Interval: time.Second,
+		TaskFunc: func(_ context.Context) {},
+	})
+	assert.ErrorIs(t, err, scheduler.ErrIntervalTimeoutBothSet)
+}
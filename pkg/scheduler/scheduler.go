@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -15,6 +16,19 @@ type (
 	priorityQueue = *heap.Heap[int64, *TaskDefinition]
 )
 
+// OverlapPolicy is what happens when a run of a task is due while the previous run has not returned.
+type OverlapPolicy int
+
+const (
+	// OverlapNone runs the task in the scheduler, other tasks wait for the task to return.
+	OverlapNone OverlapPolicy = iota
+	// OverlapSkip runs the task in the background, runs are skipped while the previous run has not returned.
+	OverlapSkip
+	// OverlapQueue runs the task in the background, a run that is due while the previous
+	// run has not returned is started when it returns (at most one run is queued).
+	OverlapQueue
+)
+
 type TaskOptions struct {
 	// Interval is the time between each task run.
 	// Timeout OR Interval must be set.
@@ -23,6 +37,14 @@ type TaskOptions struct {
 	// before it is forcefully stopped.
 	// Timeout OR Interval must be set.
 	Timeout time.Duration
+	// Schedule is the schedule of the task runs (see ParseSchedule),
+	// it is used instead of Interval and Timeout.
+	Schedule Schedule
+	// Jitter delays each run by a random duration up to Jitter,
+	// so tasks with the same interval or schedule do not run at the same time.
+	Jitter time.Duration
+	// Overlap is the overlap policy of the task, OverlapNone is the default.
+	Overlap OverlapPolicy
 	// Overwrite indicates whether to overwrite the task if it already exists.
 	// If set to false, an error will be returned if the task already exists.
 	// If set to true, the task will be overwritten with the new task and any existing timers will be reset.
@@ -56,18 +78,37 @@ type TaskDefinition struct {
 	Name     string
 	Func     TaskFunc
 	interval time.Duration
+	schedule Schedule
+	jitter   time.Duration
+	overlap  OverlapPolicy
 	ctx      context.Context
 	cancel   context.CancelFunc
+
+	// due is the time of the next run without the jitter
+	due              time.Time
+	nextRun, lastRun time.Time
+	running, queued  bool
+}
+
+// NextRun returns the time of the next run, it is zero if the task will not run again.
+func (td TaskDefinition) NextRun() time.Time {
+	return td.nextRun
+}
+
+// LastRun returns the time the last run started, it is zero if the task has not run.
+func (td TaskDefinition) LastRun() time.Time {
+	return td.lastRun
 }
 
 var (
 	ErrTaskAlreadyExists        = errors.New("task already exists")
 	ErrTaskNotFound             = errors.New("task not found")
-	ErrIntervalTimeoutBothSet   = errors.New("only one of Interval or Timeout must be set")
-	ErrIntervalTimeoutNoneSet   = errors.New("either Interval or Timeout must be set")
+	ErrIntervalTimeoutBothSet   = errors.New("only one of Interval, Timeout or Schedule must be set")
+	ErrIntervalTimeoutNoneSet   = errors.New("either Interval, Timeout or Schedule must be set")
 	ErrTaskFuncNotSet           = errors.New("TaskFunc must be set")
 	ErrIntervalDurationTooShort = errors.New("interval duration must be greater than 1 second")
 	ErrTimeoutDurationTooShort  = errors.New("timeout duration must be greater than 1 second")
+	ErrJitterNegative           = errors.New("jitter must not be negative")
 	ErrSchedulerRunning         = errors.New("scheduler is already running")
 	ErrSchedulerNotRunning      = errors.New("scheduler is not running")
 )
@@ -82,6 +123,9 @@ func New(opts Options) Scheduler {
 	if opts.Interval <= 0 {
 		opts.Interval = time.Second
 	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
 	return &scheduler{
 		opts:        opts,
 		ctx:         context.TODO(),
@@ -104,7 +148,9 @@ func (s *scheduler) Start() error {
 
 func (s *scheduler) start() {
 	s.running = true
-	s.ctx, s.cancel = context.WithCancel(s.ctx)
+	// a new context is created on each start, so the scheduler can be restarted after being stopped
+	ctx, cancel := context.WithCancel(context.TODO())
+	s.ctx, s.cancel = ctx, cancel
 	go func() {
 		ticker := time.NewTicker(s.opts.Interval)
 		defer ticker.Stop()
@@ -116,15 +162,14 @@ func (s *scheduler) start() {
 				now := time.Now()
 				taskDefTime, taskDef, ok := s.pendingJobs.Peak()
 				if !ok {
-					return
+					return ctx.Err() != nil
 				}
 				select {
-				case <-s.ctx.Done():
-					s.running = false
+				case <-ctx.Done():
 					done = true
 					return
 				case <-taskDef.ctx.Done():
-					delete(s.tasks, taskDef.Name)
+					s.deleteTask(taskDef)
 					s.pendingJobs.Pop()
 					goto START
 				default:
@@ -132,7 +177,7 @@ func (s *scheduler) start() {
 					if !tdt.After(now) {
 						// Run the task
 						s.pendingJobs.Pop()
-						s.executeTask(tdt, taskDef)
+						s.executeTask(taskDef)
 						// Go to the start of the loop to check if there are any more tasks
 						goto START
 					}
@@ -151,29 +196,91 @@ func (s *scheduler) Running() bool {
 	return s.running
 }
 
-func (s *scheduler) executeTask(tdt time.Time, taskDef *TaskDefinition) {
-	defer func() {
-		if taskDef.interval <= 0 {
-			taskDef.cancel()
-			delete(s.tasks, taskDef.Name)
+func (s *scheduler) executeTask(taskDef *TaskDefinition) {
+	defer s.reschedule(taskDef)
+	if taskDef.overlap != OverlapNone {
+		if !taskDef.running {
+			s.runInBackground(taskDef)
+		} else if taskDef.overlap == OverlapQueue {
+			taskDef.queued = true
 		} else {
-			µs := tdt.Add(taskDef.interval).UnixMicro()
-			s.pendingJobs.Push(µs, taskDef)
+			s.logger.Debug("task run skipped, the previous run has not returned",
+				zap.String("task_name", taskDef.Name))
 		}
+		return
+	}
+	defer func() {
 		if r := recover(); r != nil {
 			s.logger.Error("panic occurred while executing task",
 				zap.String("task_name", taskDef.Name), zap.Any("error", r))
 		}
 	}()
+	taskDef.lastRun = time.Now()
 	taskDef.Func(taskDef.ctx)
 }
 
+// runInBackground runs the task in a goroutine, the scheduler mutex must be held
+func (s *scheduler) runInBackground(taskDef *TaskDefinition) {
+	taskDef.running = true
+	taskDef.lastRun = time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Error("panic occurred while executing task",
+					zap.String("task_name", taskDef.Name), zap.Any("error", r))
+			}
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			taskDef.running = false
+			if taskDef.queued && taskDef.ctx.Err() == nil {
+				taskDef.queued = false
+				s.runInBackground(taskDef)
+			} else if taskDef.nextRun.IsZero() {
+				// the task will not run again
+				taskDef.cancel()
+			}
+		}()
+		taskDef.Func(taskDef.ctx)
+	}()
+}
+
+func (s *scheduler) reschedule(taskDef *TaskDefinition) {
+	var next time.Time
+	if taskDef.schedule != nil {
+		// scheduled tasks are not run again for the runs missed while the scheduler was busy
+		next = taskDef.schedule.Next(time.Now())
+	} else if taskDef.interval > 0 {
+		next = taskDef.due.Add(taskDef.interval)
+	}
+	if next.IsZero() {
+		taskDef.nextRun = time.Time{}
+		s.deleteTask(taskDef)
+		// the context of a task running in the background is canceled when the run returns
+		if !taskDef.running {
+			taskDef.cancel()
+		}
+		return
+	}
+	s.push(taskDef, next)
+}
+
+// push adds the next run of the task to the pending jobs
+func (s *scheduler) push(taskDef *TaskDefinition, due time.Time) {
+	taskDef.due = due
+	taskDef.nextRun = due
+	if taskDef.jitter > 0 {
+		taskDef.nextRun = due.Add(time.Duration(rand.Int63n(int64(taskDef.jitter))))
+	}
+	s.pendingJobs.Push(taskDef.nextRun.UnixMicro(), taskDef)
+}
+
 func (s *scheduler) Stop() {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	if !s.running {
 		return
 	}
+	s.running = false
 	s.cancel()
 }
 
@@ -181,7 +288,10 @@ func (s *scheduler) GetTask(taskId string) (TaskDefinition, bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	td, ok := s.tasks[taskId]
-	return *td, ok
+	if !ok {
+		return TaskDefinition{}, false
+	}
+	return *td, true
 }
 
 func (s *scheduler) ScheduleTask(name string, opts TaskOptions) error {
@@ -203,14 +313,17 @@ func (s *scheduler) ScheduleTask(name string, opts TaskOptions) error {
 		}
 	}
 
-	if opts.Interval == 0 && opts.Timeout == 0 {
+	if opts.Interval == 0 && opts.Timeout == 0 && opts.Schedule == nil {
 		return ErrIntervalTimeoutNoneSet
-	} else if opts.Interval != 0 && opts.Timeout != 0 {
+	} else if (opts.Interval != 0 && opts.Timeout != 0) ||
+		(opts.Schedule != nil && (opts.Interval != 0 || opts.Timeout != 0)) {
 		return ErrIntervalTimeoutBothSet
 	} else if opts.Interval > 0 && opts.Interval < s.opts.Interval {
 		return ErrIntervalDurationTooShort
 	} else if opts.Timeout > 0 && opts.Timeout < s.opts.Interval {
 		return ErrTimeoutDurationTooShort
+	} else if opts.Jitter < 0 {
+		return ErrJitterNegative
 	}
 
 	if opts.TaskFunc == nil {
@@ -224,12 +337,22 @@ func (s *scheduler) ScheduleTask(name string, opts TaskOptions) error {
 		ctx:      ctx,
 		cancel:   cancel,
 		interval: opts.Interval,
+		schedule: opts.Schedule,
+		jitter:   opts.Jitter,
+		overlap:  opts.Overlap,
 	}
-	µs := time.Now().Add(opts.Interval).UnixMicro()
+	due := time.Now().Add(opts.Interval)
 	if opts.Timeout > 0 {
-		µs = time.Now().Add(opts.Timeout).UnixMicro()
+		due = time.Now().Add(opts.Timeout)
+	} else if opts.Schedule != nil {
+		due = opts.Schedule.Next(time.Now())
+		if due.IsZero() {
+			delete(s.tasks, name)
+			cancel()
+			return ErrInvalidSchedule
+		}
 	}
-	s.pendingJobs.Push(µs, s.tasks[name])
+	s.push(s.tasks[name], due)
 	return nil
 }
 
@@ -249,6 +372,13 @@ func (s *scheduler) stopTask(name string) error {
 	return nil
 }
 
+// deleteTask removes the task definition, unless it has been overwritten by a newer task
+func (s *scheduler) deleteTask(taskDef *TaskDefinition) {
+	if td, ok := s.tasks[taskDef.Name]; ok && td == taskDef {
+		delete(s.tasks, taskDef.Name)
+	}
+}
+
 func (s *scheduler) TotalTasks() int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
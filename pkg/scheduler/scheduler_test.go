@@ -154,3 +154,153 @@ func TestStopTask(t *testing.T) {
 	err = sch.StopTask("task1")
 	assert.ErrorIs(t, err, scheduler.ErrTaskNotFound)
 }
+
+func TestScheduler_Restart(t *testing.T) {
+	sch := scheduler.New(scheduler.Options{
+		Interval: time.Millisecond * 10,
+	})
+	assert.Nil(t, sch.Start())
+	sch.Stop()
+	assert.False(t, sch.Running())
+	assert.Nil(t, sch.Start())
+	assert.True(t, sch.Running())
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	err := sch.ScheduleTask("task1", scheduler.TaskOptions{
+		Timeout:  time.Millisecond * 10,
+		TaskFunc: func(_ context.Context) { wg.Done() },
+	})
+	assert.Nil(t, err)
+	wg.Wait()
+}
+
+func TestScheduleTask_OverwriteStop(t *testing.T) {
+	sch := scheduler.New(scheduler.Options{
+		Interval: time.Millisecond * 10,
+		AutoRun:  true,
+	})
+	for i := 0; i < 2; i++ {
+		err := sch.ScheduleTask("task1", scheduler.TaskOptions{
+			Interval:  time.Millisecond * 10,
+			Overwrite: true,
+			TaskFunc:  func(_ context.Context) {},
+		})
+		assert.Nil(t, err)
+	}
+	// wait for the overwritten task to be cleaned up
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, 1, sch.TotalTasks())
+	assert.Nil(t, sch.StopTask("task1"))
+}
+
+func TestScheduleTask_Jitter(t *testing.T) {
+	sch := scheduler.New(scheduler.Options{
+		Interval: time.Millisecond * 10,
+		AutoRun:  true,
+	})
+	start := time.Now()
+	err := sch.ScheduleTask("task1", scheduler.TaskOptions{
+		Interval: time.Minute,
+		Jitter:   time.Second,
+		TaskFunc: func(_ context.Context) {},
+	})
+	assert.Nil(t, err)
+	task, ok := sch.GetTask("task1")
+	assert.True(t, ok)
+	assert.True(t, task.LastRun().IsZero())
+	assert.WithinRange(t, task.NextRun(),
+		start.Add(time.Minute), time.Now().Add(time.Minute+time.Second))
+
+	err = sch.ScheduleTask("task2", scheduler.TaskOptions{
+		Interval: time.Minute,
+		Jitter:   -time.Second,
+		TaskFunc: func(_ context.Context) {},
+	})
+	assert.ErrorIs(t, err, scheduler.ErrJitterNegative)
+
+	_, ok = sch.GetTask("task2")
+	assert.False(t, ok)
+}
+
+// limitedSchedule runs every interval, n times
+type limitedSchedule struct {
+	interval time.Duration
+	n        atomic.Int32
+}
+
+func (s *limitedSchedule) Next(t time.Time) time.Time {
+	if s.n.Add(-1) < 0 {
+		return time.Time{}
+	}
+	return t.Add(s.interval)
+}
+
+func TestScheduleTask_Overlap(t *testing.T) {
+	for policy, expected := range map[scheduler.OverlapPolicy]int32{
+		scheduler.OverlapSkip:  1,
+		scheduler.OverlapQueue: 2,
+	} {
+		sch := scheduler.New(scheduler.Options{
+			Interval: time.Millisecond * 5,
+			AutoRun:  true,
+		})
+		exeCount := atomic.Int32{}
+		release := make(chan struct{})
+		sched := &limitedSchedule{interval: time.Millisecond * 10}
+		sched.n.Store(4)
+		err := sch.ScheduleTask("task1", scheduler.TaskOptions{
+			Schedule: sched,
+			Overlap:  policy,
+			TaskFunc: func(ctx context.Context) {
+				if exeCount.Add(1) == 1 {
+					<-release
+				}
+				assert.Nil(t, ctx.Err())
+			},
+		})
+		assert.Nil(t, err)
+		// the scheduler keeps running other tasks while the task is running
+		done := make(chan struct{})
+		err = sch.ScheduleTask("task2", scheduler.TaskOptions{
+			Timeout:  time.Millisecond * 10,
+			TaskFunc: func(_ context.Context) { close(done) },
+		})
+		assert.Nil(t, err)
+		<-done
+
+		// the runs due while the first run is running are skipped or queued
+		assert.Eventually(t, func() bool {
+			_, ok := sch.GetTask("task1")
+			return !ok
+		}, time.Second, time.Millisecond*5)
+		assert.Equal(t, int32(1), exeCount.Load())
+		close(release)
+		assert.Eventually(t, func() bool {
+			return exeCount.Load() == expected
+		}, time.Second, time.Millisecond*5)
+		time.Sleep(time.Millisecond * 30)
+		assert.Equal(t, expected, exeCount.Load())
+		sch.Stop()
+	}
+}
+
+func TestScheduleTask_LastRun(t *testing.T) {
+	sch := scheduler.New(scheduler.Options{
+		Interval: time.Millisecond * 5,
+		AutoRun:  true,
+	})
+	exeCount := atomic.Int32{}
+	err := sch.ScheduleTask("task1", scheduler.TaskOptions{
+		Interval: time.Millisecond * 20,
+		TaskFunc: func(_ context.Context) { exeCount.Add(1) },
+	})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return exeCount.Load() >= 1
+	}, time.Second, time.Millisecond*5)
+	task, ok := sch.GetTask("task1")
+	assert.True(t, ok)
+	assert.False(t, task.LastRun().IsZero())
+	assert.True(t, task.NextRun().After(task.LastRun()))
+}
@@ -0,0 +1,98 @@
+package spec
+
+import (
+	"errors"
+	"time"
+)
+
+type ConcurrencyLimitMode string
+
+const (
+	// ConcurrencyLimitFixed never changes the limit
+	ConcurrencyLimitFixed ConcurrencyLimitMode = "fixed"
+	// ConcurrencyLimitAIMD increases the limit by one for each window of requests
+	// that are not slower than the latency threshold, and multiplies it by the
+	// backoff ratio when a request is slower or fails.
+	ConcurrencyLimitAIMD ConcurrencyLimitMode = "aimd"
+	// ConcurrencyLimitGradient changes the limit by the ratio between the long term
+	// latency and the latency of recent requests, so the limit is lowered when the
+	// latency starts to grow.
+	ConcurrencyLimitGradient ConcurrencyLimitMode = "gradient"
+)
+
+// ConcurrencyLimit limits the number of requests that are handled at the same time,
+// requests over the limit are queued or shed with a 503 status.
+type ConcurrencyLimit struct {
+	// Limit is the max number of concurrent requests, in adaptive modes it is the initial limit
+	Limit int `json:"limit" koanf:"limit"`
+	// Mode is fixed, aimd or gradient, defaults to fixed
+	Mode ConcurrencyLimitMode `json:"mode,omitempty" koanf:"mode"`
+	// MinLimit and MaxLimit bound the adaptive limit, default to 1 and 10 times the limit
+	MinLimit int `json:"minLimit,omitempty" koanf:"minLimit"`
+	MaxLimit int `json:"maxLimit,omitempty" koanf:"maxLimit"`
+	// QueueSize is the number of requests that wait for the limit, requests are
+	// shed right away when it is not set or when the queue is full.
+	QueueSize int `json:"queueSize,omitempty" koanf:"queueSize"`
+	// QueueTimeout is how long queued requests wait before they are shed, defaults to 1 second
+	QueueTimeout time.Duration `json:"queueTimeout,omitempty" koanf:"queueTimeout"`
+	// LatencyThreshold is the latency that lowers the limit in aimd mode, defaults to 1 second
+	LatencyThreshold time.Duration `json:"latencyThreshold,omitempty" koanf:"latencyThreshold"`
+	// BackoffRatio is what the limit is multiplied by in aimd mode when it is lowered, defaults to 0.9
+	BackoffRatio float64 `json:"backoffRatio,omitempty" koanf:"backoffRatio"`
+}
+
+const (
+	DefaultConcurrencyQueueTimeout     = time.Second
+	DefaultConcurrencyLatencyThreshold = time.Second
+	DefaultConcurrencyBackoffRatio     = 0.9
+)
+
+// WithDefaults returns a copy of the concurrency limit with all unset values defaulted.
+func (cl ConcurrencyLimit) WithDefaults() ConcurrencyLimit {
+	if cl.Mode == "" {
+		cl.Mode = ConcurrencyLimitFixed
+	}
+	if cl.MinLimit <= 0 {
+		cl.MinLimit = 1
+	}
+	if cl.MaxLimit <= 0 {
+		cl.MaxLimit = cl.Limit * 10
+	}
+	if cl.QueueTimeout <= 0 {
+		cl.QueueTimeout = DefaultConcurrencyQueueTimeout
+	}
+	if cl.LatencyThreshold <= 0 {
+		cl.LatencyThreshold = DefaultConcurrencyLatencyThreshold
+	}
+	if cl.BackoffRatio <= 0 {
+		cl.BackoffRatio = DefaultConcurrencyBackoffRatio
+	}
+	return cl
+}
+
+func (cl *ConcurrencyLimit) Validate() error {
+	if cl == nil {
+		return nil
+	}
+	switch cl.Mode {
+	case "", ConcurrencyLimitFixed, ConcurrencyLimitAIMD, ConcurrencyLimitGradient:
+	default:
+		return errors.New("invalid concurrency limit mode: " + string(cl.Mode))
+	}
+	if cl.Limit <= 0 {
+		return errors.New("concurrency limit must be greater than 0")
+	}
+	if cl.MinLimit < 0 || cl.MaxLimit < 0 || cl.QueueSize < 0 {
+		return errors.New("concurrency limit values cannot be negative")
+	}
+	if cl.MaxLimit > 0 && cl.MaxLimit < max(cl.MinLimit, 1) {
+		return errors.New("concurrency max limit cannot be less than the min limit")
+	}
+	if cl.QueueTimeout < 0 || cl.LatencyThreshold < 0 {
+		return errors.New("concurrency limit durations cannot be negative")
+	}
+	if cl.BackoffRatio < 0 || cl.BackoffRatio >= 1 {
+		return errors.New("concurrency backoff ratio must be between 0 and 1")
+	}
+	return nil
+}
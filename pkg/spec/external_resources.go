@@ -7,6 +7,8 @@ import (
 type Namespace struct {
 	Name string   `json:"name" koanf:"name"`
 	Tags []string `json:"tags,omitempty" koanf:"tags"`
+	// RateLimit limits the requests to all routes of the namespace
+	RateLimit *RateLimit `json:"rateLimit,omitempty" koanf:"rateLimit"`
 }
 
 func (n *Namespace) GetName() string {
@@ -14,18 +16,24 @@ func (n *Namespace) GetName() string {
 }
 
 type Service struct {
-	Name               string         `json:"name" koanf:"name"`
-	URLs               []string       `json:"urls" koanf:"urls"`
-	NamespaceName      string         `json:"namespace" koanf:"namespace"`
-	Retries            *int           `json:"retries,omitempty" koanf:"retries"`
-	RetryTimeout       *time.Duration `json:"retryTimeout,omitempty" koanf:"retryTimeout"`
-	ConnectTimeout     *time.Duration `json:"connectTimeout,omitempty"  koanf:"connectTimeout"`
-	RequestTimeout     *time.Duration `json:"requestTimeout,omitempty"  koanf:"requestTimeout"`
-	TLSSkipVerify      *bool          `json:"tlsSkipVerify,omitempty" koanf:"tlsSkipVerify"`
-	HTTP2Only          *bool          `json:"http2Only,omitempty" koanf:"http2Only"`
-	HideDGateHeaders   *bool          `json:"hideDGateHeaders,omitempty" koanf:"hideDGateHeaders"`
-	DisableQueryParams *bool          `json:"disableQueryParams,omitempty" koanf:"disableQueryParams"`
-	Tags               []string       `json:"tags,omitempty" koanf:"tags"`
+	Name               string            `json:"name" koanf:"name"`
+	URLs               []string          `json:"urls" koanf:"urls"`
+	NamespaceName      string            `json:"namespace" koanf:"namespace"`
+	Retries            *int              `json:"retries,omitempty" koanf:"retries"`
+	RetryTimeout       *time.Duration    `json:"retryTimeout,omitempty" koanf:"retryTimeout"`
+	ConnectTimeout     *time.Duration    `json:"connectTimeout,omitempty"  koanf:"connectTimeout"`
+	RequestTimeout     *time.Duration    `json:"requestTimeout,omitempty"  koanf:"requestTimeout"`
+	TLSSkipVerify      *bool             `json:"tlsSkipVerify,omitempty" koanf:"tlsSkipVerify"`
+	HTTP2Only          *bool             `json:"http2Only,omitempty" koanf:"http2Only"`
+	HideDGateHeaders   *bool             `json:"hideDGateHeaders,omitempty" koanf:"hideDGateHeaders"`
+	DisableQueryParams *bool             `json:"disableQueryParams,omitempty" koanf:"disableQueryParams"`
+	LoadBalancer       *LoadBalancer     `json:"loadBalancer,omitempty" koanf:"loadBalancer"`
+	HealthCheck        *HealthCheck      `json:"healthCheck,omitempty" koanf:"healthCheck"`
+	OutlierDetection   *OutlierDetection `json:"outlierDetection,omitempty" koanf:"outlierDetection"`
+	CircuitBreaker     *CircuitBreaker   `json:"circuitBreaker,omitempty" koanf:"circuitBreaker"`
+	RetryPolicy        *RetryPolicy      `json:"retryPolicy,omitempty" koanf:"retryPolicy"`
+	ConcurrencyLimit   *ConcurrencyLimit `json:"concurrencyLimit,omitempty" koanf:"concurrencyLimit"`
+	Tags               []string          `json:"tags,omitempty" koanf:"tags"`
 }
 
 func (s *Service) GetName() string {
@@ -42,6 +50,23 @@ type Route struct {
 	NamespaceName string   `json:"namespace" koanf:"namespace"`
 	Modules       []string `json:"modules,omitempty" koanf:"modules"`
 	Tags          []string `json:"tags,omitempty" koanf:"tags"`
+	// TrafficSplit splits requests between several services, the first
+	// service of the split is used as the route service when it is not set.
+	TrafficSplit *TrafficSplit `json:"trafficSplit,omitempty" koanf:"trafficSplit"`
+	// Mirror sends a copy of the requests to another service
+	Mirror *Mirror `json:"mirror,omitempty" koanf:"mirror"`
+	// Match has extra conditions for the route, on top of the paths and methods
+	Match *RouteMatch `json:"match,omitempty" koanf:"match"`
+	// Priority orders routes that share a path, higher priority routes are matched first
+	Priority int `json:"priority,omitempty" koanf:"priority"`
+	// Rewrite rules change the upstream path and host, the first matching rule is applied
+	Rewrite []RewriteRule `json:"rewrite,omitempty" koanf:"rewrite"`
+	// Cache caches the upstream responses of the route
+	Cache *ResponseCache `json:"cache,omitempty" koanf:"cache"`
+	// RateLimit limits the requests to the route
+	RateLimit *RateLimit `json:"rateLimit,omitempty" koanf:"rateLimit"`
+	// ConcurrencyLimit limits the requests the route handles at the same time
+	ConcurrencyLimit *ConcurrencyLimit `json:"concurrencyLimit,omitempty" koanf:"concurrencyLimit"`
 }
 
 func (m *Route) GetName() string {
@@ -54,6 +79,12 @@ type Module struct {
 	Payload       string     `json:"payload" koanf:"payload"`
 	Type          ModuleType `json:"moduleType,omitempty" koanf:"moduleType"`
 	Tags          []string   `json:"tags,omitempty" koanf:"tags"`
+	// Permissions limit what the module can access, modules without
+	// permissions can access everything. It is not omitted when empty,
+	// so an empty list (no permissions) is kept.
+	Permissions []string `json:"permissions" koanf:"permissions"`
+	// Limits override the default limits (proxy.module_limits) of the module
+	Limits *ModuleLimits `json:"limits,omitempty" koanf:"limits"`
 }
 
 func (m *Module) GetName() string {
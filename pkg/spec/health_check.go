@@ -0,0 +1,98 @@
+package spec
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type HealthCheck struct {
+	// Path is the path that is requested on each service url
+	Path     string        `json:"path" koanf:"path"`
+	Method   string        `json:"method,omitempty" koanf:"method"`
+	Interval time.Duration `json:"interval,omitempty" koanf:"interval"`
+	Timeout  time.Duration `json:"timeout,omitempty" koanf:"timeout"`
+	// ExpectedStatus is the list of status codes considered healthy, any 2xx status code is accepted when empty
+	ExpectedStatus []int `json:"expectedStatus,omitempty" koanf:"expectedStatus"`
+	// HealthyThreshold is the number of consecutive successful checks needed to mark a url as up
+	HealthyThreshold int `json:"healthyThreshold,omitempty" koanf:"healthyThreshold"`
+	// UnhealthyThreshold is the number of consecutive failed checks needed to mark a url as down
+	UnhealthyThreshold int `json:"unhealthyThreshold,omitempty" koanf:"unhealthyThreshold"`
+}
+
+// UpstreamStatus is the health state of a single service url.
+type UpstreamStatus struct {
+	URL                  string    `json:"url"`
+	Healthy              bool      `json:"healthy"`
+	ConsecutiveSuccesses int       `json:"consecutiveSuccesses"`
+	ConsecutiveFailures  int       `json:"consecutiveFailures"`
+	LastStatusCode       int       `json:"lastStatusCode,omitempty"`
+	LastError            string    `json:"lastError,omitempty"`
+	LastCheck            time.Time `json:"lastCheck,omitempty"`
+	// Ejected is set when the url was ejected by outlier detection
+	Ejected      bool      `json:"ejected,omitempty"`
+	EjectedUntil time.Time `json:"ejectedUntil,omitempty"`
+}
+
+const (
+	DefaultHealthCheckInterval           = 10 * time.Second
+	DefaultHealthCheckTimeout            = 5 * time.Second
+	DefaultHealthCheckHealthyThreshold   = 2
+	DefaultHealthCheckUnhealthyThreshold = 3
+)
+
+// WithDefaults returns a copy of the health check with all unset values defaulted.
+func (hc HealthCheck) WithDefaults() HealthCheck {
+	if hc.Path == "" {
+		hc.Path = "/"
+	}
+	if hc.Method == "" {
+		hc.Method = http.MethodGet
+	}
+	if hc.Interval <= 0 {
+		hc.Interval = DefaultHealthCheckInterval
+	}
+	if hc.Timeout <= 0 {
+		hc.Timeout = DefaultHealthCheckTimeout
+	}
+	if hc.HealthyThreshold <= 0 {
+		hc.HealthyThreshold = DefaultHealthCheckHealthyThreshold
+	}
+	if hc.UnhealthyThreshold <= 0 {
+		hc.UnhealthyThreshold = DefaultHealthCheckUnhealthyThreshold
+	}
+	return hc
+}
+
+// ExpectsStatus checks if the status code should be considered healthy.
+func (hc *HealthCheck) ExpectsStatus(status int) bool {
+	if len(hc.ExpectedStatus) == 0 {
+		return status >= 200 && status < 300
+	}
+	return slices.Contains(hc.ExpectedStatus, status)
+}
+
+func (hc *HealthCheck) Validate() error {
+	if hc == nil {
+		return nil
+	}
+	if hc.Interval < 0 || hc.Timeout < 0 {
+		return errors.New("health check interval and timeout cannot be negative")
+	}
+	if hc.Interval > 0 && hc.Interval < time.Second {
+		return errors.New("health check interval must be at least 1s")
+	}
+	if hc.HealthyThreshold < 0 || hc.UnhealthyThreshold < 0 {
+		return errors.New("health check thresholds cannot be negative")
+	}
+	for _, status := range hc.ExpectedStatus {
+		if status < 100 || status > 599 {
+			return errors.New("health check expected status must be a valid status code")
+		}
+	}
+	if hc.Path != "" && hc.Path[0] != '/' {
+		return errors.New("health check path must start with /")
+	}
+	return nil
+}
@@ -15,15 +15,23 @@ type Named interface {
 }
 
 type DGateRoute struct {
-	Name         string          `json:"name"`
-	Paths        []string        `json:"paths"`
-	Methods      []string        `json:"methods"`
-	StripPath    bool            `json:"stripPath"`
-	PreserveHost bool            `json:"preserveHost"`
-	Service      *DGateService   `json:"service"`
-	Namespace    *DGateNamespace `json:"namespace"`
-	Modules      []*DGateModule  `json:"modules"`
-	Tags         []string        `json:"tags,omitempty"`
+	Name             string             `json:"name"`
+	Paths            []string           `json:"paths"`
+	Methods          []string           `json:"methods"`
+	StripPath        bool               `json:"stripPath"`
+	PreserveHost     bool               `json:"preserveHost"`
+	Service          *DGateService      `json:"service"`
+	Namespace        *DGateNamespace    `json:"namespace"`
+	Modules          []*DGateModule     `json:"modules"`
+	Tags             []string           `json:"tags,omitempty"`
+	TrafficSplit     *DGateTrafficSplit `json:"trafficSplit,omitempty"`
+	Mirror           *DGateMirror       `json:"mirror,omitempty"`
+	Match            *RouteMatch        `json:"match,omitempty"`
+	Priority         int                `json:"priority,omitempty"`
+	Rewrite          []RewriteRule      `json:"rewrite,omitempty"`
+	Cache            *ResponseCache     `json:"cache,omitempty"`
+	RateLimit        *RateLimit         `json:"rateLimit,omitempty"`
+	ConcurrencyLimit *ConcurrencyLimit  `json:"concurrencyLimit,omitempty"`
 }
 
 func (r *DGateRoute) GetName() string {
@@ -36,14 +44,20 @@ type DGateService struct {
 	Tags      []string        `json:"tags,omitempty"`
 	Namespace *DGateNamespace `json:"namespace"`
 
-	DisableQueryParams bool          `json:"disableQueryParams,omitempty"`
-	Retries            int           `json:"retries,omitempty"`
-	RetryTimeout       time.Duration `json:"retryTimeout,omitempty"`
-	ConnectTimeout     time.Duration `json:"connectTimeout,omitempty"`
-	RequestTimeout     time.Duration `json:"requestTimeout,omitempty"`
-	TLSSkipVerify      bool          `json:"tlsSkipVerify,omitempty"`
-	HTTP2Only          bool          `json:"http2_only,omitempty"`
-	HideDGateHeaders   bool          `json:"hideDGateHeaders,omitempty"`
+	DisableQueryParams bool              `json:"disableQueryParams,omitempty"`
+	Retries            int               `json:"retries,omitempty"`
+	RetryTimeout       time.Duration     `json:"retryTimeout,omitempty"`
+	ConnectTimeout     time.Duration     `json:"connectTimeout,omitempty"`
+	RequestTimeout     time.Duration     `json:"requestTimeout,omitempty"`
+	TLSSkipVerify      bool              `json:"tlsSkipVerify,omitempty"`
+	HTTP2Only          bool              `json:"http2_only,omitempty"`
+	HideDGateHeaders   bool              `json:"hideDGateHeaders,omitempty"`
+	LoadBalancer       *LoadBalancer     `json:"loadBalancer,omitempty"`
+	HealthCheck        *HealthCheck      `json:"healthCheck,omitempty"`
+	OutlierDetection   *OutlierDetection `json:"outlierDetection,omitempty"`
+	CircuitBreaker     *CircuitBreaker   `json:"circuitBreaker,omitempty"`
+	RetryPolicy        *RetryPolicy      `json:"retryPolicy,omitempty"`
+	ConcurrencyLimit   *ConcurrencyLimit `json:"concurrencyLimit,omitempty"`
 }
 
 func (s *DGateService) GetName() string {
@@ -51,8 +65,9 @@ func (s *DGateService) GetName() string {
 }
 
 type DGateNamespace struct {
-	Name string   `json:"name"`
-	Tags []string `json:"tags,omitempty"`
+	Name      string     `json:"name"`
+	Tags      []string   `json:"tags,omitempty"`
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
 }
 
 func (ns *DGateNamespace) GetName() string {
@@ -81,11 +96,15 @@ type ModuleType string
 const (
 	ModuleTypeJavascript ModuleType = "javascript"
 	ModuleTypeTypescript ModuleType = "typescript"
+	ModuleTypeWasm       ModuleType = "wasm"
+	// ModuleTypeNative modules are go plugins loaded by the proxy,
+	// the payload is the name of the plugin in the proxy config.
+	ModuleTypeNative ModuleType = "native"
 )
 
 func (m ModuleType) Valid() bool {
 	switch m {
-	case ModuleTypeJavascript, ModuleTypeTypescript:
+	case ModuleTypeJavascript, ModuleTypeTypescript, ModuleTypeWasm, ModuleTypeNative:
 		return true
 	default:
 		return false
@@ -97,11 +116,13 @@ func (m ModuleType) String() string {
 }
 
 type DGateModule struct {
-	Name      string          `json:"name"`
-	Namespace *DGateNamespace `json:"namespace"`
-	Payload   string          `json:"payload"`
-	Type      ModuleType      `json:"module_type"`
-	Tags      []string        `json:"tags,omitempty"`
+	Name        string          `json:"name"`
+	Namespace   *DGateNamespace `json:"namespace"`
+	Payload     string          `json:"payload"`
+	Type        ModuleType      `json:"module_type"`
+	Tags        []string        `json:"tags,omitempty"`
+	Permissions []string        `json:"permissions"`
+	Limits      *ModuleLimits   `json:"limits,omitempty"`
 }
 
 func (m *DGateModule) GetName() string {
@@ -0,0 +1,87 @@
+package spec
+
+import (
+	"errors"
+	"strconv"
+)
+
+type LoadBalancerStrategy string
+
+const (
+	LoadBalancerRoundRobin       LoadBalancerStrategy = "round_robin"
+	LoadBalancerRandom           LoadBalancerStrategy = "random"
+	LoadBalancerWeighted         LoadBalancerStrategy = "weighted"
+	LoadBalancerLeastConnections LoadBalancerStrategy = "least_connections"
+	LoadBalancerConsistentHash   LoadBalancerStrategy = "consistent_hash"
+)
+
+func (s LoadBalancerStrategy) Valid() bool {
+	switch s {
+	case LoadBalancerRoundRobin, LoadBalancerRandom,
+		LoadBalancerWeighted, LoadBalancerLeastConnections,
+		LoadBalancerConsistentHash:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s LoadBalancerStrategy) String() string {
+	return string(s)
+}
+
+type HashOn string
+
+const (
+	HashOnHeader   HashOn = "header"
+	HashOnCookie   HashOn = "cookie"
+	HashOnClientIP HashOn = "ip"
+)
+
+type LoadBalancer struct {
+	Strategy LoadBalancerStrategy `json:"strategy" koanf:"strategy"`
+	// Weights is used by the weighted strategy, each weight
+	// is matched to the service url at the same index.
+	Weights []int `json:"weights,omitempty" koanf:"weights"`
+	// HashOn and HashKey are used by the consistent_hash strategy,
+	// HashKey is the name of the header or cookie to hash on.
+	HashOn  HashOn `json:"hashOn,omitempty" koanf:"hashOn"`
+	HashKey string `json:"hashKey,omitempty" koanf:"hashKey"`
+}
+
+// Validate checks the load balancer configuration against the number of urls of the service.
+func (lb *LoadBalancer) Validate(numUrls int) error {
+	if lb == nil {
+		return nil
+	}
+	if !lb.Strategy.Valid() {
+		return errors.New("invalid load balancer strategy: " + lb.Strategy.String())
+	}
+	switch lb.Strategy {
+	case LoadBalancerWeighted:
+		if len(lb.Weights) != numUrls {
+			return errors.New("load balancer weights must match the number of urls (" + strconv.Itoa(numUrls) + ")")
+		}
+		total := 0
+		for _, w := range lb.Weights {
+			if w < 0 {
+				return errors.New("load balancer weights cannot be negative")
+			}
+			total += w
+		}
+		if total == 0 {
+			return errors.New("load balancer weights must have at least one positive weight")
+		}
+	case LoadBalancerConsistentHash:
+		switch lb.HashOn {
+		case HashOnClientIP:
+		case HashOnHeader, HashOnCookie:
+			if lb.HashKey == "" {
+				return errors.New("load balancer hashKey is required when hashing on " + string(lb.HashOn))
+			}
+		default:
+			return errors.New("invalid load balancer hashOn: " + string(lb.HashOn))
+		}
+	}
+	return nil
+}
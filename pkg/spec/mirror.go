@@ -0,0 +1,78 @@
+package spec
+
+import (
+	"errors"
+	"time"
+)
+
+// Mirror sends a copy of the requests of a route to another service,
+// the responses of the mirror service are discarded.
+type Mirror struct {
+	ServiceName string `json:"service" koanf:"service"`
+	// Percent is the percentage of requests that are mirrored, defaults to 100.
+	// A percent of 0 mirrors no requests.
+	Percent *float64 `json:"percent,omitempty" koanf:"percent"`
+	// MaxBodySize is the largest request body that is mirrored,
+	// requests with larger bodies are not mirrored.
+	MaxBodySize int64 `json:"maxBodySize,omitempty" koanf:"maxBodySize"`
+	// Timeout is the max duration of a mirrored request, defaults to 10s
+	Timeout time.Duration `json:"timeout,omitempty" koanf:"timeout"`
+	// MaxConcurrent is the max number of mirrored requests in flight, requests
+	// are not mirrored while the limit is reached. Defaults to 100.
+	MaxConcurrent int `json:"maxConcurrent,omitempty" koanf:"maxConcurrent"`
+}
+
+type DGateMirror struct {
+	Service       *DGateService `json:"service"`
+	Percent       *float64      `json:"percent,omitempty"`
+	MaxBodySize   int64         `json:"maxBodySize,omitempty"`
+	Timeout       time.Duration `json:"timeout,omitempty"`
+	MaxConcurrent int           `json:"maxConcurrent,omitempty"`
+}
+
+const (
+	DefaultMirrorPercent       = 100
+	DefaultMirrorMaxBodySize   = 64 * 1024
+	DefaultMirrorTimeout       = 10 * time.Second
+	DefaultMirrorMaxConcurrent = 100
+)
+
+// WithDefaults returns a copy of the mirror with all unset values defaulted.
+func (m DGateMirror) WithDefaults() DGateMirror {
+	if m.Percent == nil {
+		percent := float64(DefaultMirrorPercent)
+		m.Percent = &percent
+	}
+	if m.MaxBodySize <= 0 {
+		m.MaxBodySize = DefaultMirrorMaxBodySize
+	}
+	if m.Timeout <= 0 {
+		m.Timeout = DefaultMirrorTimeout
+	}
+	if m.MaxConcurrent <= 0 {
+		m.MaxConcurrent = DefaultMirrorMaxConcurrent
+	}
+	return m
+}
+
+func (m *Mirror) Validate() error {
+	if m == nil {
+		return nil
+	}
+	if m.ServiceName == "" {
+		return errors.New("mirror service name is required")
+	}
+	if m.Percent != nil && (*m.Percent < 0 || *m.Percent > 100) {
+		return errors.New("mirror percent must be between 0 and 100")
+	}
+	if m.MaxBodySize < 0 {
+		return errors.New("mirror max body size cannot be negative")
+	}
+	if m.Timeout < 0 {
+		return errors.New("mirror timeout cannot be negative")
+	}
+	if m.MaxConcurrent < 0 {
+		return errors.New("mirror max concurrent cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package spec
+
+import "time"
+
+// ModuleJobs are the jobs exported by a module, Error
+// is set when the jobs of the module could not be loaded.
+type ModuleJobs struct {
+	Module    string            `json:"module"`
+	Namespace string            `json:"namespace"`
+	Error     string            `json:"error,omitempty"`
+	Jobs      []ModuleJobStatus `json:"jobs"`
+}
+
+// ModuleJobStatus is the state of a job, the most recent runs are first.
+type ModuleJobStatus struct {
+	Name      string         `json:"name"`
+	Schedule  string         `json:"schedule"`
+	Running   bool           `json:"running"`
+	LastRun   time.Time      `json:"lastRun,omitempty"`
+	LastError string         `json:"lastError,omitempty"`
+	Runs      []ModuleJobRun `json:"runs"`
+}
+
+// ModuleJobRun is a run of a job, skipped runs are not recorded.
+type ModuleJobRun struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
@@ -0,0 +1,31 @@
+package spec
+
+import (
+	"errors"
+	"time"
+)
+
+// ModuleLimits limit the execution of the functions of a module,
+// the limits that are not set use the defaults of the proxy.
+type ModuleLimits struct {
+	// CPUTime is the max time a function can run synchronously
+	CPUTime *time.Duration `json:"cpuTime,omitempty" koanf:"cpuTime"`
+	// WallTime is the max time of a call, including the promises it awaits
+	WallTime *time.Duration `json:"wallTime,omitempty" koanf:"wallTime"`
+	// MaxAllocations is the max bytes of memory of wasm modules, javascript modules are not limited
+	MaxAllocations *uint64 `json:"maxAllocations,omitempty" koanf:"maxAllocations"`
+}
+
+// Validate checks that the limits are not negative, nil limits are valid.
+func (l *ModuleLimits) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.CPUTime != nil && *l.CPUTime < 0 {
+		return errors.New("module limits: cpuTime cannot be negative")
+	}
+	if l.WallTime != nil && *l.WallTime < 0 {
+		return errors.New("module limits: wallTime cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	PermissionDocumentRead    = "resource:document:read"
+	PermissionDocumentWrite   = "resource:document:write"
+	PermissionCollectionRead  = "resource:collection:read"
+	PermissionCollectionWrite = "resource:collection:write"
+	PermissionCacheRead       = "resource:cache:read"
+	PermissionCacheWrite      = "resource:cache:write"
+	// PermissionSecretRead can be scoped to a secret, e.g. resource:secret:read:api-key
+	PermissionSecretRead = "resource:secret:read"
+	// PermissionNetHTTP can be scoped to a host, e.g. os:net:http:api.example.com
+	PermissionNetHTTP = "os:net:http"
+	// PermissionEnvRead can be scoped to a variable, e.g. os:env:read:API_KEY
+	PermissionEnvRead = "os:env:read"
+)
+
+var modulePermissions = []string{
+	PermissionDocumentRead,
+	PermissionDocumentWrite,
+	PermissionCollectionRead,
+	PermissionCollectionWrite,
+	PermissionCacheRead,
+	PermissionCacheWrite,
+	PermissionSecretRead,
+	PermissionNetHTTP,
+	PermissionEnvRead,
+}
+
+// scopedPermissions can have a target after the permission
+var scopedPermissions = []string{
+	PermissionSecretRead,
+	PermissionNetHTTP,
+	PermissionEnvRead,
+}
+
+// ValidateModulePermissions checks that each permission is known, a segment of
+// a permission can be * to match any value, e.g. resource:*:read.
+func ValidateModulePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !validModulePermission(permission) {
+			return errors.New("invalid module permission: " + permission)
+		}
+	}
+	return nil
+}
+
+func validModulePermission(permission string) bool {
+	if permission == "" {
+		return false
+	}
+	segments := strings.Split(permission, ":")
+	for _, known := range modulePermissions {
+		knownSegments := strings.Split(known, ":")
+		if len(segments) > len(knownSegments) {
+			// only scoped permissions can have a target
+			continue
+		}
+		if segmentsMatch(segments, knownSegments) {
+			return true
+		}
+	}
+	for _, scoped := range scopedPermissions {
+		if strings.HasPrefix(permission, scoped+":") &&
+			len(permission) > len(scoped)+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ModulePermissionGranted returns true if the permission is granted by one of the permissions
+// of a module. A permission grants the permissions it is a prefix of, os:net:http grants
+// os:net:http:example.com. Modules without permissions (nil) are granted every permission.
+func ModulePermissionGranted(granted []string, permission string) bool {
+	if granted == nil {
+		return true
+	}
+	required := strings.Split(permission, ":")
+	for _, grant := range granted {
+		if segmentsMatch(strings.Split(grant, ":"), required) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentsMatch returns true if the pattern segments are a prefix of the segments
+func segmentsMatch(pattern, segments []string) bool {
+	if len(pattern) > len(segments) {
+		return false
+	}
+	for i, seg := range pattern {
+		if seg != "*" && seg != segments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package spec
+
+import (
+	"errors"
+	"time"
+)
+
+// OutlierDetection ejects service urls that keep failing, failures are
+// connection errors and 5xx responses from the upstream.
+type OutlierDetection struct {
+	// ConsecutiveFailures is the number of failures in a row before a url is ejected
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty" koanf:"consecutiveFailures"`
+	// ErrorRate is the percentage (0-100) of failed requests in the interval before a url is ejected,
+	// the error rate is not checked when it is not set.
+	ErrorRate float64 `json:"errorRate,omitempty" koanf:"errorRate"`
+	// MinRequests is the number of requests needed in the interval before the error rate is checked
+	MinRequests int           `json:"minRequests,omitempty" koanf:"minRequests"`
+	Interval    time.Duration `json:"interval,omitempty" koanf:"interval"`
+	// EjectionDuration is how long a url is ejected for, it grows with each consecutive ejection
+	EjectionDuration    time.Duration `json:"ejectionDuration,omitempty" koanf:"ejectionDuration"`
+	MaxEjectionDuration time.Duration `json:"maxEjectionDuration,omitempty" koanf:"maxEjectionDuration"`
+	// MaxEjectionPercent is the percentage of urls that can be ejected at the same time
+	MaxEjectionPercent int `json:"maxEjectionPercent,omitempty" koanf:"maxEjectionPercent"`
+}
+
+const (
+	DefaultOutlierConsecutiveFailures = 5
+	DefaultOutlierMinRequests         = 10
+	DefaultOutlierInterval            = 10 * time.Second
+	DefaultOutlierEjectionDuration    = 30 * time.Second
+	DefaultOutlierMaxEjectionDuration = 5 * time.Minute
+	DefaultOutlierMaxEjectionPercent  = 50
+)
+
+// WithDefaults returns a copy of the outlier detection with all unset values defaulted.
+func (od OutlierDetection) WithDefaults() OutlierDetection {
+	if od.ConsecutiveFailures <= 0 {
+		od.ConsecutiveFailures = DefaultOutlierConsecutiveFailures
+	}
+	if od.MinRequests <= 0 {
+		od.MinRequests = DefaultOutlierMinRequests
+	}
+	if od.Interval <= 0 {
+		od.Interval = DefaultOutlierInterval
+	}
+	if od.EjectionDuration <= 0 {
+		od.EjectionDuration = DefaultOutlierEjectionDuration
+	}
+	if od.MaxEjectionDuration <= 0 {
+		od.MaxEjectionDuration = max(DefaultOutlierMaxEjectionDuration, od.EjectionDuration)
+	}
+	if od.MaxEjectionPercent <= 0 {
+		od.MaxEjectionPercent = DefaultOutlierMaxEjectionPercent
+	}
+	return od
+}
+
+func (od *OutlierDetection) Validate() error {
+	if od == nil {
+		return nil
+	}
+	if od.ConsecutiveFailures < 0 || od.MinRequests < 0 {
+		return errors.New("outlier detection thresholds cannot be negative")
+	}
+	if od.ErrorRate < 0 || od.ErrorRate > 100 {
+		return errors.New("outlier detection error rate must be between 0 and 100")
+	}
+	if od.MaxEjectionPercent < 0 || od.MaxEjectionPercent > 100 {
+		return errors.New("outlier detection max ejection percent must be between 0 and 100")
+	}
+	if od.Interval < 0 || od.EjectionDuration < 0 || od.MaxEjectionDuration < 0 {
+		return errors.New("outlier detection durations cannot be negative")
+	}
+	return nil
+}
+
+// CircuitBreaker stops requests from being sent to a service after
+// too many failures, requests fail fast with a 503 while the breaker is open.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of failures in a row that opens the breaker
+	FailureThreshold int `json:"failureThreshold,omitempty" koanf:"failureThreshold"`
+	// OpenDuration is how long the breaker stays open before requests are let through again
+	OpenDuration time.Duration `json:"openDuration,omitempty" koanf:"openDuration"`
+	// HalfOpenRequests is the number of successful trial requests needed to close the breaker
+	HalfOpenRequests int `json:"halfOpenRequests,omitempty" koanf:"halfOpenRequests"`
+}
+
+const (
+	DefaultCircuitBreakerFailureThreshold = 5
+	DefaultCircuitBreakerOpenDuration     = 30 * time.Second
+	DefaultCircuitBreakerHalfOpenRequests = 1
+)
+
+// WithDefaults returns a copy of the circuit breaker with all unset values defaulted.
+func (cb CircuitBreaker) WithDefaults() CircuitBreaker {
+	if cb.FailureThreshold <= 0 {
+		cb.FailureThreshold = DefaultCircuitBreakerFailureThreshold
+	}
+	if cb.OpenDuration <= 0 {
+		cb.OpenDuration = DefaultCircuitBreakerOpenDuration
+	}
+	if cb.HalfOpenRequests <= 0 {
+		cb.HalfOpenRequests = DefaultCircuitBreakerHalfOpenRequests
+	}
+	return cb
+}
+
+func (cb *CircuitBreaker) Validate() error {
+	if cb == nil {
+		return nil
+	}
+	if cb.FailureThreshold < 0 || cb.HalfOpenRequests < 0 {
+		return errors.New("circuit breaker thresholds cannot be negative")
+	}
+	if cb.OpenDuration < 0 {
+		return errors.New("circuit breaker open duration cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package spec
+
+import (
+	"errors"
+	"time"
+)
+
+type RateLimitAlgorithm string
+
+const (
+	RateLimitTokenBucket   RateLimitAlgorithm = "token-bucket"
+	RateLimitSlidingWindow RateLimitAlgorithm = "sliding-window"
+)
+
+type RateLimitKey string
+
+const (
+	// RateLimitKeyIP limits each client ip, the ip is taken from the
+	// X-Forwarded-For header when the proxy is behind trusted proxies.
+	RateLimitKeyIP RateLimitKey = "ip"
+	// RateLimitKeyHeader limits each value of a request header
+	RateLimitKeyHeader RateLimitKey = "header"
+	// RateLimitKeyConsumer limits each consumer, the consumer is set by the
+	// modules that authenticate the request with ctx.setConsumer.
+	RateLimitKeyConsumer RateLimitKey = "consumer"
+)
+
+// RateLimit limits the number of requests a client can make to a route or namespace.
+type RateLimit struct {
+	// Algorithm is token-bucket or sliding-window, defaults to token-bucket
+	Algorithm RateLimitAlgorithm `json:"algorithm,omitempty" koanf:"algorithm"`
+	// Limit is the number of requests allowed in each window
+	Limit int `json:"limit" koanf:"limit"`
+	// Window is the time period of the limit, defaults to 1 minute
+	Window time.Duration `json:"window,omitempty" koanf:"window"`
+	// Burst is the max number of tokens of the token bucket, defaults to the limit
+	Burst int `json:"burst,omitempty" koanf:"burst"`
+	// KeyBy is how clients are identified: ip, header or consumer, defaults to ip
+	KeyBy RateLimitKey `json:"keyBy,omitempty" koanf:"keyBy"`
+	// Header is the request header used for the header key
+	Header string `json:"header,omitempty" koanf:"header"`
+	// Cluster shares the request counts between the nodes of the cluster
+	Cluster bool `json:"cluster,omitempty" koanf:"cluster"`
+}
+
+const DefaultRateLimitWindow = time.Minute
+
+// WithDefaults returns a copy of the rate limit with all unset values defaulted.
+func (rl RateLimit) WithDefaults() RateLimit {
+	if rl.Algorithm == "" {
+		rl.Algorithm = RateLimitTokenBucket
+	}
+	if rl.Window <= 0 {
+		rl.Window = DefaultRateLimitWindow
+	}
+	if rl.Burst <= 0 {
+		rl.Burst = rl.Limit
+	}
+	if rl.KeyBy == "" {
+		rl.KeyBy = RateLimitKeyIP
+	}
+	return rl
+}
+
+func (rl *RateLimit) Validate() error {
+	if rl == nil {
+		return nil
+	}
+	switch rl.Algorithm {
+	case "", RateLimitTokenBucket, RateLimitSlidingWindow:
+	default:
+		return errors.New("invalid rate limit algorithm: " + string(rl.Algorithm))
+	}
+	if rl.Limit <= 0 {
+		return errors.New("rate limit must be greater than 0")
+	}
+	if rl.Window < 0 {
+		return errors.New("rate limit window cannot be negative")
+	}
+	if rl.Burst < 0 {
+		return errors.New("rate limit burst cannot be negative")
+	}
+	switch rl.KeyBy {
+	case "", RateLimitKeyIP, RateLimitKeyConsumer:
+	case RateLimitKeyHeader:
+		if rl.Header == "" {
+			return errors.New("rate limit header is required when keyed by header")
+		}
+	default:
+		return errors.New("invalid rate limit key: " + string(rl.KeyBy))
+	}
+	return nil
+}
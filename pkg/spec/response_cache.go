@@ -0,0 +1,131 @@
+package spec
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ResponseCache caches upstream responses of a route, following the
+// Cache-Control, Expires and Vary headers of the upstream responses.
+type ResponseCache struct {
+	// TTL overrides the freshness lifetime set by the upstream cache headers
+	TTL time.Duration `json:"ttl,omitempty" koanf:"ttl"`
+	// Key is the template for the cache key, it can use {method}, {host}, {path}, {query},
+	// {query.name}, {header.name} and {cookie.name}. Defaults to {method}:{host}{path}?{query}
+	Key string `json:"key,omitempty" koanf:"key"`
+	// MaxSize is the max total size in bytes of the cached responses of the route
+	MaxSize int64 `json:"maxSize,omitempty" koanf:"maxSize"`
+	// MaxEntrySize is the largest response body that is cached
+	MaxEntrySize int64 `json:"maxEntrySize,omitempty" koanf:"maxEntrySize"`
+	// Methods are the request methods that are cached, defaults to GET and HEAD.
+	// HEAD responses are not stored, HEAD requests use the cached GET response.
+	Methods []string `json:"methods,omitempty" koanf:"methods"`
+	// StaleWhileRevalidate serves stale responses while they are revalidated in
+	// the background, it is used when the upstream response does not set it.
+	StaleWhileRevalidate time.Duration `json:"staleWhileRevalidate,omitempty" koanf:"staleWhileRevalidate"`
+	// StaleIfError serves stale responses when the upstream fails, it
+	// is used when the upstream response does not set it.
+	StaleIfError time.Duration `json:"staleIfError,omitempty" koanf:"staleIfError"`
+	// Tags are added to every cached response of the route, responses
+	// can also be tagged by the upstream with the Cache-Tag header.
+	Tags []string `json:"tags,omitempty" koanf:"tags"`
+}
+
+const (
+	DefaultResponseCacheKey          = "{method}:{host}{path}?{query}"
+	DefaultResponseCacheMaxSize      = 64 * 1024 * 1024
+	DefaultResponseCacheMaxEntrySize = 1024 * 1024
+)
+
+// WithDefaults returns a copy of the response cache with all unset values defaulted.
+func (rc ResponseCache) WithDefaults() ResponseCache {
+	if rc.Key == "" {
+		rc.Key = DefaultResponseCacheKey
+	}
+	if rc.MaxSize <= 0 {
+		rc.MaxSize = DefaultResponseCacheMaxSize
+	}
+	if rc.MaxEntrySize <= 0 {
+		rc.MaxEntrySize = DefaultResponseCacheMaxEntrySize
+	}
+	if len(rc.Methods) == 0 {
+		rc.Methods = []string{"GET", "HEAD"}
+	}
+	return rc
+}
+
+func (rc *ResponseCache) Validate() error {
+	if rc == nil {
+		return nil
+	}
+	if rc.TTL < 0 || rc.StaleWhileRevalidate < 0 || rc.StaleIfError < 0 {
+		return errors.New("response cache durations cannot be negative")
+	}
+	if rc.MaxSize < 0 || rc.MaxEntrySize < 0 {
+		return errors.New("response cache sizes cannot be negative")
+	}
+	if rc.MaxSize > 0 && rc.MaxEntrySize > rc.MaxSize {
+		return errors.New("response cache max entry size cannot be larger than the max size")
+	}
+	if _, err := ParseResponseCacheKey(rc.Key); err != nil {
+		return err
+	}
+	for _, method := range rc.Methods {
+		if method != "GET" && method != "HEAD" {
+			return errors.New("response cache only supports GET and HEAD methods: " + method)
+		}
+	}
+	return nil
+}
+
+// ResponseCacheKeyPart is a literal or a placeholder of a cache key template
+type ResponseCacheKeyPart struct {
+	Literal string
+	// Source is the placeholder type (method, host, path, query,
+	// header or cookie), it is empty for literals.
+	Source string
+	// Name is the name of the query param, header or cookie
+	Name string
+}
+
+// ParseResponseCacheKey parses the cache key template into literals and placeholders
+func ParseResponseCacheKey(tmpl string) ([]ResponseCacheKeyPart, error) {
+	parts := []ResponseCacheKeyPart{}
+	for len(tmpl) > 0 {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			start = len(tmpl)
+		}
+		if strings.IndexByte(tmpl[:start], '}') >= 0 {
+			return nil, errors.New("response cache key has unbalanced braces: " + tmpl)
+		}
+		if start > 0 {
+			parts = append(parts, ResponseCacheKeyPart{Literal: tmpl[:start]})
+		}
+		if start == len(tmpl) {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return nil, errors.New("response cache key has unbalanced braces: " + tmpl)
+		}
+		placeholder := tmpl[start+1 : start+end]
+		source, name, _ := strings.Cut(placeholder, ".")
+		valid := false
+		switch source {
+		case "method", "host", "path":
+			valid = name == ""
+		case "query":
+			valid = true
+		case "header", "cookie":
+			valid = name != ""
+		}
+		if !valid {
+			return nil, errors.New("invalid response cache key placeholder: {" + placeholder + "}")
+		}
+		parts = append(parts, ResponseCacheKeyPart{Source: source, Name: name})
+		tmpl = tmpl[start+end+1:]
+	}
+	return parts, nil
+}
@@ -20,6 +20,7 @@ type rwTracker struct {
 }
 
 var _ http.Hijacker = (*rwTracker)(nil)
+var _ http.Flusher = (*rwTracker)(nil)
 var _ ResponseWriterTracker = (*rwTracker)(nil)
 
 func NewResponseWriterTracker(rw http.ResponseWriter) ResponseWriterTracker {
@@ -72,3 +73,17 @@ func (t *rwTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	}
 	return hijacker.Hijack()
 }
+
+// Flush sends the buffered data to the client, so streamed
+// responses (e.g. server-sent events) are not held back.
+func (t *rwTracker) Flush() {
+	if !t.HeadersSent() {
+		t.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(t.rw).Flush()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (t *rwTracker) Unwrap() http.ResponseWriter {
+	return t.rw
+}
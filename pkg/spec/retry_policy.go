@@ -0,0 +1,170 @@
+package spec
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type RetryCondition string
+
+const (
+	// RetryOnConnectError retries requests that failed to connect to the upstream or timed out
+	RetryOnConnectError RetryCondition = "connect_error"
+	// RetryOnStatus retries requests when the upstream responds with one of the retry status codes
+	RetryOnStatus RetryCondition = "status"
+)
+
+func (rc RetryCondition) Valid() bool {
+	switch rc {
+	case RetryOnConnectError, RetryOnStatus:
+		return true
+	}
+	return false
+}
+
+type RetryPolicy struct {
+	// Attempts is the max number of retries after the first attempt
+	Attempts int `json:"attempts,omitempty" koanf:"attempts"`
+	// RetryOn is the list of conditions to retry on, defaults to connect errors
+	RetryOn []RetryCondition `json:"retryOn,omitempty" koanf:"retryOn"`
+	// StatusCodes are the upstream status codes that are retried when retrying on status
+	StatusCodes []int `json:"statusCodes,omitempty" koanf:"statusCodes"`
+	// Methods are the request methods that can be retried, defaults to idempotent methods
+	Methods []string `json:"methods,omitempty" koanf:"methods"`
+	// Backoff is the wait time before the first retry, it doubles for each retry after
+	Backoff       time.Duration `json:"backoff,omitempty" koanf:"backoff"`
+	PerTryTimeout time.Duration `json:"perTryTimeout,omitempty" koanf:"perTryTimeout"`
+	// MaxBodySize is the largest request body that is buffered for retries,
+	// requests with larger bodies are not retried.
+	MaxBodySize int64        `json:"maxBodySize,omitempty" koanf:"maxBodySize"`
+	Budget      *RetryBudget `json:"budget,omitempty" koanf:"budget"`
+}
+
+// RetryBudget limits the number of retries sent to a service,
+// so retries can not overload a service that is already failing.
+type RetryBudget struct {
+	// Percent is the percentage of requests in the window that can be retries
+	Percent float64 `json:"percent,omitempty" koanf:"percent"`
+	// MinRetries is the number of retries always allowed in the window, for services with little traffic
+	MinRetries int           `json:"minRetries,omitempty" koanf:"minRetries"`
+	Window     time.Duration `json:"window,omitempty" koanf:"window"`
+}
+
+const (
+	DefaultRetryAttempts         = 1
+	DefaultRetryMaxBodySize      = 64 * 1024
+	DefaultRetryBudgetPercent    = 20
+	DefaultRetryBudgetMinRetries = 10
+	DefaultRetryBudgetWindow     = 10 * time.Second
+)
+
+var (
+	DefaultRetryStatusCodes = []int{
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+	// IdempotentMethods are the methods retried by default
+	IdempotentMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodTrace,
+		http.MethodPut,
+		http.MethodDelete,
+	}
+)
+
+// WithDefaults returns a copy of the retry policy with all unset values defaulted.
+func (rp RetryPolicy) WithDefaults() RetryPolicy {
+	if rp.Attempts <= 0 {
+		rp.Attempts = DefaultRetryAttempts
+	}
+	if len(rp.RetryOn) == 0 {
+		rp.RetryOn = []RetryCondition{RetryOnConnectError}
+	}
+	if len(rp.StatusCodes) == 0 {
+		rp.StatusCodes = DefaultRetryStatusCodes
+	}
+	if len(rp.Methods) == 0 {
+		rp.Methods = IdempotentMethods
+	}
+	if rp.MaxBodySize <= 0 {
+		rp.MaxBodySize = DefaultRetryMaxBodySize
+	}
+	budget := RetryBudget{}
+	if rp.Budget != nil {
+		budget = *rp.Budget
+	}
+	budget = budget.WithDefaults()
+	rp.Budget = &budget
+	return rp
+}
+
+// RetriesOn checks if the retry policy retries on the condition.
+func (rp *RetryPolicy) RetriesOn(cond RetryCondition) bool {
+	return slices.Contains(rp.RetryOn, cond)
+}
+
+// RetriesMethod checks if requests with the method can be retried.
+func (rp *RetryPolicy) RetriesMethod(method string) bool {
+	return slices.ContainsFunc(rp.Methods, func(m string) bool {
+		return strings.EqualFold(m, method)
+	})
+}
+
+func (rp *RetryPolicy) Validate() error {
+	if rp == nil {
+		return nil
+	}
+	if rp.Attempts < 0 {
+		return errors.New("retry policy attempts cannot be negative")
+	}
+	for _, cond := range rp.RetryOn {
+		if !cond.Valid() {
+			return errors.New("retry policy has invalid retry condition: " + string(cond))
+		}
+	}
+	for _, status := range rp.StatusCodes {
+		if status < 100 || status > 599 {
+			return errors.New("retry policy status codes must be valid status codes")
+		}
+	}
+	if rp.Backoff < 0 || rp.PerTryTimeout < 0 {
+		return errors.New("retry policy durations cannot be negative")
+	}
+	if rp.MaxBodySize < 0 {
+		return errors.New("retry policy max body size cannot be negative")
+	}
+	return rp.Budget.Validate()
+}
+
+// WithDefaults returns a copy of the retry budget with all unset values defaulted.
+func (rb RetryBudget) WithDefaults() RetryBudget {
+	if rb.Percent <= 0 {
+		rb.Percent = DefaultRetryBudgetPercent
+	}
+	if rb.MinRetries <= 0 {
+		rb.MinRetries = DefaultRetryBudgetMinRetries
+	}
+	if rb.Window <= 0 {
+		rb.Window = DefaultRetryBudgetWindow
+	}
+	return rb
+}
+
+func (rb *RetryBudget) Validate() error {
+	if rb == nil {
+		return nil
+	}
+	if rb.Percent < 0 || rb.Percent > 100 {
+		return errors.New("retry budget percent must be between 0 and 100")
+	}
+	if rb.MinRetries < 0 || rb.Window < 0 {
+		return errors.New("retry budget values cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package spec
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// RewriteRule rewrites the upstream path, query and host of requests.
+// Templates can use path parameters as {name}, query parameters
+// as {query.name} and capture groups of the regex as {1} or {name}.
+type RewriteRule struct {
+	// Regex is matched against the request path, the rule
+	// only applies when it matches. Rules without a regex always apply.
+	Regex string `json:"regex,omitempty" koanf:"regex"`
+	// Path is the template for the upstream path, it can have a query, e.g. /user?id={id}.
+	// The dot segments (. and ..) of the values used in the path are removed.
+	Path string `json:"path,omitempty" koanf:"path"`
+	// Host is the template for the upstream host header
+	Host string `json:"host,omitempty" koanf:"host"`
+}
+
+func (rr *RewriteRule) Validate() error {
+	if rr.Path == "" && rr.Host == "" {
+		return errors.New("rewrite rule must have a path or a host")
+	}
+	if rr.Path != "" && !strings.HasPrefix(rr.Path, "/") {
+		return errors.New("rewrite rule path must start with /")
+	}
+	if rr.Regex != "" {
+		if _, err := regexp.Compile(rr.Regex); err != nil {
+			return errors.New("rewrite rule has invalid regex: " + err.Error())
+		}
+	}
+	for _, tpl := range []string{rr.Path, rr.Host} {
+		if _, err := ParseRewriteTemplate(tpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateRewriteRules validates the rewrite rules of a route.
+func ValidateRewriteRules(rules []RewriteRule) error {
+	for _, rr := range rules {
+		if err := rr.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RewriteTemplatePart is a literal or a variable of a rewrite template
+type RewriteTemplatePart struct {
+	// Value is the literal text, or the name of the variable
+	Value    string
+	Variable bool
+}
+
+// ParseRewriteTemplate splits the template into literals and variables ({name})
+func ParseRewriteTemplate(tpl string) ([]RewriteTemplatePart, error) {
+	parts := []RewriteTemplatePart{}
+	for rest := tpl; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			start = len(rest)
+		}
+		if strings.IndexByte(rest[:start], '}') >= 0 {
+			return nil, errors.New("rewrite rule template has unbalanced braces: " + tpl)
+		}
+		if start > 0 {
+			parts = append(parts, RewriteTemplatePart{Value: rest[:start]})
+		}
+		if start == len(rest) {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 || strings.IndexByte(rest[start+1:start+end], '{') >= 0 {
+			return nil, errors.New("rewrite rule template has unbalanced braces: " + tpl)
+		}
+		name := rest[start+1 : start+end]
+		if name == "" {
+			return nil, errors.New("rewrite rule template has an empty variable: " + tpl)
+		}
+		parts = append(parts, RewriteTemplatePart{Value: name, Variable: true})
+		rest = rest[start+end+1:]
+	}
+	return parts, nil
+}
@@ -0,0 +1,59 @@
+package spec
+
+import (
+	"errors"
+	"regexp"
+)
+
+// RouteMatch has extra conditions a request must match to be
+// handled by the route, on top of the route paths and methods.
+type RouteMatch struct {
+	Headers []ValueMatch `json:"headers,omitempty" koanf:"headers"`
+	Query   []ValueMatch `json:"query,omitempty" koanf:"query"`
+	Cookies []ValueMatch `json:"cookies,omitempty" koanf:"cookies"`
+}
+
+// ValueMatch matches the value of a header, query parameter or cookie.
+// Only one of exact, prefix or regex can be set, when none
+// are set the value only has to be present.
+type ValueMatch struct {
+	Name   string `json:"name" koanf:"name"`
+	Exact  string `json:"exact,omitempty" koanf:"exact"`
+	Prefix string `json:"prefix,omitempty" koanf:"prefix"`
+	Regex  string `json:"regex,omitempty" koanf:"regex"`
+}
+
+func (rm *RouteMatch) Validate() error {
+	if rm == nil {
+		return nil
+	}
+	for _, group := range [][]ValueMatch{rm.Headers, rm.Query, rm.Cookies} {
+		for _, vm := range group {
+			if err := vm.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (vm *ValueMatch) Validate() error {
+	if vm.Name == "" {
+		return errors.New("route match name is required")
+	}
+	set := 0
+	for _, v := range []string{vm.Exact, vm.Prefix, vm.Regex} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.New("route match (" + vm.Name + ") can only have one of exact, prefix or regex")
+	}
+	if vm.Regex != "" {
+		if _, err := regexp.Compile(vm.Regex); err != nil {
+			return errors.New("route match (" + vm.Name + ") has invalid regex: " + err.Error())
+		}
+	}
+	return nil
+}
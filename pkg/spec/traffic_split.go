@@ -0,0 +1,91 @@
+package spec
+
+import (
+	"errors"
+	"strconv"
+)
+
+// TrafficSplit sends the requests of a route to several services by weight.
+type TrafficSplit struct {
+	Services []WeightedService `json:"services" koanf:"services"`
+	// Sticky keeps requests with the same cookie or header value on the same service
+	Sticky *StickySession `json:"sticky,omitempty" koanf:"sticky"`
+	// Overrides send requests with a matching header to a service, ignoring the weights
+	Overrides []SplitOverride `json:"overrides,omitempty" koanf:"overrides"`
+}
+
+type WeightedService struct {
+	ServiceName string `json:"service" koanf:"service"`
+	Weight      int    `json:"weight" koanf:"weight"`
+}
+
+type StickySession struct {
+	Cookie string `json:"cookie,omitempty" koanf:"cookie"`
+	Header string `json:"header,omitempty" koanf:"header"`
+}
+
+type SplitOverride struct {
+	Header string `json:"header" koanf:"header"`
+	// Value is the header value to match, any value matches when empty
+	Value       string `json:"value,omitempty" koanf:"value"`
+	ServiceName string `json:"service" koanf:"service"`
+}
+
+type DGateTrafficSplit struct {
+	Services  []*DGateWeightedService `json:"services"`
+	Sticky    *StickySession          `json:"sticky,omitempty"`
+	Overrides []SplitOverride         `json:"overrides,omitempty"`
+}
+
+type DGateWeightedService struct {
+	Service *DGateService `json:"service"`
+	Weight  int           `json:"weight"`
+}
+
+// ServiceNames returns the names of the services in the split, in order.
+func (ts *TrafficSplit) ServiceNames() []string {
+	names := make([]string, len(ts.Services))
+	for i, ws := range ts.Services {
+		names[i] = ws.ServiceName
+	}
+	return names
+}
+
+func (ts *TrafficSplit) Validate() error {
+	if ts == nil {
+		return nil
+	}
+	if len(ts.Services) == 0 {
+		return errors.New("traffic split must have at least one service")
+	}
+	total := 0
+	names := make(map[string]struct{}, len(ts.Services))
+	for _, ws := range ts.Services {
+		if ws.ServiceName == "" {
+			return errors.New("traffic split service name is required")
+		}
+		if _, ok := names[ws.ServiceName]; ok {
+			return errors.New("traffic split has duplicate service: " + ws.ServiceName)
+		}
+		names[ws.ServiceName] = struct{}{}
+		if ws.Weight < 0 {
+			return errors.New("traffic split weight cannot be negative: " + strconv.Itoa(ws.Weight))
+		}
+		total += ws.Weight
+	}
+	if total == 0 {
+		return errors.New("traffic split weights must add up to more than 0")
+	}
+	if ts.Sticky != nil && (ts.Sticky.Cookie == "") == (ts.Sticky.Header == "") {
+		return errors.New("traffic split sticky session must have either a cookie or a header")
+	}
+	for _, o := range ts.Overrides {
+		if o.Header == "" {
+			return errors.New("traffic split override header is required")
+		}
+		if _, ok := names[o.ServiceName]; !ok {
+			return errors.New("traffic split override references service not in the split: " + o.ServiceName)
+		}
+	}
+	return nil
+}
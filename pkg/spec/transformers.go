@@ -30,15 +30,49 @@ func TransformDGateRoute(r *DGateRoute) *Route {
 		modules = sliceutil.SliceMapper(r.Modules, func(m *DGateModule) string { return m.Name })
 	}
 	return &Route{
-		Name:          r.Name,
-		Paths:         r.Paths,
-		Methods:       r.Methods,
-		PreserveHost:  r.PreserveHost,
-		StripPath:     r.StripPath,
-		ServiceName:   svcName,
-		NamespaceName: r.Namespace.Name,
-		Modules:       modules,
-		Tags:          r.Tags,
+		Name:             r.Name,
+		Paths:            r.Paths,
+		Methods:          r.Methods,
+		PreserveHost:     r.PreserveHost,
+		StripPath:        r.StripPath,
+		ServiceName:      svcName,
+		NamespaceName:    r.Namespace.Name,
+		Modules:          modules,
+		Tags:             r.Tags,
+		TrafficSplit:     TransformDGateTrafficSplit(r.TrafficSplit),
+		Mirror:           TransformDGateMirror(r.Mirror),
+		Match:            r.Match,
+		Priority:         r.Priority,
+		Rewrite:          r.Rewrite,
+		Cache:            r.Cache,
+		RateLimit:        r.RateLimit,
+		ConcurrencyLimit: r.ConcurrencyLimit,
+	}
+}
+
+func TransformDGateMirror(m *DGateMirror) *Mirror {
+	if m == nil {
+		return nil
+	}
+	return &Mirror{
+		ServiceName:   m.Service.Name,
+		Percent:       m.Percent,
+		MaxBodySize:   m.MaxBodySize,
+		Timeout:       m.Timeout,
+		MaxConcurrent: m.MaxConcurrent,
+	}
+}
+
+func TransformDGateTrafficSplit(ts *DGateTrafficSplit) *TrafficSplit {
+	if ts == nil {
+		return nil
+	}
+	return &TrafficSplit{
+		Services: sliceutil.SliceMapper(ts.Services, func(ws *DGateWeightedService) WeightedService {
+			return WeightedService{ServiceName: ws.Service.Name, Weight: ws.Weight}
+		}),
+		Sticky:    ts.Sticky,
+		Overrides: ts.Overrides,
 	}
 }
 
@@ -60,6 +94,8 @@ func TransformDGateModule(m *DGateModule) *Module {
 		Payload:       payload,
 		NamespaceName: m.Namespace.Name,
 		Tags:          m.Tags,
+		Permissions:   m.Permissions,
+		Limits:        m.Limits,
 	}
 }
 
@@ -81,12 +117,18 @@ func TransformDGateService(s *DGateService) *Service {
 		NamespaceName: s.Namespace.Name,
 		URLs: sliceutil.SliceMapper(s.URLs,
 			func(u *url.URL) string { return u.String() }),
-		Retries:        &s.Retries,
-		HTTP2Only:      &s.HTTP2Only,
-		RetryTimeout:   &s.RetryTimeout,
-		TLSSkipVerify:  &s.TLSSkipVerify,
-		ConnectTimeout: &s.ConnectTimeout,
-		RequestTimeout: &s.RequestTimeout,
+		Retries:          &s.Retries,
+		HTTP2Only:        &s.HTTP2Only,
+		RetryTimeout:     &s.RetryTimeout,
+		TLSSkipVerify:    &s.TLSSkipVerify,
+		ConnectTimeout:   &s.ConnectTimeout,
+		RequestTimeout:   &s.RequestTimeout,
+		LoadBalancer:     s.LoadBalancer,
+		HealthCheck:      s.HealthCheck,
+		OutlierDetection: s.OutlierDetection,
+		CircuitBreaker:   s.CircuitBreaker,
+		RetryPolicy:      s.RetryPolicy,
+		ConcurrencyLimit: s.ConcurrencyLimit,
 	}
 }
 
@@ -100,8 +142,9 @@ func TransformDGateNamespaces(namespaces ...*DGateNamespace) []*Namespace {
 
 func TransformDGateNamespace(ns *DGateNamespace) *Namespace {
 	return &Namespace{
-		Name: ns.Name,
-		Tags: ns.Tags,
+		Name:      ns.Name,
+		Tags:      ns.Tags,
+		RateLimit: ns.RateLimit,
 	}
 }
 func TransformDGateDomains(domains ...*DGateDomain) []*Domain {
@@ -205,15 +248,52 @@ func TransformRoute(r Route) *DGateRoute {
 		svc.Name = r.ServiceName
 	}
 	return &DGateRoute{
-		Name:         r.Name,
-		Paths:        r.Paths,
-		Methods:      r.Methods,
-		PreserveHost: r.PreserveHost,
-		StripPath:    r.StripPath,
-		Service:      svc,
-		Namespace:    &DGateNamespace{Name: r.NamespaceName},
-		Modules:      sliceutil.SliceMapper(r.Modules, func(m string) *DGateModule { return &DGateModule{Name: m} }),
-		Tags:         r.Tags,
+		Name:             r.Name,
+		Paths:            r.Paths,
+		Methods:          r.Methods,
+		PreserveHost:     r.PreserveHost,
+		StripPath:        r.StripPath,
+		Service:          svc,
+		Namespace:        &DGateNamespace{Name: r.NamespaceName},
+		Modules:          sliceutil.SliceMapper(r.Modules, func(m string) *DGateModule { return &DGateModule{Name: m} }),
+		Tags:             r.Tags,
+		TrafficSplit:     TransformTrafficSplit(r.TrafficSplit),
+		Mirror:           TransformMirror(r.Mirror),
+		Match:            r.Match,
+		Priority:         r.Priority,
+		Rewrite:          r.Rewrite,
+		Cache:            r.Cache,
+		RateLimit:        r.RateLimit,
+		ConcurrencyLimit: r.ConcurrencyLimit,
+	}
+}
+
+func TransformMirror(m *Mirror) *DGateMirror {
+	if m == nil {
+		return nil
+	}
+	return &DGateMirror{
+		Service:       &DGateService{Name: m.ServiceName},
+		Percent:       m.Percent,
+		MaxBodySize:   m.MaxBodySize,
+		Timeout:       m.Timeout,
+		MaxConcurrent: m.MaxConcurrent,
+	}
+}
+
+func TransformTrafficSplit(ts *TrafficSplit) *DGateTrafficSplit {
+	if ts == nil {
+		return nil
+	}
+	return &DGateTrafficSplit{
+		Services: sliceutil.SliceMapper(ts.Services, func(ws WeightedService) *DGateWeightedService {
+			return &DGateWeightedService{
+				Service: &DGateService{Name: ws.ServiceName},
+				Weight:  ws.Weight,
+			}
+		}),
+		Sticky:    ts.Sticky,
+		Overrides: ts.Overrides,
 	}
 }
 
@@ -235,11 +315,13 @@ func TransformModule(ns *DGateNamespace, m *Module) (*DGateModule, error) {
 		return nil, err
 	}
 	return &DGateModule{
-		Name:      m.Name,
-		Namespace: ns,
-		Payload:   string(payload),
-		Tags:      m.Tags,
-		Type:      m.Type,
+		Name:        m.Name,
+		Namespace:   ns,
+		Payload:     string(payload),
+		Tags:        m.Tags,
+		Type:        m.Type,
+		Permissions: m.Permissions,
+		Limits:      m.Limits,
 	}, nil
 }
 
@@ -260,12 +342,18 @@ func TransformService(ns *DGateNamespace, s *Service) *DGateService {
 			url, _ := url.Parse(u)
 			return url
 		}),
-		Retries:        or(s.Retries, 3),
-		HTTP2Only:      or(s.HTTP2Only, false),
-		RetryTimeout:   or(s.RetryTimeout, 0),
-		TLSSkipVerify:  or(s.TLSSkipVerify, false),
-		ConnectTimeout: or(s.ConnectTimeout, 0),
-		RequestTimeout: or(s.RequestTimeout, 0),
+		Retries:          or(s.Retries, 3),
+		HTTP2Only:        or(s.HTTP2Only, false),
+		RetryTimeout:     or(s.RetryTimeout, 0),
+		TLSSkipVerify:    or(s.TLSSkipVerify, false),
+		ConnectTimeout:   or(s.ConnectTimeout, 0),
+		RequestTimeout:   or(s.RequestTimeout, 0),
+		LoadBalancer:     s.LoadBalancer,
+		HealthCheck:      s.HealthCheck,
+		OutlierDetection: s.OutlierDetection,
+		CircuitBreaker:   s.CircuitBreaker,
+		RetryPolicy:      s.RetryPolicy,
+		ConcurrencyLimit: s.ConcurrencyLimit,
 	}
 }
 
@@ -279,8 +367,9 @@ func TransformNamespaces(namespaces ...*Namespace) []*DGateNamespace {
 
 func TransformNamespace(ns *Namespace) *DGateNamespace {
 	return &DGateNamespace{
-		Name: ns.Name,
-		Tags: ns.Tags,
+		Name:      ns.Name,
+		Tags:      ns.Tags,
+		RateLimit: ns.RateLimit,
 	}
 }
 
@@ -3,6 +3,7 @@ package typescript
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"strings"
 
 	"github.com/clarkmcc/go-typescript"
@@ -29,6 +30,87 @@ func Transpile(ctx context.Context, src string) (string, error) {
 	)
 }
 
+// transpileModuleSource transpiles a module and returns the output with
+// the syntax errors of the module, formatted as file:line:column: message
+const transpileModuleSource = `(function (src, fileName) {
+	var res = ts.transpileModule(src, {
+		fileName: fileName,
+		reportDiagnostics: true,
+		compilerOptions: {
+			module: "commonjs",
+			target: "es5",
+			inlineSourceMap: true,
+		},
+	});
+	var errors = [];
+	(res.diagnostics || []).forEach(function (d) {
+		if (d.category !== ts.DiagnosticCategory.Error) {
+			return;
+		}
+		var msg = ts.flattenDiagnosticMessageText(d.messageText, " ");
+		if (d.file && d.start !== undefined) {
+			var pos = d.file.getLineAndCharacterOfPosition(d.start);
+			msg = (pos.line + 1) + ":" + (pos.character + 1) + ": " + msg;
+		}
+		errors.push(fileName + ":" + msg);
+	});
+	return { code: res.outputText, errors: errors };
+})`
+
+// TranspileModule transpiles the module like Transpile, the source map
+// refers to name.ts, so the positions in the stack traces of the module
+// are the positions in the typescript source. Syntax errors are returned
+// with the file, line and column (e.g. auth.ts:3:10: ';' expected.).
+func TranspileModule(ctx context.Context, name, src string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	rt := goja.New()
+	rt.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	stop := context.AfterFunc(ctx, func() {
+		rt.Interrupt(ctx.Err())
+	})
+	defer stop()
+	if _, err := rt.RunProgram(tsSrcProgram); err != nil {
+		return "", err
+	}
+	val, err := rt.RunString(transpileModuleSource)
+	if err != nil {
+		return "", err
+	}
+	transpileModule, ok := goja.AssertFunction(val)
+	if !ok {
+		return "", errors.New("typescript: transpileModule is not a function")
+	}
+	res, err := transpileModule(goja.Undefined(),
+		rt.ToValue(src), rt.ToValue(name+".ts"))
+	if err != nil {
+		return "", err
+	}
+	var output struct {
+		Code   string   `json:"code"`
+		Errors []string `json:"errors"`
+	}
+	if err = rt.ExportTo(res, &output); err != nil {
+		return "", err
+	}
+	if len(output.Errors) > 0 {
+		return "", errors.New(strings.Join(output.Errors, "; "))
+	}
+	return strings.TrimSuffix(output.Code, "\r\n"), nil
+}
+
+// SplitSourceMap splits the inline source map comment from the end of
+// the code, so the code can be wrapped without losing the source map.
+func SplitSourceMap(code string) (string, string) {
+	code = strings.TrimRight(code, "\r\n")
+	i := strings.LastIndex(code, "\n")
+	if strings.HasPrefix(code[i+1:], "//# sourceMappingURL=") {
+		return code[:i+1], code[i+1:]
+	}
+	return code, ""
+}
+
 var tsSrcProgram = goja.MustCompile("", tscSource, true)
 
 func WithCachedTypescriptSource() typescript.TranspileOptionFunc {
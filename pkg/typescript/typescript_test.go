@@ -72,3 +72,75 @@ func TestTranspile(t *testing.T) {
 		}
 	}
 }
+
+func TestTranspileModule(t *testing.T) {
+	tsSrc := `interface Data {
+	id: string;
+}
+
+export function validate(data: Data) {
+	throw new Error("invalid: " + data.id);
+}
+`
+	jsSrc, err := typescript.TranspileModule(context.Background(), "auth", tsSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := goja.New()
+	vm.Set("exports", map[string]any{})
+	prg, err := goja.Compile("auth", jsSrc, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = vm.RunProgram(prg); err != nil {
+		t.Fatal(err)
+	}
+	_, err = vm.RunString(`exports.validate({ id: "1" })`)
+	if err == nil || !strings.Contains(err.Error(), "auth.ts:6:") {
+		t.Fatalf("expected the error to refer to auth.ts:6, got: %v", err)
+	}
+
+	// the source map is kept when the code is wrapped
+	code, sourceMap := typescript.SplitSourceMap(jsSrc)
+	if !strings.HasPrefix(sourceMap, "//# sourceMappingURL=data:") {
+		t.Fatalf("expected an inline source map, got: %q", sourceMap)
+	}
+	vm = goja.New()
+	prg, err = goja.Compile("auth", "(function (exports) {"+code+"\n})\n"+sourceMap, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn, err := vm.RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exports := vm.NewObject()
+	call, _ := goja.AssertFunction(fn)
+	if _, err = call(goja.Undefined(), exports); err != nil {
+		t.Fatal(err)
+	}
+	vm.Set("exports", exports)
+	_, err = vm.RunString(`exports.validate({ id: "1" })`)
+	if err == nil || !strings.Contains(err.Error(), "auth.ts:6:") {
+		t.Fatalf("expected the error to refer to auth.ts:6, got: %v", err)
+	}
+}
+
+func TestTranspileModule_SyntaxError(t *testing.T) {
+	_, err := typescript.TranspileModule(context.Background(), "auth",
+		"const a = 1;\nconst b = (a: number => a;\n")
+	if err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	if !strings.HasPrefix(err.Error(), "auth.ts:2:") {
+		t.Fatalf("expected the error to refer to auth.ts:2, got: %v", err)
+	}
+}
+
+func TestTranspileModule_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := typescript.TranspileModule(ctx, "auth", "export const a = 1;"); err == nil {
+		t.Fatal("expected the transpile to be canceled")
+	}
+}
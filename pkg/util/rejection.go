@@ -0,0 +1,23 @@
+package util
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// RejectionError returns the error of a rejected promise, errors thrown
+// by a module have the first frame of their stack, like goja exceptions
+// (e.g. "Error: invalid key at verify (auth.ts:5:9(12))").
+func RejectionError(reason goja.Value) error {
+	if obj, ok := reason.(*goja.Object); ok {
+		if stack := obj.Get("stack"); stack != nil && !goja.IsUndefined(stack) {
+			lines := strings.SplitN(stack.String(), "\n", 3)
+			if len(lines) > 1 && strings.HasPrefix(lines[1], "\tat ") {
+				return errors.New(lines[0] + " at " + strings.TrimPrefix(lines[1], "\tat "))
+			}
+		}
+	}
+	return errors.New(reason.String())
+}
@@ -0,0 +1,319 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/dgate-io/dgate/pkg/util"
+)
+
+// ParsePrivateKey parses a PEM encoded key (PKCS #1, PKCS #8 or SEC 1),
+// a JWK as a JSON string or a map, or returns the key if it is already parsed.
+func ParsePrivateKey(key any) (crypto.Signer, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	case map[string]any:
+		return parsePrivateJWK(k)
+	}
+	data, err := util.ToBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	if jwk, ok := jsonJWK(data); ok {
+		return parsePrivateJWK(jwk)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid key: expected a PEM encoded key or a JWK")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if signer, ok := privKey.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errors.New("unsupported private key type")
+	}
+	return nil, errors.New("unsupported private key PEM type: " + block.Type)
+}
+
+// ParsePublicKey parses a PEM encoded public key (PKIX or PKCS #1), certificate
+// or private key, a JWK as a JSON string or a map, or returns the key if it is
+// already parsed. The public key of a private key is returned.
+func ParsePublicKey(key any) (crypto.PublicKey, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return k, nil
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return k.(crypto.Signer).Public(), nil
+	case map[string]any:
+		return parsePublicJWK(k)
+	}
+	data, err := util.ToBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	if jwk, ok := jsonJWK(data); ok {
+		return parsePublicJWK(jwk)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid key: expected a PEM encoded key or a JWK")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	privKey, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return privKey.Public(), nil
+}
+
+// FindJWK returns the key of a JWK set ({"keys": [...]}) with the key id,
+// when kid is empty the only key that can be used with the algorithm is returned.
+func FindJWK(set map[string]any, kid string, alg Algorithm) (map[string]any, error) {
+	keys, ok := set["keys"].([]any)
+	if !ok {
+		return nil, errors.New("invalid JWK set: keys must be an array")
+	}
+	var found map[string]any
+	for _, k := range keys {
+		jwk, ok := k.(map[string]any)
+		if !ok {
+			continue
+		}
+		if kid != "" {
+			if jwk["kid"] == kid {
+				return jwk, nil
+			}
+			continue
+		}
+		if !jwkMatchesAlgorithm(jwk, alg) {
+			continue
+		}
+		if found != nil {
+			return nil, errors.New("JWK set has more than one key for " + string(alg) + ", a key id is required")
+		}
+		found = jwk
+	}
+	if found == nil {
+		if kid != "" {
+			return nil, errors.New("JWK set has no key with id: " + kid)
+		}
+		return nil, errors.New("JWK set has no key for " + string(alg))
+	}
+	return found, nil
+}
+
+func jwkMatchesAlgorithm(jwk map[string]any, alg Algorithm) bool {
+	if use, ok := jwk["use"].(string); ok && use != "sig" {
+		return false
+	}
+	if jwkAlg, ok := jwk["alg"].(string); ok {
+		return jwkAlg == string(alg)
+	}
+	kty, _ := jwk["kty"].(string)
+	switch alg {
+	case HS256, HS384, HS512:
+		return kty == "oct"
+	case RS256, RS384, RS512, PS256, PS384, PS512:
+		return kty == "RSA"
+	case ES256, ES384, ES512:
+		crv, _ := jwk["crv"].(string)
+		return kty == "EC" && crv == algorithmCurve(alg).Params().Name
+	case EdDSA:
+		return kty == "OKP"
+	}
+	return false
+}
+
+func algorithmCurve(alg Algorithm) elliptic.Curve {
+	switch alg {
+	case ES384:
+		return elliptic.P384()
+	case ES512:
+		return elliptic.P521()
+	default:
+		return elliptic.P256()
+	}
+}
+
+func jsonJWK(data []byte) (map[string]any, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		return nil, false
+	}
+	var jwk map[string]any
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, false
+	}
+	return jwk, true
+}
+
+func parsePublicJWK(jwk map[string]any) (crypto.PublicKey, error) {
+	switch kty, _ := jwk["kty"].(string); kty {
+	case "RSA":
+		n, err := jwkInt(jwk, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := jwkInt(jwk, "e")
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid JWK: exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, err := jwkCurve(jwk)
+		if err != nil {
+			return nil, err
+		}
+		x, err := jwkInt(jwk, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := jwkInt(jwk, "y")
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid JWK: point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if crv, _ := jwk["crv"].(string); crv != "Ed25519" {
+			return nil, errors.New("unsupported JWK curve: " + crv)
+		}
+		x, err := jwkBytes(jwk, "x")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid JWK: invalid Ed25519 public key size")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return nil, errors.New("oct JWK can only be used with HMAC algorithms")
+	default:
+		return nil, errors.New("unsupported JWK key type: " + kty)
+	}
+}
+
+func parsePrivateJWK(jwk map[string]any) (crypto.Signer, error) {
+	if _, ok := jwk["d"]; !ok {
+		return nil, errors.New("invalid JWK: not a private key")
+	}
+	pubKey, err := parsePublicJWK(jwk)
+	if err != nil {
+		return nil, err
+	}
+	d, err := jwkBytes(jwk, "d")
+	if err != nil {
+		return nil, err
+	}
+	switch pub := pubKey.(type) {
+	case *rsa.PublicKey:
+		p, err := jwkInt(jwk, "p")
+		if err != nil {
+			return nil, err
+		}
+		q, err := jwkInt(jwk, "q")
+		if err != nil {
+			return nil, err
+		}
+		privKey := &rsa.PrivateKey{
+			PublicKey: *pub,
+			D:         new(big.Int).SetBytes(d),
+			Primes:    []*big.Int{p, q},
+		}
+		if err = privKey.Validate(); err != nil {
+			return nil, errors.New("invalid JWK: " + err.Error())
+		}
+		privKey.Precompute()
+		return privKey, nil
+	case *ecdsa.PublicKey:
+		return &ecdsa.PrivateKey{
+			PublicKey: *pub,
+			D:         new(big.Int).SetBytes(d),
+		}, nil
+	case ed25519.PublicKey:
+		if len(d) != ed25519.SeedSize {
+			return nil, errors.New("invalid JWK: invalid Ed25519 private key size")
+		}
+		return ed25519.NewKeyFromSeed(d), nil
+	}
+	return nil, errors.New("unsupported JWK private key")
+}
+
+func parseOctJWK(jwk map[string]any) ([]byte, error) {
+	if kty, _ := jwk["kty"].(string); kty != "oct" {
+		return nil, errors.New("HMAC algorithms require an oct JWK")
+	}
+	return jwkBytes(jwk, "k")
+}
+
+func jwkCurve(jwk map[string]any) (elliptic.Curve, error) {
+	switch crv, _ := jwk["crv"].(string); crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, errors.New("unsupported JWK curve: " + crv)
+	}
+}
+
+func jwkBytes(jwk map[string]any, name string) ([]byte, error) {
+	val, ok := jwk[name].(string)
+	if !ok || val == "" {
+		return nil, errors.New("invalid JWK: missing " + name)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(val, "="))
+	if err != nil {
+		return nil, errors.New("invalid JWK: " + name + " must be base64url encoded")
+	}
+	return b, nil
+}
+
+func jwkInt(jwk map[string]any, name string) (*big.Int, error) {
+	b, err := jwkBytes(jwk, name)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
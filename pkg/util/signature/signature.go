@@ -0,0 +1,229 @@
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/dgate-io/dgate/pkg/util"
+)
+
+// Algorithm is a JWA (RFC 7518) signature algorithm name.
+type Algorithm string
+
+const (
+	HS256 Algorithm = "HS256"
+	HS384 Algorithm = "HS384"
+	HS512 Algorithm = "HS512"
+	// RSASSA-PKCS1-v1_5
+	RS256 Algorithm = "RS256"
+	RS384 Algorithm = "RS384"
+	RS512 Algorithm = "RS512"
+	// RSASSA-PSS, the salt length is the size of the hash
+	PS256 Algorithm = "PS256"
+	PS384 Algorithm = "PS384"
+	PS512 Algorithm = "PS512"
+	// ECDSA, signatures are r || s (IEEE P1363), not ASN.1 DER
+	ES256 Algorithm = "ES256"
+	ES384 Algorithm = "ES384"
+	ES512 Algorithm = "ES512"
+	// Ed25519
+	EdDSA Algorithm = "EdDSA"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrUnknownAlgorithm = errors.New("unknown signature algorithm")
+)
+
+var algorithmHashes = map[Algorithm]crypto.Hash{
+	HS256: crypto.SHA256, HS384: crypto.SHA384, HS512: crypto.SHA512,
+	RS256: crypto.SHA256, RS384: crypto.SHA384, RS512: crypto.SHA512,
+	PS256: crypto.SHA256, PS384: crypto.SHA384, PS512: crypto.SHA512,
+	ES256: crypto.SHA256, ES384: crypto.SHA384, ES512: crypto.SHA512,
+	EdDSA: 0,
+}
+
+// Algorithms returns the supported algorithms.
+func Algorithms() []Algorithm {
+	return []Algorithm{
+		HS256, HS384, HS512,
+		RS256, RS384, RS512,
+		PS256, PS384, PS512,
+		ES256, ES384, ES512,
+		EdDSA,
+	}
+}
+
+func ParseAlgorithm(alg string) (Algorithm, error) {
+	if _, ok := algorithmHashes[Algorithm(alg)]; !ok {
+		return "", errors.New("unknown signature algorithm: " + alg)
+	}
+	return Algorithm(alg), nil
+}
+
+// IsHMAC returns true if the algorithm uses a shared secret.
+func (alg Algorithm) IsHMAC() bool {
+	return alg == HS256 || alg == HS384 || alg == HS512
+}
+
+// Sign signs the data, key is the shared secret for HMAC algorithms
+// and a private key (see ParsePrivateKey) for the others.
+func Sign(alg Algorithm, key any, data []byte) ([]byte, error) {
+	hash, ok := algorithmHashes[alg]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+	if alg.IsHMAC() {
+		secret, err := hmacSecret(key)
+		if err != nil {
+			return nil, err
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	}
+
+	privKey, err := ParsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	switch alg {
+	case RS256, RS384, RS512:
+		rsaKey, ok := privKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New(string(alg) + " requires an RSA key")
+		}
+		return rsa.SignPKCS1v15(rand.Reader, rsaKey, hash, digest(hash, data))
+	case PS256, PS384, PS512:
+		rsaKey, ok := privKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New(string(alg) + " requires an RSA key")
+		}
+		return rsa.SignPSS(rand.Reader, rsaKey, hash, digest(hash, data),
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case ES256, ES384, ES512:
+		ecKey, ok := privKey.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != algorithmCurve(alg) {
+			return nil, errors.New(string(alg) + " requires an EC key on curve " +
+				algorithmCurve(alg).Params().Name)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest(hash, data))
+		if err != nil {
+			return nil, err
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig, nil
+	case EdDSA:
+		edKey, ok := privKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("EdDSA requires an Ed25519 key")
+		}
+		return ed25519.Sign(edKey, data), nil
+	}
+	return nil, ErrUnknownAlgorithm
+}
+
+// Verify returns ErrInvalidSignature if the signature of the data is not valid, key
+// is the shared secret for HMAC algorithms and a public key (see ParsePublicKey)
+// or private key for the others.
+func Verify(alg Algorithm, key any, data, sig []byte) error {
+	hash, ok := algorithmHashes[alg]
+	if !ok {
+		return ErrUnknownAlgorithm
+	}
+	if alg.IsHMAC() {
+		expected, err := Sign(alg, key, data)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare(expected, sig) != 1 {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	pubKey, err := ParsePublicKey(key)
+	if err != nil {
+		return err
+	}
+	valid := false
+	switch alg {
+	case RS256, RS384, RS512:
+		rsaKey, ok := pubKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New(string(alg) + " requires an RSA key")
+		}
+		valid = rsa.VerifyPKCS1v15(rsaKey, hash, digest(hash, data), sig) == nil
+	case PS256, PS384, PS512:
+		rsaKey, ok := pubKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New(string(alg) + " requires an RSA key")
+		}
+		valid = rsa.VerifyPSS(rsaKey, hash, digest(hash, data), sig,
+			&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case ES256, ES384, ES512:
+		ecKey, ok := pubKey.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != algorithmCurve(alg) {
+			return errors.New(string(alg) + " requires an EC key on curve " +
+				algorithmCurve(alg).Params().Name)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(sig) == 2*size {
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			valid = ecdsa.Verify(ecKey, digest(hash, data), r, s)
+		}
+	case EdDSA:
+		edKey, ok := pubKey.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("EdDSA requires an Ed25519 key")
+		}
+		valid = ed25519.Verify(edKey, data, sig)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hmacSecret(key any) ([]byte, error) {
+	if jwk, ok := key.(map[string]any); ok {
+		return parseOctJWK(jwk)
+	}
+	secret, err := util.ToBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("secret cannot be empty")
+	}
+	// the keys of the other algorithms are not secrets, a public key
+	// used as a secret would let anyone sign tokens that are accepted.
+	if jwk, ok := jsonJWK(secret); ok {
+		return parseOctJWK(jwk)
+	}
+	if block, _ := pem.Decode(secret); block != nil {
+		return nil, errors.New("HMAC algorithms cannot use a PEM encoded key")
+	}
+	return secret, nil
+}
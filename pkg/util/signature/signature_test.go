@@ -0,0 +1,164 @@
+package signature_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/dgate-io/dgate/pkg/util/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pemKeys(t *testing.T, privKey crypto.Signer) (string, string) {
+	privDer, err := x509.MarshalPKCS8PrivateKey(privKey)
+	require.NoError(t, err)
+	pubDer, err := x509.MarshalPKIXPublicKey(privKey.Public())
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}))
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestSignVerify_PEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ec256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ec384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	ec521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := map[signature.Algorithm]crypto.Signer{
+		signature.RS256: rsaKey, signature.RS384: rsaKey, signature.RS512: rsaKey,
+		signature.PS256: rsaKey, signature.PS384: rsaKey, signature.PS512: rsaKey,
+		signature.ES256: ec256, signature.ES384: ec384, signature.ES512: ec521,
+		signature.EdDSA: edKey,
+	}
+	data := []byte("hello world")
+	for alg, key := range keys {
+		t.Run(string(alg), func(t *testing.T) {
+			privPem, pubPem := pemKeys(t, key)
+			sig, err := signature.Sign(alg, privPem, data)
+			require.NoError(t, err)
+			assert.NoError(t, signature.Verify(alg, pubPem, data, sig))
+			// the public key of a private key can be used to verify
+			assert.NoError(t, signature.Verify(alg, privPem, data, sig))
+			assert.ErrorIs(t, signature.Verify(alg, pubPem, []byte("tampered"), sig),
+				signature.ErrInvalidSignature)
+		})
+	}
+
+	_, pubPem := pemKeys(t, ec256)
+	sig, err := signature.Sign(signature.RS256, rsaKey, data)
+	require.NoError(t, err)
+	assert.ErrorContains(t, signature.Verify(signature.RS256, pubPem, data, sig), "requires an RSA key")
+}
+
+func TestSignVerify_JWK(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaJwk := map[string]any{
+		"kty": "RSA",
+		"n":   b64(rsaKey.N.Bytes()),
+		"e":   b64([]byte{1, 0, 1}),
+		"d":   b64(rsaKey.D.Bytes()),
+		"p":   b64(rsaKey.Primes[0].Bytes()),
+		"q":   b64(rsaKey.Primes[1].Bytes()),
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecJwk := map[string]any{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   b64(ecKey.X.FillBytes(make([]byte, 32))),
+		"y":   b64(ecKey.Y.FillBytes(make([]byte, 32))),
+		"d":   b64(ecKey.D.FillBytes(make([]byte, 32))),
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edJwk := map[string]any{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"x":   b64(edPub),
+		"d":   b64(edKey.Seed()),
+	}
+
+	data := []byte("hello world")
+	for alg, jwk := range map[signature.Algorithm]map[string]any{
+		signature.PS256: rsaJwk,
+		signature.ES256: ecJwk,
+		signature.EdDSA: edJwk,
+	} {
+		t.Run(string(alg), func(t *testing.T) {
+			sig, err := signature.Sign(alg, jwk, data)
+			require.NoError(t, err)
+			pubJwk := map[string]any{}
+			for k, v := range jwk {
+				if k != "d" && k != "p" && k != "q" {
+					pubJwk[k] = v
+				}
+			}
+			assert.NoError(t, signature.Verify(alg, pubJwk, data, sig))
+			_, err = signature.Sign(alg, pubJwk, data)
+			assert.ErrorContains(t, err, "not a private key")
+		})
+	}
+}
+
+func TestSignVerify_HMAC(t *testing.T) {
+	data := []byte("hello world")
+	sig, err := signature.Sign(signature.HS256, "secret", data)
+	require.NoError(t, err)
+	assert.NoError(t, signature.Verify(signature.HS256, "secret", data, sig))
+	assert.ErrorIs(t, signature.Verify(signature.HS256, "other", data, sig),
+		signature.ErrInvalidSignature)
+	octJwk := map[string]any{"kty": "oct", "k": b64([]byte("secret"))}
+	assert.NoError(t, signature.Verify(signature.HS256, octJwk, data, sig))
+	assert.NoError(t, signature.Verify(signature.HS256,
+		`{"kty": "oct", "k": "`+b64([]byte("secret"))+`"}`, data, sig))
+
+	// the keys of the other algorithms cannot be used as secrets
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, pubPem := pemKeys(t, ecKey)
+	_, err = signature.Sign(signature.HS256, pubPem, data)
+	assert.ErrorContains(t, err, "PEM encoded key")
+	_, err = signature.Sign(signature.HS256, map[string]any{"kty": "EC"}, data)
+	assert.ErrorContains(t, err, "oct JWK")
+	_, err = signature.Sign(signature.HS256, `{"kty": "RSA"}`, data)
+	assert.ErrorContains(t, err, "oct JWK")
+}
+
+func TestFindJWK(t *testing.T) {
+	set := map[string]any{"keys": []any{
+		map[string]any{"kid": "a", "kty": "RSA", "use": "sig"},
+		map[string]any{"kid": "b", "kty": "EC", "crv": "P-256"},
+		map[string]any{"kid": "c", "kty": "RSA", "use": "enc"},
+	}}
+	jwk, err := signature.FindJWK(set, "b", signature.ES256)
+	require.NoError(t, err)
+	assert.Equal(t, "b", jwk["kid"])
+
+	jwk, err = signature.FindJWK(set, "", signature.RS256)
+	require.NoError(t, err)
+	assert.Equal(t, "a", jwk["kid"])
+
+	_, err = signature.FindJWK(set, "", signature.ES384)
+	assert.Error(t, err)
+	_, err = signature.FindJWK(set, "missing", signature.RS256)
+	assert.Error(t, err)
+}